@@ -0,0 +1,50 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Allocation budgets for the hot paths, so that allocations don't creep
+// back into them.  Parsing an HS256 token allocates its Token and header
+// map, the copy of the token it decodes from, the buffer it decodes into
+// and whatever the claims decode into; a refused one adds its error.
+// Signing one allocates its buffer, its string unless built with
+// jwtunsafe, and whatever the claims marshal into.
+// The secret is an HMACKey, whose hashers are reused.
+func TestAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful under the race detector")
+	}
+	var key interface{} = jwt.NewHMACKey([]byte("secret"))
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	claims := &jwt.StandardClaims{Subject: "1234567890", Issuer: "https://auth.example.com"}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{ExpiresAt: time.Now().Add(-time.Hour).Unix()}).SignedString(key)
+	mapToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1234567890", "admin": true}).SignedString(key)
+	p := new(jwt.Parser)
+	scratch := make([]byte, jwt.ScratchSize(len(tokenString)))
+
+	var tests = []struct {
+		name   string
+		budget float64
+		run    func()
+	}{
+		{"Parse", 6, func() { p.ParseWithClaims(tokenString, &jwt.StandardClaims{}, keyFunc) }},
+		{"Parse MapClaims", 14, func() { p.ParseWithClaims(mapToken, jwt.MapClaims{}, keyFunc) }},
+		{"Parse expired", 10, func() { p.ParseWithClaims(expired, &jwt.StandardClaims{}, keyFunc) }},
+		{"ParseWithScratch", 4, func() { p.ParseWithScratch(scratch, tokenString, &jwt.StandardClaims{}, keyFunc) }},
+		{"SignedString", 2 + stringCopyAllocs, func() { token.SignedString(key) }},
+	}
+	for _, data := range tests {
+		if allocs := testing.AllocsPerRun(100, data.run); allocs > data.budget {
+			t.Errorf("[%v] Expected at most %v allocations.  Got %v", data.name, data.budget, allocs)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// The client_assertion_type of private_key_jwt client authentication,
+// RFC 7523 section 2.2
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// Lifetime of assertions made by NewClientAssertion.  They are sent once,
+// straight away, so there is no need for them to last.
+const DefaultClientAssertionLifetime = time.Minute
+
+// Longest exp - iat accepted by ClientAssertionValidator unless it sets
+// another
+const DefaultMaxAssertionLifetime = 5 * time.Minute
+
+// Claims of an RFC 7523 assertion.  exp is required.
+type AssertionClaims struct {
+	Issuer    string       `json:"iss"`
+	Subject   string       `json:"sub"`
+	Audience  ClaimStrings `json:"aud"`
+	ExpiresAt int64        `json:"exp"`
+	NotBefore int64        `json:"nbf,omitempty"`
+	IssuedAt  int64        `json:"iat,omitempty"`
+	Id        string       `json:"jti,omitempty"`
+}
+
+// Validates the time based claims, requiring exp as RFC 7523 section 3 does
+func (c *AssertionClaims) Valid() error {
+	vErr := new(ValidationError)
+	now := TimeFunc().Unix()
+
+	if c.ExpiresAt == 0 {
+		vErr.add(errors.New("assertion has no exp claim"), ValidationErrorExpired)
+	} else if !verifyExp(c.ExpiresAt, now, true) {
+		vErr.add(newExpiredError(c.ExpiresAt, now), ValidationErrorExpired)
+	}
+	if !verifyNbf(c.NotBefore, now, false) {
+		vErr.add(newNotValidYetError(c.NotBefore, now), ValidationErrorNotValidYet)
+	}
+	if !verifyIat(c.IssuedAt, now, false) {
+		vErr.add(newIssuedAtError(c.IssuedAt, now), ValidationErrorIssuedAt)
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+	return vErr
+}
+
+// Returns the aud claim
+func (c *AssertionClaims) GetAudience() []string {
+	return c.Audience
+}
+
+// Returns the iss claim
+func (c *AssertionClaims) GetIssuer() string {
+	return c.Issuer
+}
+
+// Returns the jti claim
+func (c *AssertionClaims) GetId() string {
+	return c.Id
+}
+
+// A private_key_jwt client assertion for clientId at tokenEndpoint, ready
+// to sign: iss and sub are clientId, aud is the endpoint, and it expires
+// after DefaultClientAssertionLifetime.  The jti is from IdFunc.
+//
+//	assertion, err := jwt.NewClientAssertion(jwt.SigningMethodES256, clientId, tokenURL).
+//		WithHeader("kid", kid).SignedString(key)
+//	form.Set("client_assertion_type", jwt.ClientAssertionType)
+//	form.Set("client_assertion", assertion)
+func NewClientAssertion(method SigningMethod, clientId, tokenEndpoint string) *Token {
+	now := TimeFunc()
+	return NewWithClaims(method, &AssertionClaims{
+		Issuer:    clientId,
+		Subject:   clientId,
+		Audience:  ClaimStrings{tokenEndpoint},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(DefaultClientAssertionLifetime).Unix(),
+		Id:        IdFunc(),
+	})
+}
+
+// Validates client assertions at the authorization server
+type ClientAssertionValidator struct {
+	// Accepted aud values: the token endpoint URL and, if clients may use
+	// it, the issuer identifier.  aud must contain one of them.
+	Audiences []string
+
+	MaxLifetime time.Duration // Longest exp - iat accepted.  Defaults to DefaultMaxAssertionLifetime
+	Replay      ReplayStore   // If set, jti is required and each assertion is accepted once
+
+	Parser *Parser // Parser used for verification.  Defaults to new(Parser)
+}
+
+// Parse and verify the assertion of the client clientId.  keyFunc should
+// return that client's registered key.  iss and sub must both be clientId,
+// and iat must be set when MaxLifetime applies.
+func (v *ClientAssertionValidator) Parse(tokenString string, clientId string, keyFunc Keyfunc) (*AssertionClaims, error) {
+	p := v.Parser
+	if p == nil {
+		p = new(Parser)
+	}
+
+	claims := new(AssertionClaims)
+	token, err := p.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	vErr := new(ValidationError)
+	if claims.Issuer != clientId {
+		vErr.add(&IssuerError{Expected: clientId, Got: claims.Issuer}, ValidationErrorIssuer)
+	}
+	if claims.Subject != clientId {
+		vErr.add(errors.New("assertion sub is not the client_id"), ValidationErrorClaimsInvalid)
+	}
+	checkAssertion(claims, vErr, v.Audiences, v.maxLifetime(), v.Replay)
+
+	if vErr.valid() {
+		return claims, nil
+	}
+	reportSecurityEvent(context.Background(), p.securityHook(), token, vErr)
+	return nil, vErr
+}
+
+// Checks common to RFC 7523 assertions: aud, lifetime and replay
+func checkAssertion(claims *AssertionClaims, vErr *ValidationError, audiences []string, maxLifetime time.Duration, replay ReplayStore) {
+	accepted := false
+	for _, a := range audiences {
+		accepted = accepted || verifyAudList(claims.Audience, a, true)
+	}
+	if !accepted {
+		vErr.add(&AudienceError{Expected: firstString(audiences), Got: claims.Audience}, ValidationErrorAudience)
+	}
+	if claims.IssuedAt == 0 {
+		vErr.add(errors.New("assertion has no iat claim"), ValidationErrorIssuedAt)
+	} else if time.Duration(claims.ExpiresAt-claims.IssuedAt)*time.Second > maxLifetime {
+		vErr.add(errors.New("assertion lifetime is too long"), ValidationErrorClaimsInvalid)
+	}
+
+	if replay != nil && vErr.valid() {
+		if claims.Id == "" {
+			vErr.add(errors.New("assertion has no jti claim"), ValidationErrorId)
+		} else if err := replay.Record(claims.Issuer+" "+claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+			vErr.add(err, ValidationErrorId)
+		}
+	}
+}
+
+func (v *ClientAssertionValidator) maxLifetime() time.Duration {
+	if v.MaxLifetime == 0 {
+		return DefaultMaxAssertionLifetime
+	}
+	return v.MaxLifetime
+}
+
+func firstString(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[0]
+}
+
+// The grant_type of the JWT bearer authorization grant, RFC 7523 section
+// 2.1
+const AuthorizationGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// An authorization grant from issuer, asserting subject, for use at
+// tokenEndpoint until lifetime has passed.  The jti is from IdFunc.
+func NewAuthorizationGrant(method SigningMethod, issuer, subject, tokenEndpoint string, lifetime time.Duration) *Token {
+	now := TimeFunc()
+	return NewWithClaims(method, &AssertionClaims{
+		Issuer:    issuer,
+		Subject:   subject,
+		Audience:  ClaimStrings{tokenEndpoint},
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(lifetime).Unix(),
+		Id:        IdFunc(),
+	})
+}
+
+// What a trusted issuer of authorization grants may assert
+type GrantIssuerPolicy struct {
+	Keyfunc      Keyfunc               // Supplies the issuer's verification key
+	MaxLifetime  time.Duration         // Longest exp - iat accepted.  Defaults to the validator's
+	AllowSubject func(sub string) bool // If set, reports whether the issuer may assert sub
+}
+
+// Validates authorization grants at the authorization server
+type AuthorizationGrantValidator struct {
+	Audiences []string // Accepted aud values, as for ClientAssertionValidator
+
+	// Trusted issuers, by iss.  Assertions from any other issuer are
+	// rejected before a key is looked up.
+	Issuers map[string]*GrantIssuerPolicy
+
+	MaxLifetime time.Duration // Longest exp - iat accepted.  Defaults to DefaultMaxAssertionLifetime
+	Replay      ReplayStore   // If set, jti is required and each assertion is accepted once
+
+	Parser *Parser // Parser used for verification.  Defaults to new(Parser)
+}
+
+// Parse and verify an authorization grant with the key and policy of its
+// issuer.  sub is required.
+func (v *AuthorizationGrantValidator) Parse(tokenString string) (*AssertionClaims, error) {
+	p := v.Parser
+	if p == nil {
+		p = new(Parser)
+	}
+
+	var policy *GrantIssuerPolicy
+	keyFunc := func(token *Token) (interface{}, error) {
+		iss := token.Claims.(*AssertionClaims).Issuer
+		if policy = v.Issuers[iss]; policy == nil || policy.Keyfunc == nil {
+			return nil, NewValidationError("assertion issuer is not trusted", ValidationErrorIssuer)
+		}
+		return policy.Keyfunc(token)
+	}
+
+	claims := new(AssertionClaims)
+	token, err := p.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	vErr := new(ValidationError)
+	if claims.Subject == "" {
+		vErr.add(errors.New("assertion has no sub claim"), ValidationErrorClaimsInvalid)
+	} else if policy.AllowSubject != nil && !policy.AllowSubject(claims.Subject) {
+		vErr.add(errors.New("issuer may not assert this subject"), ValidationErrorClaimsInvalid)
+	}
+	maxLifetime := policy.MaxLifetime
+	if maxLifetime == 0 {
+		maxLifetime = v.MaxLifetime
+	}
+	if maxLifetime == 0 {
+		maxLifetime = DefaultMaxAssertionLifetime
+	}
+	checkAssertion(claims, vErr, v.Audiences, maxLifetime, v.Replay)
+
+	if vErr.valid() {
+		return claims, nil
+	}
+	reportSecurityEvent(context.Background(), p.securityHook(), token, vErr)
+	return nil, vErr
+}
@@ -0,0 +1,129 @@
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+const tokenEndpoint = "https://as.example.com/token"
+
+func TestClientAssertion(t *testing.T) {
+	clientKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return &clientKey.PublicKey, nil }
+	validator := &jwt.ClientAssertionValidator{
+		Audiences: []string{tokenEndpoint, "https://as.example.com"},
+		Replay:    jwt.NewMemoryReplayStore(),
+	}
+
+	s, err := jwt.NewClientAssertion(jwt.SigningMethodRS256, "client-1", tokenEndpoint).WithHeader("kid", "k1").SignedString(clientKey)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	claims, err := validator.Parse(s, "client-1", keyFunc)
+	if err != nil || claims.Subject != "client-1" || claims.Id == "" {
+		t.Fatalf("Error parsing assertion: %v", err)
+	}
+	if _, err := validator.Parse(s, "client-1", keyFunc); !errors.Is(err, jwt.ErrTokenReplayed) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenReplayed, err)
+	}
+}
+
+func TestClientAssertion_errors(t *testing.T) {
+	clientKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return &clientKey.PublicKey, nil }
+	now := time.Now().Unix()
+	claims := func(f func(*jwt.AssertionClaims)) string {
+		c := &jwt.AssertionClaims{Issuer: "client-1", Subject: "client-1", Audience: jwt.ClaimStrings{tokenEndpoint}, IssuedAt: now, ExpiresAt: now + 60, Id: "1"}
+		f(c)
+		return test.MakeSampleToken(c, clientKey)
+	}
+
+	var clientAssertionTestData = []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"valid", claims(func(c *jwt.AssertionClaims) {}), nil},
+		{"iss", claims(func(c *jwt.AssertionClaims) { c.Issuer = "client-2" }), jwt.ErrTokenInvalidIssuer},
+		{"sub", claims(func(c *jwt.AssertionClaims) { c.Subject = "admin" }), jwt.ErrTokenInvalidClaims},
+		{"aud", claims(func(c *jwt.AssertionClaims) { c.Audience = jwt.ClaimStrings{"https://other.example.com"} }), jwt.ErrTokenInvalidAudience},
+		{"no exp", claims(func(c *jwt.AssertionClaims) { c.ExpiresAt = 0 }), jwt.ErrTokenExpired},
+		{"expired", claims(func(c *jwt.AssertionClaims) { c.IssuedAt, c.ExpiresAt = now-120, now-60 }), jwt.ErrTokenExpired},
+		{"lifetime", claims(func(c *jwt.AssertionClaims) { c.ExpiresAt = now + 3600 }), jwt.ErrTokenInvalidClaims},
+		{"no iat", claims(func(c *jwt.AssertionClaims) { c.IssuedAt = 0 }), jwt.ErrTokenUsedBeforeIssued},
+		{"no jti", claims(func(c *jwt.AssertionClaims) { c.Id = "" }), jwt.ErrTokenInvalidId},
+	}
+
+	for _, data := range clientAssertionTestData {
+		validator := &jwt.ClientAssertionValidator{Audiences: []string{tokenEndpoint}, Replay: jwt.NewMemoryReplayStore()}
+		_, err := validator.Parse(data.token, "client-1", keyFunc)
+		if (data.err == nil) != (err == nil) || (data.err != nil && !errors.Is(err, data.err)) {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
+
+func TestMemoryReplayStore(t *testing.T) {
+	store := jwt.NewMemoryReplayStore()
+	exp := time.Now().Add(time.Minute)
+	if err := store.Record("a", exp); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := store.Record("a", exp); err != jwt.ErrTokenReplayed {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenReplayed, err)
+	}
+
+	// Once expired, the id is forgotten
+	at(exp.Add(time.Second), func() {
+		if err := store.Record("a", exp.Add(time.Minute)); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestAuthorizationGrant(t *testing.T) {
+	partnerKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	validator := &jwt.AuthorizationGrantValidator{
+		Audiences: []string{tokenEndpoint},
+		Issuers: map[string]*jwt.GrantIssuerPolicy{
+			"https://partner.example.com": {
+				Keyfunc:      func(*jwt.Token) (interface{}, error) { return &partnerKey.PublicKey, nil },
+				MaxLifetime:  10 * time.Minute,
+				AllowSubject: func(sub string) bool { return sub != "admin" },
+			},
+		},
+	}
+	grant := func(iss, sub string, lifetime time.Duration) string {
+		s, err := jwt.NewAuthorizationGrant(jwt.SigningMethodRS256, iss, sub, tokenEndpoint, lifetime).SignedString(partnerKey)
+		if err != nil {
+			t.Fatalf("Error signing: %v", err)
+		}
+		return s
+	}
+
+	var authorizationGrantTestData = []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"valid", grant("https://partner.example.com", "alice", 10*time.Minute), nil},
+		{"issuer", grant("https://other.example.com", "alice", time.Minute), jwt.ErrTokenInvalidIssuer},
+		{"subject", grant("https://partner.example.com", "admin", time.Minute), jwt.ErrTokenInvalidClaims},
+		{"no subject", grant("https://partner.example.com", "", time.Minute), jwt.ErrTokenInvalidClaims},
+		{"lifetime", grant("https://partner.example.com", "alice", 11*time.Minute), jwt.ErrTokenInvalidClaims},
+	}
+
+	for _, data := range authorizationGrantTestData {
+		claims, err := validator.Parse(data.token)
+		if (data.err == nil) != (err == nil) || (data.err != nil && !errors.Is(err, data.err)) {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+		if err == nil && claims.Subject != "alice" {
+			t.Errorf("[%v] Unexpected claims %+v", data.name, claims)
+		}
+	}
+}
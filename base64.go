@@ -0,0 +1,36 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"slices"
+)
+
+// The base64url codec used for token segments.  *base64.Encoding
+// implements it, and so do the accelerated encodings of packages such as
+// github.com/segmentio/asm/base64, whose RawURLEncoding can be swapped in
+// where decoding dominates:
+//
+//	jwt.SegmentEncoding = asmbase64.RawURLEncoding
+//
+// Implementations must encode and decode exactly as
+// base64.RawURLEncoding does, without padding; DecodeSegment strips any
+// padding before decoding.
+type SegmentCodec interface {
+	EncodedLen(n int) int
+	Encode(dst, src []byte)
+	DecodedLen(n int) int
+	Decode(dst, src []byte) (n int, err error)
+}
+
+// The codec for EncodeSegment, DecodeSegment, signing and parsing.  Set it
+// during init, before any tokens are signed or parsed; it is read without
+// locking.  Streams (see stream.go) always use encoding/base64.
+var SegmentEncoding SegmentCodec = base64.RawURLEncoding
+
+// base64.Encoding.AppendEncode, for SegmentEncoding
+func appendEncodeSegment(dst, src []byte) []byte {
+	n := SegmentEncoding.EncodedLen(len(src))
+	dst = slices.Grow(dst, n)
+	SegmentEncoding.Encode(dst[len(dst):][:n], src)
+	return dst[:len(dst)+n]
+}
@@ -0,0 +1,65 @@
+package jwt_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+// Wraps encoding/base64, counting the calls that reach it
+type countingCodec struct {
+	*base64.Encoding
+	encodes, decodes int
+}
+
+func (c *countingCodec) Encode(dst, src []byte) {
+	c.encodes++
+	c.Encoding.Encode(dst, src)
+}
+
+func (c *countingCodec) Decode(dst, src []byte) (int, error) {
+	c.decodes++
+	return c.Encoding.Decode(dst, src)
+}
+
+func TestSegmentEncoding(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	hmacKey := []byte("codec secret")
+	var tests = []struct {
+		method   jwt.SigningMethod
+		key      interface{}
+		parseKey interface{}
+	}{
+		{jwt.SigningMethodHS256, hmacKey, hmacKey},
+		{jwt.SigningMethodRS256, privateKey, &privateKey.PublicKey},
+	}
+
+	for _, data := range tests {
+		claims := jwt.MapClaims{"sub": "alice"}
+		header := map[string]interface{}{"alg": data.method.Alg(), "kid": "k1"}
+		expect, err := (&jwt.Token{Header: header, Claims: claims, Method: data.method}).SignedString(data.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		codec := &countingCodec{Encoding: base64.RawURLEncoding}
+		jwt.SegmentEncoding = codec
+		got, err := (&jwt.Token{Header: header, Claims: claims, Method: data.method}).SignedString(data.key)
+		if err == nil {
+			_, err = jwt.Parse(got, func(*jwt.Token) (interface{}, error) { return data.parseKey, nil })
+		}
+		jwt.SegmentEncoding = base64.RawURLEncoding
+
+		if err != nil {
+			t.Errorf("[%v] Error with the wrapped codec: %v", data.method.Alg(), err)
+		}
+		if got != expect {
+			t.Errorf("[%v] Expected %v.  Got %v", data.method.Alg(), expect, got)
+		}
+		if codec.encodes != 3 || codec.decodes != 3 {
+			t.Errorf("[%v] Expected 3 encodes and 3 decodes by the codec.  Got %v and %v", data.method.Alg(), codec.encodes, codec.decodes)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package jwt
+
+import (
+	"context"
+	"hash"
+)
+
+// Size of the buffers VerifyBatch decodes tokens into, each shared by as
+// many tokens as fit
+const batchArenaSize = 64 << 10
+
+// The outcome for one token of VerifyBatch
+type BatchResult struct {
+	Token *Token
+	Err   error
+}
+
+// Parse and verify tokens all signed with key, as ParseWithClaims would
+// each with MapClaims and a Keyfunc returning key, returning a result for
+// each token in order.  For replaying logs and other jobs verifying many
+// archived tokens, work is shared across the batch: tokens are decoded
+// into a few large buffers rather than one each, and the HMAC keyed from
+// key, or the hasher for RSA and ECDSA, is set up once per signing method
+// and reset between tokens.
+//
+// The tokens of a batch keep the buffers they were decoded into alive
+// between them, so hold on to results only as long as the batch.
+func (p *Parser) VerifyBatch(tokens []string, key interface{}) []BatchResult {
+	var arena []byte
+	bk := &batchKey{key: key, hashers: map[string]hash.Hash{}}
+	keyFunc := func(*Token) (interface{}, error) { return bk, nil }
+	if _, ok := key.(VerificationKeySet); ok {
+		keyFunc = func(*Token) (interface{}, error) { return key, nil }
+	}
+
+	results := make([]BatchResult, len(tokens))
+	for i, tokenString := range tokens {
+		if n := ScratchSize(len(tokenString)); len(arena) < n && n <= batchArenaSize {
+			arena = make([]byte, batchArenaSize)
+		}
+		results[i].Token, results[i].Err = p.parseWithContext(context.Background(), tokenString, MapClaims{}, keyFunc, &arena, nil)
+	}
+	return results
+}
+
+// The key of a batch, with the digest state shared by its tokens
+type batchKey struct {
+	key     interface{}
+	hashers map[string]hash.Hash // By alg, nil for methods without digests
+	sum     [64]byte
+}
+
+func (bk *batchKey) verify(token *Token, signingString string) error {
+	alg := token.Method.Alg()
+	hasher, ok := bk.hashers[alg]
+	if !ok {
+		hasher, _ = newDigest(token.Method, bk.key)
+		bk.hashers[alg] = hasher
+	}
+	if hasher == nil || token.SignatureBytes == nil {
+		// Not a method newDigest knows, a key it refuses or a signature
+		// that isn't base64url: verifyToken reports which
+		return verifyToken(token.Method, signingString, token, bk.key)
+	}
+
+	hasher.Reset()
+	hasher.Write(stringBytes(signingString))
+	return verifyDigestBytes(token.Method, hasher.Sum(bk.sum[:0]), token.SignatureBytes, bk.key)
+}
@@ -0,0 +1,104 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestParser_VerifyBatch(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	hmacKey := []byte("batch secret")
+	edKey := ed25519.NewKeyFromSeed(ed25519Seed)
+	var tests = []struct {
+		name      string
+		method    jwt.SigningMethod
+		signKey   interface{}
+		verifyKey interface{}
+	}{
+		{"HS256", jwt.SigningMethodHS256, hmacKey, hmacKey},
+		{"RS256", jwt.SigningMethodRS256, privateKey, &privateKey.PublicKey},
+		{"PS256", jwt.SigningMethodPS256, privateKey, &privateKey.PublicKey},
+		{"EdDSA", jwt.SigningMethodEdDSA, edKey, edKey.Public()},
+	}
+
+	for _, data := range tests {
+		var tokens []string
+		for i := 0; i < 5; i++ {
+			s, err := jwt.NewWithClaims(data.method, jwt.MapClaims{"n": float64(i)}).SignedString(data.signKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tokens = append(tokens, s)
+		}
+		expired, _ := jwt.NewWithClaims(data.method, jwt.MapClaims{"exp": float64(time.Now().Add(-time.Hour).Unix())}).SignedString(data.signKey)
+		tokens = append(tokens, tokens[0][:len(tokens[0])-4]+"AAAA", "not.a.token", expired, tokens[1])
+
+		results := new(jwt.Parser).VerifyBatch(tokens, data.verifyKey)
+		if len(results) != len(tokens) {
+			t.Fatalf("[%v] Expected %v results.  Got %v", data.name, len(tokens), len(results))
+		}
+		for i, r := range results {
+			_, expect := jwt.Parse(tokens[i], func(*jwt.Token) (interface{}, error) { return data.verifyKey, nil })
+			if jwt.CodeOf(r.Err) != jwt.CodeOf(expect) {
+				t.Errorf("[%v %v] Expected %v, as from Parse.  Got %v", data.name, i, expect, r.Err)
+			}
+			if i < 5 && (r.Token == nil || !r.Token.Valid || r.Token.Claims.(jwt.MapClaims)["n"] != float64(i)) {
+				t.Errorf("[%v %v] Expected a valid token with n=%v.  Got %+v", data.name, i, i, r.Token)
+			}
+		}
+		if results[5].Err == nil {
+			t.Errorf("[%v] Expected the forged token to be refused", data.name)
+		}
+	}
+}
+
+func TestParser_VerifyBatch_mixed(t *testing.T) {
+	key := []byte("batch secret")
+	var tokens []string
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodHS256, jwt.SigningMethodHS512, jwt.SigningMethodHS256} {
+		s, _ := jwt.New(method).SignedString(key)
+		tokens = append(tokens, s)
+	}
+	rsaToken := test.MakeSampleToken(jwt.MapClaims{}, test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+	tokens = append(tokens, rsaToken)
+
+	results := (&jwt.Parser{ValidMethods: []string{"HS256", "HS512", "RS256"}}).VerifyBatch(tokens, key)
+	for i, r := range results[:3] {
+		if r.Err != nil {
+			t.Errorf("[%v] Error verifying: %v", i, r.Err)
+		}
+	}
+	if !errors.Is(results[3].Err, jwt.ErrInvalidKeyType) {
+		t.Errorf("Expected the RS256 token to fail with ErrInvalidKeyType.  Got %v", results[3].Err)
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	key := []byte("batch secret")
+	tokens := make([]string, 1000)
+	for i := range tokens {
+		tokens[i], _ = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": fmt.Sprint(i)}).SignedString(key)
+	}
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	b.Run("VerifyBatch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			new(jwt.Parser).VerifyBatch(tokens, key)
+		}
+	})
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, s := range tokens {
+				jwt.Parse(s, keyFunc)
+			}
+		}
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !jwtunsafe
+
+package jwt
+
+// Conversions for the signing and parsing hot paths.  These copy; building
+// with -tags jwtunsafe swaps in the zero-copy versions of
+// bytesconv_unsafe.go.
+
+// Whether stringBytes and bytesString share memory with their argument
+const zeroCopy = false
+
+func stringBytes(s string) []byte {
+	return []byte(s)
+}
+
+func bytesString(b []byte) string {
+	return string(b)
+}
@@ -0,0 +1,45 @@
+package jwt_test
+
+import (
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+// Run with and without -tags jwtunsafe: neither signing nor parsing may
+// change strings they have handed out or been given
+func TestConversions_aliasing(t *testing.T) {
+	key := []byte("aliasing secret")
+	var tokens, copies []string
+	for _, sub := range []string{"alice", "bob", "carol"} {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": sub}).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, s)
+		copies = append(copies, string([]byte(s)))
+	}
+
+	// Sign gets the signing string from the reused buffer
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	buf := make([]byte, 0, 1024)
+	for i, s := range tokens {
+		var err error
+		buf, err = jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"n": i}).AppendSignedString(buf[:0], privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = jwt.Parse(string(buf), func(*jwt.Token) (interface{}, error) { return &privateKey.PublicKey, nil }); err != nil {
+			t.Errorf("[%v] Error parsing the appended token: %v", i, err)
+		}
+		if _, err = jwt.Parse(s, func(*jwt.Token) (interface{}, error) { return key, nil }); err != nil {
+			t.Errorf("[%v] Error parsing: %v", i, err)
+		}
+	}
+	for i := range tokens {
+		if tokens[i] != copies[i] {
+			t.Errorf("[%v] Expected the token to be unchanged.  Got %v", i, tokens[i])
+		}
+	}
+}
@@ -0,0 +1,30 @@
+//go:build jwtunsafe
+
+package jwt
+
+import "unsafe"
+
+// Zero-copy conversions, for -tags jwtunsafe.  The results share memory
+// with their argument, so:
+//
+//   - bytes from stringBytes are never written to, since they may be the
+//     caller's token string.  That goes for hash.Hash implementations
+//     given them too, as io.Writer already requires.
+//   - the string from bytesString is the signing string passed to
+//     SigningMethod.Sign by AppendSignedString, which is the caller's
+//     buffer.  Sign and Verify must not keep signingString after they
+//     return, which none of the methods here do.
+//
+// Buffers this package builds and never writes to again, such as that of
+// SignedString, are returned as strings without copying too.
+
+// Whether stringBytes and bytesString share memory with their argument
+const zeroCopy = true
+
+func stringBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+func bytesString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
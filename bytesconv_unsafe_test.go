@@ -0,0 +1,7 @@
+//go:build jwtunsafe
+
+package jwt_test
+
+// Allocations of returning a built token as a string, which copies it
+// unless built with -tags jwtunsafe
+const stringCopyAllocs = 0
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"time"
+)
+
+var (
+	ErrCertificateExpired     = newError(CodeCertificateExpired, "issuer certificate has expired")
+	ErrCertificateNotYetValid = newError(CodeCertificateNotYetValid, "issuer certificate is not valid yet")
+	ErrCertificateKeyMismatch = newError(CodeCertificateKeyMismatch, "x5c certificate does not match the JWK key")
+)
+
+// Parse the x5c chain of the key.  The first certificate is the one
+// holding the key.  Returns nil if the JWK has no x5c member.
+func (k *JSONWebKey) Certificates() ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0, len(k.X5c))
+	for _, c := range k.X5c {
+		// Unlike everything else in a JWK, x5c is standard base64
+		der, err := base64.StdEncoding.DecodeString(c)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, nil
+	}
+	return certs, nil
+}
+
+// For keys carrying an x5c chain, check that the leaf certificate is
+// currently valid, allowing for leeway of clock skew, and that it holds the
+// same key as the JWK.  Keys without x5c pass.
+func (k *JSONWebKey) CheckCertificate(leeway time.Duration) error {
+	cert, err := k.leafCertificate()
+	if err != nil || cert == nil {
+		return err
+	}
+	return checkCertificateValidity(cert, TimeFunc(), leeway)
+}
+
+// The leaf of the x5c chain, after checking it holds the same key as the
+// JWK, or nil if there is no x5c
+func (k *JSONWebKey) leafCertificate() (*x509.Certificate, error) {
+	certs, err := k.Certificates()
+	if err != nil || certs == nil {
+		return nil, err
+	}
+
+	key, err := k.Key()
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := verificationKey(key).(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(certs[0].PublicKey) {
+		return nil, ErrCertificateKeyMismatch
+	}
+	return certs[0], nil
+}
+
+// Check the validity period of cert at now, allowing for leeway
+func checkCertificateValidity(cert *x509.Certificate, now time.Time, leeway time.Duration) error {
+	if now.Add(leeway).Before(cert.NotBefore) {
+		return ErrCertificateNotYetValid
+	}
+	if now.Add(-leeway).After(cert.NotAfter) {
+		return ErrCertificateExpired
+	}
+	return nil
+}
+
+// Returns the certificate if the first PEM block in data is one
+func parseCertificateFromPEM(data []byte) *x509.Certificate {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil
+	}
+	cert, _ := x509.ParseCertificate(block.Bytes)
+	return cert
+}
@@ -0,0 +1,101 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+// Self-signed certificate for the sample key, valid for January 2020
+func makeSampleCertificate(t *testing.T) []byte {
+	priv := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "issuer"},
+		NotBefore:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+var certificateTestData = []struct {
+	name string
+	now  time.Time
+	err  error
+}{
+	{"valid", time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC), nil},
+	{"expired", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), jwt.ErrCertificateExpired},
+	{"expired within leeway", time.Date(2020, 2, 1, 0, 0, 30, 0, time.UTC), nil},
+	{"not yet valid", time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC), jwt.ErrCertificateNotYetValid},
+}
+
+func TestFileKeyProvider_certificateValidity(t *testing.T) {
+	der := makeSampleCertificate(t)
+	dir := t.TempDir()
+
+	// The same certificate as a PEM file and as the x5c of a JWK
+	pemPath := filepath.Join(dir, "cert.pem")
+	ioutil.WriteFile(pemPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+
+	jwk, _ := jwt.NewJSONWebKey(test.LoadRSAPublicKeyFromDisk("test/sample_key.pub"))
+	jwk.X5c = []string{base64.StdEncoding.EncodeToString(der)}
+	jwkData, _ := json.Marshal(jwk)
+	jwkPath := filepath.Join(dir, "key.jwk")
+	ioutil.WriteFile(jwkPath, jwkData, 0600)
+
+	s := test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+
+	for _, path := range []string{pemPath, jwkPath} {
+		provider, err := jwt.NewFileKeyProvider(path)
+		if err != nil {
+			t.Fatalf("Error loading %v: %v", path, err)
+		}
+		provider.CertificateLeeway = time.Minute
+
+		for _, data := range certificateTestData {
+			check := func(how string, err error) {
+				if data.err == nil && err != nil {
+					t.Errorf("[%v %v %v] Error verifying token: %v", filepath.Base(path), data.name, how, err)
+				}
+				if data.err != nil {
+					if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != data.err {
+						t.Errorf("[%v %v %v] Expected %v.  Got %v", filepath.Base(path), data.name, how, data.err, err)
+					}
+				}
+			}
+			at(data.now, func() {
+				_, err := jwt.Parse(s, provider.Keyfunc)
+				check("TimeFunc", err)
+			})
+
+			// The certificate is checked at the parser's time too
+			parser := &jwt.Parser{TimeFunc: func() time.Time { return data.now }}
+			_, err := parser.Parse(s, provider.Keyfunc)
+			check("Parser.TimeFunc", err)
+		}
+	}
+}
+
+func TestJWKCheckCertificate_mismatch(t *testing.T) {
+	jwk, _, _ := jwt.GenerateJWK("RS256")
+	jwk.X5c = []string{base64.StdEncoding.EncodeToString(makeSampleCertificate(t))}
+
+	if err := jwk.CheckCertificate(0); err != jwt.ErrCertificateKeyMismatch {
+		t.Errorf("Expected ErrCertificateKeyMismatch.  Got %v", err)
+	}
+}
@@ -2,7 +2,7 @@ package jwt
 
 import (
 	"crypto/subtle"
-	"fmt"
+	"encoding/json"
 	"time"
 )
 
@@ -13,6 +13,15 @@ type Claims interface {
 	Valid() error
 }
 
+// Claims that can be validated at a given time, as a Parser with TimeFunc
+// set does.  Types embedding StandardClaims are only TimedClaims if they
+// declare ValidAt themselves, so a Valid of their own is never bypassed.
+// StandardClaims.CheckTimes makes ValidAt easy to write.
+type TimedClaims interface {
+	Claims
+	ValidAt(now time.Time) error
+}
+
 // Structured version of Claims Section, as referenced at
 // https://tools.ietf.org/html/rfc7519#section-4.1
 // See examples for how to use this with your own claim types
@@ -32,33 +41,67 @@ type StandardClaims struct {
 // As well, if any of the above claims are not in the token, it will still
 // be considered a valid claim.
 func (c StandardClaims) Valid() error {
-	vErr := new(ValidationError)
-	now := TimeFunc().Unix()  // 将jwt-go提供的时间转换为unix时间戳
+	return c.validAt(TimeFunc().Unix())  // 将jwt-go提供的时间转换为unix时间戳
+}
+
+// Checks exp, iat and nbf as Valid does, but at now rather than at
+// TimeFunc
+func (c StandardClaims) CheckTimes(now time.Time) error {
+	return c.validAt(now.Unix())
+}
+
+// Valid at now, a Unix time
+func (c StandardClaims) validAt(now int64) error {
+	var vErr ValidationError
 
 	// The claims below are optional, by default, so if they are set to the
 	// default value in Go, let's not fail the verification for them.
 	// 验证是否过期
 	if c.VerifyExpiresAt(now, false) == false {
-		delta := time.Unix(now, 0).Sub(time.Unix(c.ExpiresAt, 0))
-		vErr.Inner = fmt.Errorf("token is expired by %v", delta)
-		vErr.Errors |= ValidationErrorExpired
+		vErr.add(newExpiredError(c.ExpiresAt, now), ValidationErrorExpired)
 	}
 
 	if c.VerifyIssuedAt(now, false) == false {
-		vErr.Inner = fmt.Errorf("Token used before issued")
-		vErr.Errors |= ValidationErrorIssuedAt
+		vErr.add(newIssuedAtError(c.IssuedAt, now), ValidationErrorIssuedAt)
 	}
 
 	if c.VerifyNotBefore(now, false) == false {
-		vErr.Inner = fmt.Errorf("token is not valid yet")
-		vErr.Errors |= ValidationErrorNotValidYet
+		vErr.add(newNotValidYetError(c.NotBefore, now), ValidationErrorNotValidYet)
 	}
 
 	if vErr.valid() {
 		return nil
 	}
 
-	return vErr
+	return vErr.escape()
+}
+
+// Returns the aud claim as a list, empty if unset
+func (c StandardClaims) GetAudience() []string {
+	if c.Audience == "" {
+		return nil
+	}
+	return []string{c.Audience}
+}
+
+// Returns the iss claim
+func (c StandardClaims) GetIssuer() string {
+	return c.Issuer
+}
+
+// Returns the jti claim
+func (c StandardClaims) GetId() string {
+	return c.Id
+}
+
+// Returns the sub claim
+func (c StandardClaims) GetSubject() string {
+	return c.Subject
+}
+
+// Returns the iat claim, 0 if unset
+func (c StandardClaims) GetIssuedAt() int64 {
+	return c.IssuedAt
 }
 
 // Compares the aud claim against cmp. 比较aud和cmp
@@ -96,8 +139,94 @@ func (c *StandardClaims) VerifyNotBefore(cmp int64, req bool) bool {
 	return verifyNbf(c.NotBefore, cmp, req)
 }
 
+// Check that the aud claim of claims contains expected.  Returns an
+// *AudienceError if it doesn't.  Claims must be MapClaims or provide
+// GetAudience, as StandardClaims and types embedding it do.
+func ValidateAudience(claims Claims, expected string) error {
+	var got []string
+	if c, ok := claims.(interface{ GetAudience() []string }); ok {
+		got = c.GetAudience()
+	}
+	if !verifyAudList(got, expected, true) {
+		return &AudienceError{Expected: expected, Got: got}
+	}
+	return nil
+}
+
+// Check that the iss claim of claims matches expected.  Returns an
+// *IssuerError if it doesn't.  Claims must be MapClaims or provide
+// GetIssuer, as StandardClaims and types embedding it do.
+func ValidateIssuer(claims Claims, expected string) error {
+	var got string
+	if c, ok := claims.(interface{ GetIssuer() string }); ok {
+		got = c.GetIssuer()
+	}
+	if !verifyIss(got, expected, true) {
+		return &IssuerError{Expected: expected, Got: got}
+	}
+	return nil
+}
+
+// A claim that is a single string or an array of strings, such as aud.
+// Marshals a single value as a string.
+type ClaimStrings []string
+
+func (s ClaimStrings) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]string(s))
+}
+
+func (s *ClaimStrings) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*s = ClaimStrings{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*s = many
+	return nil
+}
+
 // ----- helpers 助手函数
 
+func newExpiredError(exp int64, now int64) error {
+	return &ExpiredError{
+		ExpiresAt: time.Unix(exp, 0),
+		ExpiredBy: time.Unix(now, 0).Sub(time.Unix(exp, 0)),
+	}
+}
+
+func newIssuedAtError(iat int64, now int64) error {
+	return &IssuedAtError{
+		IssuedAt: time.Unix(iat, 0),
+		IssuedIn: time.Unix(iat, 0).Sub(time.Unix(now, 0)),
+	}
+}
+
+func newNotValidYetError(nbf int64, now int64) error {
+	return &NotValidYetError{
+		NotBefore: time.Unix(nbf, 0),
+		ValidIn:   time.Unix(nbf, 0).Sub(time.Unix(now, 0)),
+	}
+}
+
+func verifyAudList(auds []string, cmp string, required bool) bool {
+	if len(auds) == 0 {
+		return !required
+	}
+	for _, aud := range auds {
+		if verifyAud(aud, cmp, required) {
+			return true
+		}
+	}
+	return false
+}
+
 func verifyAud(aud string, cmp string, required bool) bool {
 	if aud == "" {
 		return !required
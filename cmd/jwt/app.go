@@ -3,6 +3,9 @@
 //
 // Example usage:
 // The following will create and sign a token, then verify it and output the original claims.
+//     bin/jwt sign -alg RS256 -key test/sample_key -claims '{"foo":"bar"}' | bin/jwt verify -key test/sample_key.pub -
+//
+// The flag forms from before there were subcommands still work:
 //     echo {\"foo\":\"bar\"} | bin/jwt -key test/sample_key -alg RS256 -sign - | bin/jwt -key test/sample_key.pub -verify -
 package main
 
@@ -35,6 +38,20 @@ var (
 )
 
 func main() {
+	// Subcommands have flags of their own
+	if len(os.Args) > 1 {
+		if cmd := findCommand(os.Args[1]); cmd != nil {
+			e := &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+			if err := cmd.run(e, os.Args[2:]); err == flag.ErrHelp {
+				os.Exit(exitUsage)
+			} else if err != nil {
+				e.reportError(err)
+				os.Exit(exitCode(err))
+			}
+			return
+		}
+	}
+
 	// Plug in Var flags
 	flag.Var(flagClaims, "claim", "add additional claims. may be used more than once")
 	flag.Var(flagHead, "header", "add additional header params. may be used more than once")
@@ -42,7 +59,9 @@ func main() {
 	// Usage message if you ask for -help or if you mess up inputs.
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  One of the following flags is required: sign, verify\n")
+		fmt.Fprintf(os.Stderr, "  %s <command> [flags] [args]\n", os.Args[0])
+		printCommands(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Or, without a command, one of the following flags is required: sign, verify, show\n")
 		flag.PrintDefaults()
 	}
 
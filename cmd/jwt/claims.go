@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// The NumericDate claims jwt sign has flags for
+var timeClaims = []string{"exp", "nbf", "iat"}
+
+// The claims of jwt sign -claims: inline JSON, @path for a file or - for
+// stdin
+func (e *env) readClaims(arg string) (jwt.MapClaims, error) {
+	data := []byte(arg)
+	if arg == "-" || strings.HasPrefix(arg, "@") {
+		var err error
+		if data, err = e.readFile(strings.TrimPrefix(arg, "@")); err != nil {
+			return nil, fmt.Errorf("Couldn't read claims: %v", err)
+		}
+	}
+	var c jwt.MapClaims
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("Couldn't parse claims JSON: %v", err)
+	}
+	if c == nil {
+		c = jwt.MapClaims{}
+	}
+	return c, nil
+}
+
+// The value of -claim k=v: v decoded if it is JSON, and as a string if
+// not.  A string that would be JSON must be quoted, as in sub='"123"'.
+func claimValue(v string) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(v), &decoded); err == nil {
+		return decoded
+	}
+	return v
+}
+
+// A time given to jwt sign: +15m or -30s relative to now, now itself, a
+// Unix time or an RFC 3339 time
+func parseTime(s string, now time.Time) (time.Time, error) {
+	switch {
+	case s == "now":
+		return now, nil
+	case strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-"):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(d), nil
+	}
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a relative duration, now, a Unix time or an RFC 3339 time", s)
+	}
+	return t, nil
+}
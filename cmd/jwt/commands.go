@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Where a command reads and writes.  Commands never touch os.Stdin and
+// os.Stdout themselves, so that tests can run them.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+	now            func() time.Time // Defaults to time.Now
+	format         format           // Set by the command's -format
+}
+
+func (e *env) time() time.Time {
+	if e.now != nil {
+		return e.now()
+	}
+	return time.Now()
+}
+
+// A subcommand, run as: jwt <name> [flags] [args]
+type command struct {
+	name    string
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands = []command{
+	{"sign", "sign claims and print the token", runSign},
+	{"verify", "verify a token and print its claims", runVerify},
+	{"decode", "print the header and claims of a token without verifying it", runDecode},
+	{"lint", "report the security issues of a token", runLint},
+	{"keygen", "generate a key for an algorithm", runKeygen},
+	{"encrypt", "encrypt a file or token as a JWE", runEncrypt},
+	{"decrypt", "decrypt a JWE and print its plaintext", runDecrypt},
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// Print the subcommands, for the usage message of main
+func printCommands(w io.Writer) {
+	fmt.Fprintf(w, "Commands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %v\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "Run '%v <command> -help' for the flags of a command.\n", os.Args[0])
+}
+
+// A flag set for the command name taking args after its flags
+func (e *env) flagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: jwt %v [flags] %v\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Parse args with fs, allowing flags after positional arguments as in
+// "jwt verify $TOKEN -key k.pub", and return the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err == flag.ErrHelp {
+			return nil, err
+		} else if err != nil {
+			return nil, usageError{err}
+		}
+		if args = fs.Args(); len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// Read the file at path, or stdin for "-"
+func (e *env) readFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("No path specified")
+	}
+	if path == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// The token given as the only positional argument, or read from stdin
+// when that is "-" or missing
+func (e *env) readToken(args []string) (string, error) {
+	if len(args) > 1 {
+		return "", usageErrorf("Expected one token.  Got %v arguments", len(args))
+	}
+	if len(args) == 1 && args[0] != "-" {
+		return strings.TrimSpace(args[0]), nil
+	}
+	data, err := io.ReadAll(e.stdin)
+	if err != nil {
+		return "", fmt.Errorf("Couldn't read token: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func writeJSON(w io.Writer, v interface{}, compact bool) error {
+	var out []byte
+	var err error
+	if compact {
+		out, err = json.Marshal(v)
+	} else {
+		out, err = json.MarshalIndent(v, "", "    ")
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+// jwt sign: build a token from claims and flags and sign it
+func runSign(e *env, args []string) error {
+	fs := e.flagSet("sign", "")
+	alg := fs.String("alg", "", "signing algorithm identifier, e.g. HS256")
+	keyPath := fs.String("key", "", "path to the signing key, or '-' to read it from stdin: a PEM encoded private key, a JWK or JWK Set, or the secret for HMAC")
+	kid := fs.String("kid", "", "kid of the key to sign with from a JWK Set")
+	claimsJSON := fs.String("claims", "{}", "claims as a JSON object, '@path' to read them from a file, or '-' to read them from stdin")
+	claims := make(ArgList)
+	fs.Var(claims, "claim", "set a claim as key=value, overriding -claims.  values that are JSON, such as 3 or true, are used as such.  may be used more than once")
+	var times [3]string
+	for i, name := range timeClaims {
+		fs.StringVar(&times[i], name, "", "set the "+name+" claim: a time relative to now such as +15m or -30s, now, a Unix time or an RFC 3339 time")
+	}
+	headers := make(ArgList)
+	fs.Var(headers, "header", "add a header parameter as key=value. may be used more than once")
+	e.formatFlag(fs, formatRaw, "the token.  json adds its header and claims, and table lists those before it")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usageErrorf("Unexpected arguments: %v", strings.Join(rest, " "))
+	}
+
+	method := jwt.GetSigningMethod(*alg)
+	if method == nil {
+		return usageErrorf("Couldn't find signing method: %q", *alg)
+	}
+	if *claimsJSON == "-" && *keyPath == "-" {
+		return usageErrorf("Only one of -claims and -key can be read from stdin")
+	}
+	c, err := e.readClaims(*claimsJSON)
+	if err != nil {
+		return err
+	}
+	for k, v := range claims {
+		c[k] = claimValue(v)
+	}
+	now := e.time()
+	for i, name := range timeClaims {
+		if times[i] == "" {
+			continue
+		}
+		t, err := parseTime(times[i], now)
+		if err != nil {
+			return usageErrorf("Invalid -%v: %v", name, err)
+		}
+		c[name] = t.Unix()
+	}
+	key, keyID, err := e.loadSigningKey(method, *keyPath, *kid)
+	if err != nil {
+		return err
+	}
+
+	token := jwt.NewWithClaims(method, c)
+	if keyID != "" {
+		token.Header["kid"] = keyID
+	}
+	for k, v := range headers {
+		token.Header[k] = v
+	}
+	out, err := token.SignedString(key)
+	if err != nil {
+		return fmt.Errorf("Error signing token: %w", err)
+	}
+	switch e.format {
+	case formatJSON:
+		return writeJSON(e.stdout, struct {
+			Token  string                 `json:"token"`
+			Header map[string]interface{} `json:"header"`
+			Claims jwt.MapClaims          `json:"claims"`
+		}{out, token.Header, c}, false)
+	case formatTable:
+		return writeTable(e.stdout, append(mapRows(token.Header), row{"token", out}))
+	}
+	_, err = fmt.Fprintln(e.stdout, out)
+	return err
+}
+
+// jwt verify: check a token's signature and claims, and print the claims
+func runVerify(e *env, args []string) error {
+	fs := e.flagSet("verify", "token|-")
+	keyPath := fs.String("key", "", "path to the verification key, or '-' to read it from stdin: a PEM encoded key or certificate, a JWK or JWK Set, or the secret for HMAC")
+	kid := fs.String("kid", "", "kid of the key to verify with from a JWK Set.  Defaults to the token's kid")
+	jwksURL := fs.String("jwks-url", "", "URL of a JWK Set to fetch the key from, by the token's kid, instead of -key")
+	timeout := fs.Duration("timeout", 10*time.Second, "time allowed for fetching -jwks-url")
+	compact := fs.Bool("compact", false, "output compact JSON")
+	e.formatFlag(fs, formatJSON, "the claims.  raw is the claims as signed, and table lists them")
+	var pol policy
+	pol.flags(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if (*keyPath == "") == (*jwksURL == "") {
+		return usageErrorf("Exactly one of -key and -jwks-url is required")
+	}
+	tokenString, err := e.readToken(rest)
+	if err != nil {
+		return err
+	}
+
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		keyID := *kid
+		if keyID == "" {
+			keyID, _ = t.Header["kid"].(string)
+		}
+		return e.loadVerificationKey(t.Method, *keyPath, keyID)
+	}
+	if *jwksURL != "" {
+		keyFunc = remoteKeyfunc(*jwksURL, *timeout)
+	}
+	token, err := pol.parser().Parse(tokenString, pol.keyfunc(keyFunc))
+	if err == nil {
+		err = pol.check(token.Claims.(jwt.MapClaims), e.time())
+	}
+	if err != nil {
+		return fmt.Errorf("Token is invalid: %w", err)
+	}
+	switch e.format {
+	case formatRaw:
+		_, claims, err := rawSegments(token)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(e.stdout, string(claims))
+		return err
+	case formatTable:
+		return writeTable(e.stdout, mapRows(token.Claims.(jwt.MapClaims)))
+	}
+	return writeJSON(e.stdout, token.Claims, *compact)
+}
@@ -0,0 +1,637 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// The time commands run at in tests
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// Run the command line args with stdin, returning what it printed
+func runCLI(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	e := &env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr, now: func() time.Time { return testNow }}
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		t.Fatalf("No command %q", args[0])
+	}
+	err := cmd.run(e, args[1:])
+	return stdout.String(), err
+}
+
+func writeSecret(t *testing.T, secret string) string {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(secret), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSignVerify(t *testing.T) {
+	secret := writeSecret(t, "my secret")
+	var tests = []struct {
+		alg, signKey, verifyKey string
+	}{
+		{"HS256", secret, secret},
+		{"RS256", "../../test/sample_key", "../../test/sample_key.pub"},
+		{"PS384", "../../test/sample_key", "../../test/sample_key"},
+		{"ES256", "../../test/ec256-private.pem", "../../test/ec256-public.pem"},
+	}
+	for _, data := range tests {
+		token, err := runCLI(t, "", "sign", "-alg", data.alg, "-key", data.signKey, "-claims", `{"sub":"alice"}`, "-claim", "role=admin")
+		if err != nil {
+			t.Errorf("[%v] Error signing: %v", data.alg, err)
+			continue
+		}
+
+		// The token from an argument, with flags after it, and from stdin
+		for _, args := range [][]string{
+			{"verify", strings.TrimSpace(token), "-key", data.verifyKey, "-compact"},
+			{"verify", "-compact", "-key", data.verifyKey, "-"},
+		} {
+			out, err := runCLI(t, token, args...)
+			if expect := `{"role":"admin","sub":"alice"}` + "\n"; err != nil || out != expect {
+				t.Errorf("[%v] Expected %v.  Got %v, %v", data.alg, expect, out, err)
+			}
+		}
+	}
+}
+
+func TestVerify_refused(t *testing.T) {
+	secret := writeSecret(t, "my secret")
+	token, err := runCLI(t, "", "sign", "-alg", "HS256", "-key", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCLI(t, token, "verify", "-key", writeSecret(t, "other secret")); err == nil {
+		t.Errorf("Expected a token signed with another secret to be refused")
+	}
+
+	// A public key must not be usable as an HMAC secret
+	pub, _ := os.ReadFile("../../test/sample_key.pub")
+	if _, err := runCLI(t, "", "sign", "-alg", "HS256", "-key", "../../test/sample_key.pub"); err == nil {
+		t.Errorf("Expected a PEM file to be refused as an HMAC secret")
+	}
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "mallory"}).SignedString(pub)
+	if _, err := runCLI(t, forged, "verify", "-key", "../../test/sample_key.pub"); err == nil {
+		t.Errorf("Expected an HS256 token to be refused with a PEM key")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	token, err := runCLI(t, "", "sign", "-alg", "none", "-claims", `{"sub":"alice"}`, "-header", "kid=k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runCLI(t, token, "decode", "-compact")
+	expect := "NOT VERIFIED: neither the signature nor the claims of this token have been checked\n" +
+		"Header:\n" + `{"alg":"none","kid":"k1","typ":"JWT"}` + "\nClaims:\n" + `{"sub":"alice"}` + "\n"
+	if err != nil || out != expect {
+		t.Errorf("Expected %v.  Got %v, %v", expect, out, err)
+	}
+	if _, err := runCLI(t, "", "decode", "not a token"); err == nil {
+		t.Errorf("Expected a malformed token to be refused")
+	}
+}
+
+func TestDecode_times(t *testing.T) {
+	claims := fmt.Sprintf(`{"exp":%v,"iat":%v,"nbf":%v}`, testNow.Add(15*time.Minute).Unix(), testNow.Add(-72*time.Hour).Unix(), testNow.Unix()+30)
+	token, err := runCLI(t, "", "sign", "-alg", "none", "-claims", claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runCLI(t, token, "decode", "-compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{
+		"Times:\n",
+		"  exp  2024-05-01 12:15:00 UTC  (valid for another 15m0s)\n",
+		"  iat  2024-04-28 12:00:00 UTC  (issued 3d0h ago)\n",
+		"  nbf  2024-05-01 12:00:30 UTC  (not valid for another 30s)\n",
+	} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("Expected %q in the output.  Got %v", expect, out)
+		}
+	}
+
+	if out, _ := runCLI(t, token, "decode", "-color", "always"); !strings.Contains(out, colorYellow+"NOT VERIFIED") {
+		t.Errorf("Expected colored output.  Got %q", out)
+	}
+	if out, _ := runCLI(t, token, "decode"); strings.Contains(out, "\x1b[") {
+		t.Errorf("Expected no color when not writing to a terminal.  Got %q", out)
+	}
+}
+
+func writeJSONFile(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeSecret(t, string(data))
+}
+
+func TestSignVerify_jwk(t *testing.T) {
+	ec, _, err := jwt.GenerateJWK("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, _, err := jwt.GenerateJWK("RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs, _, err := jwt.GenerateJWK("HS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	private := writeJSONFile(t, jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{*ec, *rs}})
+	public := writeJSONFile(t, jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{*ec.Public(), *rs.Public()}})
+
+	var tests = []struct {
+		alg, kid, signKey, verifyKey string
+	}{
+		{"ES256", ec.Kid, private, public},
+		{"RS256", rs.Kid, private, public},
+		{"ES256", "", writeJSONFile(t, ec), writeJSONFile(t, ec.Public())},
+		{"HS256", "", writeJSONFile(t, hs), writeJSONFile(t, hs)},
+	}
+	for _, data := range tests {
+		token, err := runCLI(t, "", "sign", "-alg", data.alg, "-key", data.signKey, "-kid", data.kid)
+		if err != nil {
+			t.Errorf("[%v] Error signing: %v", data.alg, err)
+			continue
+		}
+		out, err := runCLI(t, token, "decode", "-compact")
+		if expect := `"kid":"` + ec.Kid; data.alg == "ES256" && !strings.Contains(out, expect) {
+			t.Errorf("[%v] Expected the JWK's kid in the header.  Got %v, %v", data.alg, out, err)
+		}
+		if _, err := runCLI(t, token, "verify", "-key", data.verifyKey); err != nil {
+			t.Errorf("[%v] Error verifying: %v", data.alg, err)
+		}
+	}
+
+	// A set of several needs a kid, and keys are bound to their alg
+	if _, err := runCLI(t, "", "sign", "-alg", "ES256", "-key", private); err == nil {
+		t.Errorf("Expected an error choosing from several keys without a kid")
+	}
+	if _, err := runCLI(t, "", "sign", "-alg", "ES384", "-key", private, "-kid", ec.Kid); err == nil {
+		t.Errorf("Expected an ES256 key to be refused for ES384")
+	}
+	if _, err := runCLI(t, "", "sign", "-alg", "ES256", "-key", public, "-kid", ec.Kid); err == nil {
+		t.Errorf("Expected a public key to be refused for signing")
+	}
+}
+
+func TestVerify_jwksURL(t *testing.T) {
+	ec, _, err := jwt.GenerateJWK("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := jwt.GenerateJWK("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{*ec.Public()}})
+	}))
+	defer server.Close()
+
+	token, err := runCLI(t, "", "sign", "-alg", "ES256", "-key", writeJSONFile(t, ec), "-claims", `{"sub":"alice"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runCLI(t, token, "verify", "-jwks-url", server.URL, "-compact")
+	if expect := `{"sub":"alice"}` + "\n"; err != nil || out != expect {
+		t.Errorf("Expected %v.  Got %v, %v", expect, out, err)
+	}
+
+	// A key the issuer doesn't publish is reported with the kids it does
+	unknown, err := runCLI(t, "", "sign", "-alg", "ES256", "-key", writeJSONFile(t, other))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = runCLI(t, unknown, "verify", "-jwks-url", server.URL)
+	if err == nil || !strings.Contains(err.Error(), strconv.Quote(other.Kid)) || !strings.Contains(err.Error(), strconv.Quote(ec.Kid)) {
+		t.Errorf("Expected an error naming both kids.  Got %v", err)
+	}
+
+	if _, err := runCLI(t, token, "verify", "-jwks-url", server.URL, "-key", "../../test/ec256-public.pem"); err == nil {
+		t.Errorf("Expected -key and -jwks-url together to be refused")
+	}
+}
+
+func TestKeygen(t *testing.T) {
+	for _, alg := range []string{"HS256", "HS512", "RS256", "PS384", "ES256", "ES512", "EdDSA"} {
+		dir := t.TempDir()
+		out, pub, jwkOut := filepath.Join(dir, "key"), filepath.Join(dir, "key.pub"), filepath.Join(dir, "key.jwk")
+		args := []string{"keygen", "-alg", alg, "-out", out, "-jwk-out", jwkOut}
+		if !strings.HasPrefix(alg, "HS") {
+			args = append(args, "-pub-out", pub)
+		} else {
+			pub = out
+		}
+		summary, err := runCLI(t, "", args...)
+		if err != nil {
+			t.Errorf("[%v] Error generating: %v", alg, err)
+			continue
+		}
+		if !strings.HasPrefix(summary, "alg: "+alg+"\nkid: ") {
+			t.Errorf("[%v] Expected the alg and kid.  Got %v", alg, summary)
+		}
+
+		// The keys work for signing and verifying, as PEM and as JWK
+		for _, k := range [][2]string{{out, pub}, {jwkOut, jwkOut}} {
+			token, err := runCLI(t, "", "sign", "-alg", alg, "-key", k[0])
+			if err != nil {
+				t.Errorf("[%v] Error signing with %v: %v", alg, k[0], err)
+				continue
+			}
+			if _, err := runCLI(t, token, "verify", "-key", k[1]); err != nil {
+				t.Errorf("[%v] Error verifying with %v: %v", alg, k[1], err)
+			}
+		}
+
+		if _, err := runCLI(t, "", args...); err == nil {
+			t.Errorf("[%v] Expected existing files not to be overwritten", alg)
+		}
+	}
+
+	if _, err := runCLI(t, "", "keygen", "-alg", "RS256", "-bits", "1024", "-out", filepath.Join(t.TempDir(), "key")); err == nil {
+		t.Errorf("Expected a 1024 bit RSA key to be refused")
+	}
+}
+
+func TestSign_claims(t *testing.T) {
+	file := writeSecret(t, `{"sub":"alice","role":"user"}`)
+	var tests = []struct {
+		stdin  string
+		args   []string
+		expect string
+	}{
+		{`{"sub":"alice"}`, []string{"-claims", "-"}, `{"sub":"alice"}`},
+		{"", []string{"-claims", "@" + file, "-claim", "role=admin"}, `{"role":"admin","sub":"alice"}`},
+		{"", []string{"-claim", "n=3", "-claim", "admin=true", "-claim", `id="123"`, "-claim", "groups=[\"a\"]"}, `{"admin":true,"groups":["a"],"id":"123","n":3}`},
+		{"", []string{"-exp", "+15m", "-nbf", "+0s", "-iat", "-1h"}, fmt.Sprintf(`{"exp":%v,"iat":%v,"nbf":%v}`, testNow.Unix()+900, testNow.Unix()-3600, testNow.Unix())},
+		{"", []string{"-exp", "1700000000", "-nbf", "2024-05-01T12:00:00Z", "-iat", "now"}, fmt.Sprintf(`{"exp":1700000000,"iat":%v,"nbf":%v}`, testNow.Unix(), testNow.Unix())},
+	}
+	for _, data := range tests {
+		args := append([]string{"sign", "-alg", "none"}, data.args...)
+		token, err := runCLI(t, data.stdin, args...)
+		if err != nil {
+			t.Errorf("[%v] Error signing: %v", data.args, err)
+			continue
+		}
+		out, err := runCLI(t, token, "decode", "-compact")
+		if !strings.Contains(out, "Claims:\n"+data.expect+"\n") {
+			t.Errorf("[%v] Expected %v.  Got %v, %v", data.args, data.expect, out, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-exp", "15m"},
+		{"-claims", "-", "-key", "-"},
+		{"-claims", "@/does/not/exist"},
+	} {
+		if _, err := runCLI(t, "{}", append([]string{"sign", "-alg", "HS256"}, args...)...); err == nil {
+			t.Errorf("[%v] Expected an error", args)
+		}
+	}
+}
+
+func TestVerify_policy(t *testing.T) {
+	secret := writeSecret(t, "secret")
+	sign := func(claims string) string {
+		token, err := runCLI(t, "", "sign", "-alg", "HS256", "-key", secret, "-claims", claims)
+		if err != nil {
+			t.Fatalf("Error signing: %v", err)
+		}
+		return token
+	}
+	claims := fmt.Sprintf(`{"iss":"https://issuer","aud":["api","web"],"sub":"alice","exp":%v}`, testNow.Unix()-30)
+	token := sign(claims)
+
+	var tests = []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{}, false},
+		{[]string{"-leeway", "1m"}, true},
+		{[]string{"-leeway", "10s"}, false},
+		{[]string{"-leeway", "1m", "-iss", "https://issuer", "-aud", "web", "-sub", "alice", "-alg", "HS256,HS384"}, true},
+		{[]string{"-leeway", "1m", "-iss", "https://other"}, false},
+		{[]string{"-leeway", "1m", "-aud", "admin"}, false},
+		{[]string{"-leeway", "1m", "-sub", "bob"}, false},
+		{[]string{"-leeway", "1m", "-alg", "RS256"}, false},
+	}
+	for _, data := range tests {
+		args := append([]string{"verify", "-key", secret, token}, data.args...)
+		_, err := runCLI(t, "", args...)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Expected valid.  Got %v", data.args, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("[%v] Expected an error", data.args)
+		}
+	}
+
+	future := sign(fmt.Sprintf(`{"nbf":%v,"iat":%v}`, testNow.Unix()+30, testNow.Unix()+30))
+	if _, err := runCLI(t, "", "verify", "-key", secret, future); err == nil || !strings.Contains(err.Error(), "not valid until") || !strings.Contains(err.Error(), "issued in the future") {
+		t.Errorf("Expected both nbf and iat errors.  Got %v", err)
+	}
+	if _, err := runCLI(t, "", "verify", "-key", secret, "-leeway", "1m", future); err != nil {
+		t.Errorf("Expected leeway to cover nbf and iat.  Got %v", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	secret := writeSecret(t, "secret")
+	claims := fmt.Sprintf(`{"sub":"alice","n":3,"exp":%v}`, testNow.Unix()+60)
+	token, err := runCLI(t, "", "sign", "-alg", "HS256", "-key", secret, "-claims", claims)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	token = strings.TrimSpace(token)
+	header := `{"alg":"HS256","typ":"JWT"}`
+	payload := fmt.Sprintf(`{"exp":%v,"n":3,"sub":"alice"}`, testNow.Unix()+60)
+	table := fmt.Sprintf("exp: %v  (2024-05-01 12:01:00 UTC)\nn:   3\nsub: alice\n", testNow.Unix()+60)
+
+	var tests = []struct {
+		args   []string
+		expect string
+	}{
+		{[]string{"sign", "-alg", "HS256", "-key", secret, "-claims", claims, "-format", "table"}, "alg:   HS256\ntyp:   JWT\ntoken: " + token + "\n"},
+		{[]string{"sign", "-alg", "HS256", "-key", secret, "-claims", claims, "-format", "json"}, `"token": "` + token + `"`},
+		{[]string{"verify", "-key", secret, "-format", "raw", token}, payload + "\n"},
+		{[]string{"verify", "-key", secret, "-format", "table", token}, table},
+		{[]string{"decode", "-format", "raw", token}, header + "\n" + payload + "\n"},
+		{[]string{"decode", "-format", "json", "-compact", token}, `{"verified":false,"header":` + header + `,"claims":` + payload + "}\n"},
+	}
+	for _, data := range tests {
+		out, err := runCLI(t, "", data.args...)
+		if err != nil {
+			t.Errorf("[%v] Error: %v", data.args, err)
+			continue
+		}
+		if strings.HasPrefix(data.expect, `"`) && !strings.Contains(out, data.expect) || !strings.HasPrefix(data.expect, `"`) && out != data.expect {
+			t.Errorf("[%v] Expected %q.  Got %q", data.args, data.expect, out)
+		}
+	}
+
+	if _, err := runCLI(t, "", "decode", "-format", "yaml", token); exitCode(err) != exitUsage {
+		t.Errorf("Expected a usage error for -format yaml.  Got %v", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	secret := writeSecret(t, "secret")
+	sign := func(claims string) string {
+		token, err := runCLI(t, "", "sign", "-alg", "HS256", "-key", secret, "-claims", claims)
+		if err != nil {
+			t.Fatalf("Error signing: %v", err)
+		}
+		return strings.TrimSpace(token)
+	}
+	valid := sign(`{"iss":"https://issuer"}`)
+	expired := sign(fmt.Sprintf(`{"exp":%v}`, testNow.Unix()-60))
+
+	var tests = []struct {
+		args   []string
+		expect int
+	}{
+		{[]string{"verify", "-key", secret, valid}, 0},
+		{[]string{"verify", valid}, exitUsage},
+		{[]string{"verify", "-key", secret, "-nope", valid}, exitUsage},
+		{[]string{"verify", "-key", "/does/not/exist", valid}, exitSignature},
+		{[]string{"verify", "-key", secret, "not.a.token"}, exitMalformed},
+		{[]string{"verify", "-key", writeSecret(t, "other"), valid}, exitSignature},
+		{[]string{"verify", "-key", secret, expired}, exitTime},
+		{[]string{"verify", "-key", secret, "-iss", "https://other", valid}, exitPolicy},
+		{[]string{"verify", "-key", secret, "-alg", "RS256", valid}, exitPolicy},
+		{[]string{"decode", "not-a-token"}, exitMalformed},
+		{[]string{"sign", "-alg", "XX256"}, exitUsage},
+		{[]string{"keygen", "-alg", "ES256"}, exitUsage},
+	}
+	for _, data := range tests {
+		_, err := runCLI(t, "", data.args...)
+		if code := exitCode(err); code != data.expect {
+			t.Errorf("[%v] Expected exit %v.  Got %v: %v", data.args, data.expect, code, err)
+		}
+	}
+
+	var stderr bytes.Buffer
+	e := &env{stderr: &stderr, format: formatJSON}
+	_, err := runCLI(t, "", "verify", "-key", secret, expired)
+	e.reportError(err)
+	if !strings.Contains(stderr.String(), `"code":"token_expired","exit":5}`) {
+		t.Errorf("Expected a JSON error report.  Got %v", stderr.String())
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	secret := writeSecret(t, "0123456789abcdef0123456789abcdef")
+	var tests = []struct {
+		alg, enc       string
+		encKey, decKey string
+	}{
+		{"RSA-OAEP-256", "A256GCM", "../../test/sample_key.pub", "../../test/sample_key"},
+		{"RSA-OAEP", "A128CBC-HS256", "../../test/sample_key", "../../test/sample_key"},
+		{"ECDH-ES", "A128GCM", "../../test/ec256-public.pem", "../../test/ec256-private.pem"},
+		{"ECDH-ES+A128KW", "A256GCM", "../../test/ec256-public.pem", "../../test/ec256-private.pem"},
+		{"dir", "A256GCM", secret, secret},
+		{"A256KW", "A256GCM", secret, secret},
+		{"PBES2-HS256+A128KW", "A128GCM", secret, secret},
+	}
+	for _, data := range tests {
+		token, err := runCLI(t, "secret message", "encrypt", "-alg", data.alg, "-enc", data.enc, "-key", data.encKey, "-zip")
+		if err != nil {
+			t.Errorf("[%v] Error encrypting: %v", data.alg, err)
+			continue
+		}
+		args := []string{"decrypt", "-key", data.decKey}
+		if strings.HasPrefix(data.alg, "PBES2") {
+			if _, err := runCLI(t, token, args...); exitCode(err) != exitPolicy {
+				t.Errorf("[%v] Expected PBES2 to be refused unless -alg names it.  Got %v", data.alg, err)
+			}
+			args = append(args, "-alg", data.alg)
+		}
+		out, err := runCLI(t, token, args...)
+		if err != nil || out != "secret message" {
+			t.Errorf("[%v] Expected the plaintext.  Got %q, %v", data.alg, out, err)
+		}
+	}
+
+	token, err := runCLI(t, "secret message", "encrypt", "-alg", "RSA-OAEP-256", "-key", "../../test/sample_key.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := jwt.GenerateJWK("RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Alg, other.Use = "", ""
+	for _, data := range []struct {
+		args   []string
+		expect int
+	}{
+		{[]string{"-key", "../../test/sample_key.pub"}, exitError},
+		{[]string{"-key", "../../test/sample_key", "-alg", "RSA-OAEP"}, exitPolicy},
+		{[]string{"-key", "../../test/sample_key", "-enc", "A128GCM"}, exitPolicy},
+		{[]string{"-key", writeJSONFile(t, other)}, exitSignature},
+		{[]string{"-key", secret}, exitError},
+		{[]string{}, exitUsage},
+	} {
+		_, err := runCLI(t, token, append([]string{"decrypt"}, data.args...)...)
+		if code := exitCode(err); code != data.expect {
+			t.Errorf("[%v] Expected exit %v.  Got %v: %v", data.args, data.expect, code, err)
+		}
+	}
+	if _, err := runCLI(t, "", "decrypt", "-key", secret, "a.b.c.d"); exitCode(err) != exitMalformed {
+		t.Errorf("Expected a malformed token.  Got %v", err)
+	}
+}
+
+func TestEncryptDecrypt_nested(t *testing.T) {
+	signed, err := runCLI(t, "", "sign", "-alg", "ES256", "-key", "../../test/ec256-private.pem", "-claims", `{"sub":"alice"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := runCLI(t, signed, "encrypt", "-alg", "RSA-OAEP-256", "-key", "../../test/sample_key.pub", "-cty", "JWT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := runCLI(t, token, "decrypt", "-key", "../../test/sample_key")
+	if err != nil || inner != strings.TrimSpace(signed) {
+		t.Fatalf("Expected the signed token.  Got %q, %v", inner, err)
+	}
+	out, err := runCLI(t, inner, "verify", "-key", "../../test/ec256-public.pem", "-compact")
+	if expect := `{"sub":"alice"}` + "\n"; err != nil || out != expect {
+		t.Errorf("Expected %v.  Got %v, %v", expect, out, err)
+	}
+
+	out, err = runCLI(t, token, "decrypt", "-key", "../../test/sample_key", "-format", "table")
+	if err != nil || !strings.Contains(out, "cty:       JWT\n") || !strings.Contains(out, "plaintext: "+inner) {
+		t.Errorf("Expected a table of the header and plaintext.  Got %v, %v", out, err)
+	}
+}
+
+func TestEncrypt_jwk(t *testing.T) {
+	priv, _, err := jwt.GenerateJWK("RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv.Alg, priv.Use = "RSA-OAEP-256", "enc"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{*priv.Public()}})
+	}))
+	defer server.Close()
+	set := writeJSONFile(t, jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{*priv}})
+
+	for _, args := range [][]string{
+		{"-jwks-url", server.URL},
+		{"-key", set, "-kid", priv.Kid},
+	} {
+		token, err := runCLI(t, "secret message", append([]string{"encrypt", "-alg", "RSA-OAEP-256"}, args...)...)
+		if err != nil {
+			t.Errorf("[%v] Error encrypting: %v", args, err)
+			continue
+		}
+		// The kid of the key encrypted for picks the key from the set
+		out, err := runCLI(t, token, "decrypt", "-key", set)
+		if err != nil || out != "secret message" {
+			t.Errorf("[%v] Expected the plaintext.  Got %q, %v", args, out, err)
+		}
+	}
+
+	if _, err := runCLI(t, "secret message", "encrypt", "-alg", "RSA-OAEP", "-key", set); err == nil {
+		t.Errorf("Expected a JWK for RSA-OAEP-256 to be refused for RSA-OAEP")
+	}
+	sig, _, err := jwt.GenerateJWK("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig.Alg, sig.Use = "", "sig"
+	if _, err := runCLI(t, "secret message", "encrypt", "-alg", "ECDH-ES", "-key", writeJSONFile(t, sig)); err == nil {
+		t.Errorf("Expected a signing JWK to be refused for encryption")
+	}
+}
+
+func TestLint(t *testing.T) {
+	short := writeSecret(t, "secret")
+	long := writeSecret(t, strings.Repeat("s", 32))
+	ed := filepath.Join(t.TempDir(), "ed.pem")
+	if _, err := runCLI(t, "", "keygen", "-alg", "EdDSA", "-out", ed); err != nil {
+		t.Fatal(err)
+	}
+	sign := func(alg, key, claims string) string {
+		token, err := runCLI(t, "", "sign", "-alg", alg, "-key", key, "-claims", claims)
+		if err != nil {
+			t.Fatalf("Error signing: %v", err)
+		}
+		return strings.TrimSpace(token)
+	}
+	good := fmt.Sprintf(`{"jti":"1","iat":%v,"exp":%v}`, testNow.Unix(), testNow.Unix()+900)
+	token := sign("HS256", long, good)
+	parts := strings.Split(token, ".")
+
+	var tests = []struct {
+		token  string
+		args   []string
+		expect string
+	}{
+		{token, []string{"-key", long}, ""},
+		{token, []string{}, ""},
+		{sign("none", "", good), []string{}, "alg-none\n"},
+		{sign("HS256", short, good), []string{"-key", short}, "weak-hmac-key\n"},
+		{token, []string{"-key", short}, "weak-hmac-key\nbad-signature\n"},
+		{sign("HS256", long, `{"jti":"1"}`), []string{}, "missing-exp\n"},
+		{sign("HS256", long, fmt.Sprintf(`{"exp":%v}`, testNow.Unix()+60)), []string{}, "missing-jti\n"},
+		{sign("HS256", long, fmt.Sprintf(`{"jti":"1","iat":%v,"exp":%v}`, testNow.Unix(), testNow.Unix()+48*3600)), []string{}, "long-lifetime\n"},
+		{sign("HS256", long, fmt.Sprintf(`{"jti":"1","exp":%v}`, testNow.Unix()+3600)), []string{"-max-lifetime", "30m"}, "long-lifetime\n"},
+		{sign("EdDSA", ed, good), []string{}, "deprecated-alg\n"},
+		{token, []string{"-deprecated", "HS256,HS384"}, "deprecated-alg\n"},
+		{parts[0] + "." + parts[1] + "." + parts[2] + "=", []string{}, "non-canonical-base64\n"},
+	}
+	for _, data := range tests {
+		out, _ := runCLI(t, "", append([]string{"lint", "-format", "raw", data.token}, data.args...)...)
+		if out != data.expect {
+			t.Errorf("[%v %v] Expected %q.  Got %q", data.token, data.args, data.expect, out)
+		}
+	}
+
+	// Errors fail, and warnings only with -strict
+	if _, err := runCLI(t, "", "lint", sign("none", "", good)); exitCode(err) != exitPolicy {
+		t.Errorf("Expected alg none to fail.  Got %v", err)
+	}
+	noJTI := sign("HS256", long, fmt.Sprintf(`{"exp":%v}`, testNow.Unix()+60))
+	if _, err := runCLI(t, "", "lint", noJTI); err != nil {
+		t.Errorf("Expected a warning alone to pass.  Got %v", err)
+	}
+	if _, err := runCLI(t, "", "lint", "-strict", noJTI); exitCode(err) != exitPolicy {
+		t.Errorf("Expected a warning to fail with -strict.  Got %v", err)
+	}
+	if _, err := runCLI(t, "", "lint", "not-a-token"); exitCode(err) != exitMalformed {
+		t.Errorf("Expected a malformed token.  Got %v", err)
+	}
+
+	out, _ := runCLI(t, "", "lint", noJTI)
+	if expect := "warning: jti: token has no jti claim, so replays of it can't be detected\n"; out != expect {
+		t.Errorf("Expected %q.  Got %q", expect, out)
+	}
+	out, _ = runCLI(t, "", "lint", "-format", "json", token)
+	if out != "[]\n" {
+		t.Errorf("Expected no findings.  Got %q", out)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// ANSI escapes for decode's output on a terminal
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+// Colors output when enabled, and writes it plainly otherwise
+type painter bool
+
+func (p painter) paint(color, s string) string {
+	if !p {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// Whether to color output to w for the -color flag: always, never, or auto
+// to color a terminal unless NO_COLOR is set
+func useColor(mode string, w io.Writer) (painter, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		f, ok := w.(*os.File)
+		if !ok {
+			return false, nil
+		}
+		fi, err := f.Stat()
+		return painter(err == nil && fi.Mode()&os.ModeCharDevice != 0), nil
+	}
+	return false, usageErrorf("Invalid -color %q.  Must be auto, always or never", mode)
+}
+
+// jwt decode: print a token as it is, trusting nothing in it
+func runDecode(e *env, args []string) error {
+	fs := e.flagSet("decode", "token|-")
+	compact := fs.Bool("compact", false, "output compact JSON")
+	color := fs.String("color", "auto", "color the output: auto, always or never")
+	e.formatFlag(fs, formatTable, "the header and claims, with times.  json is an object of them, and raw the two as they are in the token")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	p, err := useColor(*color, e.stdout)
+	if err != nil {
+		return err
+	}
+	tokenString, err := e.readToken(rest)
+	if err != nil {
+		return err
+	}
+
+	// An unknown alg doesn't stop a token being shown, but a malformed
+	// segment does
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	var ve *jwt.ValidationError
+	if token == nil || errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorMalformed != 0 {
+		return fmt.Errorf("Malformed token: %w", err)
+	}
+	switch e.format {
+	case formatJSON:
+		return writeJSON(e.stdout, struct {
+			Verified bool                   `json:"verified"`
+			Header   map[string]interface{} `json:"header"`
+			Claims   jwt.Claims             `json:"claims"`
+		}{false, token.Header, token.Claims}, *compact)
+	case formatRaw:
+		header, claims, err := rawSegments(token)
+		if err != nil {
+			return fmt.Errorf("Malformed token: %w", err)
+		}
+		_, err = fmt.Fprintf(e.stdout, "%s\n%s\n", header, claims)
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, p.paint(colorBold+colorYellow, "NOT VERIFIED: neither the signature nor the claims of this token have been checked"))
+	fmt.Fprintln(e.stdout, p.paint(colorBold, "Header:"))
+	if err := writeJSON(e.stdout, token.Header, *compact); err != nil {
+		return fmt.Errorf("Failed to output header: %v", err)
+	}
+	fmt.Fprintln(e.stdout, p.paint(colorBold, "Claims:"))
+	if err := writeJSON(e.stdout, token.Claims, *compact); err != nil {
+		return fmt.Errorf("Failed to output claims: %v", err)
+	}
+	printTimes(e.stdout, p, token.Claims.(jwt.MapClaims), e.time())
+	return nil
+}
+
+// Print the exp, iat and nbf claims as times, with how they stand at now
+func printTimes(w io.Writer, p painter, claims jwt.MapClaims, now time.Time) {
+	var lines []string
+	for _, name := range []string{"exp", "iat", "nbf"} {
+		v, ok := claims[name]
+		if !ok {
+			continue
+		}
+		t, ok := claimTime(v)
+		if !ok {
+			lines = append(lines, fmt.Sprintf("  %v  %v", name, p.paint(colorRed, "not a NumericDate")))
+			continue
+		}
+		d := t.Sub(now)
+		var status string
+		switch {
+		case name == "exp" && d > 0:
+			status = p.paint(colorGreen, "valid for another "+humanDuration(d))
+		case name == "exp":
+			status = p.paint(colorRed, "expired "+humanDuration(-d)+" ago")
+		case name == "nbf" && d > 0:
+			status = p.paint(colorRed, "not valid for another "+humanDuration(d))
+		case name == "nbf":
+			status = p.paint(colorGreen, "valid since "+humanDuration(-d)+" ago")
+		case d > 0:
+			status = p.paint(colorRed, "issued in the future, in "+humanDuration(d))
+		default:
+			status = "issued " + humanDuration(-d) + " ago"
+		}
+		lines = append(lines, fmt.Sprintf("  %v  %v  (%v)", name, t.UTC().Format("2006-01-02 15:04:05 MST"), status))
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintln(w, p.paint(colorBold, "Times:"))
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
+	}
+}
+
+// A NumericDate claim as a time, as the library reads it
+func claimTime(v interface{}) (time.Time, bool) {
+	var secs float64
+	switch n := v.(type) {
+	case float64:
+		secs = n
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		secs = f
+	default:
+		return time.Time{}, false
+	}
+	return time.Unix(int64(secs), 0), true
+}
+
+// d to the second, in days and hours once it is that long
+func humanDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d >= 48*time.Hour {
+		days := d / (24 * time.Hour)
+		return fmt.Sprintf("%vd%vh", int64(days), int64((d-days*24*time.Hour)/time.Hour))
+	}
+	return d.String()
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/jwe"
+)
+
+// jwt encrypt: encrypt a file, stdin or a signed token for a recipient
+func runEncrypt(e *env, args []string) error {
+	fs := e.flagSet("encrypt", "[file|-]")
+	alg := fs.String("alg", "", "key management algorithm, e.g. RSA-OAEP-256, ECDH-ES+A256KW or dir")
+	enc := fs.String("enc", "A256GCM", "content encryption algorithm")
+	keyPath := fs.String("key", "", "path to the recipient's key, or '-' to read it from stdin: a PEM encoded public key or certificate, a JWK or JWK Set, or the secret for dir, AES key wrap and PBES2")
+	kid := fs.String("kid", "", "kid of the key to encrypt for from a JWK Set")
+	jwksURL := fs.String("jwks-url", "", "URL of a JWK Set to fetch the recipient's key from, by -kid, instead of -key")
+	timeout := fs.Duration("timeout", 10*time.Second, "time allowed for fetching -jwks-url")
+	cty := fs.String("cty", "", "content type of the plaintext.  JWT for a signed token, which is then read as one")
+	zip := fs.Bool("zip", false, "compress the plaintext before encrypting it")
+	headers := make(ArgList)
+	fs.Var(headers, "header", "add a header parameter as key=value. may be used more than once")
+	e.formatFlag(fs, formatRaw, "the token.  json adds its header, and table lists that before it")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 1 {
+		return usageErrorf("Unexpected arguments: %v", strings.Join(rest[1:], " "))
+	}
+	if (*keyPath == "") == (*jwksURL == "") {
+		return usageErrorf("Exactly one of -key and -jwks-url is required")
+	}
+
+	km := jwe.GetKeyManagement(*alg)
+	if km == nil {
+		return usageErrorf("Couldn't find key management algorithm: %q", *alg)
+	}
+	ce := jwe.GetContentEncryption(*enc)
+	if ce == nil {
+		return usageErrorf("Couldn't find content encryption algorithm: %q", *enc)
+	}
+	input := "-"
+	if len(rest) == 1 {
+		input = rest[0]
+	}
+	if input == "-" && *keyPath == "-" {
+		return usageErrorf("Only one of the plaintext and -key can be read from stdin")
+	}
+	plaintext, err := e.readFile(input)
+	if err != nil {
+		return fmt.Errorf("Couldn't read plaintext: %v", err)
+	}
+	if strings.EqualFold(*cty, "JWT") {
+		plaintext = []byte(strings.TrimSpace(string(plaintext)))
+	}
+
+	var key interface{}
+	var keyID string
+	if *jwksURL != "" {
+		key, keyID, err = fetchEncryptionKey(*jwksURL, *timeout, km, *kid)
+	} else {
+		key, keyID, err = e.loadEncryptionKey(km, *keyPath, *kid, false)
+	}
+	if err != nil {
+		return err
+	}
+
+	header := map[string]interface{}{}
+	if keyID != "" {
+		header["kid"] = keyID
+	}
+	if *cty != "" {
+		header["cty"] = *cty
+	}
+	if *zip {
+		header["zip"] = "DEF"
+	}
+	for k, v := range headers {
+		header[k] = v
+	}
+	token, err := jwe.Encrypt(plaintext, km, ce, key, header)
+	if err != nil {
+		return fmt.Errorf("Error encrypting: %w", err)
+	}
+
+	if e.format == formatRaw {
+		_, err = fmt.Fprintln(e.stdout, token)
+		return err
+	}
+	// The header as it went into the token, with what Encrypt added
+	data, err := jwt.DecodeSegment(token[:strings.IndexByte(token, '.')])
+	if err == nil {
+		err = json.Unmarshal(data, &header)
+	}
+	if err != nil {
+		return err
+	}
+	if e.format == formatTable {
+		return writeTable(e.stdout, append(mapRows(header), row{"token", token}))
+	}
+	return writeJSON(e.stdout, struct {
+		Token  string                 `json:"token"`
+		Header map[string]interface{} `json:"header"`
+	}{token, header}, false)
+}
+
+// jwt decrypt: decrypt a token and print what it carries
+func runDecrypt(e *env, args []string) error {
+	fs := e.flagSet("decrypt", "token|-")
+	keyPath := fs.String("key", "", "path to the decryption key, or '-' to read it from stdin: a PEM encoded private key, a JWK or JWK Set, or the secret for dir, AES key wrap and PBES2")
+	kid := fs.String("kid", "", "kid of the key to decrypt with from a JWK Set.  Defaults to the token's kid")
+	algs := fs.String("alg", "", "comma separated key management algorithms to accept.  Defaults to any but PBES2, which must be listed")
+	encs := fs.String("enc", "", "comma separated content encryption algorithms to accept.  Defaults to any")
+	e.formatFlag(fs, formatRaw, "the plaintext, as it is.  json is an object of the header and plaintext, and table lists them")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *keyPath == "" {
+		return usageErrorf("-key is required")
+	}
+	if (len(rest) == 0 || rest[0] == "-") && *keyPath == "-" {
+		return usageErrorf("Only one of the token and -key can be read from stdin")
+	}
+	tokenString, err := e.readToken(rest)
+	if err != nil {
+		return err
+	}
+
+	d := new(jwe.Decrypter)
+	if *algs != "" {
+		d.Algorithms = strings.Split(*algs, ",")
+	}
+	if *encs != "" {
+		d.Encryptions = strings.Split(*encs, ",")
+	}
+	msg, err := d.Decrypt(tokenString, func(header map[string]interface{}) (interface{}, error) {
+		keyID := *kid
+		if keyID == "" {
+			keyID, _ = header["kid"].(string)
+		}
+		alg, _ := header["alg"].(string)
+		key, _, err := e.loadEncryptionKey(jwe.GetKeyManagement(alg), *keyPath, keyID, true)
+		return key, err
+	})
+	if err != nil {
+		return fmt.Errorf("Couldn't decrypt token: %w", err)
+	}
+
+	switch e.format {
+	case formatJSON:
+		return writeJSON(e.stdout, struct {
+			Header    map[string]interface{} `json:"header"`
+			Plaintext string                 `json:"plaintext"`
+		}{msg.Header, string(msg.Plaintext)}, false)
+	case formatTable:
+		return writeTable(e.stdout, append(mapRows(msg.Header), row{"plaintext", string(msg.Plaintext)}))
+	}
+	_, err = e.stdout.Write(msg.Plaintext)
+	return err
+}
+
+// Whether alg takes a secret shared with the recipient rather than their
+// key pair
+func isSecretAlg(alg jwe.KeyManagement) bool {
+	switch alg.(type) {
+	case *jwe.KeyManagementDirect, *jwe.KeyManagementAESKW, *jwe.KeyManagementPBES2:
+		return true
+	}
+	return false
+}
+
+// Load the key at path for the key management alg, returning it with its
+// kid if the file names one.  Encrypting takes the recipient's public key,
+// or their private key of which the public half is used; decrypting takes
+// the private key.  Files are read as for sign and verify.
+func (e *env) loadEncryptionKey(alg jwe.KeyManagement, path, kid string, decrypt bool) (interface{}, string, error) {
+	data, err := e.readFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("Couldn't read key: %v", err)
+	}
+	if isJWKFile(data) {
+		jwk, err := jwkForAlg(data, kid, alg.Alg())
+		if err != nil {
+			return nil, "", err
+		}
+		key, err := jwkEncryptionKey(jwk, alg, decrypt)
+		return key, jwk.Kid, err
+	}
+	if isSecretAlg(alg) {
+		key, err := sharedSecret(data)
+		return key, "", err
+	}
+	key, err := jwt.ParseKeyFromPEM(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("Couldn't parse key: %v", err)
+	}
+	key, err = keyPairHalf(key, alg, decrypt)
+	return key, "", err
+}
+
+// The key of the JWK Set at url with the kid, for encrypting with alg
+func fetchEncryptionKey(url string, timeout time.Duration, alg jwe.KeyManagement, kid string) (interface{}, string, error) {
+	provider := &jwt.JWKSProvider{URL: url, Client: &http.Client{Timeout: timeout}}
+	set, err := provider.KeySet(context.Background())
+	if err != nil {
+		return nil, "", fmt.Errorf("Couldn't fetch %v: %v", url, err)
+	}
+	jwk, err := setKey(set, kid)
+	if err != nil {
+		return nil, "", err
+	}
+	if jwk.Alg != "" && jwk.Alg != alg.Alg() {
+		return nil, "", fmt.Errorf("JWK %q is for %v, not %v", jwk.Kid, jwk.Alg, alg.Alg())
+	}
+	key, err := jwkEncryptionKey(jwk, alg, false)
+	return key, jwk.Kid, err
+}
+
+// The key of jwk for encrypting or decrypting, if its use allows that
+func jwkEncryptionKey(jwk *jwt.JSONWebKey, alg jwe.KeyManagement, decrypt bool) (interface{}, error) {
+	op := "wrapKey"
+	switch {
+	case alg == jwe.AlgDir && decrypt:
+		op = "decrypt"
+	case alg == jwe.AlgDir:
+		op = "encrypt"
+	case decrypt:
+		op = "unwrapKey"
+	}
+	if !jwk.Permits(op) {
+		return nil, fmt.Errorf("JWK %q may not be used to %v", jwk.Kid, op)
+	}
+	key, err := jwk.Key()
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't use JWK %q: %v", jwk.Kid, err)
+	}
+	if isSecretAlg(alg) {
+		return key, nil
+	}
+	return keyPairHalf(key, alg, decrypt)
+}
+
+// The half of a key pair that alg wants: the private key for decrypting
+// and the public one for encrypting
+func keyPairHalf(key interface{}, alg jwe.KeyManagement, decrypt bool) (interface{}, error) {
+	signer, private := key.(crypto.Signer)
+	switch {
+	case decrypt && !private:
+		return nil, fmt.Errorf("Key for %v must be a private key", alg.Alg())
+	case !decrypt && private:
+		return signer.Public(), nil
+	}
+	return key, nil
+}
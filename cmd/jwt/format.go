@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/jwe"
+)
+
+// The -format of a command's output: json, raw or table.  Errors are
+// reported in JSON too under json, so that scripts can read both.
+type format string
+
+const (
+	formatJSON  format = "json"
+	formatRaw   format = "raw"
+	formatTable format = "table"
+)
+
+func (f *format) String() string {
+	return string(*f)
+}
+
+func (f *format) Set(s string) error {
+	switch format(s) {
+	case formatJSON, formatRaw, formatTable:
+		*f = format(s)
+		return nil
+	}
+	return fmt.Errorf("must be json, raw or table")
+}
+
+// Add -format to fs, defaulting to def
+func (e *env) formatFlag(fs *flag.FlagSet, def format, help string) {
+	e.format = def
+	fs.Var(&e.format, "format", "output format: json, raw or table.  Defaults to "+string(def)+": "+help)
+}
+
+// Exit statuses of jwt, by why it failed, so that a script can tell a
+// rejected token from a missing file
+const (
+	exitError     = 1 // Anything else, such as a key file that can't be written
+	exitUsage     = 2 // Flags or arguments are wrong
+	exitMalformed = 3 // The token can't be parsed
+	exitSignature = 4 // The signature is invalid or the token won't decrypt, or there is no key for it
+	exitTime      = 5 // The token is expired, not yet valid or issued in the future
+	exitPolicy    = 6 // The claims or alg don't meet what was required of them, or lint found problems
+)
+
+// A mistake in how jwt was run, rather than in the token or keys it was
+// given
+type usageError struct {
+	error
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// The exit status for err
+func exitCode(err error) int {
+	var usage usageError
+	var alg *algError
+	var lint *lintError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp), errors.As(err, &usage):
+		return exitUsage
+	case errors.As(err, &alg), errors.As(err, &lint), errors.Is(err, jwe.ErrAlgorithmRefused):
+		return exitPolicy
+	case errors.Is(err, jwe.ErrMalformed):
+		return exitMalformed
+	case errors.Is(err, jwe.ErrDecryption):
+		return exitSignature
+	}
+	switch jwt.CodeOf(err) {
+	case jwt.CodeTokenMalformed, jwt.CodeInvalidHeader:
+		return exitMalformed
+	case jwt.CodeInvalidSignature, jwt.CodeTokenUnverifiable, jwt.CodeKeyfuncFailed:
+		return exitSignature
+	case jwt.CodeTokenExpired, jwt.CodeTokenNotValidYet, jwt.CodeTokenUsedBeforeIssued:
+		return exitTime
+	case jwt.CodeInvalidAudience, jwt.CodeInvalidIssuer, jwt.CodeInvalidId, jwt.CodeInvalidClaims:
+		return exitPolicy
+	}
+	return exitError
+}
+
+// Report err on stderr in the command's format, as an object with the
+// message, the library's error code and the exit status under json
+func (e *env) reportError(err error) {
+	if e.format != formatJSON {
+		fmt.Fprintf(e.stderr, "Error: %v\n", err)
+		return
+	}
+	report := struct {
+		Error string        `json:"error"`
+		Code  jwt.ErrorCode `json:"code,omitempty"`
+		Exit  int           `json:"exit"`
+	}{Error: err.Error(), Exit: exitCode(err)}
+	if code := jwt.CodeOf(err); code != jwt.CodeUnknown {
+		report.Code = code
+	}
+	writeJSON(e.stderr, report, true)
+}
+
+// A line of table output
+type row struct {
+	name, value string
+}
+
+func writeTable(w io.Writer, rows []row) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%v:\t%v\n", r.name, r.value)
+	}
+	return tw.Flush()
+}
+
+// Rows for a header or claims, sorted by name.  Strings are shown bare,
+// other values as JSON, and NumericDates as times too.
+func mapRows(m map[string]interface{}) []row {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([]row, len(names))
+	for i, name := range names {
+		v := m[name]
+		value, ok := v.(string)
+		if !ok {
+			data, _ := json.Marshal(v)
+			value = string(data)
+		}
+		if t, ok := claimTime(v); ok && isTimeClaim(name) {
+			value += "  (" + t.UTC().Format("2006-01-02 15:04:05 MST") + ")"
+		}
+		rows[i] = row{name, value}
+	}
+	return rows
+}
+
+func isTimeClaim(name string) bool {
+	for _, c := range timeClaims {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// The header and claims of token as the JSON it was signed with
+func rawSegments(token *jwt.Token) (header, claims []byte, err error) {
+	parts := strings.Split(token.Raw, ".")
+	if len(parts) != 3 {
+		return nil, nil, fmt.Errorf("Token has %v segments, not 3", len(parts))
+	}
+	if header, err = jwt.DecodeSegment(parts[0]); err != nil {
+		return nil, nil, err
+	}
+	if claims, err = jwt.DecodeSegment(parts[1]); err != nil {
+		return nil, nil, err
+	}
+	return header, claims, nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// RSA keys smaller than this are refused, as NIST has deprecated them
+const minRSABits = 2048
+
+// jwt keygen: make a key of the right kind and size for an alg
+func runKeygen(e *env, args []string) error {
+	fs := e.flagSet("keygen", "")
+	alg := fs.String("alg", "", "algorithm the key is for, e.g. ES256")
+	out := fs.String("out", "", "write the private key here: PEM encoded, or the raw secret for HMAC")
+	pubOut := fs.String("pub-out", "", "write the PEM encoded public key here")
+	jwkOut := fs.String("jwk-out", "", "write the private key here as a JWK, with alg, use and kid set")
+	bits := fs.Int("bits", 0, "size of RSA keys.  Defaults to 2048, 3072 or 4096 for the SHA-256, -384 or -512 algs")
+	force := fs.Bool("force", false, "overwrite existing files")
+	e.formatFlag(fs, formatTable, "the alg and kid.  json is an object of them, and raw the kid alone")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usageErrorf("Unexpected arguments: %v", rest)
+	}
+	if *out == "" && *jwkOut == "" {
+		return usageErrorf("At least one of -out and -jwk-out is required")
+	}
+
+	method := jwt.GetSigningMethod(*alg)
+	if method == nil || method == jwt.SigningMethodNone {
+		return usageErrorf("Couldn't find signing method: %q", *alg)
+	}
+	key, err := generateKey(method, *bits)
+	if err != nil {
+		return err
+	}
+
+	jwk, err := jwt.NewJSONWebKey(key)
+	if err != nil {
+		return err
+	}
+	thumb, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	jwk.Alg, jwk.Use, jwk.Kid = method.Alg(), "sig", jwt.EncodeSegment(thumb)
+	kid := jwk.Kid
+
+	if *out != "" {
+		data, err := marshalPrivateKey(key)
+		if err != nil {
+			return err
+		}
+		if err := writeKeyFile(*out, data, *force); err != nil {
+			return err
+		}
+	}
+	if *pubOut != "" {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return usageErrorf("HMAC secrets have no public key")
+		}
+		der, err := x509.MarshalPKIXPublicKey(signer.Public())
+		if err != nil {
+			return err
+		}
+		if err := writeKeyFile(*pubOut, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), *force); err != nil {
+			return err
+		}
+	}
+	if *jwkOut != "" {
+		data, err := json.MarshalIndent(jwk, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := writeKeyFile(*jwkOut, append(data, '\n'), *force); err != nil {
+			return err
+		}
+	}
+
+	switch e.format {
+	case formatJSON:
+		return writeJSON(e.stdout, struct {
+			Alg string `json:"alg"`
+			Kid string `json:"kid"`
+		}{method.Alg(), kid}, false)
+	case formatRaw:
+		_, err = fmt.Fprintln(e.stdout, kid)
+		return err
+	}
+	return writeTable(e.stdout, []row{{"alg", method.Alg()}, {"kid", kid}})
+}
+
+// A fresh key for method.  HMAC secrets are as long as the hash, and RSA
+// keys as strong as it unless bits is given.
+func generateKey(method jwt.SigningMethod, bits int) (interface{}, error) {
+	switch m := method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret := make([]byte, m.Hash.Size())
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return secret, nil
+	case *jwt.SigningMethodRSA:
+		return generateRSAKey(m.Hash, bits)
+	case *jwt.SigningMethodRSAPSS:
+		return generateRSAKey(m.Hash, bits)
+	case *jwt.SigningMethodECDSA:
+		var curve elliptic.Curve
+		switch m.CurveBits {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("No curve of %v bits", m.CurveBits)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case *jwt.SigningMethodEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return nil, fmt.Errorf("Can't generate keys for %v", method.Alg())
+}
+
+func generateRSAKey(h crypto.Hash, bits int) (*rsa.PrivateKey, error) {
+	if bits == 0 {
+		switch h {
+		case crypto.SHA384:
+			bits = 3072
+		case crypto.SHA512:
+			bits = 4096
+		default:
+			bits = minRSABits
+		}
+	}
+	if bits < minRSABits {
+		return nil, fmt.Errorf("RSA keys must be at least %v bits", minRSABits)
+	}
+	return rsa.GenerateKey(rand.Reader, bits)
+}
+
+// The key as the CLI reads it back: the secret itself for HMAC, and PKCS8
+// PEM otherwise
+func marshalPrivateKey(key interface{}) ([]byte, error) {
+	if secret, ok := key.([]byte); ok {
+		return secret, nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// Write a key readable only by its owner, and only over an existing file
+// if force is set
+func writeKeyFile(path string, data []byte, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("Couldn't write key: %v", err)
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("Couldn't write key: %v", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Load the key at path for signing with method, returning it with its kid
+// if the file names one.  The file holds a PEM encoded private key, a
+// private JWK, or a JWK Set from which kid picks the key; otherwise it is
+// the secret itself for HMAC.  alg "none" takes no key.
+func (e *env) loadSigningKey(method jwt.SigningMethod, path, kid string) (interface{}, string, error) {
+	if method == jwt.SigningMethodNone {
+		return jwt.UnsafeAllowNoneSignatureType, "", nil
+	}
+	data, err := e.readFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("Couldn't read key: %v", err)
+	}
+	if isJWKFile(data) {
+		jwk, err := jwkForAlg(data, kid, method.Alg())
+		if err != nil {
+			return nil, "", err
+		}
+		key, err := jwk.SigningKey()
+		if err != nil {
+			return nil, "", fmt.Errorf("Couldn't use JWK %q for signing: %v", jwk.Kid, err)
+		}
+		return key, jwk.Kid, nil
+	}
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		key, err := sharedSecret(data)
+		return key, "", err
+	}
+	key, err := jwt.ParseKeyFromPEM(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("Couldn't parse key: %v", err)
+	}
+	if _, ok := key.(crypto.Signer); !ok {
+		return nil, "", fmt.Errorf("Key for %v must be a private key", method.Alg())
+	}
+	return key, "", nil
+}
+
+// Load the key at path for verifying a token signed with method.  The file
+// holds a PEM encoded key or certificate, a JWK, or a JWK Set from which
+// kid picks the key; otherwise it is the secret itself for HMAC.  Of
+// private keys, the public half is used.
+func (e *env) loadVerificationKey(method jwt.SigningMethod, path, kid string) (interface{}, error) {
+	data, err := e.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't read key: %v", err)
+	}
+	if isJWKFile(data) {
+		jwk, err := jwkForAlg(data, kid, method.Alg())
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwk.VerificationKey()
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't use JWK %q for verification: %v", jwk.Kid, err)
+		}
+		return key, nil
+	}
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		return sharedSecret(data)
+	}
+	key, err := jwt.ParseKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't parse key: %v", err)
+	}
+	if signer, ok := key.(crypto.Signer); ok {
+		return signer.Public(), nil
+	}
+	return key, nil
+}
+
+// The contents of an HMAC or JWE secret key file.  A PEM file is refused:
+// verifying with a public key as the secret is how tokens with a forged
+// alg get accepted.
+func sharedSecret(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")) {
+		return nil, fmt.Errorf("Key is PEM encoded, which can't be a shared secret")
+	}
+	return data, nil
+}
+
+// Whether a key file holds JSON, so a JWK or JWK Set, rather than PEM or
+// a secret
+func isJWKFile(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// The JWK of a key file for use with alg: the file's key if it holds a
+// single JWK, or the key of a JWK Set with the kid.  Without a kid a set
+// must hold just one key.  A key bound to another alg is refused.
+func jwkForAlg(data []byte, kid string, alg string) (*jwt.JSONWebKey, error) {
+	var probe struct {
+		Keys json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("Couldn't parse JWK: %v", err)
+	}
+
+	var jwk *jwt.JSONWebKey
+	if probe.Keys == nil {
+		var err error
+		if jwk, err = jwt.ParseJWK(data); err != nil {
+			return nil, fmt.Errorf("Couldn't parse JWK: %v", err)
+		}
+		if kid != "" && jwk.Kid != kid {
+			return nil, fmt.Errorf("JWK has kid %q, not %q", jwk.Kid, kid)
+		}
+	} else {
+		set, err := jwt.ParseJWKSet(data)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't parse JWK Set: %v", err)
+		}
+		if jwk, err = setKey(set, kid); err != nil {
+			return nil, err
+		}
+	}
+
+	if jwk.Alg != "" && jwk.Alg != alg {
+		return nil, fmt.Errorf("JWK %q is for %v, not %v", jwk.Kid, jwk.Alg, alg)
+	}
+	return jwk, nil
+}
+
+// The key of set with the kid, or its only key without one
+func setKey(set *jwt.JSONWebKeySet, kid string) (*jwt.JSONWebKey, error) {
+	switch {
+	case kid != "":
+		if jwk := set.Lookup(kid); jwk != nil {
+			return jwk, nil
+		}
+		return nil, fmt.Errorf("JWK Set has no key with kid %q", kid)
+	case len(set.Keys) == 1:
+		return &set.Keys[0], nil
+	}
+	return nil, fmt.Errorf("JWK Set has %v keys.  Choose one with -kid", len(set.Keys))
+}
+
+// A Keyfunc finding the token's key by kid in the JWK Set at url.  When
+// there is none, the error lists the kids the set does have, which is
+// most of what there is to know about a rejected token.
+func remoteKeyfunc(url string, timeout time.Duration) jwt.Keyfunc {
+	provider := &jwt.JWKSProvider{URL: url, Client: &http.Client{Timeout: timeout}}
+	return func(t *jwt.Token) (interface{}, error) {
+		key, err := provider.LookupKey(t.Context(), t)
+		if !errors.Is(err, jwt.ErrJWKNotFound) {
+			return key, err
+		}
+		set, setErr := provider.KeySet(t.Context())
+		if setErr != nil {
+			return nil, err
+		}
+		kids := make([]string, len(set.Keys))
+		for i, k := range set.Keys {
+			kids[i] = strconv.Quote(k.Kid)
+		}
+		kid, _ := t.Header["kid"].(string)
+		return nil, fmt.Errorf("%w: the token has kid %q, and %v has %v", err, kid, url, strings.Join(kids, ", "))
+	}
+}
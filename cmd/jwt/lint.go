@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Algs jwt lint warns of, with why
+var deprecatedAlgs = map[string]string{
+	"EdDSA": "RFC 9864 replaces it with Ed25519 and Ed448",
+}
+
+// A problem jwt lint found with a token
+type finding struct {
+	Severity string `json:"severity"` // error or warning
+	Check    string `json:"check"`    // Names the check, and won't change
+	Field    string `json:"field"`    // The header parameter, claim or segment at fault
+	Message  string `json:"message"`
+}
+
+// The error of jwt lint when it finds problems
+type lintError struct {
+	errors, warnings int
+}
+
+func (e *lintError) Error() string {
+	return fmt.Sprintf("Found %v errors and %v warnings", e.errors, e.warnings)
+}
+
+// jwt lint: report the security issues of a token, as when auditing tokens
+// from another issuer
+func runLint(e *env, args []string) error {
+	fs := e.flagSet("lint", "token|-")
+	keyPath := fs.String("key", "", "path to the verification key, to check the signature and the length of HMAC secrets")
+	kid := fs.String("kid", "", "kid of the key to verify with from a JWK Set.  Defaults to the token's kid")
+	maxLifetime := fs.Duration("max-lifetime", 24*time.Hour, "warn of tokens valid for longer than this")
+	deprecated := fs.String("deprecated", "", "comma separated algs to warn of, besides EdDSA")
+	strict := fs.Bool("strict", false, "fail on warnings too")
+	e.formatFlag(fs, formatTable, "the findings.  json is an array of them, and raw their checks, one per line")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if (len(rest) == 0 || rest[0] == "-") && *keyPath == "-" {
+		return usageErrorf("Only one of the token and -key can be read from stdin")
+	}
+	tokenString, err := e.readToken(rest)
+	if err != nil {
+		return err
+	}
+
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	var ve *jwt.ValidationError
+	if token == nil || errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorMalformed != 0 {
+		return fmt.Errorf("Malformed token: %w", err)
+	}
+	deprecations := map[string]string{}
+	for alg, why := range deprecatedAlgs {
+		deprecations[alg] = why
+	}
+	if *deprecated != "" {
+		for _, alg := range strings.Split(*deprecated, ",") {
+			deprecations[alg] = "it is given to -deprecated"
+		}
+	}
+
+	findings := lintToken(tokenString, token, e.time(), *maxLifetime, deprecations)
+	if *keyPath != "" && token.Method != jwt.SigningMethodNone {
+		findings = append(findings, e.lintKey(tokenString, *keyPath, *kid)...)
+	}
+	if err := writeFindings(e, findings); err != nil {
+		return err
+	}
+
+	var result lintError
+	for _, f := range findings {
+		if f.Severity == "error" {
+			result.errors++
+		} else {
+			result.warnings++
+		}
+	}
+	if result.errors > 0 || *strict && result.warnings > 0 {
+		return &result
+	}
+	return nil
+}
+
+// The findings about token that need no key.  Lifetimes are counted from
+// iat, or else nbf or now.
+func lintToken(tokenString string, token *jwt.Token, now time.Time, maxLifetime time.Duration, deprecated map[string]string) []finding {
+	var findings []finding
+	add := func(severity, check, field, format string, args ...interface{}) {
+		findings = append(findings, finding{severity, check, field, fmt.Sprintf(format, args...)})
+	}
+
+	alg, _ := token.Header["alg"].(string)
+	switch {
+	case token.Method == jwt.SigningMethodNone:
+		add("error", "alg-none", "alg", "alg is none, so the token is unsigned and anyone could have made it")
+	case token.Method == nil:
+		add("warning", "unknown-alg", "alg", "alg %q is not one jwt knows", alg)
+	}
+	if why, ok := deprecated[alg]; ok {
+		add("warning", "deprecated-alg", "alg", "alg %v is deprecated: %v", alg, why)
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	exp, hasExp := claimTime(claims["exp"])
+	if !hasExp {
+		add("error", "missing-exp", "exp", "token has no exp claim, so it never expires")
+	}
+	if _, ok := claims["jti"]; !ok {
+		add("warning", "missing-jti", "jti", "token has no jti claim, so replays of it can't be detected")
+	}
+	if hasExp {
+		from, ok := claimTime(claims["iat"])
+		if !ok {
+			if from, ok = claimTime(claims["nbf"]); !ok {
+				from = now
+			}
+		}
+		if lifetime := exp.Sub(from); lifetime > maxLifetime {
+			add("warning", "long-lifetime", "exp", "token is valid for %v, longer than %v", humanDuration(lifetime), humanDuration(maxLifetime))
+		}
+	}
+
+	// Decoders tolerate padding, stray bits and line breaks, which other
+	// implementations may read differently, so only the one encoding is
+	// canonical
+	for i, seg := range strings.Split(tokenString, ".") {
+		field := [...]string{"header", "claims", "signature"}[i]
+		if data, err := jwt.DecodeSegment(seg); err != nil {
+			add("error", "non-canonical-base64", field, "%v segment is not base64url: %v", field, err)
+		} else if jwt.EncodeSegment(data) != seg {
+			add("warning", "non-canonical-base64", field, "%v segment is not canonical unpadded base64url", field)
+		}
+	}
+	return findings
+}
+
+// The findings about the token given its key: whether the signature
+// verifies, and whether an HMAC secret is as long as RFC 7518 requires
+func (e *env) lintKey(tokenString, keyPath, kid string) []finding {
+	var findings []finding
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		keyID := kid
+		if keyID == "" {
+			keyID, _ = t.Header["kid"].(string)
+		}
+		key, err := e.loadVerificationKey(t.Method, keyPath, keyID)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+			if secret, ok := key.([]byte); ok && len(secret) < m.Hash.Size() {
+				findings = append(findings, finding{"error", "weak-hmac-key", "key", fmt.Sprintf("HMAC secret is %v bytes, shorter than the %v bytes %v requires", len(secret), m.Hash.Size(), m.Alg())})
+			}
+		}
+		return key, nil
+	}
+	p := &jwt.Parser{SkipClaimsValidation: true}
+	if _, err := p.Parse(tokenString, keyFunc); err != nil {
+		findings = append(findings, finding{"error", "bad-signature", "signature", err.Error()})
+	}
+	return findings
+}
+
+func writeFindings(e *env, findings []finding) error {
+	switch e.format {
+	case formatJSON:
+		if findings == nil {
+			findings = []finding{}
+		}
+		return writeJSON(e.stdout, findings, false)
+	case formatRaw:
+		for _, f := range findings {
+			if _, err := fmt.Fprintln(e.stdout, f.Check); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	rows := make([]row, len(findings))
+	for i, f := range findings {
+		rows[i] = row{f.Severity, f.Field + ": " + f.Message}
+	}
+	return writeTable(e.stdout, rows)
+}
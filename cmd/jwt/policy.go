@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// What jwt verify requires of a token besides its signature, so that it
+// can check tokens as the server that takes them will
+type policy struct {
+	algs     string
+	issuer   string
+	audience string
+	subject  string
+	leeway   time.Duration
+}
+
+func (p *policy) flags(fs *flag.FlagSet) {
+	fs.StringVar(&p.algs, "alg", "", "comma separated algorithms to accept.  Defaults to any the key suits")
+	fs.StringVar(&p.issuer, "iss", "", "require this iss claim")
+	fs.StringVar(&p.audience, "aud", "", "require this among the aud claim")
+	fs.StringVar(&p.subject, "sub", "", "require this sub claim")
+	fs.DurationVar(&p.leeway, "leeway", 0, "clock skew allowed when checking exp, nbf and iat")
+}
+
+// A token's alg not among those of -alg
+type algError struct {
+	alg  string
+	algs []string
+}
+
+func (e *algError) Error() string {
+	return fmt.Sprintf("alg is %v, not one of %v", e.alg, strings.Join(e.algs, ", "))
+}
+
+// A parser checking the signature only.  The claims are left to check,
+// which allows for leeway.
+func (p *policy) parser() *jwt.Parser {
+	return &jwt.Parser{SkipClaimsValidation: true}
+}
+
+// keyFunc, refusing a token with the wrong alg before it is given a key
+func (p *policy) keyfunc(keyFunc jwt.Keyfunc) jwt.Keyfunc {
+	if p.algs == "" {
+		return keyFunc
+	}
+	algs := strings.Split(p.algs, ",")
+	return func(t *jwt.Token) (interface{}, error) {
+		for _, alg := range algs {
+			if alg == t.Method.Alg() {
+				return keyFunc(t)
+			}
+		}
+		return nil, &algError{t.Method.Alg(), algs}
+	}
+}
+
+// Check claims at now, reporting every failure as the library would
+func (p *policy) check(claims jwt.MapClaims, now time.Time) error {
+	var flags uint32
+	var errs []error
+	fail := func(flag uint32, format string, args ...interface{}) {
+		flags |= flag
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	leeway := int64(p.leeway / time.Second)
+	if !claims.VerifyExpiresAt(now.Unix()-leeway, false) {
+		fail(jwt.ValidationErrorExpired, "token expired at %v", claimString(claims, "exp"))
+	}
+	if !claims.VerifyNotBefore(now.Unix()+leeway, false) {
+		fail(jwt.ValidationErrorNotValidYet, "token is not valid until %v", claimString(claims, "nbf"))
+	}
+	if !claims.VerifyIssuedAt(now.Unix()+leeway, false) {
+		fail(jwt.ValidationErrorIssuedAt, "token was issued in the future, at %v", claimString(claims, "iat"))
+	}
+	if p.issuer != "" && !claims.VerifyIssuer(p.issuer, true) {
+		fail(jwt.ValidationErrorIssuer, "iss is %q, not %q", claims.GetIssuer(), p.issuer)
+	}
+	if p.audience != "" && !claims.VerifyAudience(p.audience, true) {
+		fail(jwt.ValidationErrorAudience, "aud is %q, without %q", claims.GetAudience(), p.audience)
+	}
+	if p.subject != "" && claims.GetSubject() != p.subject {
+		fail(jwt.ValidationErrorClaimsInvalid, "sub is %q, not %q", claims.GetSubject(), p.subject)
+	}
+
+	if flags == 0 {
+		return nil
+	}
+	return &jwt.ValidationError{Inner: errors.Join(errs...), Errors: flags}
+}
+
+// A NumericDate claim as a time, for messages
+func claimString(claims jwt.MapClaims, name string) string {
+	t, ok := claimTime(claims[name])
+	if !ok {
+		return fmt.Sprint(claims[name])
+	}
+	return t.UTC().Format(time.RFC3339)
+}
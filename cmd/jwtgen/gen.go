@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go/jsoncodec"
+)
+
+const jwtImportPath = "github.com/dgrijalva/jwt-go"
+
+// Field types that the codecs can read and write, by their Go name, with
+// the Reader method for each
+var kinds = map[string]string{
+	"string":   "String",
+	"bool":     "Bool",
+	"int":      "Int",
+	"int64":    "Int64",
+	"float64":  "Float64",
+	"[]string": "Strings",
+}
+
+// Members of jwt.StandardClaims, which is flattened when embedded
+var standardFields = []field{
+	{goName: "Audience", key: "aud", kind: "string", omitempty: true},
+	{goName: "ExpiresAt", key: "exp", kind: "int64", omitempty: true},
+	{goName: "Id", key: "jti", kind: "string", omitempty: true},
+	{goName: "IssuedAt", key: "iat", kind: "int64", omitempty: true},
+	{goName: "Issuer", key: "iss", kind: "string", omitempty: true},
+	{goName: "NotBefore", key: "nbf", kind: "int64", omitempty: true},
+	{goName: "Subject", key: "sub", kind: "string", omitempty: true},
+}
+
+type field struct {
+	goName    string
+	key       string
+	kind      string
+	omitempty bool
+	embedded  bool // promoted from jwt.StandardClaims
+}
+
+// The expression reading the field from the receiver c
+func (f field) access() string {
+	if f.embedded {
+		return "c.StandardClaims." + f.goName
+	}
+	return "c." + f.goName
+}
+
+type claimsType struct {
+	name     string
+	fields   []field
+	standard bool // embeds jwt.StandardClaims
+	hasValid bool
+}
+
+// Parse src and write the methods for the named types, returning the
+// formatted source of the new file
+func generate(filename string, src []byte, typeNames []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	jwtName := ""
+	for _, spec := range file.Imports {
+		if path, _ := strconv.Unquote(spec.Path.Value); path == jwtImportPath {
+			jwtName = "jwt"
+			if spec.Name != nil {
+				jwtName = spec.Name.Name
+			}
+		}
+	}
+
+	var types []*claimsType
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		st := findStruct(file, name)
+		if st == nil {
+			return nil, fmt.Errorf("%v: no struct type %v", filename, name)
+		}
+		ct, err := parseStruct(name, st, jwtName)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", filename, err)
+		}
+		ct.hasValid = hasMethod(file, name, "Valid")
+		types = append(types, ct)
+	}
+
+	var buf bytes.Buffer
+	writeFile(&buf, file.Name.Name, types)
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting the generated code: %v", err)
+	}
+	return out, nil
+}
+
+func findStruct(file *ast.File, name string) *ast.StructType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+				st, _ := ts.Type.(*ast.StructType)
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+func hasMethod(file *ast.File, typeName, method string) bool {
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || fn.Name.Name != method {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		if id, ok := recv.(*ast.Ident); ok && id.Name == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+func parseStruct(name string, st *ast.StructType, jwtName string) (*claimsType, error) {
+	ct := &claimsType{name: name}
+	var all []field
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			if !isStandardClaims(f.Type, jwtName) {
+				return nil, fmt.Errorf("%v: only jwt.StandardClaims may be embedded", name)
+			}
+			if f.Tag != nil {
+				return nil, fmt.Errorf("%v: the embedded jwt.StandardClaims can't have a tag", name)
+			}
+			ct.standard = true
+			for _, sf := range standardFields {
+				sf.embedded = true
+				all = append(all, sf)
+			}
+			continue
+		}
+
+		kind := typeName(f.Type)
+		for _, id := range f.Names {
+			if !id.IsExported() {
+				continue
+			}
+			fd := field{goName: id.Name, key: id.Name, kind: kind}
+			if f.Tag != nil {
+				tag, _ := strconv.Unquote(f.Tag.Value)
+				skip, err := parseTag(&fd, reflect.StructTag(tag).Get("json"))
+				if err != nil {
+					return nil, fmt.Errorf("%v.%v: %v", name, id.Name, err)
+				}
+				if skip {
+					continue
+				}
+			}
+			if _, ok := kinds[kind]; !ok {
+				return nil, fmt.Errorf("%v.%v: unsupported type %v", name, id.Name, kind)
+			}
+			for _, prev := range all {
+				if !prev.embedded && prev.key == fd.key {
+					return nil, fmt.Errorf("%v: fields %v and %v both have the key %q", name, prev.goName, fd.goName, fd.key)
+				}
+			}
+			all = append(all, fd)
+		}
+	}
+
+	// Keys of the struct itself hide those of StandardClaims, as they do
+	// in encoding/json
+	outer := make(map[string]bool)
+	for _, f := range all {
+		if !f.embedded {
+			outer[f.key] = true
+		}
+	}
+	for _, f := range all {
+		if !f.embedded || !outer[f.key] {
+			ct.fields = append(ct.fields, f)
+		}
+	}
+	return ct, nil
+}
+
+func isStandardClaims(expr ast.Expr, jwtName string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || jwtName == "" || sel.Sel.Name != "StandardClaims" {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && x.Name == jwtName
+}
+
+// Apply a json tag to f, returning whether the field is left out
+func parseTag(f *field, tag string) (skip bool, err error) {
+	if tag == "-" {
+		return true, nil
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if validKey(name) {
+		f.key = name
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		switch opt {
+		case "omitempty":
+			f.omitempty = true
+		case "string":
+			return false, fmt.Errorf("the string option is not supported")
+		}
+	}
+	return false, nil
+}
+
+// As encoding/json, which ignores other names and uses the field's
+func validKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c >= 0x80:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + typeName(t.Elt)
+		}
+	case *ast.StarExpr:
+		return "*" + typeName(t.X)
+	case *ast.SelectorExpr:
+		return typeName(t.X) + "." + t.Sel.Name
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+// ----- output
+
+func writeFile(buf *bytes.Buffer, pkg string, types []*claimsType) {
+	needJWT := false
+	for _, ct := range types {
+		if !ct.standard && !ct.hasValid && len(ct.timeFields()) > 0 {
+			needJWT = true
+		}
+	}
+
+	fmt.Fprintf(buf, "// Code generated by jwtgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %v\n\n", pkg)
+	fmt.Fprintf(buf, "import (\n")
+	if needJWT {
+		fmt.Fprintf(buf, "%q\n\n", "time")
+		fmt.Fprintf(buf, "%q\n", jwtImportPath)
+	}
+	fmt.Fprintf(buf, "%q\n", jwtImportPath+"/jsoncodec")
+	fmt.Fprintf(buf, ")\n")
+
+	for _, ct := range types {
+		ct.writeMarshal(buf)
+		ct.writeUnmarshal(buf)
+		if !ct.standard && !ct.hasValid {
+			ct.writeValid(buf)
+		}
+	}
+}
+
+func (ct *claimsType) writeMarshal(buf *bytes.Buffer) {
+	size := 2
+	floats := false
+	for _, f := range ct.fields {
+		size += len(f.key) + 4 + 16
+		floats = floats || f.kind == "float64"
+	}
+
+	fmt.Fprintf(buf, "\nfunc (c %v) MarshalJSON() ([]byte, error) {\n", ct.name)
+	if floats {
+		fmt.Fprintf(buf, "var err error\n")
+	}
+	fmt.Fprintf(buf, "b := make([]byte, 0, %v)\n", size)
+	fmt.Fprintf(buf, "b = append(b, '{')\n")
+	for _, f := range ct.fields {
+		if f.omitempty {
+			switch f.kind {
+			case "string":
+				fmt.Fprintf(buf, "if %v != \"\" {\n", f.access())
+			case "[]string":
+				fmt.Fprintf(buf, "if len(%v) != 0 {\n", f.access())
+			case "bool":
+				fmt.Fprintf(buf, "if %v {\n", f.access())
+			default:
+				fmt.Fprintf(buf, "if %v != 0 {\n", f.access())
+			}
+		}
+		key := strconv.Quote(string(jsoncodec.AppendString(nil, f.key)) + ":")
+		fmt.Fprintf(buf, "b = jsoncodec.AppendKey(b, %v)\n", key)
+		switch f.kind {
+		case "string":
+			fmt.Fprintf(buf, "b = jsoncodec.AppendString(b, %v)\n", f.access())
+		case "[]string":
+			fmt.Fprintf(buf, "b = jsoncodec.AppendStrings(b, %v)\n", f.access())
+		case "bool":
+			fmt.Fprintf(buf, "b = jsoncodec.AppendBool(b, %v)\n", f.access())
+		case "int":
+			fmt.Fprintf(buf, "b = jsoncodec.AppendInt(b, int64(%v))\n", f.access())
+		case "int64":
+			fmt.Fprintf(buf, "b = jsoncodec.AppendInt(b, %v)\n", f.access())
+		case "float64":
+			fmt.Fprintf(buf, "if b, err = jsoncodec.AppendFloat(b, %v); err != nil {\nreturn nil, err\n}\n", f.access())
+		}
+		if f.omitempty {
+			fmt.Fprintf(buf, "}\n")
+		}
+	}
+	fmt.Fprintf(buf, "return append(b, '}'), nil\n")
+	fmt.Fprintf(buf, "}\n")
+}
+
+func (ct *claimsType) writeUnmarshal(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "\nfunc (c *%v) UnmarshalJSON(data []byte) error {\n", ct.name)
+	fmt.Fprintf(buf, "r := jsoncodec.NewReader(data)\n")
+	fmt.Fprintf(buf, "return r.Object(func(key []byte) error {\n")
+	if len(ct.fields) > 0 {
+		fmt.Fprintf(buf, "switch string(key) {\n")
+		for _, f := range ct.fields {
+			fmt.Fprintf(buf, "case %q:\n", f.key)
+			fmt.Fprintf(buf, "return r.%v(&%v)\n", kinds[f.kind], f.access())
+		}
+		fmt.Fprintf(buf, "}\n")
+	}
+	fmt.Fprintf(buf, "return r.Skip()\n")
+	fmt.Fprintf(buf, "})\n")
+	fmt.Fprintf(buf, "}\n")
+}
+
+// The int64 fields holding exp, iat and nbf, keyed by the name of their
+// StandardClaims counterpart
+func (ct *claimsType) timeFields() map[string]string {
+	names := map[string]string{"exp": "ExpiresAt", "iat": "IssuedAt", "nbf": "NotBefore"}
+	out := make(map[string]string)
+	for _, f := range ct.fields {
+		if std, ok := names[f.key]; ok && f.kind == "int64" {
+			out[std] = f.access()
+		}
+	}
+	return out
+}
+
+func (ct *claimsType) writeValid(buf *bytes.Buffer) {
+	fields := ct.timeFields()
+	fmt.Fprintf(buf, "\nfunc (c %v) Valid() error {\n", ct.name)
+	if len(fields) == 0 {
+		fmt.Fprintf(buf, "return nil\n}\n")
+		return
+	}
+	fmt.Fprintf(buf, "return c.ValidAt(jwt.TimeFunc())\n}\n")
+
+	// So that Parsers with their own TimeFunc check the fields at it
+	fmt.Fprintf(buf, "\nfunc (c %v) ValidAt(now time.Time) error {\n", ct.name)
+	fmt.Fprintf(buf, "return jwt.StandardClaims{\n")
+	for _, std := range []string{"ExpiresAt", "IssuedAt", "NotBefore"} {
+		if access, ok := fields[std]; ok {
+			fmt.Fprintf(buf, "%v: %v,\n", std, access)
+		}
+	}
+	fmt.Fprintf(buf, "}.CheckTimes(now)\n}\n")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// The sample in jsoncodec is generated with go generate, and must match
+// what the generator writes now
+func TestGenerate_golden(t *testing.T) {
+	src, err := os.ReadFile("../../jsoncodec/claims_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := os.ReadFile("../../jsoncodec/claims_jwtgen_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate("claims_test.go", src, []string{"sessionClaims", "profileClaims"})
+	if err != nil {
+		t.Fatalf("Error generating: %v", err)
+	}
+	if string(got) != string(expect) {
+		t.Errorf("The generated code has changed; run go generate in jsoncodec.  Got:\n%s", got)
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		src    string
+		expect string
+	}{
+		{"missing type", `type other struct{}`, "no struct type claims"},
+		{"not a struct", `type claims map[string]interface{}`, "no struct type claims"},
+		{"unsupported type", `type claims struct { Exp uint64 }`, "unsupported type uint64"},
+		{"string option", "type claims struct { Exp int64 `json:\"exp,string\"` }", "string option"},
+		{"other embedded type", `type other struct{}; type claims struct { other }`, "only jwt.StandardClaims"},
+		{"embedded without import", `type claims struct { jwt.StandardClaims }`, "only jwt.StandardClaims"},
+		{"duplicate key", "type claims struct { A string `json:\"a\"`; B string `json:\"a\"` }", `both have the key "a"`},
+	}
+	for _, data := range tests {
+		_, err := generate("claims.go", []byte("package p\n"+data.src), []string{"claims"})
+		if err == nil || !strings.Contains(err.Error(), data.expect) {
+			t.Errorf("[%v] Expected an error containing %q.  Got %v", data.name, data.expect, err)
+		}
+	}
+}
+
+func TestGenerate_existingValid(t *testing.T) {
+	src := `package p
+
+import jwtgo "github.com/dgrijalva/jwt-go"
+
+type withValid struct {
+	Exp int64 ` + "`json:\"exp\"`" + `
+}
+
+func (c *withValid) Valid() error { return nil }
+
+type embedded struct {
+	jwtgo.StandardClaims
+	Role string ` + "`json:\"role\"`" + `
+}
+`
+	out, err := generate("claims.go", []byte(src), []string{"withValid", "embedded"})
+	if err != nil {
+		t.Fatalf("Error generating: %v", err)
+	}
+	if strings.Contains(string(out), "Valid()") {
+		t.Errorf("Expected no Valid method to be written.  Got:\n%s", out)
+	}
+	if !strings.Contains(string(out), "r.String(&c.StandardClaims.Subject)") {
+		t.Errorf("Expected the embedded claims to be read.  Got:\n%s", out)
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	for input, expect := range map[string]string{
+		"claims.go":      "claims_jwtgen.go",
+		"claims_test.go": "claims_jwtgen_test.go",
+	} {
+		if got := outputName(input); got != expect {
+			t.Errorf("[%v] Expected %v.  Got %v", input, expect, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// jwtgen writes MarshalJSON, UnmarshalJSON and Valid methods for claim
+// structs, so that signing and parsing them skips the reflection of
+// encoding/json.  Run it with go generate from the file declaring the
+// types:
+//
+//	//go:generate go run github.com/dgrijalva/jwt-go/cmd/jwtgen -type MyClaims,OtherClaims
+//
+// The methods go in a file named after the input, claims.go giving
+// claims_jwtgen.go.  Fields may be string, bool, int, int64, float64 or
+// []string, with the usual json tags, and an embedded jwt.StandardClaims
+// is flattened as encoding/json does it.  Valid is written unless the
+// type has one, or gets one from StandardClaims; it checks exp, nbf and
+// iat fields of type int64 as StandardClaims.Valid does.  With such
+// fields, ValidAt is written too, for Parsers with their own TimeFunc.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	flagType   = flag.String("type", "", "comma separated names of the claim types; required")
+	flagOutput = flag.String("output", "", "output file; defaults to <input>_jwtgen.go")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -type T [-output file] [input.go]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  The input defaults to $GOFILE, set by go generate.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := start(); err != nil {
+		fmt.Fprintf(os.Stderr, "jwtgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func start() error {
+	if *flagType == "" {
+		flag.Usage()
+		return fmt.Errorf("-type is required")
+	}
+	input := flag.Arg(0)
+	if input == "" {
+		input = os.Getenv("GOFILE")
+	}
+	if input == "" {
+		flag.Usage()
+		return fmt.Errorf("no input file, and GOFILE is not set")
+	}
+
+	src, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	out, err := generate(input, src, strings.Split(*flagType, ","))
+	if err != nil {
+		return err
+	}
+
+	output := *flagOutput
+	if output == "" {
+		output = outputName(input)
+	}
+	return os.WriteFile(output, out, 0644)
+}
+
+// claims.go becomes claims_jwtgen.go, and claims_test.go
+// claims_jwtgen_test.go, so that test types stay in the test build
+func outputName(input string) string {
+	if base, ok := strings.CutSuffix(input, "_test.go"); ok {
+		return base + "_jwtgen_test.go"
+	}
+	return strings.TrimSuffix(input, ".go") + "_jwtgen.go"
+}
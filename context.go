@@ -0,0 +1,31 @@
+package jwt
+
+import "context"
+
+type contextKey struct{}
+
+// Returns a copy of ctx carrying token, for handlers further down to get
+// with FromContext.  Middleware in package request and elsewhere uses this
+// one key, so they can be mixed.
+func NewContext(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, contextKey{}, token)
+}
+
+// The token stored in ctx by NewContext
+func FromContext(ctx context.Context) (*Token, bool) {
+	token, ok := ctx.Value(contextKey{}).(*Token)
+	return token, ok
+}
+
+// The claims of the token stored in ctx, if they are of type T:
+//
+//	claims, ok := jwt.ClaimsFromContext[*MyClaims](r.Context())
+func ClaimsFromContext[T Claims](ctx context.Context) (T, bool) {
+	var claims T
+	token, ok := FromContext(ctx)
+	if !ok {
+		return claims, false
+	}
+	claims, ok = token.Claims.(T)
+	return claims, ok
+}
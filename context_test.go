@@ -0,0 +1,31 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestContext(t *testing.T) {
+	claims := &jwt.StandardClaims{Subject: "alice"}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	ctx := jwt.NewContext(context.Background(), token)
+
+	if got, ok := jwt.FromContext(ctx); !ok || got != token {
+		t.Errorf("Expected the token from the context")
+	}
+	if got, ok := jwt.ClaimsFromContext[*jwt.StandardClaims](ctx); !ok || got != claims {
+		t.Errorf("Expected the claims from the context")
+	}
+	if _, ok := jwt.ClaimsFromContext[jwt.MapClaims](ctx); ok {
+		t.Errorf("Expected claims of another type to be missing")
+	}
+
+	if _, ok := jwt.FromContext(context.Background()); ok {
+		t.Errorf("Expected no token in an empty context")
+	}
+	if _, ok := jwt.ClaimsFromContext[*jwt.StandardClaims](context.Background()); ok {
+		t.Errorf("Expected no claims in an empty context")
+	}
+}
@@ -0,0 +1,85 @@
+package cwt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// From RFC 8949 appendix A
+var cborTestData = []struct {
+	hex   string
+	value interface{}
+}{
+	{"00", int64(0)},
+	{"17", int64(23)},
+	{"1818", int64(24)},
+	{"1903e8", int64(1000)},
+	{"1a000f4240", int64(1000000)},
+	{"1b000000e8d4a51000", int64(1000000000000)},
+	{"20", int64(-1)},
+	{"3903e7", int64(-1000)},
+	{"fb3ff199999999999a", 1.1},
+	{"f4", false},
+	{"f5", true},
+	{"f6", nil},
+	{"40", []byte{}},
+	{"4401020304", []byte{1, 2, 3, 4}},
+	{"60", ""},
+	{"6449455446", "IETF"},
+	{"62c3bc", "ü"},
+	{"80", []interface{}{}},
+	{"83010203", []interface{}{int64(1), int64(2), int64(3)}},
+	{"8301820203820405", []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+	{"a201020304", map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}},
+	{"a26161016162820203", map[interface{}]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+	{"c074323031332d30332d32315432303a30343a30305a", tagged{0, "2013-03-21T20:04:00Z"}},
+}
+
+func TestCBOR(t *testing.T) {
+	for _, data := range cborTestData {
+		raw, _ := hex.DecodeString(data.hex)
+		v, err := decodeCBOR(raw)
+		if err != nil || !reflect.DeepEqual(v, data.value) {
+			t.Errorf("[%v] Expected decoding to %#v.  Got %#v %v", data.hex, data.value, v, err)
+		}
+		if enc, err := encodeCBOR(data.value); err != nil || !bytes.Equal(enc, raw) {
+			t.Errorf("[%v] Expected encoding.  Got %x %v", data.hex, enc, err)
+		}
+	}
+}
+
+func TestCBOR_floats(t *testing.T) {
+	for in, want := range map[string]float64{"f93c00": 1, "f97bff": 65504, "f90001": 5.960464477539063e-8, "f9c400": -4, "fa47c35000": 100000} {
+		raw, _ := hex.DecodeString(in)
+		if v, err := decodeCBOR(raw); err != nil || v != want {
+			t.Errorf("[%v] Expected %v.  Got %v %v", in, want, v, err)
+		}
+	}
+	if v, _ := decodeCBOR([]byte{0xf9, 0x7c, 0x00}); v != math.Inf(1) {
+		t.Errorf("Expected infinity.  Got %v", v)
+	}
+}
+
+var cborMalformedTestData = []string{
+	"",
+	"18",                 // Missing argument
+	"4401",               // Short byte string
+	"9f01ff",             // Indefinite length
+	"9b0000000100000000", // Huge array
+	"a10102a0",           // Trailing data
+	"a201020103",         // Duplicate key
+	"a1400102",           // Byte string key
+	"818181818181818181818181818181818181818100", // Too deep
+}
+
+func TestCBOR_malformed(t *testing.T) {
+	for _, in := range cborMalformedTestData {
+		raw, _ := hex.DecodeString(in)
+		if _, err := decodeCBOR(raw); err == nil {
+			t.Errorf("[%v] Expected an error", in)
+		}
+	}
+}
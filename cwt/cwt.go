@@ -0,0 +1,379 @@
+package cwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Errors
+var (
+	ErrMalformed        = errors.New("cwt: token is malformed")
+	ErrUnsupported      = errors.New("cwt: alg is not supported")
+	ErrUnsupportedValue = errors.New("cwt: value cannot be represented")
+	ErrMethodRefused    = errors.New("cwt: alg is not allowed")
+	ErrNoKeyfunc        = errors.New("cwt: no Keyfunc was provided")
+	ErrTooLarge         = errors.New("cwt: token exceeds the size limit")
+)
+
+// CBOR tags of RFC 9052 and RFC 8392
+const (
+	tagCOSESign1 = 18
+	tagCWT       = 61
+)
+
+// COSE header labels
+const (
+	headerAlg = 1
+	headerKid = 4
+)
+
+// COSE algorithm identifiers, RFC 9053, of the jwt signing methods with
+// the same signature format
+var algorithmIds = map[string]int64{
+	"ES256": -7,
+	"ES384": -35,
+	"ES512": -36,
+	"EdDSA": -8,
+	"PS256": -37,
+	"PS384": -38,
+	"PS512": -39,
+}
+
+// CWT claim labels, RFC 8392 section 4
+var claimLabels = map[string]int64{
+	"iss": 1,
+	"sub": 2,
+	"aud": 3,
+	"exp": 4,
+	"nbf": 5,
+	"iat": 6,
+	"jti": 7, // cti, a byte string in CWT
+}
+
+// A CWT.  Fields are populated when you Parse a token.
+type Token struct {
+	Alg    string // The signing method, by its JWS name
+	Kid    []byte
+	Method jwt.SigningMethod
+	Claims jwt.Claims
+	Valid  bool
+}
+
+// Supplies the key for verifying, given the parsed but unverified token.
+// Like jwt.Keyfunc, it can use Kid or Alg to choose.
+type Keyfunc func(*Token) (interface{}, error)
+
+// Sign claims with method and key, returning a tagged COSE_Sign1 message.
+// kid, if not nil, is sent in the unprotected header.
+func Sign(claims jwt.Claims, method jwt.SigningMethod, key interface{}, kid []byte) ([]byte, error) {
+	alg, ok := algorithmIds[method.Alg()]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	payload, err := encodeClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := encodeCBOR(map[interface{}]interface{}{int64(headerAlg): alg})
+	if err != nil {
+		return nil, err
+	}
+	unprotected := map[interface{}]interface{}{}
+	if kid != nil {
+		unprotected[int64(headerKid)] = kid
+	}
+
+	tbs, err := sigStructure(protected, payload)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := method.Sign(string(tbs), key)
+	if err != nil {
+		return nil, err
+	}
+	sigBytes, err := jwt.DecodeSegment(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeCBOR(tagged{tagCOSESign1, []interface{}{protected, unprotected, payload, sigBytes}})
+}
+
+// Verifies tokens.  The zero value accepts every signing method with a
+// COSE identifier, and tokens up to jwt.DefaultMaxTokenLength.
+type Verifier struct {
+	Methods              []string // If populated, only these methods, by JWS name, are accepted
+	MaxLength            int      // Longer tokens are rejected.  Defaults to jwt.DefaultMaxTokenLength, negative for no limit
+	SkipClaimsValidation bool     // Skip calling Valid on the claims
+}
+
+// Parse and verify a COSE_Sign1 CWT, tagged or not, decoding its claims
+// into claims
+func Parse(data []byte, claims jwt.Claims, keyFunc Keyfunc) (*Token, error) {
+	return new(Verifier).Parse(data, claims, keyFunc)
+}
+
+func (v *Verifier) Parse(data []byte, claims jwt.Claims, keyFunc Keyfunc) (*Token, error) {
+	if max := v.maxLength(); max >= 0 && len(data) > max {
+		return nil, ErrTooLarge
+	}
+	msg, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := msg.(tagged); ok && t.tag == tagCWT {
+		msg = t.value
+	}
+	if t, ok := msg.(tagged); ok && t.tag == tagCOSESign1 {
+		msg = t.value
+	}
+
+	parts, ok := msg.([]interface{})
+	if !ok || len(parts) != 4 {
+		return nil, ErrMalformed
+	}
+	protected, ok1 := parts[0].([]byte)
+	unprotected, ok2 := parts[1].(map[interface{}]interface{})
+	payload, ok3 := parts[2].([]byte)
+	sig, ok4 := parts[3].([]byte)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return nil, ErrMalformed
+	}
+
+	token := &Token{Claims: claims}
+	header := map[interface{}]interface{}{}
+	if len(protected) > 0 {
+		h, err := decodeCBOR(protected)
+		if err != nil {
+			return nil, err
+		}
+		if header, ok = h.(map[interface{}]interface{}); !ok {
+			return nil, ErrMalformed
+		}
+	}
+	// alg must be protected, so it cannot be swapped
+	if err = token.readHeader(header, unprotected); err != nil {
+		return nil, err
+	}
+	if v.Methods != nil && !contains(v.Methods, token.Alg) {
+		return nil, ErrMethodRefused
+	}
+	if token.Method = jwt.GetSigningMethod(token.Alg); token.Method == nil {
+		return nil, ErrUnsupported
+	}
+	if err = decodeClaims(payload, claims); err != nil {
+		return token, err
+	}
+
+	if keyFunc == nil {
+		return token, ErrNoKeyfunc
+	}
+	key, err := keyFunc(token)
+	if err != nil {
+		return token, err
+	}
+	tbs, err := sigStructure(protected, payload)
+	if err != nil {
+		return token, err
+	}
+	if err = token.Method.Verify(string(tbs), jwt.EncodeSegment(sig), key); err != nil {
+		return token, err
+	}
+
+	if !v.SkipClaimsValidation {
+		if err = claims.Valid(); err != nil {
+			return token, err
+		}
+	}
+	token.Valid = true
+	return token, nil
+}
+
+// Read alg from the protected header and kid from either
+func (t *Token) readHeader(protected, unprotected map[interface{}]interface{}) error {
+	alg, ok := protected[int64(headerAlg)].(int64)
+	if !ok {
+		return ErrMalformed
+	}
+	for name, id := range algorithmIds {
+		if id == alg {
+			t.Alg = name
+		}
+	}
+	if t.Alg == "" {
+		return ErrUnsupported
+	}
+
+	kid, ok := protected[int64(headerKid)]
+	if !ok {
+		kid, ok = unprotected[int64(headerKid)]
+	}
+	if ok {
+		if t.Kid, ok = kid.([]byte); !ok {
+			return ErrMalformed
+		}
+	}
+	return nil
+}
+
+func (v *Verifier) maxLength() int {
+	if v.MaxLength == 0 {
+		return jwt.DefaultMaxTokenLength
+	}
+	return v.MaxLength
+}
+
+// The Sig_structure of RFC 9052 section 4.4, with no external data
+func sigStructure(protected, payload []byte) ([]byte, error) {
+	return encodeCBOR([]interface{}{"Signature1", protected, []byte{}, payload})
+}
+
+// ----- claims
+
+// Encode claims as a CWT claims set, by way of their JSON form
+func encodeClaims(claims jwt.Claims) ([]byte, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err = dec.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	set := map[interface{}]interface{}{}
+	for name, v := range m {
+		cv, err := fromJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		if label, ok := claimLabels[name]; ok {
+			if s, ok := cv.(string); ok && name == "jti" {
+				cv = []byte(s)
+			}
+			set[label] = cv
+		} else {
+			set[name] = cv
+		}
+	}
+	return encodeCBOR(set)
+}
+
+// Decode a CWT claims set into claims, by way of its JSON form
+func decodeClaims(payload []byte, claims jwt.Claims) error {
+	v, err := decodeCBOR(payload)
+	if err != nil {
+		return err
+	}
+	set, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return ErrMalformed
+	}
+
+	m := map[string]interface{}{}
+	for k, v := range set {
+		name, ok := k.(string)
+		if !ok {
+			name = labelName(k.(int64))
+		}
+		if b, ok := v.([]byte); ok && name == "jti" {
+			v = string(b)
+		}
+		m[name] = toJSON(v)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return ErrUnsupportedValue
+	}
+	// Special case for map type to avoid weird pointer behavior, as in jwt
+	if c, ok := claims.(jwt.MapClaims); ok {
+		err = json.Unmarshal(data, &c)
+	} else {
+		err = json.Unmarshal(data, claims)
+	}
+	if err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func labelName(label int64) string {
+	for name, l := range claimLabels {
+		if l == label {
+			return name
+		}
+	}
+	return strconv.FormatInt(label, 10)
+}
+
+// Convert a value decoded from JSON to one writeCBOR takes
+func fromJSON(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			var err error
+			if a[i], err = fromJSON(e); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(v))
+		for k, e := range v {
+			var err error
+			if m[k], err = fromJSON(e); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return v, nil
+}
+
+// Convert a decoded CBOR value to one encoding/json takes
+func toJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = toJSON(e)
+		}
+		return a
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			name, ok := k.(string)
+			if !ok {
+				name = strconv.FormatInt(k.(int64), 10)
+			}
+			m[name] = toJSON(e)
+		}
+		return m
+	case tagged:
+		return toJSON(v.value)
+	}
+	return v
+}
+
+func contains(list []string, name string) bool {
+	for _, l := range list {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package cwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func staticKey(key interface{}) Keyfunc {
+	return func(*Token) (interface{}, error) { return key, nil }
+}
+
+func TestSign(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	var signTestData = []struct {
+		method jwt.SigningMethod
+		sign   interface{}
+		verify interface{}
+	}{
+		{jwt.SigningMethodES256, ecKey, &ecKey.PublicKey},
+		{jwt.SigningMethodEdDSA, edKey, edPub},
+	}
+
+	claims := jwt.MapClaims{
+		"iss":  "gateway",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"jti":  "abc",
+		"temp": 21.5,
+		"tags": []interface{}{"a", "b"},
+	}
+	for _, data := range signTestData {
+		name := data.method.Alg()
+		s, err := Sign(claims, data.method, data.sign, []byte("k1"))
+		if err != nil {
+			t.Errorf("[%v] Error signing: %v", name, err)
+			continue
+		}
+
+		got := jwt.MapClaims{}
+		token, err := Parse(s, got, staticKey(data.verify))
+		if err != nil || !token.Valid || token.Alg != name || string(token.Kid) != "k1" {
+			t.Errorf("[%v] Error parsing: %v", name, err)
+			continue
+		}
+		if got["iss"] != "gateway" || got["jti"] != "abc" || got["temp"] != 21.5 || !reflect.DeepEqual(got["tags"], claims["tags"]) {
+			t.Errorf("[%v] Unexpected claims %v", name, got)
+		}
+
+		if _, err := (&Verifier{Methods: []string{"PS256"}}).Parse(s, jwt.MapClaims{}, staticKey(data.verify)); err != ErrMethodRefused {
+			t.Errorf("[%v] Expected %v.  Got %v", name, ErrMethodRefused, err)
+		}
+		s[len(s)-1] ^= 1
+		if _, err := Parse(s, jwt.MapClaims{}, staticKey(data.verify)); err == nil {
+			t.Errorf("[%v] Expected a modified signature to fail", name)
+		}
+	}
+
+	if _, err := Sign(claims, jwt.SigningMethodHS256, []byte("secret"), nil); err != ErrUnsupported {
+		t.Errorf("Expected %v.  Got %v", ErrUnsupported, err)
+	}
+}
+
+func TestParse_claims(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// Struct claims and their validation are shared with jwt
+	expired := &jwt.StandardClaims{Issuer: "gateway", Subject: "sensor-9", ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+	s, _ := Sign(expired, jwt.SigningMethodES256, key, nil)
+	got := new(jwt.StandardClaims)
+	if _, err := Parse(s, got, staticKey(&key.PublicKey)); !jwt.IsExpired(err) {
+		t.Errorf("Expected an expired token.  Got %v", err)
+	}
+	if *got != *expired {
+		t.Errorf("Expected %+v.  Got %+v", expired, got)
+	}
+}
+
+// RFC 8392 appendix A.3, with the key of appendix A.2.3
+const rfc8392Token = "d28443a10126a104524173796d6d657472696345434453413235365850a70175636f61703a2f2f61732e6578616d706c652e636f6d02656572696b77037818636f61703a2f2f6c696768742e6578616d706c652e636f6d041a5612aeb0051a5610d9f0061a5610d9f007420b7158405427c1ff28d23fbad1f29c4c7c6a555e601d6fa29f9179bc3d7438bacaca5acd08c8d4d4f96131680c429a01f85951ecee743a52b9b63632c57209120e1c9e30"
+
+func TestParse_rfc8392(t *testing.T) {
+	x, _ := new(big.Int).SetString("143329cce7868e416927599cf65a34f3ce2ffda55a7eca69ed8919a394d42f0f", 16)
+	y, _ := new(big.Int).SetString("60f7f1a780d8a783bfb7a2dd6b2796e8128dbbcef9d3d168db9529971a36e7b9", 16)
+	key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	data, _ := hex.DecodeString(rfc8392Token)
+
+	claims := jwt.MapClaims{}
+	token, err := (&Verifier{SkipClaimsValidation: true}).Parse(data, claims, staticKey(key))
+	if err != nil || string(token.Kid) != "AsymmetricECDSA256" {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	if claims["iss"] != "coap://as.example.com" || claims["sub"] != "erikw" || claims["exp"] != 1444064944.0 || claims["jti"] != "\x0b\x71" {
+		t.Errorf("Unexpected claims %v", claims)
+	}
+}
@@ -0,0 +1,12 @@
+// Package cwt encodes and verifies CBOR Web Tokens, RFC 8392, signed as
+// COSE_Sign1 messages, RFC 9052, for devices where JSON and base64 are
+// too costly.
+//
+// Claims are the same types used with package jwt: they are converted
+// through their JSON form, with the registered claims (iss, sub, aud, exp,
+// nbf, iat and jti) mapped to their CWT integer labels, and validated
+// with their Valid method.  Signing methods come from jwt's registry:
+//
+//	data, err := cwt.Sign(claims, jwt.SigningMethodES256, key, kid)
+//	token, err := cwt.Parse(data, &MyClaims{}, keyFunc)
+package cwt
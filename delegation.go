@@ -0,0 +1,183 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Hops a delegation chain may have by default
+const DefaultMaxDelegationHops = 5
+
+// act claims nested deeper than this are malformed
+const maxActorDepth = 16
+
+// The principals of a verified delegation chain
+type DelegationChain struct {
+	Subject string // sub of the innermost token
+
+	// Who vouched, in order: the iss of the innermost token, then that of
+	// each service that wrapped it, ending with the one that sent the chain
+	Principals []string
+
+	// The act claim of the innermost token, RFC 8693 section 4.1,
+	// flattened with the earliest actor first
+	Actors []string
+
+	Token *Token // The innermost token, with its claims
+}
+
+// Vouch for a token received from another service by wrapping it in a
+// nested JWT of its own, for delegation between services.  issuer is the
+// service signing and audience the one it is sent to; both are replicated
+// as header parameters, RFC 7519 section 5.3, so each hop can be checked
+// without claims.  header may add parameters such as kid.
+func SignDelegation(inner string, method SigningMethod, key interface{}, issuer, audience string, header map[string]interface{}) (string, error) {
+	h := map[string]interface{}{}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["iss"] = issuer
+	h["aud"] = audience
+	return SignNested(inner, method, key, h)
+}
+
+// Verifies delegation chains built with SignDelegation.  The zero value
+// allows DefaultMaxDelegationHops.
+type DelegationVerifier struct {
+	MaxHops int     // Chains with more wrapping hops are rejected.  Defaults to DefaultMaxDelegationHops
+	Parser  *Parser // Options for each token.  Defaults to a zero Parser
+}
+
+// Verify a delegation chain sent to audience.  Layers are verified from
+// the outside in, with keyFunc asked for each; a hop's issuer is in its
+// header's iss, which keyFunc must check is a service it trusts.  Each
+// token must be addressed to the service that wrapped it, and the
+// outermost to audience, so no hop can forward a token it was not given.
+// The innermost token's claims are decoded into claims and validated.
+func VerifyDelegationChain(tokenString string, claims Claims, audience string, keyFunc Keyfunc) (*DelegationChain, error) {
+	return new(DelegationVerifier).Verify(tokenString, claims, audience, keyFunc)
+}
+
+func (v *DelegationVerifier) Verify(tokenString string, claims Claims, audience string, keyFunc Keyfunc) (*DelegationChain, error) {
+	p := v.Parser
+	if p == nil {
+		p = new(Parser)
+	}
+	maxHops := v.MaxHops
+	if maxHops == 0 {
+		maxHops = DefaultMaxDelegationHops
+	}
+
+	// From the outside in, the service each token must be addressed to
+	var hops []string
+	expected := audience
+	for {
+		token, parts, err := p.parseDetached(context.Background(), tokenString)
+		if err != nil {
+			return nil, err
+		}
+		if cty, _ := token.Header["cty"].(string); !strings.EqualFold(cty, "JWT") {
+			break
+		}
+		if len(hops) == maxHops {
+			return nil, NewValidationError("delegation chain has too many hops", ValidationErrorMalformed)
+		}
+
+		issuer, _ := token.Header["iss"].(string)
+		if issuer == "" {
+			return nil, NewValidationError("delegation hop has no iss header", ValidationErrorMalformed)
+		}
+		if err = checkDelegationAudience(headerStrings(token.Header["aud"]), expected); err != nil {
+			return nil, err
+		}
+		if err = checkCritical(token.Header, p.CriticalHeaders); err != nil {
+			return nil, err
+		}
+		if err = p.verifyDetached(token, parts[0]+"."+parts[1], keyFunc); err != nil {
+			return nil, err
+		}
+
+		inner, err := DecodeSegment(parts[1])
+		if err != nil {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+		}
+		hops = append(hops, issuer)
+		tokenString, expected = string(inner), issuer
+	}
+
+	token, err := p.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	var origin struct {
+		Issuer   string          `json:"iss"`
+		Subject  string          `json:"sub"`
+		Audience ClaimStrings    `json:"aud"`
+		Actor    json.RawMessage `json:"act"`
+	}
+	if err = json.Unmarshal(token.RawClaims, &origin); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	if origin.Issuer == "" {
+		return nil, NewValidationError("delegated token has no iss claim", ValidationErrorIssuer)
+	}
+	if err = checkDelegationAudience(origin.Audience, expected); err != nil {
+		return nil, err
+	}
+
+	chain := &DelegationChain{Subject: origin.Subject, Principals: []string{origin.Issuer}, Token: token}
+	for i := len(hops) - 1; i >= 0; i-- {
+		chain.Principals = append(chain.Principals, hops[i])
+	}
+	if chain.Actors, err = flattenActors(origin.Actor); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	return chain, nil
+}
+
+func checkDelegationAudience(aud []string, expected string) error {
+	if !verifyAudList(aud, expected, true) {
+		return &ValidationError{Inner: &AudienceError{Expected: expected, Got: aud}, Errors: ValidationErrorAudience}
+	}
+	return nil
+}
+
+// A header parameter that is a string or an array of strings
+func headerStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// The sub of each actor in an act claim, whose nested act is the actor
+// before it, earliest first
+func flattenActors(act json.RawMessage) ([]string, error) {
+	var actors []string
+	for depth := 0; len(act) > 0 && string(act) != "null"; depth++ {
+		if depth == maxActorDepth {
+			return nil, errors.New("act claim is nested too deeply")
+		}
+		var actor struct {
+			Subject string          `json:"sub"`
+			Actor   json.RawMessage `json:"act"`
+		}
+		if err := json.Unmarshal(act, &actor); err != nil {
+			return nil, err
+		}
+		actors = append([]string{actor.Subject}, actors...)
+		act = actor.Actor
+	}
+	return actors, nil
+}
@@ -0,0 +1,111 @@
+package jwt_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestVerifyDelegationChain(t *testing.T) {
+	issuerKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keys := map[string]interface{}{
+		"service-a": []byte("a secret"),
+		"service-b": []byte("b secret"),
+	}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if token.ContentType() != "JWT" {
+			return &issuerKey.PublicKey, nil
+		}
+		iss, _ := token.Header["iss"].(string)
+		if key, ok := keys[iss]; ok {
+			return key, nil
+		}
+		return nil, errors.New("untrusted service " + iss)
+	}
+
+	// The issuer sends a token for alice to A, which vouches for it to B,
+	// which vouches for it to C
+	origin := test.MakeSampleToken(jwt.MapClaims{
+		"iss": "https://idp.example.com",
+		"sub": "alice",
+		"aud": "service-a",
+		"act": map[string]interface{}{"sub": "admin-console", "act": map[string]interface{}{"sub": "support"}},
+	}, issuerKey)
+	toB, err := jwt.SignDelegation(origin, jwt.SigningMethodHS256, keys["service-a"], "service-a", "service-b", nil)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	toC, _ := jwt.SignDelegation(toB, jwt.SigningMethodHS256, keys["service-b"], "service-b", "service-c", nil)
+
+	chain, err := jwt.VerifyDelegationChain(toC, jwt.MapClaims{}, "service-c", keyFunc)
+	if err != nil {
+		t.Fatalf("Error verifying: %v", err)
+	}
+	if chain.Subject != "alice" || !chain.Token.Valid {
+		t.Errorf("Unexpected chain %+v", chain)
+	}
+	if want := []string{"https://idp.example.com", "service-a", "service-b"}; !reflect.DeepEqual(chain.Principals, want) {
+		t.Errorf("Expected principals %v.  Got %v", want, chain.Principals)
+	}
+	if want := []string{"support", "admin-console"}; !reflect.DeepEqual(chain.Actors, want) {
+		t.Errorf("Expected actors %v.  Got %v", want, chain.Actors)
+	}
+
+	// A single token, with no hops
+	chain, err = jwt.VerifyDelegationChain(origin, jwt.MapClaims{}, "service-a", keyFunc)
+	if err != nil || !reflect.DeepEqual(chain.Principals, []string{"https://idp.example.com"}) {
+		t.Errorf("Unexpected chain %v: %v", chain, err)
+	}
+
+	if _, err := (&jwt.DelegationVerifier{MaxHops: 1}).Verify(toC, jwt.MapClaims{}, "service-c", keyFunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected a chain that is too long to be malformed.  Got %v", err)
+	}
+}
+
+func TestVerifyDelegationChain_invalid(t *testing.T) {
+	issuerKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyA, keyB := []byte("a secret"), []byte("b secret")
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		switch token.Header["iss"] {
+		case "service-a":
+			return keyA, nil
+		case "service-b":
+			return keyB, nil
+		}
+		return &issuerKey.PublicKey, nil
+	}
+
+	origin := test.MakeSampleToken(jwt.MapClaims{"iss": "idp", "sub": "alice", "aud": "service-a"}, issuerKey)
+	toB, _ := jwt.SignDelegation(origin, jwt.SigningMethodHS256, keyA, "service-a", "service-b", nil)
+
+	// B was not given the token meant for A, so cannot vouch for it
+	stolen, _ := jwt.SignDelegation(origin, jwt.SigningMethodHS256, keyB, "service-b", "service-c", nil)
+	// B claims to be A
+	forged, _ := jwt.SignDelegation(origin, jwt.SigningMethodHS256, keyB, "service-a", "service-b", nil)
+	noIss, _ := jwt.SignNested(origin, jwt.SigningMethodHS256, keyA, map[string]interface{}{"aud": "service-b"})
+
+	var delegationTestData = []struct {
+		name     string
+		token    string
+		audience string
+		check    func(error) bool
+	}{
+		{"wrong audience", toB, "service-c", isAudienceError},
+		{"skipped hop", stolen, "service-c", isAudienceError},
+		{"forged hop", forged, "service-b", jwt.IsSignatureInvalid},
+		{"hop without iss", noIss, "service-b", jwt.IsMalformed},
+	}
+
+	for _, data := range delegationTestData {
+		if _, err := jwt.VerifyDelegationChain(data.token, jwt.MapClaims{}, data.audience, keyFunc); !data.check(err) {
+			t.Errorf("[%v] Unexpected error %v", data.name, err)
+		}
+	}
+}
+
+func isAudienceError(err error) bool {
+	return errors.Is(err, jwt.ErrTokenInvalidAudience)
+}
@@ -0,0 +1,97 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Sign content, passed out of band, per RFC 7515 appendix F.  The returned
+// compact JWS has an empty payload segment, so large documents don't have
+// to travel base64 encoded inside the token.  header may add parameters
+// such as kid.
+func SignDetached(method SigningMethod, key interface{}, content []byte, header map[string]interface{}) (string, error) {
+	token := New(method)
+	for k, v := range header {
+		token.Header[k] = v
+	}
+	if err := checkCriticalForSigning(token.Header); err != nil {
+		return "", err
+	}
+	headerJSON, err := json.Marshal(token.Header)
+	if err != nil {
+		return "", err
+	}
+	protected := EncodeSegment(headerJSON)
+
+	sig, err := method.Sign(protected+"."+EncodeSegment(content), key)
+	if err != nil {
+		return "", err
+	}
+	return protected + ".." + sig, nil
+}
+
+// Verify a token created with SignDetached against content
+func VerifyDetached(tokenString string, content []byte, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).VerifyDetached(tokenString, content, keyFunc)
+}
+
+// VerifyDetached, checking the signing method and crit header with the
+// options of the Parser.  The content is not interpreted as claims, so
+// the returned Token has empty Claims.  The result is reported to the
+// Parser's ErrorLogger, Metrics, Tracer and SecurityHook as Parse does.
+func (p *Parser) VerifyDetached(tokenString string, content []byte, keyFunc Keyfunc) (*Token, error) {
+	return p.reported(context.Background(), func(ctx context.Context) (*Token, error) {
+		token, parts, err := p.parseDetached(ctx, tokenString)
+		if err != nil {
+			return token, err
+		}
+		if parts[1] != "" {
+			return token, NewValidationError("token payload segment is not empty", ValidationErrorMalformed)
+		}
+		if err = checkCritical(token.Header, p.CriticalHeaders); err != nil {
+			return token, err
+		}
+		return token, p.verifyDetached(token, parts[0]+"."+EncodeSegment(content), keyFunc)
+	})
+}
+
+// Decode the header of a token whose payload is not claims, with the
+// checks Parse makes of it
+func (p *Parser) parseDetached(ctx context.Context, tokenString string) (*Token, [3]string, error) {
+	var parts [3]string
+	if err := p.checkLength(tokenString); err != nil {
+		return nil, parts, err
+	}
+	parts, ok := splitToken(tokenString)
+	if !ok {
+		return nil, parts, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+	}
+
+	token := &Token{Raw: tokenString, Claims: MapClaims{}, Signature: parts[2], ctx: ctx, now: p.TimeFunc}
+	headerBytes, err := DecodeSegment(parts[0])
+	if err != nil {
+		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	token.RawHeader = headerBytes
+	if sig, err := DecodeSegment(parts[2]); err == nil {
+		token.SignatureBytes = sig
+	}
+	if token.Header, err = parseHeader(headerBytes); err != nil {
+		return token, parts, err
+	}
+	return token, parts, p.lookupMethod(token)
+}
+
+// Look up the key and verify the signature of token over signingString
+func (p *Parser) verifyDetached(token *Token, signingString string, keyFunc Keyfunc) error {
+	key, err := p.lookupKey(token, keyFunc)
+	if err != nil {
+		return err
+	}
+	if err = p.verifySignature(token, signingString, key); err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorSignatureInvalid}
+	}
+
+	token.Valid = true
+	return nil
+}
@@ -0,0 +1,81 @@
+package jwt_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestSignDetached(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return &privateKey.PublicKey, nil }
+	content := bytes.Repeat([]byte("a large document "), 10000)
+
+	s, err := jwt.SignDetached(jwt.SigningMethodRS256, privateKey, content, map[string]interface{}{"kid": "docs"})
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	if len(s) > 1024 || strings.Split(s, ".")[1] != "" {
+		t.Errorf("Expected a short token with an empty payload.  Got %v bytes", len(s))
+	}
+
+	if token, err := jwt.VerifyDetached(s, content, keyfunc); err != nil || !token.Valid {
+		t.Errorf("Error verifying: %v", err)
+	}
+	if _, err := jwt.VerifyDetached(s, content[1:], keyfunc); !jwt.IsSignatureInvalid(err) {
+		t.Errorf("Expected modified content to fail.  Got %v", err)
+	}
+
+	// Reattaching the content gives an ordinary token
+	parts := strings.Split(s, ".")
+	attached := parts[0] + "." + jwt.EncodeSegment([]byte(`{"foo":"bar"}`)) + "." + parts[2]
+	if _, err := jwt.VerifyDetached(attached, content, keyfunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected a token with a payload to be malformed.  Got %v", err)
+	}
+
+	// Tokens with b64=false need VerifyUnencoded
+	unencoded, _ := jwt.SignUnencoded(jwt.SigningMethodRS256, privateKey, content, nil)
+	if _, err := jwt.VerifyDetached(unencoded, content, keyfunc); err == nil {
+		t.Errorf("Expected VerifyDetached to reject b64=false")
+	}
+}
+
+func TestParser_VerifyDetached(t *testing.T) {
+	content := []byte("document")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return []byte("secret"), nil }
+	s, _ := jwt.SignDetached(jwt.SigningMethodHS256, []byte("secret"), content, nil)
+
+	// The header is checked as Parse checks it
+	kid := jwt.EncodeSegment([]byte(`{"alg":"HS256","kid":1}`)) + ".." + strings.Split(s, ".")[2]
+	if _, err := jwt.VerifyDetached(kid, content, keyfunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected a non-string kid to be malformed.  Got %v", err)
+	}
+	if _, err := (&jwt.Parser{MaxTokenLength: 16}).VerifyDetached(s, content, keyfunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected a long token to be malformed.  Got %v", err)
+	}
+
+	// Results are reported as Parse reports them
+	var rejections, events int
+	metrics := new(recordingMetrics)
+	p := &jwt.Parser{
+		ErrorLogger:  jwt.ErrorLoggerFunc(func(*jwt.Rejection) { rejections++ }),
+		SecurityHook: jwt.SecurityHookFunc(func(*jwt.SecurityEvent) { events++ }),
+		Metrics:      metrics,
+	}
+	if _, err := p.VerifyDetached(s, content, keyfunc); err != nil {
+		t.Fatalf("Error verifying: %v", err)
+	}
+	if _, err := p.VerifyDetached(s, []byte("forged"), keyfunc); !jwt.IsSignatureInvalid(err) {
+		t.Fatalf("Expected forged content to fail.  Got %v", err)
+	}
+	unencoded, _ := jwt.SignUnencoded(jwt.SigningMethodHS256, []byte("secret"), content, nil)
+	if _, err := p.VerifyUnencoded(unencoded, []byte("forged"), keyfunc); !jwt.IsSignatureInvalid(err) {
+		t.Fatalf("Expected forged unencoded content to fail.  Got %v", err)
+	}
+	if rejections != 2 || events != 2 || len(metrics.verifys) != 3 || metrics.verifys[0] != "HS256 " {
+		t.Errorf("Expected three verifications reported.  Got %v rejections, %v events and %v", rejections, events, metrics.verifys)
+	}
+}
@@ -0,0 +1,41 @@
+package jwt
+
+// Deterministic signing, for golden file tests and for diffing tokens.  A
+// token with Deterministic set signs to the same string on every run,
+// given the same header, claims and key.  Headers and MapClaims already
+// marshal with their keys sorted, and structs in field order; the claims
+// the package fills in take iat from TimeFunc and jti from IdFunc, which
+// tests can fix too.
+
+// Returned when a Deterministic token's method signs with randomness,
+// as PS256 does with its salt
+var ErrNotDeterministic = newError(CodeUnsupportedAlgorithm, "signing method cannot sign deterministically")
+
+// Optionally implemented by a SigningMethod whose Sign is randomized but
+// that has a deterministic form, as ECDSA has in RFC 6979.  Deterministic
+// tokens are signed with SignDeterministic.
+type DeterministicSigner interface {
+	SignDeterministic(signingString string, key interface{}) (string, error)
+}
+
+// Whether tokens of method can be Deterministic: the HMAC, RSA PKCS #1
+// v1.5, EdDSA and none methods, whose signatures never vary, and
+// DeterministicSigners
+func SignsDeterministically(method SigningMethod) bool {
+	switch method.(type) {
+	case DeterministicSigner, *SigningMethodHMAC, *SigningMethodRSA, *SigningMethodEd25519, *signingMethodNone:
+		return true
+	}
+	return false
+}
+
+// Sign with method so the signature is the same every time
+func signDeterministic(method SigningMethod, signingString string, key interface{}) (string, error) {
+	if m, ok := method.(DeterministicSigner); ok {
+		return m.SignDeterministic(signingString, key)
+	}
+	if !SignsDeterministically(method) {
+		return "", ErrNotDeterministic
+	}
+	return method.Sign(signingString, key)
+}
@@ -0,0 +1,129 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestToken_Deterministic(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	var tests = []struct {
+		method jwt.SigningMethod
+		key    interface{}
+		expect error
+	}{
+		{jwt.SigningMethodHS256, []byte("secret"), nil},
+		{jwt.SigningMethodRS256, rsaKey, nil},
+		{jwt.SigningMethodES256, loadECKey(t, "test/ec256-private.pem"), nil},
+		{jwt.SigningMethodES384, loadECKey(t, "test/ec384-private.pem"), nil},
+		{jwt.SigningMethodES512, loadECKey(t, "test/ec512-private.pem"), nil},
+		{jwt.SigningMethodPS256, rsaKey, jwt.ErrNotDeterministic},
+		{jwt.SigningMethodES256, rsaKey, jwt.ErrInvalidKeyType},
+	}
+	for _, data := range tests {
+		sign := func() (string, error) {
+			token := jwt.NewWithClaims(data.method, jwt.MapClaims{"sub": "user-1", "iat": 1700000000, "aud": []string{"b", "a"}})
+			token.Header["kid"] = "k1"
+			token.Header["cty"] = "JWT"
+			token.Deterministic = true
+			return token.SignedString(data.key)
+		}
+		first, err := sign()
+		if !errors.Is(err, data.expect) {
+			t.Errorf("[%v] Expected %v.  Got %v", data.method.Alg(), data.expect, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			if again, _ := sign(); again != first {
+				t.Errorf("[%v] Expected the same token each time.  Got %v and %v", data.method.Alg(), first, again)
+			}
+		}
+
+		token, err := jwt.Parse(first, func(*jwt.Token) (interface{}, error) {
+			if k, ok := data.key.(*ecdsa.PrivateKey); ok {
+				return &k.PublicKey, nil
+			}
+			if data.key == rsaKey {
+				return &rsaKey.PublicKey, nil
+			}
+			return data.key, nil
+		})
+		if err != nil || !token.Valid {
+			t.Errorf("[%v] Expected the token to verify.  Got %v", data.method.Alg(), err)
+			continue
+		}
+		if expect := `{"alg":"` + data.method.Alg() + `","cty":"JWT","kid":"k1","typ":"JWT"}`; string(token.RawHeader) != expect {
+			t.Errorf("[%v] Expected header %v.  Got %s", data.method.Alg(), expect, token.RawHeader)
+		}
+	}
+
+	if !jwt.SignsDeterministically(jwt.SigningMethodEdDSA) || jwt.SignsDeterministically(jwt.SigningMethodPS384) {
+		t.Errorf("Expected EdDSA to sign deterministically, and PS384 not to")
+	}
+}
+
+// The P-256, SHA-256 vector of RFC 6979 appendix A.2.5
+func TestSigningMethodECDSA_SignDeterministic(t *testing.T) {
+	d, _ := new(big.Int).SetString("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721", 16)
+	key := &ecdsa.PrivateKey{D: d}
+	key.Curve = elliptic.P256()
+	key.X, key.Y = key.Curve.ScalarBaseMult(d.Bytes())
+
+	sig, err := jwt.SigningMethodES256.SignDeterministic("sample", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := jwt.DecodeSegment(sig)
+	expect := "efd48b2aacb6a8fd1140dd9cd45e81d69d2c877b56aaf991c34d0ea84eaf3716" + "f7cb1c942d657c41d436c7a1b6e29f65f3e900dbb9aff4064dc4ab2f843acda8"
+	if got := hex.EncodeToString(raw); got != expect {
+		t.Errorf("Expected r || s of %v.  Got %v", expect, got)
+	}
+}
+
+func TestIdFunc(t *testing.T) {
+	prevTime, prevId := jwt.TimeFunc, jwt.IdFunc
+	defer func() { jwt.TimeFunc, jwt.IdFunc = prevTime, prevId }()
+	jwt.TimeFunc = func() time.Time { return time.Unix(1700000000, 0) }
+	jwt.IdFunc = func() string { return "id-1" }
+
+	key := loadECKey(t, "test/ec256-private.pem")
+	sign := func() string {
+		token := jwt.NewClientAssertion(jwt.SigningMethodES256, "client", "https://as.example.com/token")
+		token.Deterministic = true
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	if first, second := sign(), sign(); first != second {
+		t.Errorf("Expected the same assertion each time.  Got %v and %v", first, second)
+	}
+	if claims := jwt.NewRequestObject("client", "https://as.example.com", "code", time.Minute); claims.Id != "id-1" || claims.IssuedAt != 1700000000 {
+		t.Errorf("Expected jti id-1 and iat 1700000000.  Got %v and %v", claims.Id, claims.IssuedAt)
+	}
+}
+
+func loadECKey(t *testing.T, path string) *ecdsa.PrivateKey {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
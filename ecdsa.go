@@ -4,13 +4,13 @@ import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/rand"
-	"errors"
+	"encoding/asn1"
 	"math/big"
 )
 
 var (
 	// Sadly this is missing from crypto/ecdsa compared to crypto/rsa
-	ErrECDSAVerification = errors.New("crypto/ecdsa: verification error")
+	ErrECDSAVerification = newError(CodeInvalidSignature, "crypto/ecdsa: verification error")
 )
 
 // Implements the ECDSA family of signing methods signing methods
@@ -56,14 +56,16 @@ func (m *SigningMethodECDSA) Alg() string {
 // Implements the Verify method from SigningMethod
 // For this verify method, key must be an ecdsa.PublicKey struct
 func (m *SigningMethodECDSA) Verify(signingString, signature string, key interface{}) error {
-	var err error
-
 	// Decode the signature
-	var sig []byte
-	if sig, err = DecodeSegment(signature); err != nil {
+	sig, err := DecodeSegment(signature)
+	if err != nil {
 		return err
 	}
+	return m.VerifyBytes(signingString, sig, key)
+}
 
+// Implements BytesVerifier.  Verify, with the signature already decoded
+func (m *SigningMethodECDSA) VerifyBytes(signingString string, sig []byte, key interface{}) error {
 	// Get the key
 	var ecdsaKey *ecdsa.PublicKey
 	switch k := key.(type) {
@@ -85,7 +87,7 @@ func (m *SigningMethodECDSA) Verify(signingString, signature string, key interfa
 		return ErrHashUnavailable
 	}
 	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
+	hasher.Write(stringBytes(signingString))
 
 	// Verify the signature
 	if verifystatus := ecdsa.Verify(ecdsaKey, hasher.Sum(nil), r, s); verifystatus == true {
@@ -113,7 +115,7 @@ func (m *SigningMethodECDSA) Sign(signingString string, key interface{}) (string
 	}
 
 	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
+	hasher.Write(stringBytes(signingString))
 
 	// Sign the string and return r, s
 	if r, s, err := ecdsa.Sign(rand.Reader, ecdsaKey, hasher.Sum(nil)); err == nil {
@@ -146,3 +148,35 @@ func (m *SigningMethodECDSA) Sign(signingString string, key interface{}) (string
 		return "", err
 	}
 }
+
+// Implements DeterministicSigner.  Sign, with the nonce derived from the
+// key and the signing string as RFC 6979 describes, so the signature is
+// the same every time
+func (m *SigningMethodECDSA) SignDeterministic(signingString string, key interface{}) (string, error) {
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", ErrInvalidKeyType
+	}
+	if ecdsaKey.Curve.Params().BitSize != m.CurveBits {
+		return "", ErrInvalidKey
+	}
+	if !m.Hash.Available() {
+		return "", ErrHashUnavailable
+	}
+	hasher := m.Hash.New()
+	hasher.Write(stringBytes(signingString))
+
+	// A nil rand selects RFC 6979
+	der, err := ecdsaKey.Sign(nil, hasher.Sum(nil), m.Hash)
+	if err != nil {
+		return "", err
+	}
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return "", err
+	}
+	sig := make([]byte, 2*m.KeySize)
+	rs.R.FillBytes(sig[:m.KeySize])
+	rs.S.FillBytes(sig[m.KeySize:])
+	return EncodeSegment(sig), nil
+}
@@ -4,12 +4,11 @@ import (
 	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/pem"
-	"errors"
 )
 
 var (
-	ErrNotECPublicKey  = errors.New("Key is not a valid ECDSA public key")
-	ErrNotECPrivateKey = errors.New("Key is not a valid ECDSA private key")
+	ErrNotECPublicKey  = newError(CodeInvalidKey, "Key is not a valid ECDSA public key")
+	ErrNotECPrivateKey = newError(CodeInvalidKey, "Key is not a valid ECDSA private key")
 )
 
 // Parse PEM encoded Elliptic Curve Private Key Structure
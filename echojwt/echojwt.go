@@ -0,0 +1,66 @@
+//go:build echojwt
+
+// Package echojwt is Echo middleware that verifies bearer tokens with the
+// rules of request.Middleware.
+//
+// It depends on github.com/labstack/echo/v4, which the rest of jwt-go
+// does not, so it is only built with the echojwt tag:
+//
+//	go get github.com/labstack/echo/v4
+//	go build -tags echojwt
+//
+// Routes the skipper selects, such as health checks, are served without a
+// token:
+//
+//	e.Use(echojwt.New(&request.Middleware{Keyfunc: keyFunc}, func(c echo.Context) bool {
+//		return c.Path() == "/healthz"
+//	}))
+//
+// Handlers get the token with c.Get(echojwt.TokenKey), or with
+// jwt.FromContext on c.Request().Context().
+package echojwt
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Keys the middleware sets on the echo.Context
+const (
+	TokenKey  = "jwt.token"  // The verified *jwt.Token
+	ClaimsKey = "jwt.claims" // Its jwt.Claims
+)
+
+// Middleware that rejects requests without a valid token.  The Bearer
+// challenge of RFC 6750 is set on the response and an *echo.HTTPError
+// with its status code returned, for the application's error handler.
+// skipper may be nil.
+func New(m *request.Middleware, skipper middleware.Skipper) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper != nil && skipper(c) {
+				return next(c)
+			}
+
+			r := c.Request()
+			token, challenge := m.Verify(r)
+			if challenge != nil {
+				c.Response().Header().Set("WWW-Authenticate", challenge.String())
+				return echo.NewHTTPError(challenge.StatusCode())
+			}
+
+			c.Set(TokenKey, token)
+			c.Set(ClaimsKey, token.Claims)
+			c.SetRequest(r.WithContext(jwt.NewContext(r.Context(), token)))
+			return next(c)
+		}
+	}
+}
+
+// The token New verified for c
+func Token(c echo.Context) (*jwt.Token, bool) {
+	token, ok := c.Get(TokenKey).(*jwt.Token)
+	return token, ok
+}
@@ -0,0 +1,63 @@
+//go:build echojwt
+
+package echojwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"github.com/labstack/echo/v4"
+)
+
+func TestNew(t *testing.T) {
+	key := []byte("secret")
+	e := echo.New()
+	e.Use(New(&request.Middleware{
+		Keyfunc: func(*jwt.Token) (interface{}, error) { return key, nil },
+		Realm:   "example",
+	}, func(c echo.Context) bool {
+		return c.Request().URL.Path == "/healthz"
+	}))
+	e.GET("/", func(c echo.Context) error {
+		token, ok := Token(c)
+		if _, inContext := jwt.FromContext(c.Request().Context()); !ok || !inContext {
+			t.Errorf("Expected the token on the echo and request contexts")
+		}
+		return c.String(http.StatusOK, token.Claims.(jwt.MapClaims)["sub"].(string))
+	})
+	e.GET("/healthz", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	valid, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	var echoTestData = []struct {
+		name   string
+		path   string
+		auth   string
+		status int
+		body   string
+		header string
+	}{
+		{"valid", "/", "Bearer " + valid, http.StatusOK, "alice", ""},
+		{"no token", "/", "", http.StatusUnauthorized, "", `Bearer realm="example"`},
+		{"skipped", "/healthz", "", http.StatusOK, "ok", ""},
+	}
+
+	for _, data := range echoTestData {
+		r := httptest.NewRequest("GET", data.path, nil)
+		if data.auth != "" {
+			r.Header.Set("Authorization", data.auth)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+		if w.Code != data.status || w.Header().Get("WWW-Authenticate") != data.header {
+			t.Errorf("[%v] Unexpected response %v %q", data.name, w.Code, w.Header().Get("WWW-Authenticate"))
+		}
+		if data.body != "" && w.Body.String() != data.body {
+			t.Errorf("[%v] Unexpected body %q", data.name, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+)
+
+var (
+	ErrEd25519Verification = newError(CodeInvalidSignature, "ed25519: verification error")
+)
+
+// Implements the EdDSA signing method of RFC 8037 with Ed25519
+// Expects ed25519.PrivateKey for signing and ed25519.PublicKey for verification
+type SigningMethodEd25519 struct{}
+
+// Specific instance for EdDSA
+var (
+	SigningMethodEdDSA *SigningMethodEd25519
+)
+
+func init() {
+	SigningMethodEdDSA = &SigningMethodEd25519{}
+	RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *SigningMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+// Implements the Verify method from SigningMethod
+// For this verify method, key must be an ed25519.PublicKey
+func (m *SigningMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	sig, err := DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	return m.VerifyBytes(signingString, sig, key)
+}
+
+// Implements BytesVerifier.  Verify, with the signature already decoded
+func (m *SigningMethodEd25519) VerifyBytes(signingString string, sig []byte, key interface{}) error {
+	ed25519Key, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if len(ed25519Key) != ed25519.PublicKeySize {
+		return ErrInvalidKey
+	}
+
+	if !ed25519.Verify(ed25519Key, stringBytes(signingString), sig) {
+		return ErrEd25519Verification
+	}
+	return nil
+}
+
+// Implements the Sign method from SigningMethod
+// For this signing method, key must be an ed25519.PrivateKey
+func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	ed25519Key, ok := key.(crypto.Signer)
+	if !ok {
+		return "", ErrInvalidKeyType
+	}
+	if _, ok := ed25519Key.Public().(ed25519.PublicKey); !ok {
+		return "", ErrInvalidKeyType
+	}
+
+	// Ed25519 signs the message itself, so there is no hash
+	sig, err := ed25519Key.Sign(nil, stringBytes(signingString), crypto.Hash(0))
+	if err != nil {
+		return "", err
+	}
+	return EncodeSegment(sig), nil
+}
@@ -0,0 +1,37 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// RFC 8037 appendix A.4
+var ed25519Seed, _ = hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+
+const ed25519Token = "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc.hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg"
+
+func TestEd25519(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(ed25519Seed)
+	parts := strings.Split(ed25519Token, ".")
+
+	sig, err := jwt.SigningMethodEdDSA.Sign(parts[0]+"."+parts[1], key)
+	if err != nil || sig != parts[2] {
+		t.Errorf("Expected the RFC 8037 signature.  Got %v %v", sig, err)
+	}
+	if err := jwt.SigningMethodEdDSA.Verify(parts[0]+"."+parts[1], parts[2], key.Public()); err != nil {
+		t.Errorf("Error verifying: %v", err)
+	}
+	if err := jwt.SigningMethodEdDSA.Verify(parts[0]+".x"+parts[1], parts[2], key.Public()); err != jwt.ErrEd25519Verification {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrEd25519Verification, err)
+	}
+	if _, err := jwt.SigningMethodEdDSA.Sign(parts[0], []byte("secret")); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrInvalidKeyType, err)
+	}
+	if jwt.GetSigningMethod("EdDSA") != jwt.SigningMethodEdDSA {
+		t.Errorf("Expected EdDSA to be registered")
+	}
+}
@@ -0,0 +1,91 @@
+package jwt
+
+import "errors"
+
+// Stable, machine-readable identifier of an error, for use in logs,
+// metrics and client responses.  Unlike error messages, codes will not
+// change between releases.
+type ErrorCode string
+
+// Error codes for the errors returned by this package
+const (
+	CodeUnknown ErrorCode = "unknown_error"
+
+	// Token validation, one for each ValidationError flag
+	CodeTokenMalformed        ErrorCode = "token_malformed"
+	CodeTokenUnverifiable     ErrorCode = "token_unverifiable"
+	CodeInvalidSignature      ErrorCode = "invalid_signature"
+	CodeInvalidAudience       ErrorCode = "invalid_audience"
+	CodeTokenExpired          ErrorCode = "token_expired"
+	CodeTokenUsedBeforeIssued ErrorCode = "token_used_before_issued"
+	CodeInvalidIssuer         ErrorCode = "invalid_issuer"
+	CodeTokenNotValidYet      ErrorCode = "token_not_valid_yet"
+	CodeInvalidId             ErrorCode = "invalid_id"
+	CodeInvalidClaims         ErrorCode = "invalid_claims"
+	CodeInvalidHeader         ErrorCode = "invalid_header"
+	CodeTokenReplayed         ErrorCode = "token_replayed"
+	CodeTokenRevoked          ErrorCode = "token_revoked"
+
+	// Keys
+	CodeInvalidKey             ErrorCode = "invalid_key"
+	CodeInvalidKeyType         ErrorCode = "invalid_key_type"
+	CodeHashUnavailable        ErrorCode = "hash_unavailable"
+	CodeUnsupportedKeyType     ErrorCode = "unsupported_key_type"
+	CodeUnsupportedAlgorithm   ErrorCode = "unsupported_algorithm"
+	CodeDeprecatedAlgorithm    ErrorCode = "deprecated_algorithm"
+	CodeKeyUsageNotPermitted   ErrorCode = "key_usage_not_permitted"
+	CodeKeyNotFound            ErrorCode = "key_not_found"
+	CodeInvalidKid             ErrorCode = "invalid_kid"
+	CodeNoSigningKey           ErrorCode = "no_signing_key"
+	CodeKeyNotPinned           ErrorCode = "key_not_pinned"
+	CodeKeyfuncFailed          ErrorCode = "keyfunc_failed"
+	CodeCertificateExpired     ErrorCode = "certificate_expired"
+	CodeCertificateNotYetValid ErrorCode = "certificate_not_yet_valid"
+	CodeCertificateKeyMismatch ErrorCode = "certificate_key_mismatch"
+
+	// Introspection
+	CodeTokenInactive            ErrorCode = "token_inactive"
+	CodeIntrospectionUnavailable ErrorCode = "introspection_unavailable"
+)
+
+func (c ErrorCode) String() string {
+	return string(c)
+}
+
+// An error with a fixed message and code.  All of the package's error
+// variables are of this type.
+type codedError struct {
+	code ErrorCode
+	text string
+}
+
+func newError(code ErrorCode, text string) error {
+	return &codedError{code, text}
+}
+
+func (e *codedError) Error() string {
+	return e.text
+}
+
+func (e *codedError) Code() ErrorCode {
+	return e.code
+}
+
+// Returns the code of the first error in err's chain that has one, or
+// CodeUnknown if there is none.  For a ValidationError that is the code of
+// the validation failure, not of the error it wraps.  Returns "" for nil.
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	// Most errors have a code themselves, so look before errors.As, which
+	// allocates
+	if coded, ok := err.(interface{ Code() ErrorCode }); ok {
+		return coded.Code()
+	}
+	var coded interface{ Code() ErrorCode }
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+	return CodeUnknown
+}
@@ -0,0 +1,67 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+// JSON representation of a parse or validation error, for API error bodies.
+// Message is one of the package's fixed messages, never the text of a
+// wrapped error, so Keyfunc internals are not leaked to clients.
+type ErrorDetail struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Claim     string    `json:"claim,omitempty"`      // The failing claim, if any
+	ExpiredBy int64     `json:"expired_by,omitempty"` // Seconds since exp, for CodeTokenExpired
+	ValidIn   int64     `json:"valid_in,omitempty"`   // Seconds until nbf or iat
+}
+
+// Claim checked for each code
+var errorCodeClaims = map[ErrorCode]string{
+	CodeInvalidAudience:       "aud",
+	CodeTokenExpired:          "exp",
+	CodeTokenUsedBeforeIssued: "iat",
+	CodeInvalidIssuer:         "iss",
+	CodeTokenNotValidYet:      "nbf",
+	CodeInvalidId:             "jti",
+}
+
+// Describe err for a client.  Returns nil if err is nil.
+//
+//	w.WriteHeader(http.StatusUnauthorized)
+//	json.NewEncoder(w).Encode(jwt.DescribeError(err))
+func DescribeError(err error) *ErrorDetail {
+	if err == nil {
+		return nil
+	}
+
+	detail := &ErrorDetail{
+		Code:    CodeOf(err),
+		Message: "token is invalid",
+		Claim:   errorCodeClaims[CodeOf(err)],
+	}
+
+	var ve *ValidationError
+	var coded *codedError
+	if errors.As(err, &ve) {
+		if s := ve.sentinel(); s != nil {
+			detail.Message = s.Error()
+		}
+	} else if errors.As(err, &coded) {
+		detail.Message = coded.text
+	}
+
+	var expired *ExpiredError
+	var nbf *NotValidYetError
+	var iat *IssuedAtError
+	switch {
+	case detail.Code == CodeTokenExpired && errors.As(err, &expired):
+		detail.ExpiredBy = int64(expired.ExpiredBy / time.Second)
+	case detail.Code == CodeTokenNotValidYet && errors.As(err, &nbf):
+		detail.ValidIn = int64(nbf.ValidIn / time.Second)
+	case detail.Code == CodeTokenUsedBeforeIssued && errors.As(err, &iat):
+		detail.ValidIn = int64(iat.IssuedIn / time.Second)
+	}
+
+	return detail
+}
@@ -0,0 +1,63 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Describes a token rejected by a Parser, for failed-verification telemetry.
+// Alg, Kid and Issuer come from the unverified token and may be empty.
+type Rejection struct {
+	Err    error
+	Code   ErrorCode
+	Alg    string
+	Kid    string
+	Issuer string
+	Remote string // URL the Keyfunc failed to fetch keys from, if that is what failed
+}
+
+// Receives a Rejection for every token a Parser rejects
+type ErrorLogger interface {
+	LogRejection(*Rejection)
+}
+
+// Adapter to use an ordinary function as an ErrorLogger
+type ErrorLoggerFunc func(*Rejection)
+
+func (f ErrorLoggerFunc) LogRejection(r *Rejection) {
+	f(r)
+}
+
+func newRejection(err error) *Rejection {
+	r := &Rejection{Err: err, Code: CodeOf(err)}
+
+	var ve *ValidationError
+	if errors.As(err, &ve) && ve.Context != nil {
+		r.Alg, r.Kid, r.Issuer = ve.Context.Alg, ve.Context.Kid, ve.Context.Issuer
+	}
+	var fetchErr *FetchError
+	if errors.As(err, &fetchErr) {
+		r.Remote = fetchErr.URL
+	}
+	return r
+}
+
+// ErrorLogger writing each rejection to logger at warning level
+//
+//	parser := &jwt.Parser{ErrorLogger: jwt.NewSlogErrorLogger(slog.Default())}
+func NewSlogErrorLogger(logger *slog.Logger) ErrorLogger {
+	return ErrorLoggerFunc(func(r *Rejection) {
+		attrs := []slog.Attr{
+			slog.String("code", r.Code.String()),
+			slog.String("error", r.Err.Error()),
+			slog.String("alg", r.Alg),
+			slog.String("kid", r.Kid),
+			slog.String("iss", r.Issuer),
+		}
+		if r.Remote != "" {
+			attrs = append(attrs, slog.String("remote", r.Remote))
+		}
+		logger.LogAttrs(context.Background(), slog.LevelWarn, "jwt: token rejected", attrs...)
+	})
+}
@@ -0,0 +1,51 @@
+package jwt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestParser_errorLogger(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	var out bytes.Buffer
+	parser := &jwt.Parser{ErrorLogger: jwt.NewSlogErrorLogger(slog.New(slog.NewJSONHandler(&out, nil)))}
+	provider := jwt.NewJWKSProvider(server.URL + "/jwks.json")
+
+	if _, err := parser.Parse(makeJWKSToken(t, "key-1"), provider.Keyfunc); err == nil {
+		t.Fatal("Expected the JWKS fetch to fail")
+	}
+
+	var entry map[string]string
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Error decoding log entry %q: %v", out.String(), err)
+	}
+	expect := map[string]string{
+		"level":  "WARN",
+		"code":   "keyfunc_failed",
+		"alg":    "RS256",
+		"kid":    "key-1",
+		"remote": server.URL + "/jwks.json",
+	}
+	for k, v := range expect {
+		if entry[k] != v {
+			t.Errorf("Expected %v=%q in log entry.  Got %q", k, v, entry[k])
+		}
+	}
+
+	// Accepted tokens are not logged
+	var rejections int
+	parser.ErrorLogger = jwt.ErrorLoggerFunc(func(*jwt.Rejection) { rejections++ })
+	parser.Parse(makeJWKSToken(t, ""), defaultKeyFunc)
+	parser.Parse("not.a.token", defaultKeyFunc)
+	if rejections != 1 {
+		t.Errorf("Expected one rejection.  Got %v", rejections)
+	}
+}
@@ -2,13 +2,15 @@ package jwt
 
 import (
 	"errors"
+	"fmt"
+	"time"
 )
 
 // Error constants
 var (
-	ErrInvalidKey      = errors.New("key is invalid") // 无效的key
-	ErrInvalidKeyType  = errors.New("key is of invalid type") // key是无效的类型
-	ErrHashUnavailable = errors.New("the requested hash function is unavailable")
+	ErrInvalidKey      = newError(CodeInvalidKey, "key is invalid") // 无效的key
+	ErrInvalidKeyType  = newError(CodeInvalidKeyType, "key is of invalid type") // key是无效的类型
+	ErrHashUnavailable = newError(CodeHashUnavailable, "the requested hash function is unavailable")
 )
 
 // The errors that might occur when parsing and validating a token
@@ -28,6 +30,46 @@ const (
 	ValidationErrorClaimsInvalid // Generic claims validation error
 )
 
+// Sentinel errors for the ValidationError bitfield.  A ValidationError
+// matches, via errors.Is, every sentinel whose flag is set in Errors:
+//
+//	if errors.Is(err, jwt.ErrTokenExpired) { ... }
+//
+// A failed signature check matches ErrSignatureInvalid.
+var (
+	ErrTokenMalformed        = newError(CodeTokenMalformed, "token is malformed")
+	ErrTokenUnverifiable     = newError(CodeTokenUnverifiable, "token is unverifiable")
+	ErrTokenInvalidAudience  = newError(CodeInvalidAudience, "token has invalid audience")
+	ErrTokenExpired          = newError(CodeTokenExpired, "token is expired")
+	ErrTokenUsedBeforeIssued = newError(CodeTokenUsedBeforeIssued, "token used before issued")
+	ErrTokenInvalidIssuer    = newError(CodeInvalidIssuer, "token has invalid issuer")
+	ErrTokenNotValidYet      = newError(CodeTokenNotValidYet, "token is not valid yet")
+	ErrTokenInvalidId        = newError(CodeInvalidId, "token has invalid id")
+	ErrTokenInvalidClaims    = newError(CodeInvalidClaims, "token has invalid claims")
+)
+
+// Matched, in addition to ErrTokenUnverifiable, when the Keyfunc returned
+// an error, so a key source being down can be told apart from a forged
+// token.  The Keyfunc's error is still available from Inner.
+var ErrKeyfuncFailed = newError(CodeKeyfuncFailed, "unable to resolve the verification key")
+
+// Flag for each sentinel error
+var validationErrorSentinels = []struct {
+	flag uint32
+	err  error
+}{
+	{ValidationErrorMalformed, ErrTokenMalformed},
+	{ValidationErrorUnverifiable, ErrTokenUnverifiable},
+	{ValidationErrorSignatureInvalid, ErrSignatureInvalid},
+	{ValidationErrorAudience, ErrTokenInvalidAudience},
+	{ValidationErrorExpired, ErrTokenExpired},
+	{ValidationErrorIssuedAt, ErrTokenUsedBeforeIssued},
+	{ValidationErrorIssuer, ErrTokenInvalidIssuer},
+	{ValidationErrorNotValidYet, ErrTokenNotValidYet},
+	{ValidationErrorId, ErrTokenInvalidId},
+	{ValidationErrorClaimsInvalid, ErrTokenInvalidClaims},
+}
+
 // Helper for constructing a ValidationError with a string error message
 func NewValidationError(errorText string, errorFlags uint32) *ValidationError {
 	return &ValidationError{
@@ -41,6 +83,12 @@ type ValidationError struct {
 	Inner  error  // stores the error returned by external dependencies, i.e.: KeyFunc
 	Errors uint32 // bitfield.  see ValidationError... constants
 	text   string // errors that do not have a valid error just have text 没有错误仅仅包含文本信息
+
+	// Header and claim values of the token that failed, when the parser got
+	// far enough to decode them.  Nil otherwise.
+	Context *TokenContext
+
+	keyfunc bool // Inner was returned by the Keyfunc
 }
 
 // Validation error is an error type
@@ -54,7 +102,279 @@ func (e ValidationError) Error() string {
 	}
 }
 
+// Reports whether target is the sentinel error for one of the flags set in
+// Errors.  This is what makes errors.Is work on a ValidationError.
+func (e *ValidationError) Is(target error) bool {
+	if target == ErrKeyfuncFailed {
+		return e.keyfunc
+	}
+	for _, s := range validationErrorSentinels {
+		if target == s.err {
+			return e.Errors&s.flag != 0
+		}
+	}
+	return false
+}
+
+// Returns Inner, so errors.Is and errors.As see through the ValidationError
+// to the error returned by the Keyfunc, the signing method or the claims
+func (e *ValidationError) Unwrap() error {
+	return e.Inner
+}
+
+// Code of the first failure recorded in Errors, in the order of the
+// ValidationError flags, so a malformed token reports CodeTokenMalformed
+// and a bad signature takes precedence over claim failures.  Keyfunc
+// failures report CodeKeyfuncFailed.
+func (e *ValidationError) Code() ErrorCode {
+	if s := e.sentinel(); s != nil {
+		return CodeOf(s)
+	}
+	return CodeUnknown
+}
+
+// The sentinel error for the failure reported by Code
+func (e *ValidationError) sentinel() error {
+	if e.keyfunc {
+		return ErrKeyfuncFailed
+	}
+	for _, s := range validationErrorSentinels {
+		if e.Errors&s.flag != 0 {
+			return s.err
+		}
+	}
+	return nil
+}
+
+// Record a failure: set flags and add err to Inner.  When several checks
+// fail, Inner holds all of their errors, joined with errors.Join.
+func (e *ValidationError) add(err error, flags uint32) {
+	e.Errors |= flags
+	switch inner := e.Inner.(type) {
+	case nil:
+		e.Inner = err
+	case interface{ Unwrap() []error }:
+		e.Inner = errors.Join(append(inner.Unwrap(), err)...)
+	default:
+		e.Inner = errors.Join(inner, err)
+	}
+}
+
+// Copy of the error with Context describing token
+func (e *ValidationError) withContext(token *Token) *ValidationError {
+	ve := *e
+	ve.Context = newTokenContext(token)
+	return &ve
+}
+
+// Undo add: clear flags and take err out of Inner
+func (e *ValidationError) remove(err error, flags uint32) {
+	e.Errors &^= flags
+	switch inner := e.Inner.(type) {
+	case interface{ Unwrap() []error }:
+		var rest []error
+		for _, i := range inner.Unwrap() {
+			if i != err {
+				rest = append(rest, i)
+			}
+		}
+		if len(rest) == 1 {
+			e.Inner = rest[0]
+		} else {
+			e.Inner = errors.Join(rest...)
+		}
+	default:
+		if inner == err {
+			e.Inner = nil
+		}
+	}
+}
+
 // No errors 没有错误
 func (e *ValidationError) valid() bool {
 	return e.Errors == 0
 }
+
+// A copy of e on the heap.  Checks collect their failures in a
+// ValidationError on the stack and return this only when there are any,
+// so that passing checks allocate nothing.
+func (e *ValidationError) escape() *ValidationError {
+	ve := *e
+	return &ve
+}
+
+// Non-sensitive values from a token, for diagnosing failures from logs:
+//
+//	var ve *jwt.ValidationError
+//	if errors.As(err, &ve) && ve.Context != nil {
+//		log.Printf("rejected token (%v): %v", ve.Context, err)
+//	}
+//
+// None of these are verified, so treat them as attacker controlled.  Each
+// is truncated to maxContextLength bytes.
+type TokenContext struct {
+	Alg    string // The alg header
+	Kid    string // The kid header
+	Issuer string // The iss claim
+}
+
+// Keep attacker controlled values from flooding logs
+const maxContextLength = 128
+
+func newTokenContext(token *Token) *TokenContext {
+	c := &TokenContext{}
+	c.Alg, _ = token.Header["alg"].(string)
+	c.Kid, _ = token.Header["kid"].(string)
+	if claims, ok := token.Claims.(interface{ GetIssuer() string }); ok {
+		c.Issuer = claims.GetIssuer()
+	}
+	c.Alg, c.Kid, c.Issuer = truncate(c.Alg), truncate(c.Kid), truncate(c.Issuer)
+	return c
+}
+
+func truncate(s string) string {
+	if len(s) > maxContextLength {
+		return s[:maxContextLength]
+	}
+	return s
+}
+
+func (c *TokenContext) String() string {
+	return fmt.Sprintf("alg=%q kid=%q iss=%q", c.Alg, c.Kid, c.Issuer)
+}
+
+// Returned, wrapped in a ValidationError, when the exp claim is in the past
+type ExpiredError struct {
+	ExpiresAt time.Time
+	ExpiredBy time.Duration
+}
+
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("token is expired by %v", e.ExpiredBy)
+}
+
+func (e *ExpiredError) Is(target error) bool {
+	return target == ErrTokenExpired
+}
+
+func (e *ExpiredError) Code() ErrorCode {
+	return CodeTokenExpired
+}
+
+// Returned, wrapped in a ValidationError, when the nbf claim is in the future
+type NotValidYetError struct {
+	NotBefore time.Time
+	ValidIn   time.Duration
+}
+
+func (e *NotValidYetError) Error() string {
+	return fmt.Sprintf("token is not valid yet, valid in %v", e.ValidIn)
+}
+
+func (e *NotValidYetError) Is(target error) bool {
+	return target == ErrTokenNotValidYet
+}
+
+func (e *NotValidYetError) Code() ErrorCode {
+	return CodeTokenNotValidYet
+}
+
+// Returned, wrapped in a ValidationError, when the iat claim is in the future
+type IssuedAtError struct {
+	IssuedAt time.Time
+	IssuedIn time.Duration
+}
+
+func (e *IssuedAtError) Error() string {
+	return fmt.Sprintf("token used before issued, issued in %v", e.IssuedIn)
+}
+
+func (e *IssuedAtError) Is(target error) bool {
+	return target == ErrTokenUsedBeforeIssued
+}
+
+func (e *IssuedAtError) Code() ErrorCode {
+	return CodeTokenUsedBeforeIssued
+}
+
+// Returned, wrapped in a ValidationError, when the aud claim does not
+// contain the expected audience.  Got is empty if the claim is missing.
+// Error leaves Got out, so logging the error doesn't log the claim.
+type AudienceError struct {
+	Expected string
+	Got      []string
+}
+
+func (e *AudienceError) Error() string {
+	if len(e.Got) == 0 {
+		return fmt.Sprintf("token has no audience, expected %q", e.Expected)
+	}
+	return fmt.Sprintf("token has invalid audience, expected %q", e.Expected)
+}
+
+func (e *AudienceError) Is(target error) bool {
+	return target == ErrTokenInvalidAudience
+}
+
+func (e *AudienceError) Code() ErrorCode {
+	return CodeInvalidAudience
+}
+
+// Returned, wrapped in a ValidationError, when the iss claim does not
+// match the expected issuer.  Got is empty if the claim is missing.
+// Error leaves Got out, as AudienceError's does.
+type IssuerError struct {
+	Expected string
+	Got      string
+}
+
+func (e *IssuerError) Error() string {
+	if e.Got == "" {
+		return fmt.Sprintf("token has no issuer, expected %q", e.Expected)
+	}
+	return fmt.Sprintf("token has invalid issuer, expected %q", e.Expected)
+}
+
+func (e *IssuerError) Is(target error) bool {
+	return target == ErrTokenInvalidIssuer
+}
+
+func (e *IssuerError) Code() ErrorCode {
+	return CodeInvalidIssuer
+}
+
+// Returned, wrapped in a ValidationError, when the claims can't be decoded
+// into the Claims type.  The JSON error, which can quote the claims, is
+// left out of Error but still available through errors.As.
+type claimsDecodeError struct {
+	err error
+}
+
+func (e *claimsDecodeError) Error() string {
+	return "token claims could not be decoded"
+}
+
+func (e *claimsDecodeError) Unwrap() error {
+	return e.err
+}
+
+// Reports whether err is, or wraps, a failure for an expired token
+func IsExpired(err error) bool {
+	return errors.Is(err, ErrTokenExpired)
+}
+
+// Reports whether err is, or wraps, a failure for a token whose nbf is
+// still in the future
+func IsNotYetValid(err error) bool {
+	return errors.Is(err, ErrTokenNotValidYet)
+}
+
+// Reports whether err is, or wraps, a failed signature check
+func IsSignatureInvalid(err error) bool {
+	return errors.Is(err, ErrSignatureInvalid)
+}
+
+// Reports whether err is, or wraps, a failure to decode the token
+func IsMalformed(err error) bool {
+	return errors.Is(err, ErrTokenMalformed)
+}
@@ -0,0 +1,322 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+var errorsIsTestData = []struct {
+	name    string
+	claims  jwt.MapClaims
+	keyfunc jwt.Keyfunc
+	is      []error
+	isNot   []error
+}{
+	{
+		"expired",
+		jwt.MapClaims{"exp": float64(time.Now().Unix() - 100)},
+		defaultKeyFunc,
+		[]error{jwt.ErrTokenExpired},
+		[]error{jwt.ErrTokenNotValidYet, jwt.ErrSignatureInvalid, jwt.ErrTokenMalformed},
+	},
+	{
+		"expired and nbf",
+		jwt.MapClaims{"exp": float64(time.Now().Unix() - 100), "nbf": float64(time.Now().Unix() + 100)},
+		defaultKeyFunc,
+		[]error{jwt.ErrTokenExpired, jwt.ErrTokenNotValidYet},
+		[]error{jwt.ErrSignatureInvalid},
+	},
+	{
+		"bad key",
+		jwt.MapClaims{"foo": "bar"},
+		emptyKeyFunc,
+		[]error{jwt.ErrSignatureInvalid},
+		[]error{jwt.ErrTokenExpired, jwt.ErrKeyfuncFailed},
+	},
+	{
+		"keyfunc error",
+		jwt.MapClaims{"foo": "bar"},
+		errorKeyFunc,
+		[]error{jwt.ErrTokenUnverifiable, jwt.ErrKeyfuncFailed},
+		[]error{jwt.ErrSignatureInvalid},
+	},
+}
+
+func TestValidationError_Is(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+
+	for _, data := range errorsIsTestData {
+		_, err := jwt.Parse(test.MakeSampleToken(data.claims, privateKey), data.keyfunc)
+		for _, target := range data.is {
+			if !errors.Is(err, target) {
+				t.Errorf("[%v] Expected error to match %q", data.name, target)
+			}
+		}
+		for _, target := range data.isNot {
+			if errors.Is(err, target) {
+				t.Errorf("[%v] Expected error not to match %q", data.name, target)
+			}
+		}
+	}
+
+	if _, err := jwt.Parse("not.a.token", defaultKeyFunc); !errors.Is(err, jwt.ErrTokenMalformed) {
+		t.Errorf("Expected malformed token to match ErrTokenMalformed.  Got %v", err)
+	}
+}
+
+func TestValidationError_Unwrap(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, privateKey)
+
+	// A remote Keyfunc timing out
+	_, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) {
+		return nil, fmt.Errorf("fetching keys: %w", context.DeadlineExceeded)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error to match context.DeadlineExceeded.  Got %v", err)
+	}
+	if !errors.Is(err, jwt.ErrTokenUnverifiable) {
+		t.Errorf("Expected error to still match ErrTokenUnverifiable")
+	}
+
+	// Signing method errors are reachable as well
+	_, err = jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) {
+		return []byte("wrong key type"), nil
+	})
+	if !errors.Is(err, jwt.ErrInvalidKeyType) {
+		t.Errorf("Expected error to match ErrInvalidKeyType.  Got %v", err)
+	}
+
+	var ve *jwt.ValidationError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &ve) || ve.Inner != jwt.ErrInvalidKeyType {
+		t.Errorf("Expected errors.As to find the ValidationError")
+	}
+}
+
+func TestTypedClaimErrors(t *testing.T) {
+	now := time.Unix(10000, 0)
+
+	at(now, func() {
+		err := jwt.MapClaims{"exp": float64(9000)}.Valid()
+		var expired *jwt.ExpiredError
+		if !errors.As(err, &expired) || expired.ExpiredBy != 1000*time.Second {
+			t.Errorf("Expected ExpiredError with ExpiredBy 1000s.  Got %v", err)
+		}
+
+		err = (&jwt.StandardClaims{NotBefore: 10060}).Valid()
+		var nbf *jwt.NotValidYetError
+		if !errors.As(err, &nbf) || nbf.ValidIn != time.Minute || !nbf.NotBefore.Equal(time.Unix(10060, 0)) {
+			t.Errorf("Expected NotValidYetError valid in 1m.  Got %v", err)
+		}
+		if !errors.Is(err, jwt.ErrTokenNotValidYet) {
+			t.Errorf("Expected NotValidYetError to match ErrTokenNotValidYet")
+		}
+
+		err = jwt.MapClaims{"iat": float64(10005)}.Valid()
+		var iat *jwt.IssuedAtError
+		if !errors.As(err, &iat) || iat.IssuedIn != 5*time.Second {
+			t.Errorf("Expected IssuedAtError issued in 5s.  Got %v", err)
+		}
+	})
+
+	err := jwt.ValidateAudience(jwt.MapClaims{"aud": []interface{}{"a", "b"}}, "c")
+	var aud *jwt.AudienceError
+	if !errors.As(err, &aud) || aud.Expected != "c" || len(aud.Got) != 2 {
+		t.Errorf("Expected AudienceError.  Got %v", err)
+	}
+	if err = jwt.ValidateAudience(jwt.MapClaims{"aud": []interface{}{"a", "b"}}, "b"); err != nil {
+		t.Errorf("Error validating audience from array: %v", err)
+	}
+
+	type customClaims struct {
+		Foo string `json:"foo"`
+		jwt.StandardClaims
+	}
+	err = jwt.ValidateIssuer(&customClaims{StandardClaims: jwt.StandardClaims{Issuer: "mallory"}}, "alice")
+	var iss *jwt.IssuerError
+	if !errors.As(err, &iss) || iss.Got != "mallory" || iss.Expected != "alice" {
+		t.Errorf("Expected IssuerError.  Got %v", err)
+	}
+	if !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Errorf("Expected IssuerError to match ErrTokenInvalidIssuer")
+	}
+}
+
+func TestValidationError_joined(t *testing.T) {
+	at(time.Unix(10000, 0), func() {
+		err := jwt.MapClaims{"exp": float64(9000), "nbf": float64(11000), "iat": float64(10500)}.Valid()
+
+		ve := err.(*jwt.ValidationError)
+		if ve.Errors != jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet|jwt.ValidationErrorIssuedAt {
+			t.Errorf("Bitfield doesn't match expectation.  Got %v", ve.Errors)
+		}
+
+		joined, ok := ve.Inner.(interface{ Unwrap() []error })
+		if !ok || len(joined.Unwrap()) != 3 {
+			t.Fatalf("Expected Inner to join the three claim errors.  Got %v", ve.Inner)
+		}
+
+		var expired *jwt.ExpiredError
+		var nbf *jwt.NotValidYetError
+		var iat *jwt.IssuedAtError
+		if !errors.As(err, &expired) || !errors.As(err, &nbf) || !errors.As(err, &iat) {
+			t.Errorf("Expected every typed error to be reachable with errors.As")
+		}
+	})
+
+	// A single failure is not wrapped in a join
+	err := jwt.MapClaims{"exp": float64(time.Now().Unix() - 100)}.Valid()
+	if _, ok := err.(*jwt.ValidationError).Inner.(*jwt.ExpiredError); !ok {
+		t.Errorf("Expected a lone ExpiredError as Inner.  Got %T", err.(*jwt.ValidationError).Inner)
+	}
+
+	// Claim and signature failures are reported together by the parser
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	s := test.MakeSampleToken(jwt.MapClaims{"exp": float64(time.Now().Unix() - 100)}, privateKey)
+	_, err = jwt.Parse(s, emptyKeyFunc)
+	if !errors.Is(err, jwt.ErrTokenExpired) || !errors.Is(err, jwt.ErrInvalidKeyType) {
+		t.Errorf("Expected both expiry and signature errors.  Got %v", err)
+	}
+}
+
+var errorCodeTestData = []struct {
+	name string
+	err  error
+	code jwt.ErrorCode
+}{
+	{"nil", nil, ""},
+	{"sentinel", jwt.ErrTokenExpired, jwt.CodeTokenExpired},
+	{"signature", jwt.ErrSignatureInvalid, jwt.CodeInvalidSignature},
+	{"wrapped", fmt.Errorf("loading: %w", jwt.ErrKeyNotFound), jwt.CodeKeyNotFound},
+	{"typed", &jwt.ExpiredError{}, jwt.CodeTokenExpired},
+	{"joined claims", jwt.MapClaims{"exp": float64(1), "nbf": float64(1 << 40)}.Valid(), jwt.CodeTokenExpired},
+	{"malformed", jwt.NewValidationError("bad", jwt.ValidationErrorMalformed|jwt.ValidationErrorExpired), jwt.CodeTokenMalformed},
+	{"foreign", errors.New("boom"), jwt.CodeUnknown},
+}
+
+func TestCodeOf(t *testing.T) {
+	for _, data := range errorCodeTestData {
+		if code := jwt.CodeOf(data.err); code != data.code {
+			t.Errorf("[%v] Expected code %q.  Got %q", data.name, data.code, code)
+		}
+	}
+
+	// Keyfunc failures have their own code, whatever the Keyfunc returned
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	_, err := jwt.Parse(test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, privateKey), func(*jwt.Token) (interface{}, error) {
+		return nil, jwt.ErrKeyNotFound
+	})
+	if code := jwt.CodeOf(err); code.String() != "keyfunc_failed" {
+		t.Errorf("Expected keyfunc_failed.  Got %q", code)
+	}
+}
+
+func TestDescribeError(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+
+	at(time.Unix(10000, 0), func() {
+		_, err := jwt.Parse(test.MakeSampleToken(jwt.MapClaims{"exp": float64(9000)}, privateKey), defaultKeyFunc)
+		out, _ := json.Marshal(jwt.DescribeError(err))
+		if string(out) != `{"code":"token_expired","message":"token is expired","claim":"exp","expired_by":1000}` {
+			t.Errorf("Unexpected JSON for expired token: %s", out)
+		}
+	})
+
+	// The Keyfunc's error text is not exposed
+	_, err := jwt.Parse(test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, privateKey), func(*jwt.Token) (interface{}, error) {
+		return nil, errors.New("dial tcp 10.0.0.1:443: connection refused")
+	})
+	detail := jwt.DescribeError(err)
+	if detail.Code != jwt.CodeKeyfuncFailed || detail.Message != jwt.ErrKeyfuncFailed.Error() || detail.Claim != "" {
+		t.Errorf("Unexpected detail for keyfunc error: %+v", detail)
+	}
+
+	if detail := jwt.DescribeError(jwt.ErrKeyNotPinned); detail.Message != jwt.ErrKeyNotPinned.Error() {
+		t.Errorf("Expected the library message for ErrKeyNotPinned.  Got %+v", detail)
+	}
+	if jwt.DescribeError(nil) != nil {
+		t.Errorf("Expected nil detail for nil error")
+	}
+}
+
+func TestValidationError_context(t *testing.T) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{Issuer: "https://issuer.example.com"})
+	token.Header["kid"] = "rotated-away"
+	s, _ := token.SignedString([]byte("secret"))
+
+	_, err := jwt.Parse(s, func(*jwt.Token) (interface{}, error) {
+		return nil, jwt.ErrKeyNotFound
+	})
+	var ve *jwt.ValidationError
+	if !errors.As(fmt.Errorf("auth: %w", err), &ve) || ve.Context == nil {
+		t.Fatalf("Expected a ValidationError with context.  Got %v", err)
+	}
+	if *ve.Context != (jwt.TokenContext{Alg: "HS256", Kid: "rotated-away", Issuer: "https://issuer.example.com"}) {
+		t.Errorf("Unexpected context: %v", ve.Context)
+	}
+	if strings.Contains(ve.Context.String(), s) {
+		t.Errorf("Context must not include the token")
+	}
+
+	// Nothing to report if the header can't be decoded
+	_, err = jwt.Parse("not.a.token", defaultKeyFunc)
+	if err.(*jwt.ValidationError).Context != nil {
+		t.Errorf("Expected no context for a malformed token")
+	}
+}
+
+func TestErrorPredicates(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	now := time.Now().Unix()
+
+	_, expired := jwt.Parse(test.MakeSampleToken(jwt.MapClaims{"exp": float64(now - 100)}, privateKey), defaultKeyFunc)
+	_, nbf := jwt.Parse(test.MakeSampleToken(jwt.MapClaims{"nbf": float64(now + 100)}, privateKey), defaultKeyFunc)
+	_, badSig := jwt.Parse(test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, privateKey), emptyKeyFunc)
+	_, malformed := jwt.Parse("not.a.token", defaultKeyFunc)
+
+	predicates := []func(error) bool{jwt.IsExpired, jwt.IsNotYetValid, jwt.IsSignatureInvalid, jwt.IsMalformed}
+	for i, err := range []error{expired, nbf, badSig, malformed} {
+		for j, is := range predicates {
+			if is(fmt.Errorf("wrapped: %w", err)) != (i == j) {
+				t.Errorf("Predicate %v on error %v: expected %v", j, err, i == j)
+			}
+		}
+		if predicates[i](nil) {
+			t.Errorf("Predicate %v matched nil", i)
+		}
+	}
+}
+
+// Passing checks must not allocate a ValidationError
+func TestValid_allocations(t *testing.T) {
+	now := time.Now().Unix()
+	var tests = []struct {
+		name   string
+		claims jwt.Claims
+	}{
+		{"StandardClaims", jwt.StandardClaims{ExpiresAt: now + 60, IssuedAt: now, NotBefore: now}},
+		{"MapClaims", jwt.MapClaims{"exp": float64(now + 60), "iat": float64(now)}},
+		{"SecurityEventClaims", &jwt.SecurityEventClaims{Issuer: "iss", IssuedAt: now, Id: "jti", Events: jwt.SecurityEvents{"e": map[string]interface{}{}}}},
+	}
+	for _, data := range tests {
+		if allocs := testing.AllocsPerRun(100, func() { data.claims.Valid() }); allocs != 0 {
+			t.Errorf("[%v] Expected no allocations.  Got %v", data.name, allocs)
+		}
+	}
+
+	// Failures still come back as a *ValidationError of their own
+	expired := jwt.StandardClaims{ExpiresAt: now - 60}
+	first, second := expired.Valid(), expired.Valid()
+	var ve *jwt.ValidationError
+	if !errors.As(first, &ve) || ve.Errors != jwt.ValidationErrorExpired || first == second {
+		t.Errorf("Expected separate expiry errors.  Got %#v and %#v", first, second)
+	}
+}
@@ -0,0 +1,104 @@
+//go:build fasthttpjwt
+
+// Package fasthttpjwt verifies bearer tokens on fasthttp servers with the
+// rules of request.Middleware, reading requests through fasthttp rather
+// than converting them to net/http.
+//
+// It depends on github.com/valyala/fasthttp, which the rest of jwt-go
+// does not, so it is only built with the fasthttpjwt tag:
+//
+//	go get github.com/valyala/fasthttp
+//	go build -tags fasthttpjwt
+//
+// The Middleware's own Extractor works on net/http requests and is not
+// used; pass one of this package's instead:
+//
+//	m := &request.Middleware{Keyfunc: keyFunc, Realm: "api"}
+//	fasthttp.ListenAndServe(":8080", fasthttpjwt.Handler(m, nil, handler))
+//
+// Handlers get the token with Token(ctx).
+package fasthttpjwt
+
+import (
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"github.com/valyala/fasthttp"
+)
+
+// The user value key the token is stored under
+const TokenKey = "jwt.token"
+
+// Finds a token in a request, returning "" if there is none
+type Extractor func(ctx *fasthttp.RequestCtx) string
+
+// Tokens in the Authorization header, with or without the Bearer prefix,
+// like request.AuthorizationHeaderExtractor
+func AuthorizationHeaderExtractor(ctx *fasthttp.RequestCtx) string {
+	tok := string(ctx.Request.Header.Peek("Authorization"))
+	if len(tok) > 6 && strings.EqualFold(tok[:7], "bearer ") {
+		return tok[7:]
+	}
+	return tok
+}
+
+// Tokens in the query parameter name
+func QueryExtractor(name string) Extractor {
+	return func(ctx *fasthttp.RequestCtx) string {
+		return string(ctx.QueryArgs().Peek(name))
+	}
+}
+
+// Tokens in the cookie name
+func CookieExtractor(name string) Extractor {
+	return func(ctx *fasthttp.RequestCtx) string {
+		return string(ctx.Request.Header.Cookie(name))
+	}
+}
+
+// Tries extractors in order until one finds a token
+func MultiExtractor(extractors ...Extractor) Extractor {
+	return func(ctx *fasthttp.RequestCtx) string {
+		for _, e := range extractors {
+			if tok := e(ctx); tok != "" {
+				return tok
+			}
+		}
+		return ""
+	}
+}
+
+// Extract and check the token of ctx, as request.Middleware.Verify does.
+// extractor defaults to AuthorizationHeaderExtractor.
+func Verify(ctx *fasthttp.RequestCtx, m *request.Middleware, extractor Extractor) (*jwt.Token, *request.BearerChallenge) {
+	if extractor == nil {
+		extractor = AuthorizationHeaderExtractor
+	}
+	tokenString := extractor(ctx)
+	if tokenString == "" {
+		return nil, request.NewBearerChallenge(m.Realm, request.ErrNoTokenInRequest)
+	}
+	return m.VerifyToken(tokenString)
+}
+
+// Wrap next so that it only sees requests with a valid token.  Others are
+// answered with the Bearer challenge and status code of RFC 6750.
+func Handler(m *request.Middleware, extractor Extractor, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		token, challenge := Verify(ctx, m, extractor)
+		if challenge != nil {
+			ctx.Response.Header.Set("WWW-Authenticate", challenge.String())
+			ctx.SetStatusCode(challenge.StatusCode())
+			return
+		}
+		ctx.SetUserValue(TokenKey, token)
+		next(ctx)
+	}
+}
+
+// The token Handler verified for ctx
+func Token(ctx *fasthttp.RequestCtx) (*jwt.Token, bool) {
+	token, ok := ctx.UserValue(TokenKey).(*jwt.Token)
+	return token, ok
+}
@@ -0,0 +1,62 @@
+//go:build fasthttpjwt
+
+package fasthttpjwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"github.com/valyala/fasthttp"
+)
+
+func TestHandler(t *testing.T) {
+	key := []byte("secret")
+	m := &request.Middleware{
+		Keyfunc: func(*jwt.Token) (interface{}, error) { return key, nil },
+		Realm:   "example",
+	}
+	handler := Handler(m, MultiExtractor(AuthorizationHeaderExtractor, CookieExtractor("session")), func(ctx *fasthttp.RequestCtx) {
+		token, ok := Token(ctx)
+		if !ok {
+			t.Errorf("Expected the token on the context")
+		}
+		ctx.SetBodyString(token.Claims.(jwt.MapClaims)["sub"].(string))
+	})
+	sign := func(claims jwt.MapClaims) string {
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		return s
+	}
+
+	var fasthttpTestData = []struct {
+		name   string
+		header string
+		cookie string
+		status int
+		auth   string
+	}{
+		{"header", "Bearer " + sign(jwt.MapClaims{"sub": "alice"}), "", fasthttp.StatusOK, ""},
+		{"cookie", "", sign(jwt.MapClaims{"sub": "alice"}), fasthttp.StatusOK, ""},
+		{"no token", "", "", fasthttp.StatusUnauthorized, `Bearer realm="example"`},
+		{"expired", "Bearer " + sign(jwt.MapClaims{"sub": "alice", "exp": time.Now().Unix() - 100}), "", fasthttp.StatusUnauthorized, `Bearer realm="example", error="invalid_token", error_description="token is expired"`},
+	}
+
+	for _, data := range fasthttpTestData {
+		ctx := new(fasthttp.RequestCtx)
+		if data.header != "" {
+			ctx.Request.Header.Set("Authorization", data.header)
+		}
+		if data.cookie != "" {
+			ctx.Request.Header.SetCookie("session", data.cookie)
+		}
+		handler(ctx)
+
+		if ctx.Response.StatusCode() != data.status || string(ctx.Response.Header.Peek("WWW-Authenticate")) != data.auth {
+			t.Errorf("[%v] Unexpected response %v %q", data.name, ctx.Response.StatusCode(), ctx.Response.Header.Peek("WWW-Authenticate"))
+		}
+		if data.status == fasthttp.StatusOK && string(ctx.Response.Body()) != "alice" {
+			t.Errorf("[%v] Unexpected body %q", data.name, ctx.Response.Body())
+		}
+	}
+}
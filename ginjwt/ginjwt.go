@@ -0,0 +1,63 @@
+//go:build ginjwt
+
+// Package ginjwt is gin middleware that verifies bearer tokens with the
+// rules of request.Middleware.
+//
+// It depends on github.com/gin-gonic/gin, which the rest of jwt-go does
+// not, so it is only built with the ginjwt tag:
+//
+//	go get github.com/gin-gonic/gin
+//	go build -tags ginjwt
+//
+// Configure it as you would the net/http middleware, e.g. with the keys
+// of a JWKS endpoint and a fixed set of methods:
+//
+//	jwks := jwt.NewJWKSProvider("https://idp.example.com/.well-known/jwks.json")
+//	router.Use(ginjwt.New(&request.Middleware{
+//		Keyfunc: jwks.Keyfunc,
+//		Parser:  &jwt.Parser{ValidMethods: []string{"RS256"}},
+//	}))
+//
+// Handlers get the token with c.Get(ginjwt.TokenKey), or with
+// jwt.FromContext on c.Request.Context().
+package ginjwt
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"github.com/gin-gonic/gin"
+)
+
+// Keys the middleware sets on the gin.Context
+const (
+	TokenKey  = "jwt.token"  // The verified *jwt.Token
+	ClaimsKey = "jwt.claims" // Its jwt.Claims
+)
+
+// Middleware that aborts requests without a valid token, answering with
+// the Bearer challenge and status code of RFC 6750
+func New(m *request.Middleware) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, challenge := m.Verify(c.Request)
+		if challenge != nil {
+			c.Header("WWW-Authenticate", challenge.String())
+			c.AbortWithStatus(challenge.StatusCode())
+			return
+		}
+
+		c.Set(TokenKey, token)
+		c.Set(ClaimsKey, token.Claims)
+		c.Request = c.Request.WithContext(jwt.NewContext(c.Request.Context(), token))
+		c.Next()
+	}
+}
+
+// The token New verified for c
+func Token(c *gin.Context) (*jwt.Token, bool) {
+	v, ok := c.Get(TokenKey)
+	if !ok {
+		return nil, false
+	}
+	token, ok := v.(*jwt.Token)
+	return token, ok
+}
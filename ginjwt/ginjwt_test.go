@@ -0,0 +1,58 @@
+//go:build ginjwt
+
+package ginjwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNew(t *testing.T) {
+	key := []byte("secret")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(New(&request.Middleware{
+		Keyfunc: func(*jwt.Token) (interface{}, error) { return key, nil },
+		Parser:  &jwt.Parser{ValidMethods: []string{"HS256"}},
+		Realm:   "example",
+	}))
+	router.GET("/", func(c *gin.Context) {
+		token, ok := Token(c)
+		if _, inContext := jwt.FromContext(c.Request.Context()); !ok || !inContext {
+			t.Errorf("Expected the token on the gin and request contexts")
+		}
+		c.String(http.StatusOK, token.Claims.(jwt.MapClaims)["sub"].(string))
+	})
+
+	valid, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	var ginTestData = []struct {
+		name   string
+		auth   string
+		status int
+		header string
+	}{
+		{"valid", "Bearer " + valid, http.StatusOK, ""},
+		{"no token", "", http.StatusUnauthorized, `Bearer realm="example"`},
+		{"malformed", "Bearer not-a-token", http.StatusUnauthorized, `Bearer realm="example", error="invalid_token", error_description="token is malformed"`},
+	}
+
+	for _, data := range ginTestData {
+		r := httptest.NewRequest("GET", "/", nil)
+		if data.auth != "" {
+			r.Header.Set("Authorization", data.auth)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != data.status || w.Header().Get("WWW-Authenticate") != data.header {
+			t.Errorf("[%v] Unexpected response %v %q", data.name, w.Code, w.Header().Get("WWW-Authenticate"))
+		}
+		if data.status == http.StatusOK && w.Body.String() != "alice" {
+			t.Errorf("[%v] Unexpected body %q", data.name, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,126 @@
+//go:build grpcjwt
+
+// Package grpcjwt has gRPC server interceptors that verify bearer tokens
+// with the rules of request.Middleware.
+//
+// It depends on google.golang.org/grpc, which the rest of jwt-go does
+// not, so it is only built with the grpcjwt tag:
+//
+//	go get google.golang.org/grpc
+//	go build -tags grpcjwt
+//
+// The token is read from the authorization metadata, with or without the
+// Bearer prefix; the Middleware's Extractor and Realm are not used.
+//
+//	m := &request.Middleware{Keyfunc: keyFunc}
+//	server := grpc.NewServer(
+//		grpc.UnaryInterceptor(grpcjwt.UnaryServerInterceptor(m)),
+//		grpc.StreamInterceptor(grpcjwt.StreamServerInterceptor(m)),
+//	)
+//
+// Handlers get the token with jwt.FromContext.  Failures are reported as
+// codes.Unauthenticated, or codes.PermissionDenied when Authorize refuses,
+// with an errdetails.ErrorInfo whose Reason is the jwt.ErrorCode in upper
+// case, e.g. TOKEN_EXPIRED.
+package grpcjwt
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The Domain of the ErrorInfo details
+const ErrorDomain = "github.com/dgrijalva/jwt-go"
+
+// ErrorInfo Reasons that are not jwt error codes
+const (
+	ReasonTokenMissing      = "TOKEN_MISSING"
+	ReasonInsufficientScope = "INSUFFICIENT_SCOPE"
+)
+
+// Interceptor for unary RPCs that rejects calls without a valid token
+func UnaryServerInterceptor(m *request.Middleware) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := verify(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Interceptor for streaming RPCs that rejects calls without a valid token
+func StreamServerInterceptor(m *request.Middleware) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := verify(ss.Context(), m)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ss, ctx})
+	}
+}
+
+// A ServerStream whose context carries the token
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// Check the token in ctx's metadata, returning ctx with the token added or
+// the status error to fail the call with
+func verify(ctx context.Context, m *request.Middleware) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var tokenString string
+	for _, v := range md.Get("authorization") {
+		if v != "" {
+			tokenString = v
+			break
+		}
+	}
+	if len(tokenString) > 6 && strings.EqualFold(tokenString[:7], "bearer ") {
+		tokenString = tokenString[7:]
+	}
+	if tokenString == "" {
+		return nil, statusError(codes.Unauthenticated, request.ErrNoTokenInRequest.Error(), ReasonTokenMissing, nil)
+	}
+
+	token, challenge := m.VerifyToken(tokenString)
+	if challenge == nil {
+		return jwt.NewContext(ctx, token), nil
+	}
+	if challenge.Error == request.BearerErrorInsufficientScope {
+		meta := map[string]string{}
+		if challenge.Scope != "" {
+			meta["scope"] = challenge.Scope
+		}
+		return nil, statusError(codes.PermissionDenied, challenge.ErrorDescription, ReasonInsufficientScope, meta)
+	}
+
+	detail := jwt.DescribeError(challenge.Err)
+	meta := map[string]string{}
+	if detail.Claim != "" {
+		meta["claim"] = detail.Claim
+	}
+	return nil, statusError(codes.Unauthenticated, detail.Message, strings.ToUpper(string(detail.Code)), meta)
+}
+
+func statusError(code codes.Code, message, reason string, meta map[string]string) error {
+	st := status.New(code, message)
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain, Metadata: meta}
+	if withDetails, err := st.WithDetails(info); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,83 @@
+//go:build grpcjwt
+
+package grpcjwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	key := []byte("secret")
+	interceptor := UnaryServerInterceptor(&request.Middleware{
+		Keyfunc: func(*jwt.Token) (interface{}, error) { return key, nil },
+		Authorize: func(token *jwt.Token) error {
+			if token.Claims.(jwt.MapClaims)["sub"] != "alice" {
+				return errors.New("only alice")
+			}
+			return nil
+		},
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		token, ok := jwt.FromContext(ctx)
+		if !ok {
+			t.Errorf("Expected the token in the context")
+		}
+		return token.Claims.(jwt.MapClaims)["sub"], nil
+	}
+	sign := func(claims jwt.MapClaims) string {
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		return s
+	}
+
+	var grpcTestData = []struct {
+		name   string
+		auth   string
+		code   codes.Code
+		reason string
+	}{
+		{"valid", "Bearer " + sign(jwt.MapClaims{"sub": "alice"}), codes.OK, ""},
+		{"without prefix", sign(jwt.MapClaims{"sub": "alice"}), codes.OK, ""},
+		{"no token", "", codes.Unauthenticated, ReasonTokenMissing},
+		{"expired", "Bearer " + sign(jwt.MapClaims{"sub": "alice", "exp": time.Now().Unix() - 100}), codes.Unauthenticated, "TOKEN_EXPIRED"},
+		{"refused", "Bearer " + sign(jwt.MapClaims{"sub": "bob"}), codes.PermissionDenied, ReasonInsufficientScope},
+	}
+
+	for _, data := range grpcTestData {
+		ctx := context.Background()
+		if data.auth != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", data.auth))
+		}
+		resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test/Call"}, handler)
+
+		st := status.Convert(err)
+		if st.Code() != data.code {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.code, err)
+			continue
+		}
+		if data.code == codes.OK {
+			if resp != "alice" {
+				t.Errorf("[%v] Unexpected response %v", data.name, resp)
+			}
+			continue
+		}
+		details := st.Details()
+		if len(details) != 1 {
+			t.Errorf("[%v] Unexpected details %v", data.name, details)
+			continue
+		}
+		if info, ok := details[0].(*errdetails.ErrorInfo); !ok || info.Reason != data.reason || info.Domain != ErrorDomain {
+			t.Errorf("[%v] Unexpected details %v", data.name, details[0])
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/dgrijalva/jwt-go/jsoncodec"
+)
+
+// Typed view of a token header.  The registered parameters of RFC 7515
+// section 4.1 have fields; anything else, such as b64, is kept in Extra.
+// Token.Header remains the map that is signed and parsed; use
+// Token.TypedHeader and Token.SetTypedHeader to convert.
+type Header struct {
+	Typ  string      `json:"typ,omitempty"`
+	Alg  string      `json:"alg,omitempty"`
+	Kid  string      `json:"kid,omitempty"`
+	Cty  string      `json:"cty,omitempty"`
+	Crit []string    `json:"crit,omitempty"`
+	X5c  []string    `json:"x5c,omitempty"`
+	X5t  string      `json:"x5t,omitempty"`
+	JKU  string      `json:"jku,omitempty"`
+	JWK  *JSONWebKey `json:"jwk,omitempty"`
+
+	Extra map[string]interface{} `json:"-"` // Other parameters, by name
+}
+
+// Header parameters with a field in Header
+var typedHeaders = []string{"typ", "alg", "kid", "cty", "crit", "x5c", "x5t", "jku", "jwk"}
+
+// The fields of Header, without its methods
+type headerFields Header
+
+// Build a Header from a header map, such as Token.Header.  A registered
+// parameter of the wrong type is malformed.
+func NewHeader(header map[string]interface{}) (*Header, error) {
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	h := new(Header)
+	if err = json.Unmarshal(data, h); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	return h, nil
+}
+
+// The header as a map, in the form Token.Header holds it
+func (h *Header) Map() map[string]interface{} {
+	m := map[string]interface{}{}
+	for k, v := range h.Extra {
+		m[k] = v
+	}
+	set := func(name string, v interface{}, empty bool) {
+		if !empty {
+			m[name] = v
+		}
+	}
+	set("typ", h.Typ, h.Typ == "")
+	set("alg", h.Alg, h.Alg == "")
+	set("kid", h.Kid, h.Kid == "")
+	set("cty", h.Cty, h.Cty == "")
+	set("crit", h.Crit, len(h.Crit) == 0)
+	set("x5c", h.X5c, len(h.X5c) == 0)
+	set("x5t", h.X5t, h.X5t == "")
+	set("jku", h.JKU, h.JKU == "")
+	set("jwk", h.JWK, h.JWK == nil)
+	return m
+}
+
+func (h *Header) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Map())
+}
+
+func (h *Header) UnmarshalJSON(data []byte) error {
+	var fields headerFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var extra map[string]interface{}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	for _, name := range typedHeaders {
+		delete(extra, name)
+	}
+	if len(extra) == 0 {
+		extra = nil
+	}
+
+	*h = Header(fields)
+	h.Extra = extra
+	return nil
+}
+
+// The token's header as a Header
+func (t *Token) TypedHeader() (*Header, error) {
+	return NewHeader(t.Header)
+}
+
+// Replace the token's header with h.  alg is taken from the signing
+// method when h does not set it.
+func (t *Token) SetTypedHeader(h *Header) *Token {
+	t.Header = h.Map()
+	if _, ok := t.Header["alg"]; !ok && t.Method != nil {
+		t.Header["alg"] = t.Method.Alg()
+	}
+	return t
+}
+
+// Header names and values common enough to keep as constants, so that
+// decoding a header needn't allocate them
+var (
+	internedHeaderNames = map[string]string{}
+
+	internedHeaderValues = map[string]interface{}{}
+)
+
+func init() {
+	for _, name := range []string{"alg", "typ", "kid", "cty", "jku", "x5t", "x5u", "x5t#S256"} {
+		internedHeaderNames[name] = name
+	}
+	for _, v := range []string{
+		"JWT", "JOSE", "none", "EdDSA",
+		"HS256", "HS384", "HS512", "RS256", "RS384", "RS512",
+		"PS256", "PS384", "PS512", "ES256", "ES384", "ES512",
+	} {
+		internedHeaderValues[v] = v
+	}
+}
+
+// Decode a token header.  Headers of only string parameters, which is
+// nearly all of them, are read without reflection and with registered
+// names and algs interned; anything else is left to encoding/json, as are
+// the errors of malformed headers, so that they read as they always have.
+func decodeHeader(data []byte) (map[string]interface{}, error) {
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		header := make(map[string]interface{}, 4)
+		r := jsoncodec.NewReader(data)
+		err := r.Object(func(key []byte) error {
+			v, err := r.StringBytes()
+			if err != nil {
+				return err
+			}
+			name, ok := internedHeaderNames[string(key)]
+			if !ok {
+				name = string(key)
+			}
+			value, ok := internedHeaderValues[string(v)]
+			if !ok {
+				value = string(v)
+			}
+			header[name] = value
+			return nil
+		})
+		if err == nil {
+			return header, nil
+		}
+	}
+
+	var header map[string]interface{}
+	err := json.Unmarshal(data, &header)
+	return header, err
+}
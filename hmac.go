@@ -3,11 +3,13 @@ package jwt
 import (
 	"crypto"
 	"crypto/hmac"
-	"errors"
+	"hash"
+	"slices"
+	"sync"
 )
 
 // Implements the HMAC-SHA family of signing methods signing methods
-// Expects key type of []byte for both signing and validation
+// Expects key type of []byte, or *HMACKey, for both signing and validation
 type SigningMethodHMAC struct {
 	Name string // 签名的方法名
 	Hash crypto.Hash // 签名方法
@@ -19,7 +21,7 @@ var (
 	SigningMethodHS256  *SigningMethodHMAC
 	SigningMethodHS384  *SigningMethodHMAC
 	SigningMethodHS512  *SigningMethodHMAC
-	ErrSignatureInvalid = errors.New("signature is invalid") // 无效的签名方法
+	ErrSignatureInvalid = newError(CodeInvalidSignature, "signature is invalid") // 无效的签名方法
 )
 
 func init() {
@@ -51,8 +53,7 @@ func (m *SigningMethodHMAC) Alg() string {
 // 验证某个HS令牌的签名。如果签名有效返回nil
 func (m *SigningMethodHMAC) Verify(signingString, signature string, key interface{}) error {
 	// Verify the key is the right type
-	keyBytes, ok := key.([]byte)
-	if !ok {
+	if !isHMACKey(key) {
 		return ErrInvalidKeyType
 	}
 
@@ -61,6 +62,14 @@ func (m *SigningMethodHMAC) Verify(signingString, signature string, key interfac
 	if err != nil {
 		return err
 	}
+	return m.VerifyBytes(signingString, sig, key)
+}
+
+// Implements BytesVerifier.  Verify, with the signature already decoded
+func (m *SigningMethodHMAC) VerifyBytes(signingString string, sig []byte, key interface{}) error {
+	if !isHMACKey(key) {
+		return ErrInvalidKeyType
+	}
 
 	// Can we use the specified hashing method?
 	if !m.Hash.Available() {
@@ -70,9 +79,14 @@ func (m *SigningMethodHMAC) Verify(signingString, signature string, key interfac
 	// This signing method is symmetric, so we validate the signature
 	// by reproducing the signature from the signing string and key, then
 	// comparing that against the provided signature.
-	hasher := hmac.New(m.Hash.New, keyBytes)
-	hasher.Write([]byte(signingString))
-	if !hmac.Equal(sig, hasher.Sum(nil)) {
+	st, pool, err := getHMAC(m.Hash, key)
+	if err != nil {
+		return err
+	}
+	st.writeString(signingString)
+	ok := hmac.Equal(sig, st.Sum(st.sum[:0]))
+	putHMAC(pool, st)
+	if !ok {
 		return ErrSignatureInvalid
 	}
 
@@ -81,18 +95,148 @@ func (m *SigningMethodHMAC) Verify(signingString, signature string, key interfac
 }
 
 // Implements the Sign method from SigningMethod for this signing method.
-// Key must be []byte
+// Key must be []byte or *HMACKey
 func (m *SigningMethodHMAC) Sign(signingString string, key interface{}) (string, error) {
-	if keyBytes, ok := key.([]byte); ok {
+	if isHMACKey(key) {
 		if !m.Hash.Available() {
 			return "", ErrHashUnavailable
 		}
 
-		hasher := hmac.New(m.Hash.New, keyBytes)
-		hasher.Write([]byte(signingString))
+		st, pool, err := getHMAC(m.Hash, key)
+		if err != nil {
+			return "", err
+		}
+		st.writeString(signingString)
+		sig := EncodeSegment(st.Sum(st.sum[:0]))
+		putHMAC(pool, st)
 
-		return EncodeSegment(hasher.Sum(nil)), nil
+		return sig, nil
 	}
 
 	return "", ErrInvalidKeyType
 }
+
+// Sign, appending "." and the signature to dst, for AppendSignedString
+func (m *SigningMethodHMAC) appendSign(dst, signingString []byte, key interface{}) ([]byte, error) {
+	if !isHMACKey(key) {
+		return dst, ErrInvalidKeyType
+	}
+	if !m.Hash.Available() {
+		return dst, ErrHashUnavailable
+	}
+
+	// The sum goes in spare capacity past the encoded signature, which
+	// appendSignedString reserves, so that it needn't be allocated
+	n := m.Hash.Size()
+	end := len(dst) + 1 + SegmentEncoding.EncodedLen(n)
+	dst = slices.Grow(dst, end+n-len(dst))
+	st, pool, err := getHMAC(m.Hash, key)
+	if err != nil {
+		return dst, err
+	}
+	st.Write(signingString)
+	sum := st.Sum(dst[end:end])
+	putHMAC(pool, st)
+
+	dst = append(dst, '.')
+	return appendEncodeSegment(dst, sum), nil
+}
+
+// An HMAC secret that keeps keyed hashers for reuse.  Signing and
+// verifying with an *HMACKey rather than its []byte skips keying a new
+// hasher for each token, and makes checking one allocate nothing.  The
+// hashers last as long as the HMACKey, so drop it when the secret is
+// rotated out.  It is safe for concurrent use.
+type HMACKey struct {
+	secret []byte
+
+	mu    sync.RWMutex
+	pools map[crypto.Hash]*sync.Pool
+}
+
+// An HMACKey for secret, which is copied
+func NewHMACKey(secret []byte) *HMACKey {
+	return &HMACKey{secret: slices.Clone(secret)}
+}
+
+// The pool of hashers keyed with k for h.  The pools are made on first
+// use, so that a zero HMACKey works, as one for an empty secret.
+func (k *HMACKey) pool(h crypto.Hash) *sync.Pool {
+	k.mu.RLock()
+	pool := k.pools[h]
+	k.mu.RUnlock()
+	if pool != nil {
+		return pool
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if pool = k.pools[h]; pool == nil {
+		if k.pools == nil {
+			k.pools = map[crypto.Hash]*sync.Pool{}
+		}
+		pool = new(sync.Pool)
+		k.pools[h] = pool
+	}
+	return pool
+}
+
+// The secret of an HMAC key: a []byte, or an *HMACKey
+func hmacSecret(key interface{}) ([]byte, bool) {
+	switch k := key.(type) {
+	case []byte:
+		return k, true
+	case *HMACKey:
+		return k.secret, true
+	}
+	return nil, false
+}
+
+func isHMACKey(key interface{}) bool {
+	_, ok := hmacSecret(key)
+	return ok
+}
+
+// A keyed HMAC, with room for its sum and for the signing string on its way
+// in, so that checking one allocates nothing
+type hmacState struct {
+	hash.Hash
+	sum [64]byte
+	buf [512]byte
+}
+
+// Write s, a buffer at a time unless it can be had as bytes without a copy
+func (st *hmacState) writeString(s string) {
+	if zeroCopy {
+		st.Write(stringBytes(s))
+		return
+	}
+	for len(s) > 0 {
+		n := copy(st.buf[:], s)
+		st.Write(st.buf[:n])
+		s = s[n:]
+	}
+}
+
+// A hasher keyed with key for h, from the key's pool if it is an
+// *HMACKey, and that pool, for putHMAC.  h must be available.
+func getHMAC(h crypto.Hash, key interface{}) (*hmacState, *sync.Pool, error) {
+	switch k := key.(type) {
+	case []byte:
+		return &hmacState{Hash: hmac.New(h.New, k)}, nil, nil
+	case *HMACKey:
+		pool := k.pool(h)
+		if st, ok := pool.Get().(*hmacState); ok {
+			return st, pool, nil
+		}
+		return &hmacState{Hash: hmac.New(h.New, k.secret)}, pool, nil
+	}
+	return nil, nil, ErrInvalidKeyType
+}
+
+// Return st, from getHMAC, to pool if it came with one
+func putHMAC(pool *sync.Pool, st *hmacState) {
+	if pool != nil {
+		st.Reset()
+		pool.Put(st)
+	}
+}
@@ -1,6 +1,8 @@
 package jwt_test
 
 import (
+	"crypto/hmac"
+	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"io/ioutil"
 	"strings"
@@ -89,3 +91,73 @@ func BenchmarkHS384Signing(b *testing.B) {
 func BenchmarkHS512Signing(b *testing.B) {
 	benchmarkSigning(b, jwt.SigningMethodHS512, hmacTestKey)
 }
+
+// HMACKeys pool their hashers, so signatures must never mix keys or hashes
+// up, and must match those of the raw secret
+func TestHMACKey(t *testing.T) {
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 50; i++ {
+			secret := []byte(fmt.Sprintf("key %v", i))
+			handle := jwt.NewHMACKey(secret)
+			for _, method := range []*jwt.SigningMethodHMAC{jwt.SigningMethodHS256, jwt.SigningMethodHS512} {
+				mac := hmac.New(method.Hash.New, secret)
+				mac.Write([]byte("signing string"))
+				expect := jwt.EncodeSegment(mac.Sum(nil))
+				for _, key := range []interface{}{secret, handle} {
+					sig, err := method.Sign("signing string", key)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if sig != expect {
+						t.Fatalf("[%v %v %T] Expected %v.  Got %v", i, method.Alg(), key, expect, sig)
+					}
+					if err = method.Verify("signing string", sig, key); err != nil {
+						t.Errorf("[%v %v %T] Error verifying: %v", i, method.Alg(), key, err)
+					}
+				}
+			}
+		}
+	}
+
+	// The secret is copied
+	secret := []byte("secret")
+	handle := jwt.NewHMACKey(secret)
+	sig, _ := jwt.SigningMethodHS256.Sign("signing string", secret)
+	secret[0] = 'S'
+	if err := jwt.SigningMethodHS256.Verify("signing string", sig, handle); err != nil {
+		t.Errorf("Expected the HMACKey to keep the original secret.  Got %v", err)
+	}
+
+	// A zero HMACKey is one for an empty secret
+	sig, _ = jwt.SigningMethodHS256.Sign("signing string", []byte{})
+	if err := jwt.SigningMethodHS256.Verify("signing string", sig, new(jwt.HMACKey)); err != nil {
+		t.Errorf("Expected a zero HMACKey to work as an empty secret.  Got %v", err)
+	}
+}
+
+func BenchmarkHS256Verify(b *testing.B) {
+	parts := strings.Split(hmacTestData[0].tokenString, ".")
+	signingString := strings.Join(parts[0:2], ".")
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := jwt.SigningMethodHS256.Verify(signingString, parts[2], hmacTestKey); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkHS256Verify_HMACKey(b *testing.B) {
+	parts := strings.Split(hmacTestData[0].tokenString, ".")
+	signingString := strings.Join(parts[0:2], ".")
+	key := jwt.NewHMACKey(hmacTestKey)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := jwt.SigningMethodHS256.Verify(signingString, parts[2], key); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
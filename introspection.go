@@ -0,0 +1,185 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// The typ of a JWT introspection response, RFC 9701 section 5
+const IntrospectionResponseType = "token-introspection+jwt"
+
+// The introspection response of RFC 7662 section 2.2, describing the token
+// that was introspected.  Members without a field, such as cnf or acr, are
+// kept in Extra.
+type TokenIntrospection struct {
+	Active    bool         `json:"active"`
+	Scope     string       `json:"scope,omitempty"`
+	ClientId  string       `json:"client_id,omitempty"`
+	Username  string       `json:"username,omitempty"`
+	TokenType string       `json:"token_type,omitempty"`
+	ExpiresAt int64        `json:"exp,omitempty"`
+	IssuedAt  int64        `json:"iat,omitempty"`
+	NotBefore int64        `json:"nbf,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  ClaimStrings `json:"aud,omitempty"`
+	Issuer    string       `json:"iss,omitempty"`
+	Id        string       `json:"jti,omitempty"`
+
+	Extra map[string]interface{} `json:"-"` // Other members, by name
+}
+
+// Members of the response with a field in TokenIntrospection
+var introspectionFields = []string{"active", "scope", "client_id", "username", "token_type", "exp", "iat", "nbf", "sub", "aud", "iss", "jti"}
+
+// The fields of TokenIntrospection, without its methods
+type tokenIntrospectionFields TokenIntrospection
+
+func (ti *TokenIntrospection) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*tokenIntrospectionFields)(ti))
+	if err != nil || len(ti.Extra) == 0 {
+		return data, err
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range ti.Extra {
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+	return json.Marshal(m)
+}
+
+func (ti *TokenIntrospection) UnmarshalJSON(data []byte) error {
+	var fields tokenIntrospectionFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var extra map[string]interface{}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	for _, name := range introspectionFields {
+		delete(extra, name)
+	}
+	if len(extra) == 0 {
+		extra = nil
+	}
+
+	*ti = TokenIntrospection(fields)
+	ti.Extra = extra
+	return nil
+}
+
+// Whether the introspected token is active and, going by its own exp and
+// nbf, still usable now.  A response is a snapshot and may be cached, so
+// this is what a resource server should check before serving a request.
+func (ti *TokenIntrospection) IsActive() bool {
+	now := TimeFunc().Unix()
+	return ti.Active && verifyExp(ti.ExpiresAt, now, false) && verifyNbf(ti.NotBefore, now, false)
+}
+
+// Claims of a JWT introspection response, RFC 9701.  The top level claims
+// describe the response itself; the token it describes is in
+// TokenIntrospection.
+type IntrospectionResponseClaims struct {
+	Issuer             string              `json:"iss"`
+	Audience           ClaimStrings        `json:"aud"`
+	IssuedAt           int64               `json:"iat"`
+	Id                 string              `json:"jti,omitempty"`
+	TokenIntrospection *TokenIntrospection `json:"token_introspection"`
+}
+
+// Failures of IntrospectionResponseClaims.Valid, made once rather than per
+// token
+var (
+	errIntrospectionNoIssuer   = errors.New("introspection response has no iss claim")
+	errIntrospectionNoAudience = errors.New("introspection response has no aud claim")
+	errIntrospectionNoIssuedAt = errors.New("introspection response has no iat claim")
+	errIntrospectionNoResponse = errors.New("introspection response has no token_introspection claim")
+)
+
+// Validates the rules of RFC 9701 section 5: iss, aud, iat and
+// token_introspection are required, and iat must not be in the future.
+// The response is valid whether or not the token it describes is active.
+func (c *IntrospectionResponseClaims) Valid() error {
+	var vErr ValidationError
+	now := TimeFunc().Unix()
+
+	if c.Issuer == "" {
+		vErr.add(errIntrospectionNoIssuer, ValidationErrorIssuer)
+	}
+	if len(c.Audience) == 0 {
+		vErr.add(errIntrospectionNoAudience, ValidationErrorAudience)
+	}
+	if c.IssuedAt == 0 {
+		vErr.add(errIntrospectionNoIssuedAt, ValidationErrorIssuedAt)
+	} else if !verifyIat(c.IssuedAt, now, true) {
+		vErr.add(newIssuedAtError(c.IssuedAt, now), ValidationErrorIssuedAt)
+	}
+	if c.TokenIntrospection == nil {
+		vErr.add(errIntrospectionNoResponse, ValidationErrorClaimsInvalid)
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+	return vErr.escape()
+}
+
+// Returns the aud claim
+func (c *IntrospectionResponseClaims) GetAudience() []string {
+	return c.Audience
+}
+
+// Returns the iss claim
+func (c *IntrospectionResponseClaims) GetIssuer() string {
+	return c.Issuer
+}
+
+// Returns the jti claim
+func (c *IntrospectionResponseClaims) GetId() string {
+	return c.Id
+}
+
+// A response from issuer to the resource server audience, issued now and
+// with typ set to token-introspection+jwt, ready to sign.  An inactive
+// response should carry nothing but Active, RFC 7662 section 2.2.
+func NewIntrospectionResponse(method SigningMethod, issuer, audience string, introspection *TokenIntrospection) *Token {
+	claims := &IntrospectionResponseClaims{
+		Issuer:             issuer,
+		Audience:           ClaimStrings{audience},
+		IssuedAt:           TimeFunc().Unix(),
+		TokenIntrospection: introspection,
+	}
+	return NewWithClaims(method, claims).WithHeader("typ", IntrospectionResponseType)
+}
+
+// Parse and verify a JWT introspection response.  typ must be
+// token-introspection+jwt, iss must be issuer, the authorization server
+// that was asked, and aud must contain audience, the resource server
+// asking.  Check IsActive on the result before trusting the token it
+// describes.
+func ParseIntrospectionResponse(tokenString, issuer, audience string, keyFunc Keyfunc) (*TokenIntrospection, error) {
+	return new(Parser).ParseIntrospectionResponse(tokenString, issuer, audience, keyFunc)
+}
+
+// ParseIntrospectionResponse, with the options of the Parser
+func (p *Parser) ParseIntrospectionResponse(tokenString, issuer, audience string, keyFunc Keyfunc) (*TokenIntrospection, error) {
+	claims := new(IntrospectionResponseClaims)
+	token, err := p.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkType(token, IntrospectionResponseType); err != nil {
+		return nil, err
+	}
+	if err = ValidateIssuer(claims, issuer); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorIssuer}
+	}
+	if err = ValidateAudience(claims, audience); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorAudience}
+	}
+	return claims.TokenIntrospection, nil
+}
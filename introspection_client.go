@@ -0,0 +1,215 @@
+package jwt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTokenInactive            = newError(CodeTokenInactive, "token is not active")
+	ErrIntrospectionUnavailable = newError(CodeIntrospectionUnavailable, "introspection endpoint is unavailable")
+)
+
+const (
+	// Default time an introspection response is served from cache
+	DefaultIntrospectionCacheTTL = 30 * time.Second
+	// Default number of responses kept in cache
+	DefaultIntrospectionCacheSize = 10000
+	// Default number of failed calls in a row that open the circuit
+	DefaultIntrospectionFailureThreshold = 5
+	// Default time the circuit stays open before the endpoint is tried again
+	DefaultIntrospectionCooldown = 30 * time.Second
+)
+
+// Client for a token introspection endpoint, RFC 7662, for deployments
+// where the authorization server must have the last word on revocation.
+// Use Verify for opaque tokens, or Keyfunc to introspect JWTs after the
+// usual checks:
+//
+//	client := jwt.NewIntrospectionClient(endpoint, clientId, clientSecret)
+//	token, err := jwt.Parse(tokenString, client.Keyfunc(provider.Keyfunc))
+//
+// Responses are cached for CacheTTL, and never past the exp of an active
+// token.  After FailureThreshold failed calls in a row the circuit opens:
+// calls fail with ErrIntrospectionUnavailable for Cooldown, without
+// contacting the endpoint, so an outage there doesn't pile up requests.
+// An IntrospectionClient is safe for concurrent use.
+type IntrospectionClient struct {
+	Endpoint      string
+	ClientId      string // Sent with ClientSecret as HTTP Basic credentials, if set
+	ClientSecret  string
+	TokenTypeHint string       // Sent as token_type_hint, if set
+	Client        *http.Client // HTTP client used for calls.  Defaults to http.DefaultClient
+
+	CacheTTL         time.Duration // Defaults to DefaultIntrospectionCacheTTL, negative for no caching
+	CacheSize        int           // Defaults to DefaultIntrospectionCacheSize
+	FailureThreshold int           // Defaults to DefaultIntrospectionFailureThreshold
+	Cooldown         time.Duration // Defaults to DefaultIntrospectionCooldown
+
+	// With Keyfunc, accept tokens that pass the local checks when the
+	// endpoint can't be reached, rather than failing closed
+	FailOpen bool
+
+	mu        sync.Mutex
+	cache     map[[sha256.Size]byte]introspectionEntry
+	failures  int
+	openUntil time.Time
+}
+
+type introspectionEntry struct {
+	response *TokenIntrospection
+	expires  time.Time
+}
+
+// Create a client for the endpoint, authenticating with the client
+// credentials, using default settings
+func NewIntrospectionClient(endpoint, clientId, clientSecret string) *IntrospectionClient {
+	return &IntrospectionClient{Endpoint: endpoint, ClientId: clientId, ClientSecret: clientSecret}
+}
+
+// Look up token at the endpoint, or in the cache.  An inactive token is a
+// response, not an error.  Fails with a *FetchError if the call fails, or
+// ErrIntrospectionUnavailable while the circuit is open.
+func (c *IntrospectionClient) Introspect(ctx context.Context, token string) (*TokenIntrospection, error) {
+	key := sha256.Sum256([]byte(token))
+	now := TimeFunc()
+
+	c.mu.Lock()
+	if e, ok := c.cache[key]; ok && now.Before(e.expires) {
+		c.mu.Unlock()
+		return e.response, nil
+	}
+	if now.Before(c.openUntil) {
+		c.mu.Unlock()
+		return nil, ErrIntrospectionUnavailable
+	}
+	c.mu.Unlock()
+
+	ti, err := c.call(ctx, token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.failures++
+		if c.failures >= intOr(c.FailureThreshold, DefaultIntrospectionFailureThreshold) {
+			c.openUntil = TimeFunc().Add(durationOr(c.Cooldown, DefaultIntrospectionCooldown))
+		}
+		return nil, err
+	}
+	c.failures = 0
+	c.store(key, ti, now)
+	return ti, nil
+}
+
+// Introspect token and require it to be active, for opaque tokens.  Fails
+// with ErrTokenInactive if it isn't.
+func (c *IntrospectionClient) Verify(ctx context.Context, token string) (*TokenIntrospection, error) {
+	ti, err := c.Introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !ti.IsActive() {
+		return nil, ErrTokenInactive
+	}
+	return ti, nil
+}
+
+// Keyfunc that resolves the key with next, then requires the token to be
+// active at the endpoint.  The parser still checks the signature and
+// claims, so a token is accepted only if both agree.
+func (c *IntrospectionClient) Keyfunc(next Keyfunc) Keyfunc {
+	return func(token *Token) (interface{}, error) {
+		key, err := next(token)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = c.Verify(context.Background(), token.Raw); err != nil {
+			var fetchErr *FetchError
+			if c.FailOpen && (errors.Is(err, ErrIntrospectionUnavailable) || errors.As(err, &fetchErr)) {
+				return key, nil
+			}
+			return nil, err
+		}
+		return key, nil
+	}
+}
+
+// POST token to the endpoint, RFC 7662 section 2.1
+func (c *IntrospectionClient) call(ctx context.Context, token string) (*TokenIntrospection, error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	form := url.Values{"token": {token}}
+	if c.TokenTypeHint != "" {
+		form.Set("token_type_hint", c.TokenTypeHint)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, &FetchError{c.Endpoint, err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.ClientId != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientId), url.QueryEscape(c.ClientSecret))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &FetchError{c.Endpoint, err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &FetchError{c.Endpoint, fmt.Errorf("unexpected status %v", resp.Status)}
+	}
+	ti := new(TokenIntrospection)
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxFetchSize)).Decode(ti); err != nil {
+		return nil, &FetchError{c.Endpoint, err}
+	}
+	return ti, nil
+}
+
+// Cache ti until CacheTTL passes or the token expires.  Called with mu held.
+func (c *IntrospectionClient) store(key [sha256.Size]byte, ti *TokenIntrospection, now time.Time) {
+	ttl := durationOr(c.CacheTTL, DefaultIntrospectionCacheTTL)
+	if ttl < 0 {
+		return
+	}
+	expires := now.Add(ttl)
+	if ti.Active && ti.ExpiresAt != 0 && time.Unix(ti.ExpiresAt, 0).Before(expires) {
+		expires = time.Unix(ti.ExpiresAt, 0)
+	}
+
+	if c.cache == nil {
+		c.cache = map[[sha256.Size]byte]introspectionEntry{}
+	}
+	if len(c.cache) >= intOr(c.CacheSize, DefaultIntrospectionCacheSize) {
+		for k, e := range c.cache {
+			if !now.Before(e.expires) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= intOr(c.CacheSize, DefaultIntrospectionCacheSize) {
+			return
+		}
+	}
+	c.cache[key] = introspectionEntry{ti, expires}
+}
+
+func intOr(n, def int) int {
+	if n == 0 {
+		return def
+	}
+	return n
+}
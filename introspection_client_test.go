@@ -0,0 +1,81 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestIntrospectionClient(t *testing.T) {
+	key := []byte("secret")
+	active, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	revoked, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "mallory"}).SignedString(key)
+
+	calls := 0
+	down := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if id, secret, _ := r.BasicAuth(); id != "rs" || secret != "rs-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"active": r.PostFormValue("token") == active,
+			"sub":    "alice",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		})
+	}))
+	defer server.Close()
+
+	client := jwt.NewIntrospectionClient(server.URL, "rs", "rs-secret")
+	client.FailureThreshold = 2
+	keyFunc := client.Keyfunc(func(*jwt.Token) (interface{}, error) { return key, nil })
+
+	token, err := jwt.Parse(active, keyFunc)
+	if err != nil || !token.Valid {
+		t.Fatalf("Error parsing active token: %v", err)
+	}
+	if _, err = jwt.Parse(revoked, keyFunc); !errors.Is(err, jwt.ErrTokenInactive) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInactive, err)
+	}
+	if ti, err := client.Verify(context.Background(), "opaque"); ti != nil || !errors.Is(err, jwt.ErrTokenInactive) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInactive, err)
+	}
+
+	// Cached
+	before := calls
+	if _, err = jwt.Parse(active, keyFunc); err != nil || calls != before {
+		t.Errorf("Expected a cached response.  Got %v calls, %v", calls-before, err)
+	}
+
+	// The circuit opens after two failures
+	down = true
+	for _, tok := range []string{"a", "b"} {
+		var fetchErr *jwt.FetchError
+		if _, err = client.Introspect(context.Background(), tok); !errors.As(err, &fetchErr) {
+			t.Errorf("Expected a FetchError.  Got %v", err)
+		}
+	}
+	before = calls
+	if _, err = client.Introspect(context.Background(), "c"); err != jwt.ErrIntrospectionUnavailable || calls != before {
+		t.Errorf("Expected %v without a call.  Got %v", jwt.ErrIntrospectionUnavailable, err)
+	}
+	unseen, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "bob"}).SignedString(key)
+	if _, err = jwt.Parse(unseen, keyFunc); !errors.Is(err, jwt.ErrIntrospectionUnavailable) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrIntrospectionUnavailable, err)
+	}
+	client.FailOpen = true
+	if _, err = jwt.Parse(unseen, keyFunc); err != nil {
+		t.Errorf("Expected the token to be accepted failing open.  Got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestIntrospectionResponse(t *testing.T) {
+	key := []byte("authorization server secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	introspection := &jwt.TokenIntrospection{
+		Active:    true,
+		Scope:     "read write",
+		ClientId:  "client-1",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		Extra:     map[string]interface{}{"acr": "urn:example:mfa"},
+	}
+	s, err := jwt.NewIntrospectionResponse(jwt.SigningMethodHS256, "https://as.example.com", "https://rs.example.com", introspection).SignedString(key)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+
+	got, err := jwt.ParseIntrospectionResponse(s, "https://as.example.com", "https://rs.example.com", keyFunc)
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	if !got.IsActive() || got.Scope != "read write" || got.ClientId != "client-1" || got.Extra["acr"] != "urn:example:mfa" {
+		t.Errorf("Unexpected introspection %+v", got)
+	}
+
+	if _, err := jwt.ParseIntrospectionResponse(s, "https://other.example.com", "https://rs.example.com", keyFunc); !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInvalidIssuer, err)
+	}
+	if _, err := jwt.ParseIntrospectionResponse(s, "https://as.example.com", "https://other.example.com", keyFunc); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInvalidAudience, err)
+	}
+
+	// The same claims without the typ are not an introspection response
+	token := jwt.NewIntrospectionResponse(jwt.SigningMethodHS256, "https://as.example.com", "https://rs.example.com", introspection)
+	delete(token.Header, "typ")
+	untyped, _ := token.SignedString(key)
+	if _, err := jwt.ParseIntrospectionResponse(untyped, "https://as.example.com", "https://rs.example.com", keyFunc); !errors.Is(err, jwt.ErrTokenInvalidType) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInvalidType, err)
+	}
+}
+
+func TestTokenIntrospection_IsActive(t *testing.T) {
+	now := time.Now().Unix()
+
+	var isActiveTestData = []struct {
+		name          string
+		introspection jwt.TokenIntrospection
+		active        bool
+	}{
+		{"active", jwt.TokenIntrospection{Active: true}, true},
+		{"inactive", jwt.TokenIntrospection{Active: false}, false},
+		{"expired since", jwt.TokenIntrospection{Active: true, ExpiresAt: now - 10}, false},
+		{"not yet valid", jwt.TokenIntrospection{Active: true, NotBefore: now + 100}, false},
+	}
+
+	for _, data := range isActiveTestData {
+		if got := data.introspection.IsActive(); got != data.active {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.active, got)
+		}
+	}
+}
+
+func TestTokenIntrospection_JSON(t *testing.T) {
+	data := []byte(`{"active":true,"sub":"alice","aud":"https://rs.example.com","cnf":{"x5t#S256":"abc"}}`)
+	ti := new(jwt.TokenIntrospection)
+	if err := json.Unmarshal(data, ti); err != nil {
+		t.Fatalf("Error unmarshalling: %v", err)
+	}
+	if ti.Subject != "alice" || len(ti.Audience) != 1 || len(ti.Extra) != 1 || ti.Extra["cnf"] == nil {
+		t.Errorf("Unexpected introspection %+v", ti)
+	}
+
+	out, err := json.Marshal(ti)
+	if err != nil {
+		t.Fatalf("Error marshalling: %v", err)
+	}
+	var m map[string]interface{}
+	json.Unmarshal(out, &m)
+	if m["active"] != true || m["sub"] != "alice" || m["aud"] != "https://rs.example.com" || m["cnf"] == nil {
+		t.Errorf("Unexpected JSON %s", out)
+	}
+}
+
+func TestIntrospectionResponseClaims_Valid(t *testing.T) {
+	now := time.Now().Unix()
+	ti := &jwt.TokenIntrospection{Active: false}
+	aud := jwt.ClaimStrings{"rs"}
+
+	var introspectionTestData = []struct {
+		name   string
+		claims *jwt.IntrospectionResponseClaims
+		err    error
+	}{
+		{"valid", &jwt.IntrospectionResponseClaims{Issuer: "as", Audience: aud, IssuedAt: now, TokenIntrospection: ti}, nil},
+		{"no iss", &jwt.IntrospectionResponseClaims{Audience: aud, IssuedAt: now, TokenIntrospection: ti}, jwt.ErrTokenInvalidIssuer},
+		{"no aud", &jwt.IntrospectionResponseClaims{Issuer: "as", IssuedAt: now, TokenIntrospection: ti}, jwt.ErrTokenInvalidAudience},
+		{"no iat", &jwt.IntrospectionResponseClaims{Issuer: "as", Audience: aud, TokenIntrospection: ti}, jwt.ErrTokenUsedBeforeIssued},
+		{"future iat", &jwt.IntrospectionResponseClaims{Issuer: "as", Audience: aud, IssuedAt: now + 100, TokenIntrospection: ti}, jwt.ErrTokenUsedBeforeIssued},
+		{"no token_introspection", &jwt.IntrospectionResponseClaims{Issuer: "as", Audience: aud, IssuedAt: now}, jwt.ErrTokenInvalidClaims},
+	}
+
+	for _, data := range introspectionTestData {
+		err := data.claims.Valid()
+		if (data.err == nil) != (err == nil) || (data.err != nil && !errors.Is(err, data.err)) {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+// The typ of a request object, RFC 9101 section 10.8
+const RequestObjectType = "oauth-authz-req+jwt"
+
+// Longest exp - nbf accepted in a request object unless the validator sets
+// another, as FAPI requires
+const DefaultRequestObjectLifetime = time.Hour
+
+var (
+	ErrRequestObjectUnsigned = newError(CodeUnsupportedAlgorithm, "request objects must be signed")
+)
+
+// Claims of a JWT-Secured Authorization Request, RFC 9101: the
+// authorization request parameters, signed by the client.  Parameters
+// without a field can be carried by embedding this in another type.
+type RequestObjectClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Audience  ClaimStrings `json:"aud,omitempty"`
+	ExpiresAt int64        `json:"exp,omitempty"`
+	NotBefore int64        `json:"nbf,omitempty"`
+	IssuedAt  int64        `json:"iat,omitempty"`
+	Id        string       `json:"jti,omitempty"`
+
+	ResponseType        string `json:"response_type"`
+	ClientId            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri,omitempty"`
+	Scope               string `json:"scope,omitempty"`
+	State               string `json:"state,omitempty"`
+	Nonce               string `json:"nonce,omitempty"`
+	CodeChallenge       string `json:"code_challenge,omitempty"`
+	CodeChallengeMethod string `json:"code_challenge_method,omitempty"`
+}
+
+// Start a request object from clientId to the authorization server
+// audience, valid from now for lifetime, with a jti from IdFunc
+func NewRequestObject(clientId, audience, responseType string, lifetime time.Duration) *RequestObjectClaims {
+	now := TimeFunc()
+	return &RequestObjectClaims{
+		Issuer:       clientId,
+		Audience:     ClaimStrings{audience},
+		IssuedAt:     now.Unix(),
+		NotBefore:    now.Unix(),
+		ExpiresAt:    now.Add(lifetime).Unix(),
+		Id:           IdFunc(),
+		ResponseType: responseType,
+		ClientId:     clientId,
+	}
+}
+
+// Validates the time based claims and the presence of response_type and
+// client_id.  The stricter rules of RequestObjectValidator need to know
+// the expected client and audience.
+func (c *RequestObjectClaims) Valid() error {
+	vErr := new(ValidationError)
+	now := TimeFunc().Unix()
+
+	if !verifyExp(c.ExpiresAt, now, false) {
+		vErr.add(newExpiredError(c.ExpiresAt, now), ValidationErrorExpired)
+	}
+	if !verifyNbf(c.NotBefore, now, false) {
+		vErr.add(newNotValidYetError(c.NotBefore, now), ValidationErrorNotValidYet)
+	}
+	if !verifyIat(c.IssuedAt, now, false) {
+		vErr.add(newIssuedAtError(c.IssuedAt, now), ValidationErrorIssuedAt)
+	}
+	if c.ResponseType == "" || c.ClientId == "" {
+		vErr.add(errors.New("request object needs response_type and client_id"), ValidationErrorClaimsInvalid)
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+	return vErr
+}
+
+// Returns the aud claim
+func (c *RequestObjectClaims) GetAudience() []string {
+	return c.Audience
+}
+
+// Returns the iss claim
+func (c *RequestObjectClaims) GetIssuer() string {
+	return c.Issuer
+}
+
+// Returns the jti claim
+func (c *RequestObjectClaims) GetId() string {
+	return c.Id
+}
+
+// A token for claims with typ set to oauth-authz-req+jwt, ready to sign
+func NewRequestObjectToken(method SigningMethod, claims *RequestObjectClaims) *Token {
+	return NewWithClaims(method, claims).WithHeader("typ", RequestObjectType)
+}
+
+// Validates request objects at an authorization server
+type RequestObjectValidator struct {
+	Audience string // The authorization server's issuer identifier, required in aud
+	ClientId string // The client_id of the authorization request, which the request object must match
+
+	// Allowed signing methods.  Defaults to PS256 and ES256, as FAPI
+	// requires.  none is never accepted.
+	Methods []string
+
+	MaxLifetime time.Duration // Longest exp - nbf accepted.  Defaults to DefaultRequestObjectLifetime
+	RequireType bool          // Require typ oauth-authz-req+jwt.  Otherwise JWT or no typ is accepted too
+
+	Parser *Parser // Parser used for verification.  Defaults to new(Parser)
+}
+
+// Parse and verify a request object.  On top of the checks of
+// RequestObjectClaims.Valid, it must be signed with one of Methods by
+// ClientId to Audience, and carry exp and nbf no further than MaxLifetime
+// apart.
+func (v *RequestObjectValidator) Parse(tokenString string, keyFunc Keyfunc) (*RequestObjectClaims, error) {
+	p := new(Parser)
+	if v.Parser != nil {
+		*p = *v.Parser
+	}
+	p.ValidMethods = v.Methods
+	if p.ValidMethods == nil {
+		p.ValidMethods = []string{"PS256", "ES256"}
+	}
+
+	claims := new(RequestObjectClaims)
+	token, err := p.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method == SigningMethodNone {
+		return nil, &ValidationError{Inner: ErrRequestObjectUnsigned, Errors: ValidationErrorSignatureInvalid}
+	}
+	if typ, ok := token.Header["typ"]; (ok && typ != "JWT") || v.RequireType {
+		if err = checkType(token, RequestObjectType); err != nil {
+			return nil, err
+		}
+	}
+
+	vErr := new(ValidationError)
+	if v.ClientId != "" && claims.ClientId != v.ClientId {
+		vErr.add(errors.New("request object client_id does not match the request"), ValidationErrorClaimsInvalid)
+	}
+	if claims.Issuer != claims.ClientId {
+		vErr.add(errors.New("request object iss is not its client_id"), ValidationErrorIssuer)
+	}
+	if err = ValidateAudience(claims, v.Audience); err != nil {
+		vErr.add(err, ValidationErrorAudience)
+	}
+	if claims.ExpiresAt == 0 || claims.NotBefore == 0 {
+		vErr.add(errors.New("request object needs exp and nbf"), ValidationErrorClaimsInvalid)
+	} else if time.Duration(claims.ExpiresAt-claims.NotBefore)*time.Second > v.maxLifetime() {
+		vErr.add(errors.New("request object lifetime is too long"), ValidationErrorClaimsInvalid)
+	}
+
+	if vErr.valid() {
+		return claims, nil
+	}
+	return nil, vErr
+}
+
+func (v *RequestObjectValidator) maxLifetime() time.Duration {
+	if v.MaxLifetime == 0 {
+		return DefaultRequestObjectLifetime
+	}
+	return v.MaxLifetime
+}
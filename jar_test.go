@@ -0,0 +1,89 @@
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestRequestObject(t *testing.T) {
+	clientKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return &clientKey.PublicKey, nil }
+	validator := &jwt.RequestObjectValidator{Audience: "https://as.example.com", ClientId: "s6BhdRkqt3"}
+
+	sign := func(method jwt.SigningMethod, claims *jwt.RequestObjectClaims, typ string) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["typ"] = typ
+		if typ == "" {
+			delete(token.Header, "typ")
+		}
+		s, err := token.SignedString(clientKey)
+		if err != nil {
+			t.Fatalf("Error signing: %v", err)
+		}
+		return s
+	}
+	valid := func() *jwt.RequestObjectClaims {
+		c := jwt.NewRequestObject("s6BhdRkqt3", "https://as.example.com", "code", 5*time.Minute)
+		c.RedirectURI = "https://client.example.org/cb"
+		return c
+	}
+
+	s := jwt.NewRequestObjectToken(jwt.SigningMethodPS256, valid())
+	signed, _ := s.SignedString(clientKey)
+	claims, err := validator.Parse(signed, keyFunc)
+	if err != nil || claims.RedirectURI != "https://client.example.org/cb" {
+		t.Fatalf("Error parsing request object: %v", err)
+	}
+
+	long := valid()
+	long.ExpiresAt = long.NotBefore + 7200
+	noNbf := valid()
+	noNbf.NotBefore = 0
+	otherIss := valid()
+	otherIss.Issuer = "attacker"
+	otherClient := valid()
+	otherClient.ClientId, otherClient.Issuer = "other", "other"
+	noResponseType := valid()
+	noResponseType.ResponseType = ""
+
+	var requestObjectTestData = []struct {
+		name      string
+		token     string
+		validator *jwt.RequestObjectValidator
+		err       error
+	}{
+		{"no typ", sign(jwt.SigningMethodPS256, valid(), ""), validator, nil},
+		{"JWT typ", sign(jwt.SigningMethodPS256, valid(), "JWT"), validator, nil},
+		{"typ required", sign(jwt.SigningMethodPS256, valid(), ""), &jwt.RequestObjectValidator{Audience: "https://as.example.com", RequireType: true}, jwt.ErrTokenInvalidType},
+		{"wrong typ", sign(jwt.SigningMethodPS256, valid(), "at+jwt"), validator, jwt.ErrTokenInvalidType},
+		{"RS256", sign(jwt.SigningMethodRS256, valid(), jwt.RequestObjectType), validator, jwt.ErrSignatureInvalid},
+		{"lifetime", sign(jwt.SigningMethodPS256, long, jwt.RequestObjectType), validator, jwt.ErrTokenInvalidClaims},
+		{"no nbf", sign(jwt.SigningMethodPS256, noNbf, jwt.RequestObjectType), validator, jwt.ErrTokenInvalidClaims},
+		{"iss", sign(jwt.SigningMethodPS256, otherIss, jwt.RequestObjectType), validator, jwt.ErrTokenInvalidIssuer},
+		{"client_id", sign(jwt.SigningMethodPS256, otherClient, jwt.RequestObjectType), validator, jwt.ErrTokenInvalidClaims},
+		{"response_type", sign(jwt.SigningMethodPS256, noResponseType, jwt.RequestObjectType), validator, jwt.ErrTokenInvalidClaims},
+		{"aud", sign(jwt.SigningMethodPS256, valid(), jwt.RequestObjectType), &jwt.RequestObjectValidator{Audience: "https://other.example.com"}, jwt.ErrTokenInvalidAudience},
+	}
+
+	for _, data := range requestObjectTestData {
+		_, err := data.validator.Parse(data.token, keyFunc)
+		if (data.err == nil) != (err == nil) || (data.err != nil && !errors.Is(err, data.err)) {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
+
+func TestRequestObject_unsigned(t *testing.T) {
+	claims := jwt.NewRequestObject("s6BhdRkqt3", "https://as.example.com", "code", time.Minute)
+	s, _ := jwt.NewRequestObjectToken(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	keyFunc := func(*jwt.Token) (interface{}, error) { return jwt.UnsafeAllowNoneSignatureType, nil }
+
+	validator := &jwt.RequestObjectValidator{Audience: "https://as.example.com", Methods: []string{"none"}}
+	if _, err := validator.Parse(s, keyFunc); !errors.Is(err, jwt.ErrRequestObjectUnsigned) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrRequestObjectUnsigned, err)
+	}
+}
@@ -0,0 +1,152 @@
+// Code generated by jwtgen. DO NOT EDIT.
+
+package jsoncodec_test
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/jsoncodec"
+)
+
+func (c sessionClaims) MarshalJSON() ([]byte, error) {
+	var err error
+	b := make([]byte, 0, 225)
+	b = append(b, '{')
+	b = jsoncodec.AppendKey(b, "\"sub\":")
+	b = jsoncodec.AppendString(b, c.Subject)
+	if len(c.Scopes) != 0 {
+		b = jsoncodec.AppendKey(b, "\"scope\":")
+		b = jsoncodec.AppendStrings(b, c.Scopes)
+	}
+	if c.Admin {
+		b = jsoncodec.AppendKey(b, "\"admin\":")
+		b = jsoncodec.AppendBool(b, c.Admin)
+	}
+	b = jsoncodec.AppendKey(b, "\"level\":")
+	b = jsoncodec.AppendInt(b, int64(c.Level))
+	if c.ExpiresAt != 0 {
+		b = jsoncodec.AppendKey(b, "\"exp\":")
+		b = jsoncodec.AppendInt(b, c.ExpiresAt)
+	}
+	if c.NotBefore != 0 {
+		b = jsoncodec.AppendKey(b, "\"nbf\":")
+		b = jsoncodec.AppendInt(b, c.NotBefore)
+	}
+	if c.Score != 0 {
+		b = jsoncodec.AppendKey(b, "\"score\":")
+		if b, err = jsoncodec.AppendFloat(b, c.Score); err != nil {
+			return nil, err
+		}
+	}
+	if c.Note != "" {
+		b = jsoncodec.AppendKey(b, "\"\\u003cnote\\u003e\":")
+		b = jsoncodec.AppendString(b, c.Note)
+	}
+	b = jsoncodec.AppendKey(b, "\"Untagged\":")
+	b = jsoncodec.AppendString(b, c.Untagged)
+	return append(b, '}'), nil
+}
+
+func (c *sessionClaims) UnmarshalJSON(data []byte) error {
+	r := jsoncodec.NewReader(data)
+	return r.Object(func(key []byte) error {
+		switch string(key) {
+		case "sub":
+			return r.String(&c.Subject)
+		case "scope":
+			return r.Strings(&c.Scopes)
+		case "admin":
+			return r.Bool(&c.Admin)
+		case "level":
+			return r.Int(&c.Level)
+		case "exp":
+			return r.Int64(&c.ExpiresAt)
+		case "nbf":
+			return r.Int64(&c.NotBefore)
+		case "score":
+			return r.Float64(&c.Score)
+		case "<note>":
+			return r.String(&c.Note)
+		case "Untagged":
+			return r.String(&c.Untagged)
+		}
+		return r.Skip()
+	})
+}
+
+func (c sessionClaims) Valid() error {
+	return c.ValidAt(jwt.TimeFunc())
+}
+
+func (c sessionClaims) ValidAt(now time.Time) error {
+	return jwt.StandardClaims{
+		ExpiresAt: c.ExpiresAt,
+		NotBefore: c.NotBefore,
+	}.CheckTimes(now)
+}
+
+func (c profileClaims) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 213)
+	b = append(b, '{')
+	if c.StandardClaims.Audience != "" {
+		b = jsoncodec.AppendKey(b, "\"aud\":")
+		b = jsoncodec.AppendString(b, c.StandardClaims.Audience)
+	}
+	if c.StandardClaims.ExpiresAt != 0 {
+		b = jsoncodec.AppendKey(b, "\"exp\":")
+		b = jsoncodec.AppendInt(b, c.StandardClaims.ExpiresAt)
+	}
+	if c.StandardClaims.Id != "" {
+		b = jsoncodec.AppendKey(b, "\"jti\":")
+		b = jsoncodec.AppendString(b, c.StandardClaims.Id)
+	}
+	if c.StandardClaims.IssuedAt != 0 {
+		b = jsoncodec.AppendKey(b, "\"iat\":")
+		b = jsoncodec.AppendInt(b, c.StandardClaims.IssuedAt)
+	}
+	if c.StandardClaims.NotBefore != 0 {
+		b = jsoncodec.AppendKey(b, "\"nbf\":")
+		b = jsoncodec.AppendInt(b, c.StandardClaims.NotBefore)
+	}
+	if c.StandardClaims.Subject != "" {
+		b = jsoncodec.AppendKey(b, "\"sub\":")
+		b = jsoncodec.AppendString(b, c.StandardClaims.Subject)
+	}
+	b = jsoncodec.AppendKey(b, "\"iss\":")
+	b = jsoncodec.AppendString(b, c.Issuer)
+	if c.Name != "" {
+		b = jsoncodec.AppendKey(b, "\"name\":")
+		b = jsoncodec.AppendString(b, c.Name)
+	}
+	b = jsoncodec.AppendKey(b, "\"groups\":")
+	b = jsoncodec.AppendStrings(b, c.Groups)
+	return append(b, '}'), nil
+}
+
+func (c *profileClaims) UnmarshalJSON(data []byte) error {
+	r := jsoncodec.NewReader(data)
+	return r.Object(func(key []byte) error {
+		switch string(key) {
+		case "aud":
+			return r.String(&c.StandardClaims.Audience)
+		case "exp":
+			return r.Int64(&c.StandardClaims.ExpiresAt)
+		case "jti":
+			return r.String(&c.StandardClaims.Id)
+		case "iat":
+			return r.Int64(&c.StandardClaims.IssuedAt)
+		case "nbf":
+			return r.Int64(&c.StandardClaims.NotBefore)
+		case "sub":
+			return r.String(&c.StandardClaims.Subject)
+		case "iss":
+			return r.String(&c.Issuer)
+		case "name":
+			return r.String(&c.Name)
+		case "groups":
+			return r.Strings(&c.Groups)
+		}
+		return r.Skip()
+	})
+}
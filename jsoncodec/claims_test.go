@@ -0,0 +1,30 @@
+package jsoncodec_test
+
+import (
+	"github.com/dgrijalva/jwt-go"
+)
+
+//go:generate go run github.com/dgrijalva/jwt-go/cmd/jwtgen -type sessionClaims,profileClaims
+
+// Every supported field type, with a Valid written by jwtgen
+type sessionClaims struct {
+	Subject   string   `json:"sub"`
+	Scopes    []string `json:"scope,omitempty"`
+	Admin     bool     `json:"admin,omitempty"`
+	Level     int      `json:"level"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	Score     float64  `json:"score,omitempty"`
+	Note      string   `json:"<note>,omitempty"`
+	Untagged  string
+	Ignored   string `json:"-"`
+	private   string
+}
+
+// StandardClaims flattened, with iss replaced by a field of the struct
+type profileClaims struct {
+	jwt.StandardClaims
+	Issuer string   `json:"iss"`
+	Name   string   `json:"name,omitempty"`
+	Groups []string `json:"groups"`
+}
@@ -0,0 +1,248 @@
+package jsoncodec_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/jsoncodec"
+)
+
+// The same structs without the generated methods, for encoding/json to
+// compare against
+type plainSession sessionClaims
+type plainProfile profileClaims
+
+var marshalTestData = []struct {
+	name    string
+	session sessionClaims
+}{
+	{"empty", sessionClaims{}},
+	{"all fields", sessionClaims{Subject: "alice", Scopes: []string{"read", "write"}, Admin: true, Level: -3, ExpiresAt: 1500000000, NotBefore: 1400000000, Score: 0.25, Note: "hi", Untagged: "u", Ignored: "x", private: "y"}},
+	{"empty scopes", sessionClaims{Scopes: []string{}}},
+	{"escapes", sessionClaims{Subject: "\"quoted\" \\ <a href=\"x\">&amp;</a>\n\r\t\b\f\x00\x1f\x7f"}},
+	{"unicode", sessionClaims{Subject: "héllo, 世界 \U0001F600   "}},
+	{"invalid utf-8", sessionClaims{Subject: "a\xffb\xc3", Scopes: []string{"\xed\xa0\x80"}}},
+	{"small float", sessionClaims{Score: 1e-7}},
+	{"large float", sessionClaims{Score: -1e21}},
+	{"plain float", sessionClaims{Score: 123456789.125}},
+	{"extreme ints", sessionClaims{Level: math.MinInt, ExpiresAt: math.MaxInt64}},
+}
+
+func TestGenerated_MarshalJSON(t *testing.T) {
+	for _, data := range marshalTestData {
+		got, err := json.Marshal(data.session)
+		if err != nil {
+			t.Errorf("[%v] Error marshaling: %v", data.name, err)
+			continue
+		}
+		expect, _ := json.Marshal(plainSession(data.session))
+		if string(got) != string(expect) {
+			t.Errorf("[%v] Expected %s.  Got %s", data.name, expect, got)
+		}
+	}
+
+	profile := profileClaims{StandardClaims: jwt.StandardClaims{Subject: "bob", Issuer: "hidden", ExpiresAt: 2}, Issuer: "https://auth.example.com", Name: "Bob"}
+	got, _ := json.Marshal(profile)
+	expect, _ := json.Marshal(plainProfile(profile))
+	if string(got) != string(expect) {
+		t.Errorf("[profile] Expected %s.  Got %s", expect, got)
+	}
+
+	if _, err := json.Marshal(sessionClaims{Score: math.NaN()}); err == nil {
+		t.Errorf("[NaN] Expected an error")
+	}
+}
+
+var unmarshalTestData = []struct {
+	name  string
+	input string
+	valid bool
+}{
+	{"all fields", `{"sub":"alice","scope":["read","write"],"admin":true,"level":-3,"exp":1500000000,"nbf":1400000000,"score":2.5e-3,"<note>":"hi","Untagged":"u"}`, true},
+	{"white space", " \t\n{ \"sub\" : \"alice\" , \"level\" :\r7 } \n", true},
+	{"empty", `{}`, true},
+	{"null object", `null`, true},
+	{"null members", `{"sub":null,"scope":null,"admin":null,"level":null,"exp":null,"score":null}`, true},
+	{"empty scopes", `{"scope":[]}`, true},
+	{"unknown members", `{"other":{"a":[1,2.5e3,{"b":null}],"c":"\"}"},"x":true,"y":-0,"sub":"alice"}`, true},
+	{"ignored members", `{"Ignored":"x","private":"y"}`, true},
+	{"repeated member", `{"sub":"a","sub":"b"}`, true},
+	{"escapes", `{"sub":"\"\\\/\b\f\n\r\tAé世😀"}`, true},
+	{"lone surrogates", `{"sub":"\ud83d x \ude00 \ud83dA"}`, true},
+	{"invalid utf-8", "{\"sub\":\"a\xffb\"}", true},
+	{"raw unicode", `{"sub":"héllo, 世界"}`, true},
+	{"large exp", `{"exp":9223372036854775807,"level":-9223372036854775808}`, true},
+
+	{"exp out of range", `{"exp":9223372036854775808}`, false},
+	{"exp too long", `{"exp":100000000000000000000000}`, false},
+	{"fraction for int", `{"exp":1.5}`, false},
+	{"exponent for int", `{"level":1e3}`, false},
+	{"string for int", `{"level":"7"}`, false},
+	{"number for string", `{"sub":7}`, false},
+	{"object for strings", `{"scope":{}}`, false},
+	{"number in strings", `{"scope":["a",1]}`, false},
+	{"string for bool", `{"admin":"true"}`, false},
+	{"array", `[]`, false},
+	{"string", `"sub"`, false},
+	{"trailing data", `{"sub":"a"}x`, false},
+	{"second object", `{} {}`, false},
+	{"missing colon", `{"sub" "a"}`, false},
+	{"trailing comma", `{"sub":"a",}`, false},
+	{"unterminated", `{"sub":"a"`, false},
+	{"unterminated string", `{"sub":"a}`, false},
+	{"control character", "{\"sub\":\"a\nb\"}", false},
+	{"bad escape", `{"sub":"\x"}`, false},
+	{"short unicode escape", `{"sub":"\u12"}`, false},
+	{"leading zero", `{"level":01}`, false},
+	{"bare minus", `{"level":-}`, false},
+	{"bad literal", `{"admin":tru}`, false},
+	{"bad unknown value", `{"other":[1,]}`, false},
+	{"unquoted key", `{sub:"a"}`, false},
+	{"empty input", ``, false},
+}
+
+func TestGenerated_UnmarshalJSON(t *testing.T) {
+	for _, data := range unmarshalTestData {
+		var got sessionClaims
+		err := got.UnmarshalJSON([]byte(data.input))
+		var expect plainSession
+		expectErr := json.Unmarshal([]byte(data.input), &expect)
+		if (expectErr == nil) != data.valid {
+			t.Fatalf("[%v] The test data is wrong: encoding/json says %v", data.name, expectErr)
+		}
+
+		if (err == nil) != data.valid {
+			t.Errorf("[%v] Expected an error: %v.  Got %v", data.name, !data.valid, err)
+			continue
+		}
+		if data.valid && !reflect.DeepEqual(plainSession(got), expect) {
+			t.Errorf("[%v] Expected %+v.  Got %+v", data.name, expect, got)
+		}
+	}
+}
+
+func TestGenerated_UnmarshalJSON_embedded(t *testing.T) {
+	input := `{"sub":"bob","iss":"https://auth.example.com","exp":2,"aud":"api","name":"Bob","groups":["a"]}`
+	var got profileClaims
+	if err := json.Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("Error unmarshaling: %v", err)
+	}
+	var expect plainProfile
+	json.Unmarshal([]byte(input), &expect)
+	if !reflect.DeepEqual(plainProfile(got), expect) {
+		t.Errorf("Expected %+v.  Got %+v", expect, got)
+	}
+	if got.StandardClaims.Issuer != "" {
+		t.Errorf("Expected iss to go to the outer field only.  Got %q", got.StandardClaims.Issuer)
+	}
+}
+
+func TestReader_errors(t *testing.T) {
+	var tests = []struct {
+		input  string
+		expect error
+	}{
+		{`{"level":"x"}`, jsoncodec.ErrType},
+		{`{"level":1.5}`, jsoncodec.ErrType},
+		{`{"level":99999999999999999999}`, jsoncodec.ErrRange},
+		{`{"score":1e400}`, jsoncodec.ErrRange},
+		{`{"level":}`, jsoncodec.ErrSyntax},
+		{`{"level":"x}`, jsoncodec.ErrSyntax},
+	}
+	for _, data := range tests {
+		var c sessionClaims
+		if err := c.UnmarshalJSON([]byte(data.input)); !errors.Is(err, data.expect) {
+			t.Errorf("[%v] Expected %v.  Got %v", data.input, data.expect, err)
+		}
+	}
+}
+
+func TestGenerated_Valid(t *testing.T) {
+	now := time.Now().Unix()
+	if err := (sessionClaims{ExpiresAt: now + 60, NotBefore: now - 60}).Valid(); err != nil {
+		t.Errorf("Expected current claims to be valid.  Got %v", err)
+	}
+	if err := (sessionClaims{ExpiresAt: now - 60}).Valid(); err == nil {
+		t.Errorf("Expected expired claims to be invalid")
+	}
+	if err := (sessionClaims{NotBefore: now + 60}).Valid(); err == nil {
+		t.Errorf("Expected claims not valid yet to be invalid")
+	}
+}
+
+func TestGenerated_ValidAt(t *testing.T) {
+	then := time.Unix(1000, 0)
+	if err := (sessionClaims{ExpiresAt: 1500, NotBefore: 500}).ValidAt(then); err != nil {
+		t.Errorf("Expected the claims to be valid at %v.  Got %v", then, err)
+	}
+	if err := (sessionClaims{NotBefore: 1001}).ValidAt(then); err == nil {
+		t.Errorf("Expected the claims not to be valid yet at %v", then)
+	}
+	var _ jwt.TimedClaims = sessionClaims{}
+}
+
+func TestGenerated_token(t *testing.T) {
+	key := []byte("jsoncodec secret")
+	claims := &sessionClaims{Subject: "alice", Scopes: []string{"read"}, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+
+	parsed := &sessionClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, parsed, func(*jwt.Token) (interface{}, error) { return key, nil })
+	if err != nil || !token.Valid {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, claims) {
+		t.Errorf("Expected %+v.  Got %+v", claims, parsed)
+	}
+}
+
+var benchmarkInput = []byte(`{"sub":"alice","scope":["read","write"],"level":3,"exp":1500000000,"nbf":1400000000,"Untagged":""}`)
+
+func BenchmarkUnmarshal(b *testing.B) {
+	b.Run("jwtgen", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var c sessionClaims
+			if err := c.UnmarshalJSON(benchmarkInput); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var c plainSession
+			if err := json.Unmarshal(benchmarkInput, &c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	c := sessionClaims{Subject: "alice", Scopes: []string{"read", "write"}, Level: 3, ExpiresAt: 1500000000, NotBefore: 1400000000}
+	b.Run("jwtgen", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := c.MarshalJSON(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(plainSession(c)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,481 @@
+package jsoncodec
+
+import (
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Values nested deeper than this are not skipped, as encoding/json refuses
+// them too
+const maxDepth = 10000
+
+// Reads one JSON object from data.  Values that are not wanted must be
+// skipped with Skip, so that the next member can be read.
+type Reader struct {
+	data []byte
+	pos  int
+}
+
+func NewReader(data []byte) Reader {
+	return Reader{data: data}
+}
+
+// Read an object, calling member with the name of each member so that it
+// reads the value.  A null object is allowed and reads nothing, as for
+// encoding/json.  Only white space may follow the object.
+func (r *Reader) Object(member func(key []byte) error) error {
+	r.ws()
+	if r.literal("null") {
+		return r.end()
+	}
+	if !r.consume('{') {
+		return ErrSyntax
+	}
+	r.ws()
+	if !r.consume('}') {
+		for {
+			r.ws()
+			key, err := r.str()
+			if err != nil {
+				return err
+			}
+			r.ws()
+			if !r.consume(':') {
+				return ErrSyntax
+			}
+			r.ws()
+			if err = member(key); err != nil {
+				return err
+			}
+			r.ws()
+			if r.consume(',') {
+				continue
+			}
+			if r.consume('}') {
+				break
+			}
+			return ErrSyntax
+		}
+	}
+	return r.end()
+}
+
+// Read a string into p.  null leaves p as it was.
+func (r *Reader) String(p *string) error {
+	if r.literal("null") {
+		return nil
+	}
+	if r.peek() != '"' {
+		return r.wrongType()
+	}
+	s, err := r.str()
+	if err != nil {
+		return err
+	}
+	*p = string(s)
+	return nil
+}
+
+// Read a string, returning its contents without copying them: unless it
+// has escapes or invalid UTF-8, they are a slice of the input.  null is
+// the wrong type.
+func (r *Reader) StringBytes() ([]byte, error) {
+	if r.peek() != '"' {
+		return nil, r.wrongType()
+	}
+	return r.str()
+}
+
+// Read an array of strings into p, reusing its storage as encoding/json
+// does.  null sets p to nil.
+func (r *Reader) Strings(p *[]string) error {
+	if r.literal("null") {
+		*p = nil
+		return nil
+	}
+	if !r.consume('[') {
+		return r.wrongType()
+	}
+	out := (*p)[:0]
+	if out == nil {
+		out = []string{}
+	}
+	r.ws()
+	if !r.consume(']') {
+		for {
+			r.ws()
+			var s string
+			if r.peek() != '"' {
+				return r.wrongType()
+			}
+			if err := r.String(&s); err != nil {
+				return err
+			}
+			out = append(out, s)
+			r.ws()
+			if r.consume(',') {
+				continue
+			}
+			if r.consume(']') {
+				break
+			}
+			return ErrSyntax
+		}
+	}
+	*p = out
+	return nil
+}
+
+// Read an integer into p.  Numbers with a fraction or exponent are the
+// wrong type, as for encoding/json.  null leaves p as it was.
+func (r *Reader) Int64(p *int64) error {
+	if r.literal("null") {
+		return nil
+	}
+	tok, integer := r.number()
+	if tok == nil {
+		return r.wrongType()
+	}
+	if !integer {
+		return ErrType
+	}
+
+	neg := tok[0] == '-'
+	if neg {
+		tok = tok[1:]
+	}
+	var n uint64
+	for _, c := range tok {
+		if n > (1<<63)/10 {
+			return ErrRange
+		}
+		n = n*10 + uint64(c-'0')
+	}
+	switch {
+	case neg && n <= 1<<63:
+		*p = -int64(n)
+	case !neg && n < 1<<63:
+		*p = int64(n)
+	default:
+		return ErrRange
+	}
+	return nil
+}
+
+// Int64, for an int
+func (r *Reader) Int(p *int) error {
+	var n int64
+	if r.literal("null") {
+		return nil
+	}
+	if err := r.Int64(&n); err != nil {
+		return err
+	}
+	if int64(int(n)) != n {
+		return ErrRange
+	}
+	*p = int(n)
+	return nil
+}
+
+// Read a number into p.  null leaves p as it was.
+func (r *Reader) Float64(p *float64) error {
+	if r.literal("null") {
+		return nil
+	}
+	tok, _ := r.number()
+	if tok == nil {
+		return r.wrongType()
+	}
+	f, err := strconv.ParseFloat(string(tok), 64)
+	if err != nil {
+		return ErrRange
+	}
+	*p = f
+	return nil
+}
+
+// Read true or false into p.  null leaves p as it was.
+func (r *Reader) Bool(p *bool) error {
+	switch {
+	case r.literal("null"):
+	case r.literal("true"):
+		*p = true
+	case r.literal("false"):
+		*p = false
+	default:
+		return r.wrongType()
+	}
+	return nil
+}
+
+// Skip over a value of any type
+func (r *Reader) Skip() error {
+	return r.skip(0)
+}
+
+// ----- helpers
+
+func (r *Reader) skip(depth int) error {
+	if depth > maxDepth {
+		return ErrSyntax
+	}
+	switch r.peek() {
+	case '"':
+		_, err := r.str()
+		return err
+	case '{':
+		r.pos++
+		r.ws()
+		if r.consume('}') {
+			return nil
+		}
+		for {
+			r.ws()
+			if _, err := r.str(); err != nil {
+				return err
+			}
+			r.ws()
+			if !r.consume(':') {
+				return ErrSyntax
+			}
+			r.ws()
+			if err := r.skip(depth + 1); err != nil {
+				return err
+			}
+			r.ws()
+			if r.consume(',') {
+				continue
+			}
+			if r.consume('}') {
+				return nil
+			}
+			return ErrSyntax
+		}
+	case '[':
+		r.pos++
+		r.ws()
+		if r.consume(']') {
+			return nil
+		}
+		for {
+			r.ws()
+			if err := r.skip(depth + 1); err != nil {
+				return err
+			}
+			r.ws()
+			if r.consume(',') {
+				continue
+			}
+			if r.consume(']') {
+				return nil
+			}
+			return ErrSyntax
+		}
+	}
+	if r.literal("true") || r.literal("false") || r.literal("null") {
+		return nil
+	}
+	if tok, _ := r.number(); tok != nil {
+		return nil
+	}
+	return ErrSyntax
+}
+
+// A value of the wrong type is ErrType, or ErrSyntax if it is not a value
+func (r *Reader) wrongType() error {
+	if err := r.Skip(); err != nil {
+		return err
+	}
+	return ErrType
+}
+
+func (r *Reader) ws() {
+	for r.pos < len(r.data) {
+		switch r.data[r.pos] {
+		case ' ', '\t', '\n', '\r':
+			r.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (r *Reader) end() error {
+	r.ws()
+	if r.pos != len(r.data) {
+		return ErrSyntax
+	}
+	return nil
+}
+
+func (r *Reader) peek() byte {
+	if r.pos < len(r.data) {
+		return r.data[r.pos]
+	}
+	return 0
+}
+
+func (r *Reader) consume(c byte) bool {
+	if r.peek() == c {
+		r.pos++
+		return true
+	}
+	return false
+}
+
+func (r *Reader) literal(lit string) bool {
+	if len(r.data)-r.pos >= len(lit) && string(r.data[r.pos:r.pos+len(lit)]) == lit {
+		r.pos += len(lit)
+		return true
+	}
+	return false
+}
+
+// Scan a number, returning nil if there is none, and whether it is an
+// integer
+func (r *Reader) number() (tok []byte, integer bool) {
+	start, i := r.pos, r.pos
+	digits := func() int {
+		n := 0
+		for i < len(r.data) && r.data[i] >= '0' && r.data[i] <= '9' {
+			i++
+			n++
+		}
+		return n
+	}
+
+	if i < len(r.data) && r.data[i] == '-' {
+		i++
+	}
+	if i < len(r.data) && r.data[i] == '0' {
+		i++
+	} else if digits() == 0 {
+		return nil, false
+	}
+	integer = true
+	if i < len(r.data) && r.data[i] == '.' {
+		i++
+		if digits() == 0 {
+			return nil, false
+		}
+		integer = false
+	}
+	if i < len(r.data) && (r.data[i] == 'e' || r.data[i] == 'E') {
+		i++
+		if i < len(r.data) && (r.data[i] == '+' || r.data[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return nil, false
+		}
+		integer = false
+	}
+	r.pos = i
+	return r.data[start:i], integer
+}
+
+// Read a string, returning its contents.  Without escapes or bytes that
+// must be replaced, that is a slice of the input.
+func (r *Reader) str() ([]byte, error) {
+	if !r.consume('"') {
+		return nil, ErrSyntax
+	}
+	start := r.pos
+	for i := start; i < len(r.data); i++ {
+		switch c := r.data[i]; {
+		case c == '"':
+			r.pos = i + 1
+			return r.data[start:i], nil
+		case c == '\\' || c < 0x20 || c >= utf8.RuneSelf:
+			return r.unquote(start)
+		}
+	}
+	return nil, ErrSyntax
+}
+
+// The slow path of str, decoding escapes and replacing invalid UTF-8 with
+// U+FFFD as encoding/json does
+func (r *Reader) unquote(start int) ([]byte, error) {
+	out := make([]byte, 0, len(r.data)-start)
+	for i := start; i < len(r.data); {
+		c := r.data[i]
+		switch {
+		case c == '"':
+			r.pos = i + 1
+			return out, nil
+		case c < 0x20:
+			return nil, ErrSyntax
+		case c < utf8.RuneSelf && c != '\\':
+			out = append(out, c)
+			i++
+		case c >= utf8.RuneSelf:
+			rr, size := utf8.DecodeRune(r.data[i:])
+			out = utf8.AppendRune(out, rr)
+			i += size
+		default:
+			if i+1 >= len(r.data) {
+				return nil, ErrSyntax
+			}
+			i += 2
+			switch r.data[i-1] {
+			case '"', '\\', '/':
+				out = append(out, r.data[i-1])
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'u':
+				rr, ok := hex4(r.data[i:])
+				if !ok {
+					return nil, ErrSyntax
+				}
+				i += 4
+				if utf16.IsSurrogate(rr) {
+					// Must pair with the next escape, or it is replaced
+					lo, ok := rune(0), false
+					if i+6 <= len(r.data) && r.data[i] == '\\' && r.data[i+1] == 'u' {
+						lo, ok = hex4(r.data[i+2:])
+					}
+					if rr = utf16.DecodeRune(rr, lo); ok && rr != utf8.RuneError {
+						i += 6
+					} else {
+						rr = utf8.RuneError
+					}
+				}
+				out = utf8.AppendRune(out, rr)
+			default:
+				return nil, ErrSyntax
+			}
+		}
+	}
+	return nil, ErrSyntax
+}
+
+func hex4(b []byte) (rune, bool) {
+	if len(b) < 4 {
+		return 0, false
+	}
+	var rr rune
+	for _, c := range b[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		rr = rr*16 + rune(c)
+	}
+	return rr, true
+}
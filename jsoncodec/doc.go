@@ -0,0 +1,10 @@
+// Package jsoncodec encodes and decodes JSON without reflection, for the
+// MarshalJSON and UnmarshalJSON methods that cmd/jwtgen generates for claim
+// types.  It covers what claims need: objects of strings, numbers, bools
+// and string arrays.  Output matches encoding/json; on input, keys must
+// match field names exactly rather than case insensitively:
+//
+//	//go:generate go run github.com/dgrijalva/jwt-go/cmd/jwtgen -type MyClaims
+//
+// The functions are not meant to be called by hand, but nothing stops it.
+package jsoncodec
@@ -0,0 +1,124 @@
+package jwe
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// Implements AES CBC with HMAC SHA-2, RFC 7518 section 5.2.  The CEK is
+// the MAC key followed by the encryption key, each KeyBytes/2 long, and the
+// tag is the HMAC truncated to the same length.
+type ContentEncryptionCBCHMAC struct {
+	Name     string
+	Hash     crypto.Hash
+	KeyBytes int
+}
+
+var (
+	EncA128CBCHS256 *ContentEncryptionCBCHMAC
+	EncA192CBCHS384 *ContentEncryptionCBCHMAC
+	EncA256CBCHS512 *ContentEncryptionCBCHMAC
+)
+
+func init() {
+	EncA128CBCHS256 = &ContentEncryptionCBCHMAC{"A128CBC-HS256", crypto.SHA256, 32}
+	RegisterContentEncryption(EncA128CBCHS256.Enc(), func() ContentEncryption {
+		return EncA128CBCHS256
+	})
+
+	EncA192CBCHS384 = &ContentEncryptionCBCHMAC{"A192CBC-HS384", crypto.SHA384, 48}
+	RegisterContentEncryption(EncA192CBCHS384.Enc(), func() ContentEncryption {
+		return EncA192CBCHS384
+	})
+
+	EncA256CBCHS512 = &ContentEncryptionCBCHMAC{"A256CBC-HS512", crypto.SHA512, 64}
+	RegisterContentEncryption(EncA256CBCHS512.Enc(), func() ContentEncryption {
+		return EncA256CBCHS512
+	})
+}
+
+func (e *ContentEncryptionCBCHMAC) Enc() string {
+	return e.Name
+}
+
+func (e *ContentEncryptionCBCHMAC) KeySize() int {
+	return e.KeyBytes
+}
+
+func (e *ContentEncryptionCBCHMAC) Encrypt(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	if len(cek) != e.KeyBytes {
+		return nil, nil, nil, ErrInvalidKey
+	}
+	macKey, encKey := cek[:e.KeyBytes/2], cek[e.KeyBytes/2:]
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err = rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// PKCS #7 padding
+	pad := aes.BlockSize - len(plaintext)%aes.BlockSize
+	ciphertext := make([]byte, len(plaintext)+pad)
+	copy(ciphertext, plaintext)
+	for i := len(plaintext); i < len(ciphertext); i++ {
+		ciphertext[i] = byte(pad)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+
+	return iv, ciphertext, e.tag(macKey, aad, iv, ciphertext), nil
+}
+
+func (e *ContentEncryptionCBCHMAC) Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	if len(cek) != e.KeyBytes {
+		return nil, ErrInvalidKey
+	}
+	macKey, encKey := cek[:e.KeyBytes/2], cek[e.KeyBytes/2:]
+
+	// Check the tag before touching the ciphertext, so there is no padding
+	// oracle
+	if !hmac.Equal(tag, e.tag(macKey, aad, iv, ciphertext)) {
+		return nil, ErrDecryption
+	}
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, ErrDecryption
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	pad := int(plaintext[len(plaintext)-1])
+	if pad == 0 || pad > aes.BlockSize {
+		return nil, ErrDecryption
+	}
+	for _, b := range plaintext[len(plaintext)-pad:] {
+		if subtle.ConstantTimeByteEq(b, byte(pad)) != 1 {
+			return nil, ErrDecryption
+		}
+	}
+	return plaintext[:len(plaintext)-pad], nil
+}
+
+// HMAC over aad, iv, ciphertext and the bit length of aad, truncated
+func (e *ContentEncryptionCBCHMAC) tag(macKey, aad, iv, ciphertext []byte) []byte {
+	mac := hmac.New(e.Hash.New, macKey)
+	mac.Write(aad)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	binary.Write(mac, binary.BigEndian, uint64(len(aad))*8)
+	return mac.Sum(nil)[:e.KeyBytes/2]
+}
@@ -0,0 +1,86 @@
+package jwe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+)
+
+// Implements the AES GCM family of content encryption algorithms
+type ContentEncryptionGCM struct {
+	Name     string
+	KeyBytes int
+}
+
+var (
+	EncA128GCM *ContentEncryptionGCM
+	EncA192GCM *ContentEncryptionGCM
+	EncA256GCM *ContentEncryptionGCM
+)
+
+func init() {
+	EncA128GCM = &ContentEncryptionGCM{"A128GCM", 16}
+	RegisterContentEncryption(EncA128GCM.Enc(), func() ContentEncryption {
+		return EncA128GCM
+	})
+
+	EncA192GCM = &ContentEncryptionGCM{"A192GCM", 24}
+	RegisterContentEncryption(EncA192GCM.Enc(), func() ContentEncryption {
+		return EncA192GCM
+	})
+
+	EncA256GCM = &ContentEncryptionGCM{"A256GCM", 32}
+	RegisterContentEncryption(EncA256GCM.Enc(), func() ContentEncryption {
+		return EncA256GCM
+	})
+}
+
+func (e *ContentEncryptionGCM) Enc() string {
+	return e.Name
+}
+
+func (e *ContentEncryptionGCM) KeySize() int {
+	return e.KeyBytes
+}
+
+func (e *ContentEncryptionGCM) Encrypt(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	aead, err := e.aead(cek)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+	sealed := aead.Seal(nil, iv, plaintext, aad)
+	split := len(sealed) - aead.Overhead()
+	return iv, sealed[:split], sealed[split:], nil
+}
+
+func (e *ContentEncryptionGCM) Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	aead, err := e.aead(cek)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+		return nil, ErrDecryption
+	}
+
+	plaintext, err := aead.Open(nil, iv, append(ciphertext[:len(ciphertext):len(ciphertext)], tag...), aad)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+func (e *ContentEncryptionGCM) aead(cek []byte) (cipher.AEAD, error) {
+	if len(cek) != e.KeyBytes {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
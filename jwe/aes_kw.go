@@ -0,0 +1,156 @@
+package jwe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+)
+
+// Implements the AES Key Wrap family: A128KW, A192KW and A256KW.  The CEK
+// is wrapped with the shared symmetric key, a []byte of KeyBytes bytes.
+type KeyManagementAESKW struct {
+	Name     string
+	KeyBytes int
+}
+
+var (
+	AlgA128KW *KeyManagementAESKW
+	AlgA192KW *KeyManagementAESKW
+	AlgA256KW *KeyManagementAESKW
+)
+
+func init() {
+	AlgA128KW = &KeyManagementAESKW{"A128KW", 16}
+	RegisterKeyManagement(AlgA128KW.Alg(), func() KeyManagement {
+		return AlgA128KW
+	})
+
+	AlgA192KW = &KeyManagementAESKW{"A192KW", 24}
+	RegisterKeyManagement(AlgA192KW.Alg(), func() KeyManagement {
+		return AlgA192KW
+	})
+
+	AlgA256KW = &KeyManagementAESKW{"A256KW", 32}
+	RegisterKeyManagement(AlgA256KW.Alg(), func() KeyManagement {
+		return AlgA256KW
+	})
+}
+
+func (m *KeyManagementAESKW) Alg() string {
+	return m.Name
+}
+
+func (m *KeyManagementAESKW) WrapKey(cekSize int, key interface{}, header map[string]interface{}) ([]byte, []byte, error) {
+	return wrapNewCEK(m, cekSize, key, header)
+}
+
+func (m *KeyManagementAESKW) WrapCEK(cek []byte, key interface{}, header map[string]interface{}) ([]byte, error) {
+	kek, ok := key.([]byte)
+	if !ok || len(kek) != m.KeyBytes {
+		return nil, ErrInvalidKey
+	}
+	return keyWrap(kek, cek)
+}
+
+func (m *KeyManagementAESKW) UnwrapKey(encryptedKey []byte, cekSize int, key interface{}, header map[string]interface{}) ([]byte, error) {
+	kek, ok := key.([]byte)
+	if !ok || len(kek) != m.KeyBytes {
+		return nil, ErrInvalidKey
+	}
+	return unwrapCEK(encryptedKey, cekSize, kek)
+}
+
+// ----- helpers
+
+// Generate a random CEK and wrap it with m
+func wrapNewCEK(m KeyWrapper, cekSize int, key interface{}, header map[string]interface{}) ([]byte, []byte, error) {
+	cek := make([]byte, cekSize)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := m.WrapCEK(cek, key, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cek, wrapped, nil
+}
+
+// Unwrap a CEK and check its size
+func unwrapCEK(wrapped []byte, cekSize int, kek []byte) ([]byte, error) {
+	cek, err := keyUnwrap(kek, wrapped)
+	if err != nil || len(cek) != cekSize {
+		return nil, ErrDecryption
+	}
+	return cek, nil
+}
+
+// The RFC 3394 default initial value
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// AES Key Wrap, RFC 3394 section 2.2.1
+func keyWrap(kek, plaintext []byte) ([]byte, error) {
+	if len(plaintext)%8 != 0 || len(plaintext) < 16 {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	copy(out, keyWrapIV)
+	copy(out[8:], plaintext)
+
+	var b [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b[:8], out[:8])
+			copy(b[8:], out[8*i:8*i+8])
+			block.Encrypt(b[:], b[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(b[:8])^t)
+			copy(out[8*i:], b[8:])
+		}
+	}
+	return out, nil
+}
+
+// AES Key Unwrap, RFC 3394 section 2.2.2, with the integrity check
+func keyUnwrap(kek, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext)%8 != 0 || len(ciphertext) < 24 {
+		return nil, ErrDecryption
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return unwrapBlocks(block, ciphertext)
+}
+
+func unwrapBlocks(block cipher.Block, ciphertext []byte) ([]byte, error) {
+	n := len(ciphertext)/8 - 1
+	a := make([]byte, 8)
+	copy(a, ciphertext[:8])
+	r := make([]byte, len(ciphertext)-8)
+	copy(r, ciphertext[8:])
+
+	var b [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], r[8*(i-1):8*i])
+			block.Decrypt(b[:], b[:])
+			copy(a, b[:8])
+			copy(r[8*(i-1):], b[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, ErrDecryption
+	}
+	return r, nil
+}
@@ -0,0 +1,87 @@
+package jwe
+
+import (
+	"sync"
+)
+
+var keyManagements = map[string]func() KeyManagement{}
+var contentEncryptions = map[string]func() ContentEncryption{}
+var algorithmLock = new(sync.RWMutex)
+
+// Implement KeyManagement to add new "alg" algorithms for delivering the
+// content encryption key (CEK) to the recipient
+type KeyManagement interface {
+	Alg() string // returns the alg identifier for this algorithm (example: 'RSA-OAEP')
+
+	// Returns a CEK of cekSize bytes and its encrypted form for the
+	// recipient's key.  Parameters the recipient needs, such as an
+	// ephemeral public key, are added to header.
+	WrapKey(cekSize int, key interface{}, header map[string]interface{}) (cek, encryptedKey []byte, err error)
+
+	// Recovers the CEK from encryptedKey and header with the recipient's key
+	UnwrapKey(encryptedKey []byte, cekSize int, key interface{}, header map[string]interface{}) ([]byte, error)
+}
+
+// Implemented by key management algorithms that encrypt a CEK chosen by
+// the caller rather than deriving one.  Only these can be used to encrypt
+// for several recipients at once.
+type KeyWrapper interface {
+	KeyManagement
+
+	// Returns cek encrypted for the recipient's key, adding parameters the
+	// recipient needs to header
+	WrapCEK(cek []byte, key interface{}, header map[string]interface{}) ([]byte, error)
+}
+
+// Implement ContentEncryption to add new "enc" algorithms for encrypting
+// the payload
+type ContentEncryption interface {
+	Enc() string  // returns the enc identifier for this algorithm (example: 'A256GCM')
+	KeySize() int // size of the CEK in bytes
+
+	// Encrypt and integrity protect plaintext and aad with cek
+	Encrypt(cek, plaintext, aad []byte) (iv, ciphertext, tag []byte, err error)
+
+	// Decrypt ciphertext, checking tag covers it and aad
+	Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error)
+}
+
+// Register the "alg" name and a factory function for a key management
+// algorithm.  This is typically done during init() in its implementation
+func RegisterKeyManagement(alg string, f func() KeyManagement) {
+	algorithmLock.Lock()
+	defer algorithmLock.Unlock()
+
+	keyManagements[alg] = f
+}
+
+// Get a key management algorithm from an "alg" string
+func GetKeyManagement(alg string) (km KeyManagement) {
+	algorithmLock.RLock()
+	defer algorithmLock.RUnlock()
+
+	if f, ok := keyManagements[alg]; ok {
+		km = f()
+	}
+	return
+}
+
+// Register the "enc" name and a factory function for a content encryption
+// algorithm.  This is typically done during init() in its implementation
+func RegisterContentEncryption(enc string, f func() ContentEncryption) {
+	algorithmLock.Lock()
+	defer algorithmLock.Unlock()
+
+	contentEncryptions[enc] = f
+}
+
+// Get a content encryption algorithm from an "enc" string
+func GetContentEncryption(enc string) (ce ContentEncryption) {
+	algorithmLock.RLock()
+	defer algorithmLock.RUnlock()
+
+	if f, ok := contentEncryptions[enc]; ok {
+		ce = f()
+	}
+	return
+}
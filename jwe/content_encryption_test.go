@@ -0,0 +1,61 @@
+package jwe
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestContentEncryption(t *testing.T) {
+	contentEncryptionTestData := []ContentEncryption{
+		EncA128GCM, EncA192GCM, EncA256GCM,
+		EncA128CBCHS256, EncA192CBCHS384, EncA256CBCHS512,
+	}
+
+	for _, enc := range contentEncryptionTestData {
+		cek := bytes.Repeat([]byte{3}, enc.KeySize())
+		for _, plaintext := range [][]byte{nil, []byte("x"), bytes.Repeat([]byte("sixteen bytes!!!"), 4)} {
+			s, err := Encrypt(plaintext, AlgDir, enc, cek, nil)
+			if err != nil {
+				t.Errorf("[%v] Error encrypting: %v", enc.Enc(), err)
+				continue
+			}
+			msg, err := Decrypt(s, staticKey(cek))
+			if err != nil || !bytes.Equal(msg.Plaintext, plaintext) {
+				t.Errorf("[%v] Error decrypting %d bytes: %v", enc.Enc(), len(plaintext), err)
+			}
+		}
+
+		// Every bit of the tag counts
+		iv, ciphertext, tag, _ := enc.Encrypt(cek, []byte("secret"), []byte("aad"))
+		for i := range tag {
+			tag[i] ^= 0x80
+			if _, err := enc.Decrypt(cek, iv, ciphertext, tag, []byte("aad")); err != ErrDecryption {
+				t.Errorf("[%v] Expected a modified tag to fail.  Got %v", enc.Enc(), err)
+			}
+			tag[i] ^= 0x80
+		}
+		if _, err := enc.Decrypt(cek, iv, ciphertext, tag, []byte("other")); err != ErrDecryption {
+			t.Errorf("[%v] Expected modified aad to fail.  Got %v", enc.Enc(), err)
+		}
+	}
+}
+
+func TestCBCHMAC_vector(t *testing.T) {
+	// RFC 7518 appendix B.1
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	iv, _ := hex.DecodeString("1af38c2dc2b96ffdd86694092341bc04")
+	ciphertext, _ := hex.DecodeString("c80edfa32ddf39d5ef00c0b468834279a2e46a1b8049f792f76bfe54b903a9c9" +
+		"a94ac9b47ad2655c5f10f9aef71427e2fc6f9b3f399a221489f16362c703233" +
+		"609d45ac69864e3321cf82935ac4096c86e133314c54019e8ca7980dfa4b9cf1" +
+		"b384c486f3a54c51078158ee5d79de59fbd34d848b3d69550a67646344427ade" +
+		"54b8851ffb598f7f80074b9473c82e2db")
+	aad, _ := hex.DecodeString("546865207365636f6e64207072696e6369706c65206f66204175677573746520" +
+		"4b6572636b686f666673")
+	tag, _ := hex.DecodeString("652c3fa36b0a7c5b3219fab3a30bc1c4")
+
+	plaintext, err := EncA128CBCHS256.Decrypt(key, iv, ciphertext, tag, aad)
+	if err != nil || !bytes.HasPrefix(plaintext, []byte("A cipher system must not be required to be secret")) {
+		t.Errorf("Error decrypting RFC 7518 test vector: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package jwe
+
+import (
+	"crypto/subtle"
+)
+
+// Implements "dir": the shared symmetric key is used as the CEK directly.
+// Expects a []byte of the size the content encryption algorithm requires.
+type KeyManagementDirect struct{}
+
+var AlgDir *KeyManagementDirect
+
+func init() {
+	AlgDir = &KeyManagementDirect{}
+	RegisterKeyManagement(AlgDir.Alg(), func() KeyManagement {
+		return AlgDir
+	})
+}
+
+func (m *KeyManagementDirect) Alg() string {
+	return "dir"
+}
+
+func (m *KeyManagementDirect) WrapKey(cekSize int, key interface{}, header map[string]interface{}) ([]byte, []byte, error) {
+	cek, ok := key.([]byte)
+	if !ok || len(cek) != cekSize {
+		return nil, nil, ErrInvalidKey
+	}
+	return cek, nil, nil
+}
+
+func (m *KeyManagementDirect) UnwrapKey(encryptedKey []byte, cekSize int, key interface{}, header map[string]interface{}) ([]byte, error) {
+	cek, ok := key.([]byte)
+	if !ok || len(cek) != cekSize {
+		return nil, ErrInvalidKey
+	}
+	// The encrypted key must be empty, RFC 7516 section 5.2
+	if subtle.ConstantTimeEq(int32(len(encryptedKey)), 0) != 1 {
+		return nil, ErrDecryption
+	}
+	return cek, nil
+}
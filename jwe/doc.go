@@ -0,0 +1,20 @@
+// Package jwe implements JSON Web Encryption, RFC 7516, for carrying
+// confidential claims in encrypted tokens.
+//
+// Encrypt takes a key management algorithm ("alg"), which protects the
+// content encryption key for the recipient, and a content encryption
+// algorithm ("enc"), which encrypts the payload with that key:
+//
+//	s, err := jwe.Encrypt(plaintext, jwe.AlgDir, jwe.EncA256GCM, key, nil)
+//	msg, err := jwe.Decrypt(s, func(map[string]interface{}) (interface{}, error) { return key, nil })
+//
+// EncryptJSON uses the JSON serialization instead, which can encrypt the
+// same content for several recipients, each with its own key:
+//
+//	data, err := jwe.EncryptJSON(plaintext, jwe.EncA256GCM, nil,
+//		jwe.Recipient{Alg: jwe.AlgRSAOAEP256, Key: regulatorKey},
+//		jwe.Recipient{Alg: jwe.AlgA256KW, Key: archiveKey})
+//
+// As with signing methods in package jwt, further algorithms can be added
+// with RegisterKeyManagement and RegisterContentEncryption.
+package jwe
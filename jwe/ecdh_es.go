@@ -0,0 +1,212 @@
+package jwe
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Implements ECDH-ES and ECDH-ES+A128KW and company.  Expects
+// *ecdsa.PublicKey for encrypting and *ecdsa.PrivateKey for decrypting.
+// With KeyBytes of 0 the agreed key is the CEK itself, otherwise it wraps
+// a random CEK.  Set apu and apv in the header passed to Encrypt to bind
+// the key to the parties.
+type KeyManagementECDHES struct {
+	Name     string
+	KeyBytes int
+}
+
+var (
+	AlgECDHES       *KeyManagementECDHES
+	AlgECDHESA128KW *KeyManagementECDHES
+	AlgECDHESA192KW *KeyManagementECDHES
+	AlgECDHESA256KW *KeyManagementECDHES
+)
+
+func init() {
+	AlgECDHES = &KeyManagementECDHES{"ECDH-ES", 0}
+	RegisterKeyManagement(AlgECDHES.Alg(), func() KeyManagement {
+		return AlgECDHES
+	})
+
+	AlgECDHESA128KW = &KeyManagementECDHES{"ECDH-ES+A128KW", 16}
+	RegisterKeyManagement(AlgECDHESA128KW.Alg(), func() KeyManagement {
+		return AlgECDHESA128KW
+	})
+
+	AlgECDHESA192KW = &KeyManagementECDHES{"ECDH-ES+A192KW", 24}
+	RegisterKeyManagement(AlgECDHESA192KW.Alg(), func() KeyManagement {
+		return AlgECDHESA192KW
+	})
+
+	AlgECDHESA256KW = &KeyManagementECDHES{"ECDH-ES+A256KW", 32}
+	RegisterKeyManagement(AlgECDHESA256KW.Alg(), func() KeyManagement {
+		return AlgECDHESA256KW
+	})
+}
+
+func (m *KeyManagementECDHES) Alg() string {
+	return m.Name
+}
+
+func (m *KeyManagementECDHES) WrapKey(cekSize int, key interface{}, header map[string]interface{}) ([]byte, []byte, error) {
+	if m.KeyBytes != 0 {
+		return wrapNewCEK(m, cekSize, key, header)
+	}
+	agreed, err := m.agree(cekSize, key, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return agreed, nil, nil
+}
+
+// Fails with ErrUnsupported for ECDH-ES, which cannot carry a chosen CEK
+func (m *KeyManagementECDHES) WrapCEK(cek []byte, key interface{}, header map[string]interface{}) ([]byte, error) {
+	if m.KeyBytes == 0 {
+		return nil, ErrUnsupported
+	}
+	kek, err := m.agree(len(cek), key, header)
+	if err != nil {
+		return nil, err
+	}
+	return keyWrap(kek, cek)
+}
+
+// Agree on a key with the recipient's public key through a fresh
+// ephemeral key, which is added to header as epk
+func (m *KeyManagementECDHES) agree(cekSize int, key interface{}, header map[string]interface{}) ([]byte, error) {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	remote, err := pub.ECDH()
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	eph, err := ecdsa.GenerateKey(pub.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	local, err := eph.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	z, err := local.ECDH(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	epk, err := jwt.NewJSONWebKey(&eph.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	header["epk"] = epk
+
+	return m.deriveKey(z, cekSize, header)
+}
+
+func (m *KeyManagementECDHES) UnwrapKey(encryptedKey []byte, cekSize int, key interface{}, header map[string]interface{}) ([]byte, error) {
+	priv, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	local, err := priv.ECDH()
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	// Points off the curve are rejected by ECDH, which stops invalid
+	// curve attacks
+	epk, err := headerPublicKey(header["epk"])
+	if err != nil || epk.Curve != priv.Curve {
+		return nil, ErrMalformed
+	}
+	remote, err := epk.ECDH()
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	z, err := local.ECDH(remote)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	agreed, err := m.deriveKey(z, cekSize, header)
+	if err != nil {
+		return nil, err
+	}
+	if m.KeyBytes == 0 {
+		if len(encryptedKey) != 0 {
+			return nil, ErrDecryption
+		}
+		return agreed, nil
+	}
+	return unwrapCEK(encryptedKey, cekSize, agreed)
+}
+
+// Derive the key from the shared secret z.  For ECDH-ES it is the CEK for
+// enc, otherwise the key wrapping key for alg.
+func (m *KeyManagementECDHES) deriveKey(z []byte, cekSize int, header map[string]interface{}) ([]byte, error) {
+	algID, size := m.Name, m.KeyBytes
+	if size == 0 {
+		algID, _ = header["enc"].(string)
+		size = cekSize
+	}
+
+	var party [2][]byte
+	for i, name := range []string{"apu", "apv"} {
+		if v, ok := header[name].(string); ok {
+			var err error
+			if party[i], err = jwt.DecodeSegment(v); err != nil {
+				return nil, ErrMalformed
+			}
+		}
+	}
+	return concatKDF(z, []byte(algID), party[0], party[1], size), nil
+}
+
+// Decode the epk header parameter
+func headerPublicKey(v interface{}) (*ecdsa.PublicKey, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := jwt.ParseJWK(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwk.Key()
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrMalformed
+	}
+	return pub, nil
+}
+
+// The Concat KDF of NIST SP 800-56A with SHA-256, as profiled by RFC 7518
+// section 4.6.2
+func concatKDF(z, algID, apu, apv []byte, size int) []byte {
+	var otherInfo []byte
+	for _, field := range [][]byte{algID, apu, apv} {
+		otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(field)))
+		otherInfo = append(otherInfo, field...)
+	}
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(size*8))
+
+	var out []byte
+	for counter := uint32(1); len(out) < size; counter++ {
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, counter)
+		h.Write(z)
+		h.Write(otherInfo)
+		out = h.Sum(out)
+	}
+	return out[:size]
+}
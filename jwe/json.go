@@ -0,0 +1,201 @@
+package jwe
+
+import (
+	"crypto/rand"
+	"encoding/json"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// A token in the JWE JSON Serialization, RFC 7516 section 7.2.  Unlike the
+// compact form, the content can be encrypted for several recipients, each
+// with its own encrypted copy of the CEK.
+type JSONWebEncryption struct {
+	Protected   string                 `json:"protected,omitempty"`
+	Unprotected map[string]interface{} `json:"unprotected,omitempty"`
+	Recipients  []JSONRecipient        `json:"recipients"`
+	AAD         string                 `json:"aad,omitempty"`
+	IV          string                 `json:"iv"`
+	Ciphertext  string                 `json:"ciphertext"`
+	Tag         string                 `json:"tag"`
+}
+
+// Also accepts the flattened serialization of RFC 7516 section 7.2.2,
+// which has a single recipient at the top level
+func (jwe *JSONWebEncryption) UnmarshalJSON(data []byte) error {
+	type general JSONWebEncryption
+	var raw struct {
+		general
+		JSONRecipient
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*jwe = JSONWebEncryption(raw.general)
+	if raw.Recipients == nil && (raw.Header != nil || raw.EncryptedKey != "") {
+		jwe.Recipients = []JSONRecipient{raw.JSONRecipient}
+	}
+	return nil
+}
+
+// One recipient of a JSONWebEncryption.  Header holds the recipient's
+// parameters, such as alg and kid, which are not integrity protected.
+type JSONRecipient struct {
+	Header       map[string]interface{} `json:"header,omitempty"`
+	EncryptedKey string                 `json:"encrypted_key,omitempty"`
+}
+
+// A key to encrypt for, for EncryptJSON
+type Recipient struct {
+	Alg    KeyManagement
+	Key    interface{}
+	Header map[string]interface{} // Added to the recipient's header, e.g. kid
+}
+
+// Encrypt plaintext for each of recipients and return the general JWE JSON
+// Serialization.  header is the protected header shared by all of them and
+// may add parameters such as cty or zip.  With more than one recipient, every alg
+// must be a KeyWrapper, so dir and ECDH-ES cannot be used.
+func EncryptJSON(plaintext []byte, enc ContentEncryption, header map[string]interface{}, recipients ...Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	h := map[string]interface{}{}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["enc"] = enc.Enc()
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	jwe := &JSONWebEncryption{Protected: jwt.EncodeSegment(headerJSON)}
+
+	var cek []byte
+	if len(recipients) > 1 {
+		cek = make([]byte, enc.KeySize())
+		if _, err := rand.Read(cek); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range recipients {
+		rh := map[string]interface{}{}
+		for k, v := range r.Header {
+			rh[k] = v
+		}
+		rh["alg"] = r.Alg.Alg()
+		for k := range rh {
+			if _, ok := h[k]; ok {
+				// RFC 7516 section 7.2.1 requires the headers to be disjoint
+				return nil, ErrMalformed
+			}
+		}
+
+		var encryptedKey []byte
+		if cek == nil {
+			cek, encryptedKey, err = r.Alg.WrapKey(enc.KeySize(), r.Key, rh)
+		} else if w, ok := r.Alg.(KeyWrapper); ok {
+			encryptedKey, err = w.WrapCEK(cek, r.Key, rh)
+		} else {
+			err = ErrUnsupported
+		}
+		if err != nil {
+			return nil, err
+		}
+		jwe.Recipients = append(jwe.Recipients, JSONRecipient{rh, jwt.EncodeSegment(encryptedKey)})
+	}
+
+	compressed, err := compress(h, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	iv, ciphertext, tag, err := enc.Encrypt(cek, compressed, []byte(jwe.Protected))
+	if err != nil {
+		return nil, err
+	}
+	jwe.IV = jwt.EncodeSegment(iv)
+	jwe.Ciphertext = jwt.EncodeSegment(ciphertext)
+	jwe.Tag = jwt.EncodeSegment(tag)
+
+	return json.Marshal(jwe)
+}
+
+// Decrypt a token in the general or flattened JWE JSON Serialization with
+// the key from keyFunc.  Each recipient is tried in turn, with keyFunc
+// seeing the protected, shared and recipient headers merged; it can return
+// an error to skip a recipient whose kid is not its own.  The returned
+// Message carries the merged header of the recipient that decrypted.
+func DecryptJSON(data []byte, keyFunc Keyfunc) (*Message, error) {
+	return new(Decrypter).DecryptJSON(data, keyFunc)
+}
+
+func (d *Decrypter) DecryptJSON(data []byte, keyFunc Keyfunc) (*Message, error) {
+	jwe := new(JSONWebEncryption)
+	if err := json.Unmarshal(data, jwe); err != nil || len(jwe.Recipients) == 0 {
+		return nil, ErrMalformed
+	}
+
+	var segments [3][]byte
+	for i, seg := range []string{jwe.IV, jwe.Ciphertext, jwe.Tag} {
+		var err error
+		if segments[i], err = jwt.DecodeSegment(seg); err != nil {
+			return nil, ErrMalformed
+		}
+	}
+
+	protected := map[string]interface{}{}
+	if jwe.Protected != "" {
+		var err error
+		if protected, err = decodeHeader(jwe.Protected); err != nil {
+			return nil, err
+		}
+	}
+
+	// RFC 7516 section 5.2, step 14
+	aad := []byte(jwe.Protected)
+	if jwe.AAD != "" {
+		aad = append(aad, '.')
+		aad = append(aad, jwe.AAD...)
+	}
+
+	var firstErr error
+	for _, r := range jwe.Recipients {
+		header, err := mergeHeaders(protected, jwe.Unprotected, r.Header)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := protected["zip"]; !ok && header["zip"] != nil {
+			// zip must be integrity protected, RFC 7516 section 4.1.3
+			return nil, ErrMalformed
+		}
+		encryptedKey, err := jwt.DecodeSegment(r.EncryptedKey)
+		if err != nil {
+			return nil, ErrMalformed
+		}
+
+		plaintext, err := d.decrypt(header, aad, encryptedKey, segments[0], segments[1], segments[2], keyFunc)
+		if err == nil {
+			return &Message{header, plaintext}, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// Union of headers, which must not share a parameter
+func mergeHeaders(headers ...map[string]interface{}) (map[string]interface{}, error) {
+	merged := map[string]interface{}{}
+	for _, h := range headers {
+		for k, v := range h {
+			if _, ok := merged[k]; ok {
+				return nil, ErrMalformed
+			}
+			merged[k] = v
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,107 @@
+package jwe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestEncryptJSON(t *testing.T) {
+	regulator := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	auditor, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	plaintext := []byte(`{"sub":"audit-42"}`)
+
+	data, err := EncryptJSON(plaintext, EncA256GCM, map[string]interface{}{"cty": "JWT"},
+		Recipient{AlgRSAOAEP256, &regulator.PublicKey, map[string]interface{}{"kid": "regulator"}},
+		Recipient{AlgA256KW, sampleKey, map[string]interface{}{"kid": "archive"}},
+		Recipient{AlgECDHESA128KW, &auditor.PublicKey, map[string]interface{}{"kid": "auditor"}},
+	)
+	if err != nil {
+		t.Fatalf("Error encrypting: %v", err)
+	}
+
+	keys := map[string]interface{}{"regulator": regulator, "archive": sampleKey, "auditor": auditor}
+	for kid, key := range keys {
+		// Each service only holds its own key
+		mine := func(header map[string]interface{}) (interface{}, error) {
+			if header["kid"] != kid {
+				return nil, errors.New("not my key")
+			}
+			return key, nil
+		}
+		msg, err := DecryptJSON(data, mine)
+		if err != nil {
+			t.Errorf("[%v] Error decrypting: %v", kid, err)
+			continue
+		}
+		if string(msg.Plaintext) != string(plaintext) || msg.Header["kid"] != kid || msg.Header["cty"] != "JWT" {
+			t.Errorf("[%v] Unexpected message %v %s", kid, msg.Header, msg.Plaintext)
+		}
+	}
+
+	// Only the protected header is covered by the tag
+	var jwe JSONWebEncryption
+	json.Unmarshal(data, &jwe)
+	jwe.Protected = encodeHeader(`{"enc":"A256GCM","cty":"JOSE"}`)
+	tampered, _ := json.Marshal(jwe)
+	if _, err := DecryptJSON(tampered, staticKey(sampleKey)); err == nil {
+		t.Errorf("Expected tampered protected header to fail")
+	}
+}
+
+func TestEncryptJSON_single(t *testing.T) {
+	// dir, with one recipient, uses the flattened form
+	data, err := EncryptJSON([]byte("secret"), EncA128CBCHS256, nil, Recipient{Alg: AlgDir, Key: sampleKey})
+	if err != nil {
+		t.Fatalf("Error encrypting: %v", err)
+	}
+	var jwe map[string]interface{}
+	json.Unmarshal(data, &jwe)
+	recipient := jwe["recipients"].([]interface{})[0].(map[string]interface{})
+	delete(jwe, "recipients")
+	jwe["header"] = recipient["header"]
+	flattened, _ := json.Marshal(jwe)
+
+	msg, err := DecryptJSON(flattened, staticKey(sampleKey))
+	if err != nil || string(msg.Plaintext) != "secret" {
+		t.Errorf("Error decrypting flattened form: %v", err)
+	}
+}
+
+func TestEncryptJSON_errors(t *testing.T) {
+	var encryptJSONErrorTestData = []struct {
+		name       string
+		header     map[string]interface{}
+		recipients []Recipient
+		err        error
+	}{
+		{"no recipients", nil, nil, ErrInvalidKey},
+		{"dir", nil, []Recipient{{Alg: AlgA256KW, Key: sampleKey}, {Alg: AlgDir, Key: sampleKey}}, ErrUnsupported},
+		{"ECDH-ES", nil, []Recipient{{Alg: AlgA256KW, Key: sampleKey}, {Alg: AlgECDHES, Key: sampleKey}}, ErrUnsupported},
+		{"disjoint", map[string]interface{}{"kid": "k1"}, []Recipient{{AlgA256KW, sampleKey, map[string]interface{}{"kid": "k1"}}}, ErrMalformed},
+	}
+
+	for _, data := range encryptJSONErrorTestData {
+		if _, err := EncryptJSON([]byte("secret"), EncA256GCM, data.header, data.recipients...); err != data.err {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
+
+func TestDecryptJSON_zip(t *testing.T) {
+	data, _ := EncryptJSON([]byte("secret"), EncA256GCM, map[string]interface{}{"zip": "DEF"}, Recipient{Alg: AlgA256KW, Key: sampleKey})
+	if msg, err := DecryptJSON(data, staticKey(sampleKey)); err != nil || string(msg.Plaintext) != "secret" {
+		t.Errorf("Error decrypting: %v", err)
+	}
+
+	// zip outside the protected header could be stripped or added
+	data, _ = EncryptJSON([]byte("secret"), EncA256GCM, nil, Recipient{AlgA256KW, sampleKey, map[string]interface{}{"zip": "DEF"}})
+	if _, err := DecryptJSON(data, staticKey(sampleKey)); err != ErrMalformed {
+		t.Errorf("Expected %v.  Got %v", ErrMalformed, err)
+	}
+}
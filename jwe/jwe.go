@@ -0,0 +1,204 @@
+package jwe
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Errors
+var (
+	ErrMalformed            = errors.New("jwe: token is malformed")
+	ErrUnsupported          = errors.New("jwe: alg or enc is not supported")
+	ErrInvalidKey           = errors.New("jwe: key is invalid for the algorithm")
+	ErrDecryption           = errors.New("jwe: decryption failed")
+	ErrCriticalHeader       = errors.New("jwe: token has a critical header that is not supported")
+	ErrAlgorithmRefused     = errors.New("jwe: alg or enc is not allowed")
+	ErrDecompressedTooLarge = errors.New("jwe: decompressed payload exceeds the size limit")
+	ErrNotNested            = errors.New("jwe: token does not carry a signed JWT")
+)
+
+// Supplies the key for decrypting, given the protected header of the
+// token.  Like jwt.Keyfunc, it can use kid or alg to choose.
+type Keyfunc func(header map[string]interface{}) (interface{}, error)
+
+// A decrypted JWE
+type Message struct {
+	Header    map[string]interface{} // The protected header, with the unprotected ones for JSON
+	Plaintext []byte
+}
+
+// Encrypt plaintext for the recipient holding key, returning the compact
+// serialization.  header may add parameters such as kid or cty, or zip
+// set to "DEF" to compress plaintext before encrypting it.
+func Encrypt(plaintext []byte, alg KeyManagement, enc ContentEncryption, key interface{}, header map[string]interface{}) (string, error) {
+	h := map[string]interface{}{}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["alg"] = alg.Alg()
+	h["enc"] = enc.Enc()
+
+	cek, encryptedKey, err := alg.WrapKey(enc.KeySize(), key, h)
+	if err != nil {
+		return "", err
+	}
+
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	protected := jwt.EncodeSegment(headerJSON)
+
+	compressed, err := compress(h, plaintext)
+	if err != nil {
+		return "", err
+	}
+	iv, ciphertext, tag, err := enc.Encrypt(cek, compressed, []byte(protected))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		protected,
+		jwt.EncodeSegment(encryptedKey),
+		jwt.EncodeSegment(iv),
+		jwt.EncodeSegment(ciphertext),
+		jwt.EncodeSegment(tag),
+	}, "."), nil
+}
+
+// Decrypts tokens.  The zero value accepts every registered algorithm but
+// PBES2, which is accepted only when listed in Algorithms.
+type Decrypter struct {
+	Algorithms      []string // If populated, only these alg values are accepted
+	Encryptions     []string // If populated, only these enc values are accepted
+	CriticalHeaders []string // Header extensions the application understands
+
+	// Compressed payloads that decompress to more bytes are rejected.
+	// Defaults to DefaultMaxDecompressedSize, negative for no limit.
+	MaxDecompressedSize int
+
+	// Parses the signed token inside, for DecryptAndVerify.  Defaults to
+	// a zero jwt.Parser.
+	Parser *jwt.Parser
+}
+
+// Decrypt a token in the compact serialization with the key from keyFunc
+func Decrypt(token string, keyFunc Keyfunc) (*Message, error) {
+	return new(Decrypter).Decrypt(token, keyFunc)
+}
+
+func (d *Decrypter) Decrypt(token string, keyFunc Keyfunc) (*Message, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return nil, ErrMalformed
+	}
+
+	var segments [4][]byte
+	for i := range segments {
+		var err error
+		if segments[i], err = jwt.DecodeSegment(parts[i+1]); err != nil {
+			return nil, ErrMalformed
+		}
+	}
+
+	header, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := d.decrypt(header, []byte(parts[0]), segments[0], segments[1], segments[2], segments[3], keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{header, plaintext}, nil
+}
+
+// Decrypt with the algorithms named in header, after checking they are
+// allowed.  aad is the encoded protected header.
+func (d *Decrypter) decrypt(header map[string]interface{}, aad, encryptedKey, iv, ciphertext, tag []byte, keyFunc Keyfunc) ([]byte, error) {
+	algName, _ := header["alg"].(string)
+	encName, _ := header["enc"].(string)
+	if !allowed(d.Algorithms, algName) || !allowed(d.Encryptions, encName) {
+		return nil, ErrAlgorithmRefused
+	}
+	alg, enc := GetKeyManagement(algName), GetContentEncryption(encName)
+	if alg == nil || enc == nil {
+		return nil, ErrUnsupported
+	}
+	if _, pbes2 := alg.(*KeyManagementPBES2); pbes2 && !contains(d.Algorithms, algName) {
+		return nil, ErrAlgorithmRefused
+	}
+	if err := checkCritical(header, d.CriticalHeaders); err != nil {
+		return nil, err
+	}
+
+	if keyFunc == nil {
+		return nil, ErrInvalidKey
+	}
+	key, err := keyFunc(header)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := alg.UnwrapKey(encryptedKey, enc.KeySize(), key, header)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := enc.Decrypt(cek, iv, ciphertext, tag, aad)
+	if err != nil {
+		return nil, err
+	}
+	return d.decompress(header, plaintext)
+}
+
+// ----- helpers
+
+func decodeHeader(seg string) (map[string]interface{}, error) {
+	headerJSON, err := jwt.DecodeSegment(seg)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var header map[string]interface{}
+	if err = json.Unmarshal(headerJSON, &header); err != nil || header == nil {
+		return nil, ErrMalformed
+	}
+	return header, nil
+}
+
+func allowed(list []string, name string) bool {
+	if list == nil {
+		return true
+	}
+	return contains(list, name)
+}
+
+func contains(list []string, name string) bool {
+	for _, l := range list {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Unknown extensions listed in crit must be rejected, RFC 7516 section 4.1.13
+func checkCritical(header map[string]interface{}, understood []string) error {
+	v, ok := header["crit"]
+	if !ok {
+		return nil
+	}
+	crit, ok := v.([]interface{})
+	if !ok || len(crit) == 0 {
+		return ErrMalformed
+	}
+	for _, c := range crit {
+		name, _ := c.(string)
+		if _, ok := header[name]; !ok || !contains(understood, name) {
+			return ErrCriticalHeader
+		}
+	}
+	return nil
+}
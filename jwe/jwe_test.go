@@ -0,0 +1,123 @@
+package jwe
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var sampleKey = bytes.Repeat([]byte{7}, 32)
+
+func staticKey(key interface{}) Keyfunc {
+	return func(map[string]interface{}) (interface{}, error) { return key, nil }
+}
+
+func TestEncrypt(t *testing.T) {
+	plaintext := []byte(`{"ssn":"078-05-1120"}`)
+
+	s, err := Encrypt(plaintext, AlgDir, EncA256GCM, sampleKey, map[string]interface{}{"kid": "k1", "cty": "JWT"})
+	if err != nil {
+		t.Fatalf("Error encrypting: %v", err)
+	}
+	if parts := strings.Split(s, "."); len(parts) != 5 || parts[1] != "" {
+		t.Fatalf("Expected compact serialization with an empty encrypted key.  Got %v", s)
+	}
+	if strings.Contains(s, "078-05") {
+		t.Errorf("Plaintext visible in token")
+	}
+
+	msg, err := Decrypt(s, staticKey(sampleKey))
+	if err != nil {
+		t.Fatalf("Error decrypting: %v", err)
+	}
+	if !bytes.Equal(msg.Plaintext, plaintext) || msg.Header["kid"] != "k1" || msg.Header["enc"] != "A256GCM" {
+		t.Errorf("Unexpected message: %+v", msg)
+	}
+}
+
+var decryptErrorTestData = []struct {
+	name   string
+	mangle func(parts []string) []string
+	d      *Decrypter
+	key    interface{}
+	err    error
+}{
+	{"wrong key", nil, nil, bytes.Repeat([]byte{8}, 32), ErrDecryption},
+	{"short key", nil, nil, []byte("short"), ErrInvalidKey},
+	{"tampered ciphertext", func(p []string) []string { p[3] = "AAAA" + p[3][4:]; return p }, nil, sampleKey, ErrDecryption},
+	{"tampered header", func(p []string) []string { p[0] = encodeHeader(`{"alg":"dir","enc":"A256GCM","kid":"x"}`); return p }, nil, sampleKey, ErrDecryption},
+	{"unknown enc", func(p []string) []string { p[0] = encodeHeader(`{"alg":"dir","enc":"A1GCM"}`); return p }, nil, sampleKey, ErrUnsupported},
+	{"refused alg", nil, &Decrypter{Algorithms: []string{"RSA-OAEP"}}, sampleKey, ErrAlgorithmRefused},
+	{"critical", func(p []string) []string {
+		p[0] = encodeHeader(`{"alg":"dir","enc":"A256GCM","crit":["x"],"x":1}`)
+		return p
+	}, nil, sampleKey, ErrCriticalHeader},
+	{"segments", func(p []string) []string { return p[:4] }, nil, sampleKey, ErrMalformed},
+}
+
+func TestDecrypt_errors(t *testing.T) {
+	s, _ := Encrypt([]byte("secret"), AlgDir, EncA256GCM, sampleKey, nil)
+
+	for _, data := range decryptErrorTestData {
+		parts := strings.Split(s, ".")
+		if data.mangle != nil {
+			parts = data.mangle(parts)
+		}
+		d := data.d
+		if d == nil {
+			d = new(Decrypter)
+		}
+		if _, err := d.Decrypt(strings.Join(parts, "."), staticKey(data.key)); err != data.err {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
+
+func encodeHeader(header string) string {
+	return jwt.EncodeSegment([]byte(header))
+}
+
+func TestEncrypt_zip(t *testing.T) {
+	plaintext := bytes.Repeat([]byte(`{"role":"auditor"}`), 1000)
+
+	s, err := Encrypt(plaintext, AlgDir, EncA256GCM, sampleKey, map[string]interface{}{"zip": "DEF"})
+	if err != nil {
+		t.Fatalf("Error encrypting: %v", err)
+	}
+	if l := len(mustDecode(t, s, 3)); l >= len(plaintext)/10 {
+		t.Errorf("Expected compressed ciphertext.  Got %v bytes", l)
+	}
+	msg, err := Decrypt(s, staticKey(sampleKey))
+	if err != nil || !bytes.Equal(msg.Plaintext, plaintext) {
+		t.Errorf("Error decrypting: %v", err)
+	}
+
+	d := &Decrypter{MaxDecompressedSize: len(plaintext) - 1}
+	if _, err := d.Decrypt(s, staticKey(sampleKey)); err != ErrDecompressedTooLarge {
+		t.Errorf("Expected %v.  Got %v", ErrDecompressedTooLarge, err)
+	}
+	d.MaxDecompressedSize = -1
+	if _, err := d.Decrypt(s, staticKey(sampleKey)); err != nil {
+		t.Errorf("Expected no limit.  Got %v", err)
+	}
+
+	if _, err := Encrypt(plaintext, AlgDir, EncA256GCM, sampleKey, map[string]interface{}{"zip": "GZIP"}); err != ErrUnsupported {
+		t.Errorf("Expected %v.  Got %v", ErrUnsupported, err)
+	}
+}
+
+func TestDecrypt_zipBomb(t *testing.T) {
+	bomb := make([]byte, 64<<20)
+	s, err := Encrypt(bomb, AlgDir, EncA256GCM, sampleKey, map[string]interface{}{"zip": "DEF"})
+	if err != nil {
+		t.Fatalf("Error encrypting: %v", err)
+	}
+	if len(s) > 200<<10 {
+		t.Fatalf("Expected a small token.  Got %v bytes", len(s))
+	}
+	if _, err := Decrypt(s, staticKey(sampleKey)); err != ErrDecompressedTooLarge {
+		t.Errorf("Expected %v.  Got %v", ErrDecompressedTooLarge, err)
+	}
+}
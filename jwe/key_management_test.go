@@ -0,0 +1,162 @@
+package jwe
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestKeyManagement(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherECKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var keyManagementTestData = []struct {
+		alg       KeyManagement
+		encrypt   interface{}
+		decrypt   interface{}
+		wrong     interface{}
+		header    map[string]interface{}
+		keyLength int
+	}{
+		{AlgDir, sampleKey, sampleKey, bytes.Repeat([]byte{1}, 32), nil, 0},
+		{AlgRSAOAEP, &rsaKey.PublicKey, rsaKey, nil, nil, 256},
+		{AlgRSAOAEP256, &rsaKey.PublicKey, rsaKey, nil, nil, 256},
+		{AlgA128KW, sampleKey[:16], sampleKey[:16], bytes.Repeat([]byte{1}, 16), nil, 40},
+		{AlgA192KW, sampleKey[:24], sampleKey[:24], bytes.Repeat([]byte{1}, 24), nil, 40},
+		{AlgA256KW, sampleKey, sampleKey, bytes.Repeat([]byte{1}, 32), nil, 40},
+		{AlgECDHES, &ecKey.PublicKey, ecKey, otherECKey, map[string]interface{}{"apu": "QWxpY2U", "apv": "Qm9i"}, 0},
+		{AlgECDHESA128KW, &ecKey.PublicKey, ecKey, otherECKey, nil, 40},
+		{AlgECDHESA256KW, &ecKey.PublicKey, ecKey, otherECKey, nil, 40},
+		{AlgPBES2HS256A128KW, "correct horse", "correct horse", "battery staple", map[string]interface{}{"p2c": 1000}, 40},
+		{AlgPBES2HS512A256KW, []byte("correct horse"), "correct horse", "battery staple", map[string]interface{}{"p2c": 1000}, 40},
+	}
+
+	for _, data := range keyManagementTestData {
+		name := data.alg.Alg()
+		s, err := Encrypt([]byte("secret"), data.alg, EncA256GCM, data.encrypt, data.header)
+		if err != nil {
+			t.Errorf("[%v] Error encrypting: %v", name, err)
+			continue
+		}
+		if l := len(mustDecode(t, s, 1)); l != data.keyLength {
+			t.Errorf("[%v] Expected encrypted key of %v bytes.  Got %v", name, data.keyLength, l)
+		}
+
+		// Selected per token, from the header.  PBES2 must be listed.
+		d := &Decrypter{Algorithms: []string{name}}
+		msg, err := d.Decrypt(s, staticKey(data.decrypt))
+		if err != nil || string(msg.Plaintext) != "secret" || GetKeyManagement(msg.Header["alg"].(string)) != data.alg {
+			t.Errorf("[%v] Error decrypting: %v", name, err)
+		}
+		if data.wrong != nil {
+			if _, err := d.Decrypt(s, staticKey(data.wrong)); err == nil {
+				t.Errorf("[%v] Expected decryption with the wrong key to fail", name)
+			}
+		}
+	}
+}
+
+func TestPBES2_maxCount(t *testing.T) {
+	header := map[string]interface{}{"p2c": MaxPBES2Count + 1}
+	s, _ := Encrypt([]byte("secret"), AlgPBES2HS256A128KW, EncA256GCM, "password", header)
+	d := &Decrypter{Algorithms: []string{AlgPBES2HS256A128KW.Alg()}}
+	if _, err := d.Decrypt(s, staticKey("password")); err != ErrMalformed {
+		t.Errorf("Expected a p2c above MaxPBES2Count to be refused.  Got %v", err)
+	}
+}
+
+// A key meant for AES key wrap must not do as a PBES2 password unless the
+// Decrypter asks for PBES2
+func TestPBES2_refused(t *testing.T) {
+	s, err := Encrypt([]byte("secret"), AlgPBES2HS256A128KW, EncA256GCM, sampleKey[:16], map[string]interface{}{"p2c": 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []*Decrypter{{}, {Algorithms: []string{"A128KW"}}} {
+		if _, err := d.Decrypt(s, staticKey(sampleKey[:16])); err != ErrAlgorithmRefused {
+			t.Errorf("[%v] Expected %v.  Got %v", d.Algorithms, ErrAlgorithmRefused, err)
+		}
+	}
+}
+
+func TestPBES2_count(t *testing.T) {
+	var tests = []struct {
+		p2c    interface{}
+		expect bool
+	}{
+		{1000, true},
+		{int64(1000), true},
+		{float64(1000), true},
+		{json.Number("1000"), true},
+		{"1000", false},
+		{1000.5, false},
+		{0, false},
+		{-1, false},
+	}
+	d := &Decrypter{Algorithms: []string{AlgPBES2HS256A128KW.Alg()}}
+	for _, data := range tests {
+		s, err := Encrypt([]byte("secret"), AlgPBES2HS256A128KW, EncA256GCM, "password", map[string]interface{}{"p2c": data.p2c})
+		if !data.expect {
+			if err == nil {
+				t.Errorf("[%#v] Expected an error", data.p2c)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%#v] Error encrypting: %v", data.p2c, err)
+			continue
+		}
+		msg, err := d.Decrypt(s, staticKey("password"))
+		if err != nil {
+			t.Errorf("[%#v] Error decrypting: %v", data.p2c, err)
+		} else if c := msg.Header["p2c"]; c != float64(1000) {
+			t.Errorf("[%#v] Expected p2c of 1000.  Got %v", data.p2c, c)
+		}
+	}
+}
+
+func TestKeyWrap(t *testing.T) {
+	// RFC 3394 section 4.1
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF")
+	expect, _ := hex.DecodeString("1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+
+	wrapped, err := keyWrap(kek, key)
+	if err != nil || !bytes.Equal(wrapped, expect) {
+		t.Errorf("Unexpected wrapped key %X: %v", wrapped, err)
+	}
+	if unwrapped, err := keyUnwrap(kek, wrapped); err != nil || !bytes.Equal(unwrapped, key) {
+		t.Errorf("Unexpected unwrapped key %X: %v", unwrapped, err)
+	}
+	wrapped[0] ^= 1
+	if _, err := keyUnwrap(kek, wrapped); err != ErrDecryption {
+		t.Errorf("Expected integrity check to fail.  Got %v", err)
+	}
+}
+
+func TestConcatKDF(t *testing.T) {
+	// RFC 7518 appendix C
+	z := []byte{158, 86, 217, 29, 129, 113, 53, 211, 114, 131, 66, 131, 191, 132, 38, 156,
+		251, 49, 110, 163, 218, 128, 106, 72, 246, 218, 167, 121, 140, 254, 144, 196}
+	key := concatKDF(z, []byte("A128GCM"), []byte("Alice"), []byte("Bob"), 16)
+	if s := jwt.EncodeSegment(key); s != "VqqN6vgjbSBcIijNcacQGg" {
+		t.Errorf("Unexpected derived key %v", s)
+	}
+}
+
+func mustDecode(t *testing.T, token string, segment int) []byte {
+	parts := bytes.Split([]byte(token), []byte("."))
+	b, err := jwt.DecodeSegment(string(parts[segment]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,50 @@
+package jwe
+
+import (
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Sign token with signingKey, then encrypt the result for the recipient
+// holding key with cty set to JWT, as RFC 7519 section 5.2 requires of a
+// nested JWT.  header may add parameters such as kid to the JWE header.
+func SignAndEncrypt(token *jwt.Token, signingKey interface{}, alg KeyManagement, enc ContentEncryption, key interface{}, header map[string]interface{}) (string, error) {
+	signed, err := token.SignedString(signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	h := map[string]interface{}{}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["cty"] = "JWT"
+	return Encrypt([]byte(signed), alg, enc, key, h)
+}
+
+// Decrypt a token created with SignAndEncrypt using the key from
+// decryptKey, then parse the signed token it carries into claims and
+// verify it with verifyKey.  Tokens without cty JWT are rejected, so an
+// encrypted but unsigned token is never accepted.
+func DecryptAndVerify(token string, claims jwt.Claims, decryptKey Keyfunc, verifyKey jwt.Keyfunc) (*jwt.Token, error) {
+	return new(Decrypter).DecryptAndVerify(token, claims, decryptKey, verifyKey)
+}
+
+// DecryptAndVerify, checking alg and enc with the options of the Decrypter
+// and the signed token with its Parser
+func (d *Decrypter) DecryptAndVerify(token string, claims jwt.Claims, decryptKey Keyfunc, verifyKey jwt.Keyfunc) (*jwt.Token, error) {
+	msg, err := d.Decrypt(token, decryptKey)
+	if err != nil {
+		return nil, err
+	}
+	if cty, _ := msg.Header["cty"].(string); !strings.EqualFold(cty, "JWT") {
+		return nil, ErrNotNested
+	}
+
+	p := d.Parser
+	if p == nil {
+		p = new(jwt.Parser)
+	}
+	return p.ParseWithClaims(string(msg.Plaintext), claims, verifyKey)
+}
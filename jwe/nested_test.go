@@ -0,0 +1,47 @@
+package jwe
+
+import (
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestSignAndEncrypt(t *testing.T) {
+	signingKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	verifyKey := func(*jwt.Token) (interface{}, error) { return &signingKey.PublicKey, nil }
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "patient-7"})
+	s, err := SignAndEncrypt(token, signingKey, AlgA256KW, EncA256GCM, sampleKey, map[string]interface{}{"kid": "k1"})
+	if err != nil {
+		t.Fatalf("Error encrypting: %v", err)
+	}
+
+	msg, err := Decrypt(s, staticKey(sampleKey))
+	if err != nil || msg.Header["cty"] != "JWT" || msg.Header["kid"] != "k1" {
+		t.Fatalf("Expected nested JWT header.  Got %v %v", msg, err)
+	}
+
+	inner, err := DecryptAndVerify(s, jwt.MapClaims{}, staticKey(sampleKey), verifyKey)
+	if err != nil || !inner.Valid || inner.Claims.(jwt.MapClaims)["sub"] != "patient-7" {
+		t.Errorf("Error verifying: %v", err)
+	}
+
+	d := &Decrypter{Parser: &jwt.Parser{ValidMethods: []string{"ES256"}}}
+	if _, err := d.DecryptAndVerify(s, jwt.MapClaims{}, staticKey(sampleKey), verifyKey); err == nil {
+		t.Errorf("Expected inner signing method to be refused")
+	}
+	d = &Decrypter{Algorithms: []string{"RSA-OAEP-256"}}
+	if _, err := d.DecryptAndVerify(s, jwt.MapClaims{}, staticKey(sampleKey), verifyKey); err != ErrAlgorithmRefused {
+		t.Errorf("Expected %v.  Got %v", ErrAlgorithmRefused, err)
+	}
+}
+
+func TestDecryptAndVerify_notNested(t *testing.T) {
+	// Encrypted, but not signed
+	s, _ := Encrypt([]byte(`{"sub":"patient-7"}`), AlgDir, EncA256GCM, sampleKey, nil)
+	verifyKey := func(*jwt.Token) (interface{}, error) { return sampleKey, nil }
+	if _, err := DecryptAndVerify(s, jwt.MapClaims{}, staticKey(sampleKey), verifyKey); err != ErrNotNested {
+		t.Errorf("Expected %v.  Got %v", ErrNotNested, err)
+	}
+}
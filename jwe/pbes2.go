@@ -0,0 +1,139 @@
+package jwe
+
+import (
+	"crypto"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Iteration count used by PBES2 when the header doesn't set p2c
+const DefaultPBES2Count = 100000
+
+// Tokens asking for more PBES2 iterations than this are rejected, so a
+// forged p2c can't make decryption arbitrarily slow.  At the default, one
+// costs tens of milliseconds.
+var MaxPBES2Count = 100000
+
+// Implements PBES2-HS256+A128KW and company: the CEK is wrapped with a key
+// derived from a password, a string or []byte, with PBKDF2.  Set p2c in
+// the header passed to Encrypt to choose the iteration count.  Decrypters
+// accept these algorithms only when they are listed in Algorithms, since
+// a []byte meant as an AES key wrap key would otherwise do as a password,
+// and every token would cost its p2c in CPU.
+type KeyManagementPBES2 struct {
+	Name     string
+	Hash     crypto.Hash
+	KeyBytes int
+}
+
+var (
+	AlgPBES2HS256A128KW *KeyManagementPBES2
+	AlgPBES2HS384A192KW *KeyManagementPBES2
+	AlgPBES2HS512A256KW *KeyManagementPBES2
+)
+
+func init() {
+	AlgPBES2HS256A128KW = &KeyManagementPBES2{"PBES2-HS256+A128KW", crypto.SHA256, 16}
+	RegisterKeyManagement(AlgPBES2HS256A128KW.Alg(), func() KeyManagement {
+		return AlgPBES2HS256A128KW
+	})
+
+	AlgPBES2HS384A192KW = &KeyManagementPBES2{"PBES2-HS384+A192KW", crypto.SHA384, 24}
+	RegisterKeyManagement(AlgPBES2HS384A192KW.Alg(), func() KeyManagement {
+		return AlgPBES2HS384A192KW
+	})
+
+	AlgPBES2HS512A256KW = &KeyManagementPBES2{"PBES2-HS512+A256KW", crypto.SHA512, 32}
+	RegisterKeyManagement(AlgPBES2HS512A256KW.Alg(), func() KeyManagement {
+		return AlgPBES2HS512A256KW
+	})
+}
+
+func (m *KeyManagementPBES2) Alg() string {
+	return m.Name
+}
+
+func (m *KeyManagementPBES2) WrapKey(cekSize int, key interface{}, header map[string]interface{}) ([]byte, []byte, error) {
+	return wrapNewCEK(m, cekSize, key, header)
+}
+
+func (m *KeyManagementPBES2) WrapCEK(cek []byte, key interface{}, header map[string]interface{}) ([]byte, error) {
+	count := DefaultPBES2Count
+	if c, set := header["p2c"]; set {
+		var ok bool
+		if count, ok = headerInt(c); !ok || count < 1 {
+			return nil, fmt.Errorf("jwe: p2c must be a positive integer, not %v", c)
+		}
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	kek, err := m.deriveKey(key, salt, count)
+	if err != nil {
+		return nil, err
+	}
+	header["p2s"] = jwt.EncodeSegment(salt)
+	header["p2c"] = count
+	return keyWrap(kek, cek)
+}
+
+func (m *KeyManagementPBES2) UnwrapKey(encryptedKey []byte, cekSize int, key interface{}, header map[string]interface{}) ([]byte, error) {
+	p2s, _ := header["p2s"].(string)
+	salt, err := jwt.DecodeSegment(p2s)
+	if err != nil || len(salt) < 8 {
+		return nil, ErrMalformed
+	}
+	count, ok := headerInt(header["p2c"])
+	if !ok || count < 1 || count > MaxPBES2Count {
+		return nil, ErrMalformed
+	}
+
+	kek, err := m.deriveKey(key, salt, count)
+	if err != nil {
+		return nil, err
+	}
+	return unwrapCEK(encryptedKey, cekSize, kek)
+}
+
+// PBKDF2 with the alg name prepended to the salt, RFC 7518 section 4.8.1.1
+func (m *KeyManagementPBES2) deriveKey(key interface{}, salt []byte, count int) ([]byte, error) {
+	var password string
+	switch k := key.(type) {
+	case string:
+		password = k
+	case []byte:
+		password = string(k)
+	default:
+		return nil, ErrInvalidKey
+	}
+
+	input := append(append([]byte(m.Name), 0), salt...)
+	return pbkdf2.Key(m.Hash.New, password, input, count, m.KeyBytes)
+}
+
+// Integer value of a decoded JSON header parameter
+func headerInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		if n != float64(int(n)) {
+			return 0, false
+		}
+		return int(n), true
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+	return 0, false
+}
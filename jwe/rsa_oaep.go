@@ -0,0 +1,62 @@
+package jwe
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+)
+
+// Implements RSA-OAEP and RSA-OAEP-256.  Expects *rsa.PublicKey for
+// encrypting and *rsa.PrivateKey for decrypting.
+type KeyManagementRSAOAEP struct {
+	Name string
+	Hash crypto.Hash
+}
+
+var (
+	AlgRSAOAEP    *KeyManagementRSAOAEP
+	AlgRSAOAEP256 *KeyManagementRSAOAEP
+)
+
+func init() {
+	AlgRSAOAEP = &KeyManagementRSAOAEP{"RSA-OAEP", crypto.SHA1}
+	RegisterKeyManagement(AlgRSAOAEP.Alg(), func() KeyManagement {
+		return AlgRSAOAEP
+	})
+
+	AlgRSAOAEP256 = &KeyManagementRSAOAEP{"RSA-OAEP-256", crypto.SHA256}
+	RegisterKeyManagement(AlgRSAOAEP256.Alg(), func() KeyManagement {
+		return AlgRSAOAEP256
+	})
+}
+
+func (m *KeyManagementRSAOAEP) Alg() string {
+	return m.Name
+}
+
+func (m *KeyManagementRSAOAEP) WrapKey(cekSize int, key interface{}, header map[string]interface{}) ([]byte, []byte, error) {
+	return wrapNewCEK(m, cekSize, key, header)
+}
+
+func (m *KeyManagementRSAOAEP) WrapCEK(cek []byte, key interface{}, header map[string]interface{}) ([]byte, error) {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	return rsa.EncryptOAEP(m.Hash.New(), rand.Reader, pub, cek, nil)
+}
+
+func (m *KeyManagementRSAOAEP) UnwrapKey(encryptedKey []byte, cekSize int, key interface{}, header map[string]interface{}) ([]byte, error) {
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	cek, err := rsa.DecryptOAEP(m.Hash.New(), nil, priv, encryptedKey, nil)
+	if err != nil || len(cek) != cekSize {
+		return nil, ErrDecryption
+	}
+	return cek, nil
+}
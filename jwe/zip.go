@@ -0,0 +1,70 @@
+package jwe
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+)
+
+// Limit on the size of a decompressed payload, unless the Decrypter sets
+// another.  Compressed payloads can expand enormously, so without one a
+// small token could exhaust memory.
+const DefaultMaxDecompressedSize = 1 << 20
+
+// Compress plaintext as the zip header parameter asks, RFC 7516 section
+// 4.1.3.  DEF, raw DEFLATE, is the only one defined.
+func compress(header map[string]interface{}, plaintext []byte) ([]byte, error) {
+	zip, ok := header["zip"]
+	if !ok {
+		return plaintext, nil
+	}
+	if zip != "DEF" {
+		return nil, ErrUnsupported
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Reverse compress, stopping at the Decrypter's limit
+func (d *Decrypter) decompress(header map[string]interface{}, data []byte) ([]byte, error) {
+	zip, ok := header["zip"]
+	if !ok {
+		return data, nil
+	}
+	if zip != "DEF" {
+		return nil, ErrUnsupported
+	}
+
+	limit := d.maxDecompressedSize()
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	if limit > 0 {
+		r = io.NopCloser(io.LimitReader(r, int64(limit)+1))
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if limit > 0 && len(plaintext) > limit {
+		return nil, ErrDecompressedTooLarge
+	}
+	return plaintext, nil
+}
+
+func (d *Decrypter) maxDecompressedSize() int {
+	if d.MaxDecompressedSize == 0 {
+		return DefaultMaxDecompressedSize
+	}
+	return d.MaxDecompressedSize
+}
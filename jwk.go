@@ -0,0 +1,498 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/json"
+	"math/big"
+)
+
+var (
+	ErrJWKUnsupportedKeyType = newError(CodeUnsupportedKeyType, "JWK key type is not supported")
+	ErrJWKInvalid            = newError(CodeInvalidKey, "JWK is missing required members or is malformed")
+	ErrJWKUnsupportedAlg     = newError(CodeUnsupportedAlgorithm, "cannot generate a key for the requested algorithm")
+	ErrJWKUsage              = newError(CodeKeyUsageNotPermitted, "JWK use or key_ops does not permit this operation")
+)
+
+// JSON Web Key, as described in RFC 7517.
+// Binary members are kept in their base64url encoded form, exactly as they
+// appear on the wire, so a JSONWebKey round-trips through encoding/json unchanged.
+// Use Key to get the crypto key it describes.
+type JSONWebKey struct {
+	Kty     string   `json:"kty"`
+	Use     string   `json:"use,omitempty"`
+	KeyOps  []string `json:"key_ops,omitempty"`
+	Alg     string   `json:"alg,omitempty"`
+	Kid     string   `json:"kid,omitempty"`
+	X5u     string   `json:"x5u,omitempty"`
+	X5c     []string `json:"x5c,omitempty"`
+	X5t     string   `json:"x5t,omitempty"`
+	X5tS256 string   `json:"x5t#S256,omitempty"`
+
+	// Elliptic curve (EC) and octet key pair (OKP) members
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+
+	// RSA members
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+
+	// Private exponent (RSA), private scalar (EC) or private seed (OKP)
+	D string `json:"d,omitempty"`
+
+	// Symmetric key value
+	K string `json:"k,omitempty"`
+}
+
+// A set of JSON Web Keys, as served from a jwks_uri endpoint
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Returns the first key in the set with a matching kid, or nil
+func (s *JSONWebKeySet) Lookup(kid string) *JSONWebKey {
+	for i := range s.Keys {
+		if s.Keys[i].Kid == kid {
+			return &s.Keys[i]
+		}
+	}
+	return nil
+}
+
+// Parse a single JWK from its JSON representation
+func ParseJWK(data []byte) (*JSONWebKey, error) {
+	var k JSONWebKey
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, err
+	}
+	if k.Kty == "" {
+		return nil, ErrJWKInvalid
+	}
+	return &k, nil
+}
+
+// Parse a JWK Set from its JSON representation
+func ParseJWKSet(data []byte) (*JSONWebKeySet, error) {
+	var s JSONWebKeySet
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Build a JWK from a Go crypto key.  Supported key types are the ones
+// accepted by the built-in signing methods: []byte, *rsa.PrivateKey,
+// *rsa.PublicKey, *ecdsa.PrivateKey, *ecdsa.PublicKey, ed25519.PrivateKey
+// and ed25519.PublicKey.  Ed25519 keys become RFC 8037 OKP keys.
+func NewJSONWebKey(key interface{}) (*JSONWebKey, error) {
+	switch k := key.(type) {
+	case []byte:
+		return &JSONWebKey{Kty: "oct", K: EncodeSegment(k)}, nil
+	case *rsa.PublicKey:
+		return &JSONWebKey{
+			Kty: "RSA",
+			N:   EncodeSegment(k.N.Bytes()),
+			E:   EncodeSegment(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *rsa.PrivateKey:
+		jwk, _ := NewJSONWebKey(&k.PublicKey)
+		jwk.D = EncodeSegment(k.D.Bytes())
+		if len(k.Primes) == 2 {
+			// Derived here rather than with Precompute, which would
+			// modify the caller's key
+			p, q := k.Primes[0], k.Primes[1]
+			one := big.NewInt(1)
+			dp := new(big.Int).Mod(k.D, new(big.Int).Sub(p, one))
+			dq := new(big.Int).Mod(k.D, new(big.Int).Sub(q, one))
+			qi := new(big.Int).ModInverse(q, p)
+			if qi == nil {
+				return nil, ErrInvalidKey
+			}
+			jwk.P = EncodeSegment(p.Bytes())
+			jwk.Q = EncodeSegment(q.Bytes())
+			jwk.DP = EncodeSegment(dp.Bytes())
+			jwk.DQ = EncodeSegment(dq.Bytes())
+			jwk.QI = EncodeSegment(qi.Bytes())
+		}
+		return jwk, nil
+	case *ecdsa.PublicKey:
+		crv, size, err := curveName(k.Curve)
+		if err != nil {
+			return nil, err
+		}
+		return &JSONWebKey{
+			Kty: "EC",
+			Crv: crv,
+			X:   EncodeSegment(k.X.FillBytes(make([]byte, size))),
+			Y:   EncodeSegment(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case *ecdsa.PrivateKey:
+		jwk, err := NewJSONWebKey(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		_, size, _ := curveName(k.Curve)
+		jwk.D = EncodeSegment(k.D.FillBytes(make([]byte, size)))
+		return jwk, nil
+	case ed25519.PublicKey:
+		if len(k) != ed25519.PublicKeySize {
+			return nil, ErrInvalidKey
+		}
+		return &JSONWebKey{Kty: "OKP", Crv: "Ed25519", X: EncodeSegment(k)}, nil
+	case ed25519.PrivateKey:
+		if len(k) != ed25519.PrivateKeySize {
+			return nil, ErrInvalidKey
+		}
+		jwk, _ := NewJSONWebKey(k.Public())
+		jwk.D = EncodeSegment(k.Seed())
+		return jwk, nil
+	}
+	return nil, ErrInvalidKeyType
+}
+
+// Reports whether the JWK carries private (or symmetric) key material
+func (k *JSONWebKey) IsPrivate() bool {
+	return k.D != "" || k.K != ""
+}
+
+// Reports whether the key's use and key_ops members permit op, one of the
+// RFC 7517 key operations ("sign", "verify", "encrypt", "wrapKey", ...).
+// Keys without use and key_ops may be used for anything.
+func (k *JSONWebKey) Permits(op string) bool {
+	switch k.Use {
+	case "":
+	case "sig":
+		if op != "sign" && op != "verify" {
+			return false
+		}
+	case "enc":
+		if op == "sign" || op == "verify" {
+			return false
+		}
+	default:
+		// Unknown use values can't be reasoned about, so don't trust them
+		return false
+	}
+
+	if k.KeyOps == nil {
+		return true
+	}
+	for _, o := range k.KeyOps {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Decode the key for signing.  Fails with ErrJWKUsage if the key is not
+// declared for signing and ErrNoSigningKey if it holds no private material.
+func (k *JSONWebKey) SigningKey() (interface{}, error) {
+	if !k.Permits("sign") {
+		return nil, ErrJWKUsage
+	}
+	if !k.IsPrivate() {
+		return nil, ErrNoSigningKey
+	}
+	return k.Key()
+}
+
+// Decode the key for verifying signatures.  Fails with ErrJWKUsage if the
+// key is not declared for verification.  Private keys are reduced to their
+// public half.
+func (k *JSONWebKey) VerificationKey() (interface{}, error) {
+	if !k.Permits("verify") {
+		return nil, ErrJWKUsage
+	}
+	key, err := k.Key()
+	if err != nil {
+		return nil, err
+	}
+	return verificationKey(key), nil
+}
+
+// Returns a copy of the key with all private members removed.  Symmetric
+// keys have no public form, so nil is returned for kty "oct".
+func (k *JSONWebKey) Public() *JSONWebKey {
+	if k.Kty == "oct" {
+		return nil
+	}
+	pub := *k
+	pub.D, pub.P, pub.Q, pub.DP, pub.DQ, pub.QI = "", "", "", "", "", ""
+	return &pub
+}
+
+// Decode the JWK into the matching Go crypto key.  Private JWKs yield
+// *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey, public JWKs
+// yield the public key types and symmetric JWKs yield []byte, as expected
+// by the signing methods.
+func (k *JSONWebKey) Key() (interface{}, error) {
+	switch k.Kty {
+	case "oct":
+		if k.K == "" {
+			return nil, ErrJWKInvalid
+		}
+		return DecodeSegment(k.K)
+	case "RSA":
+		return k.rsaKey()
+	case "EC":
+		return k.ecKey()
+	case "OKP":
+		return k.okpKey()
+	}
+	return nil, ErrJWKUnsupportedKeyType
+}
+
+func (k *JSONWebKey) rsaKey() (interface{}, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, ErrJWKInvalid
+	}
+	pub := &rsa.PublicKey{N: n, E: int(e.Int64())}
+	if k.D == "" {
+		return pub, nil
+	}
+
+	d, err := decodeBigInt(k.D)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decodeBigInt(k.P)
+	if err != nil {
+		return nil, err
+	}
+	q, err := decodeBigInt(k.Q)
+	if err != nil {
+		return nil, err
+	}
+	priv := &rsa.PrivateKey{PublicKey: *pub, D: d, Primes: []*big.Int{p, q}}
+	if err = priv.Validate(); err != nil {
+		return nil, err
+	}
+	priv.Precompute()
+	return priv, nil
+}
+
+func (k *JSONWebKey) ecKey() (interface{}, error) {
+	curve, err := curveByName(k.Crv)
+	if err != nil {
+		return nil, err
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, ErrJWKInvalid
+	}
+	pub := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if k.D == "" {
+		return pub, nil
+	}
+
+	d, err := decodeBigInt(k.D)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PrivateKey{PublicKey: *pub, D: d}, nil
+}
+
+func (k *JSONWebKey) okpKey() (interface{}, error) {
+	// X25519 and Ed448 keys are also OKP, but nothing here can use them
+	if k.Crv != "Ed25519" {
+		return nil, ErrJWKUnsupportedKeyType
+	}
+	x, err := DecodeSegment(k.X)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, ErrJWKInvalid
+	}
+	if k.D == "" {
+		return ed25519.PublicKey(x), nil
+	}
+
+	seed, err := DecodeSegment(k.D)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, ErrJWKInvalid
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	// The public key is derived from the seed, so x must agree with it
+	if subtle.ConstantTimeCompare(priv.Public().(ed25519.PublicKey), x) != 1 {
+		return nil, ErrJWKInvalid
+	}
+	return priv, nil
+}
+
+// Compute the RFC 7638 thumbprint of the key using the given hash
+// (crypto.SHA256 in most deployments).  Only the required public members
+// take part, so a private key and its public half share a thumbprint.
+func (k *JSONWebKey) Thumbprint(h crypto.Hash) ([]byte, error) {
+	var members []byte
+	var err error
+
+	// The members must be serialized in lexicographic order with no
+	// whitespace.  Structs marshal in field order, so declare them sorted.
+	switch k.Kty {
+	case "EC":
+		members, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y})
+	case "OKP":
+		members, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X})
+	case "RSA":
+		members, err = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N})
+	case "oct":
+		members, err = json.Marshal(struct {
+			K   string `json:"k"`
+			Kty string `json:"kty"`
+		}{k.K, k.Kty})
+	default:
+		return nil, ErrJWKUnsupportedKeyType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !h.Available() {
+		return nil, ErrHashUnavailable
+	}
+	hasher := h.New()
+	hasher.Write(members)
+	return hasher.Sum(nil), nil
+}
+
+// Create a fresh key suitable for the given JOSE algorithm (e.g. "ES256")
+// and return it both as a private JWK and as the Go key expected by the
+// signing method.  The JWK has alg and use=sig set, and its kid is the
+// base64url encoded SHA-256 thumbprint.
+func GenerateJWK(alg string) (*JSONWebKey, interface{}, error) {
+	var key interface{}
+	var err error
+
+	switch m := GetSigningMethod(alg).(type) {
+	case *SigningMethodHMAC:
+		secret := make([]byte, m.Hash.Size())
+		if _, err = rand.Read(secret); err != nil {
+			return nil, nil, err
+		}
+		key = secret
+	case *SigningMethodRSA, *SigningMethodRSAPSS:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case *SigningMethodECDSA:
+		var curve elliptic.Curve
+		if curve, err = curveForBits(m.CurveBits); err != nil {
+			return nil, nil, err
+		}
+		key, err = ecdsa.GenerateKey(curve, rand.Reader)
+	case *SigningMethodEd25519:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, nil, ErrJWKUnsupportedAlg
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jwk, err := NewJSONWebKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	jwk.Alg = alg
+	jwk.Use = "sig"
+
+	thumb, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+	jwk.Kid = EncodeSegment(thumb)
+
+	return jwk, key, nil
+}
+
+// ----- helpers
+
+func decodeBigInt(seg string) (*big.Int, error) {
+	if seg == "" {
+		return nil, ErrJWKInvalid
+	}
+	b, err := DecodeSegment(seg)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Returns the JWK crv name and the coordinate size in bytes for a curve
+func curveName(curve elliptic.Curve) (string, int, error) {
+	switch curve {
+	case elliptic.P256():
+		return "P-256", 32, nil
+	case elliptic.P384():
+		return "P-384", 48, nil
+	case elliptic.P521():
+		return "P-521", 66, nil
+	}
+	return "", 0, ErrJWKUnsupportedKeyType
+}
+
+func curveByName(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	}
+	return nil, ErrJWKUnsupportedKeyType
+}
+
+func curveForBits(bits int) (elliptic.Curve, error) {
+	switch bits {
+	case 256:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	case 521:
+		return elliptic.P521(), nil
+	}
+	return nil, ErrJWKUnsupportedAlg
+}
@@ -0,0 +1,258 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+var jwkGenerateAlgs = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+func TestGenerateJWK(t *testing.T) {
+	for _, alg := range jwkGenerateAlgs {
+		jwk, key, err := jwt.GenerateJWK(alg)
+		if err != nil {
+			t.Errorf("[%v] Error generating key: %v", alg, err)
+			continue
+		}
+		if jwk.Alg != alg || jwk.Use != "sig" || jwk.Kid == "" {
+			t.Errorf("[%v] Unexpected JWK metadata: alg=%v use=%v kid=%v", alg, jwk.Alg, jwk.Use, jwk.Kid)
+		}
+
+		// Round trip through JSON and back into a Go key
+		data, err := json.Marshal(jwk)
+		if err != nil {
+			t.Errorf("[%v] Error marshaling JWK: %v", alg, err)
+			continue
+		}
+		parsed, err := jwt.ParseJWK(data)
+		if err != nil {
+			t.Errorf("[%v] Error parsing JWK: %v", alg, err)
+			continue
+		}
+		if !reflect.DeepEqual(jwk, parsed) {
+			t.Errorf("[%v] JWK changed during JSON round trip", alg)
+		}
+
+		// Sign with the generated key, verify with the public half of the JWK
+		signKey, err := parsed.Key()
+		if err != nil {
+			t.Errorf("[%v] Error decoding JWK: %v", alg, err)
+			continue
+		}
+		verifyKey := signKey
+		if pub := parsed.Public(); pub != nil {
+			if verifyKey, err = pub.Key(); err != nil {
+				t.Errorf("[%v] Error decoding public JWK: %v", alg, err)
+				continue
+			}
+		}
+
+		method := jwt.GetSigningMethod(alg)
+		sig, err := method.Sign("header.claims", signKey)
+		if err != nil {
+			t.Errorf("[%v] Error signing with JWK: %v", alg, err)
+			continue
+		}
+		if err = method.Verify("header.claims", sig, verifyKey); err != nil {
+			t.Errorf("[%v] Error verifying with JWK: %v", alg, err)
+		}
+
+		// The Go key returned alongside the JWK must be usable directly
+		if _, err = method.Sign("header.claims", key); err != nil {
+			t.Errorf("[%v] Error signing with generated key: %v", alg, err)
+		}
+	}
+}
+
+func TestGenerateJWK_unsupported(t *testing.T) {
+	if _, _, err := jwt.GenerateJWK("none"); err != jwt.ErrJWKUnsupportedAlg {
+		t.Errorf("Expected ErrJWKUnsupportedAlg.  Got %v", err)
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// Example from RFC 7638, section 3.1
+	jwk := &jwt.JSONWebKey{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+		Alg: "RS256",
+		Kid: "2011-04-29",
+	}
+	thumb, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Error computing thumbprint: %v", err)
+	}
+	if got := jwt.EncodeSegment(thumb); got != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Thumbprint mismatch.  Got %v", got)
+	}
+}
+
+// Example from RFC 8037, appendix A
+var rfc8037Key = `{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+
+func TestJWKThumbprint_OKP(t *testing.T) {
+	jwk, err := jwt.ParseJWK([]byte(rfc8037Key))
+	if err != nil {
+		t.Fatalf("Error parsing JWK: %v", err)
+	}
+	thumb, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Error computing thumbprint: %v", err)
+	}
+	if got := jwt.EncodeSegment(thumb); got != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("Thumbprint mismatch.  Got %v", got)
+	}
+}
+
+func TestNewJSONWebKey_Ed25519(t *testing.T) {
+	jwk, err := jwt.ParseJWK([]byte(rfc8037Key))
+	if err != nil {
+		t.Fatalf("Error parsing JWK: %v", err)
+	}
+	key, err := jwk.Key()
+	if err != nil {
+		t.Fatalf("Error decoding JWK: %v", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		t.Fatalf("Expected an ed25519.PrivateKey.  Got %T", key)
+	}
+
+	// The signature from RFC 8037, appendix A.4
+	sig, err := jwt.SigningMethodEdDSA.Sign("eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc", priv)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	if sig != "hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg" {
+		t.Errorf("Signature mismatch.  Got %v", sig)
+	}
+
+	again, err := jwt.NewJSONWebKey(priv)
+	if err != nil {
+		t.Fatalf("Error building JWK: %v", err)
+	}
+	if !reflect.DeepEqual(again, jwk) {
+		t.Errorf("Expected the RFC 8037 JWK back.  Got %+v", again)
+	}
+	pub, err := jwk.Public().Key()
+	if err != nil {
+		t.Fatalf("Error decoding public JWK: %v", err)
+	}
+	if !reflect.DeepEqual(pub, priv.Public()) {
+		t.Errorf("Public key mismatch after JWK conversion")
+	}
+
+	var invalid = []struct {
+		name string
+		jwk  jwt.JSONWebKey
+		err  error
+	}{
+		{"X25519", jwt.JSONWebKey{Kty: "OKP", Crv: "X25519", X: jwk.X}, jwt.ErrJWKUnsupportedKeyType},
+		{"no x", jwt.JSONWebKey{Kty: "OKP", Crv: "Ed25519"}, jwt.ErrJWKInvalid},
+		{"short x", jwt.JSONWebKey{Kty: "OKP", Crv: "Ed25519", X: jwk.X[:10]}, jwt.ErrJWKInvalid},
+		{"short d", jwt.JSONWebKey{Kty: "OKP", Crv: "Ed25519", X: jwk.X, D: jwk.D[:10]}, jwt.ErrJWKInvalid},
+		{"x of another key", jwt.JSONWebKey{Kty: "OKP", Crv: "Ed25519", X: jwt.EncodeSegment(make([]byte, ed25519.PublicKeySize)), D: jwk.D}, jwt.ErrJWKInvalid},
+	}
+	for _, data := range invalid {
+		if _, err := data.jwk.Key(); err != data.err {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
+
+func TestNewJSONWebKey(t *testing.T) {
+	priv := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	pub := test.LoadRSAPublicKeyFromDisk("test/sample_key.pub")
+
+	jwk, err := jwt.NewJSONWebKey(priv)
+	if err != nil {
+		t.Fatalf("Error building JWK: %v", err)
+	}
+
+	// Keys without precomputed values get the same CRT members, and are
+	// left as they were
+	bare := &rsa.PrivateKey{PublicKey: priv.PublicKey, D: priv.D, Primes: priv.Primes}
+	if again, err := jwt.NewJSONWebKey(bare); err != nil || !reflect.DeepEqual(again, jwk) {
+		t.Errorf("Expected the same JWK from a key without precomputed values.  Got %+v, %v", again, err)
+	}
+	if bare.Precomputed.Dp != nil {
+		t.Errorf("NewJSONWebKey modified the key it was given")
+	}
+	if !jwk.IsPrivate() {
+		t.Errorf("JWK built from private key is not private")
+	}
+
+	key, err := jwk.Public().Key()
+	if err != nil {
+		t.Fatalf("Error decoding public JWK: %v", err)
+	}
+	if !reflect.DeepEqual(key, pub) {
+		t.Errorf("Public key mismatch after JWK conversion")
+	}
+
+	if _, err = jwt.NewJSONWebKey("not a key"); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expected ErrInvalidKeyType.  Got %v", err)
+	}
+}
+
+var jwkPermitsTestData = []struct {
+	name   string
+	use    string
+	keyOps []string
+	op     string
+	permit bool
+}{
+	{"unrestricted", "", nil, "verify", true},
+	{"use sig verify", "sig", nil, "verify", true},
+	{"use sig sign", "sig", nil, "sign", true},
+	{"use enc verify", "enc", nil, "verify", false},
+	{"use enc encrypt", "enc", nil, "encrypt", true},
+	{"unknown use", "tls", nil, "verify", false},
+	{"key_ops verify", "", []string{"verify"}, "verify", true},
+	{"key_ops verify only", "", []string{"verify"}, "sign", false},
+	{"use sig with key_ops", "sig", []string{"sign"}, "verify", false},
+}
+
+func TestJWKPermits(t *testing.T) {
+	for _, data := range jwkPermitsTestData {
+		jwk := &jwt.JSONWebKey{Kty: "oct", K: "c2VjcmV0", Use: data.use, KeyOps: data.keyOps}
+		if jwk.Permits(data.op) != data.permit {
+			t.Errorf("[%v] Expected Permits(%v) to be %v", data.name, data.op, data.permit)
+		}
+	}
+}
+
+func TestJWKUsageEnforced(t *testing.T) {
+	jwk, _, _ := jwt.GenerateJWK("ES256")
+
+	jwk.KeyOps = []string{"verify"}
+	if _, err := jwk.SigningKey(); err != jwt.ErrJWKUsage {
+		t.Errorf("Expected ErrJWKUsage signing with a verify-only key.  Got %v", err)
+	}
+	if _, err := jwk.VerificationKey(); err != nil {
+		t.Errorf("Error getting verification key: %v", err)
+	}
+
+	jwk.KeyOps = nil
+	jwk.Use = "enc"
+	if _, err := jwk.VerificationKey(); err != jwt.ErrJWKUsage {
+		t.Errorf("Expected ErrJWKUsage verifying with an encryption key.  Got %v", err)
+	}
+	if _, err := jwk.Public().SigningKey(); err != jwt.ErrJWKUsage {
+		t.Errorf("Expected ErrJWKUsage signing with an encryption key.  Got %v", err)
+	}
+}
@@ -0,0 +1,411 @@
+package jwt
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrJWKNotFound = newError(CodeKeyNotFound, "no matching key found in JWK set")
+
+	errRefreshLimited = errors.New("JWK Set was fetched too recently")
+)
+
+// Client that fetchJSON uses by default.  Unlike http.DefaultClient, it
+// gives up on an endpoint that never answers.
+var defaultFetchClient = &http.Client{Timeout: DefaultFetchTimeout}
+
+const (
+	// Default time a fetched JWK Set is served from cache before it is refetched
+	DefaultJWKSRefreshInterval = time.Hour
+	// Default minimum time between refetches triggered by an unknown kid
+	DefaultJWKSMinRefreshInterval = 5 * time.Minute
+	// Timeout of the HTTP client used when none is given
+	DefaultFetchTimeout = 30 * time.Second
+
+	// Upper bound on the size of documents fetched from key endpoints
+	maxFetchSize = 1 << 20
+)
+
+// Fetches, caches and resolves verification keys from a remote JWK Set
+// (a jwks_uri).  Keys are looked up by the kid header of the token.  An
+// unknown kid triggers a refetch, rate limited by MinRefreshInterval, so
+// key rotations at the issuer are picked up without waiting for the cache
+// to expire.
+//
+// To keep verifying through outages at the issuer, FallbackURLs are tried in
+// order when URL can't be fetched, and if every URL fails the last fetched
+// set keeps being served.  When nothing was ever fetched, the Offline set is
+// served instead; it can be a snapshot embedded in the binary:
+//
+//	//go:embed jwks.json
+//	var snapshot []byte
+//
+//	offline, _ := jwt.ParseJWKSet(snapshot)
+//	provider := &jwt.JWKSProvider{URL: primary, FallbackURLs: []string{mirror}, Offline: offline}
+//
+// A JWKSProvider is safe for concurrent use, and callers needing a fetch
+// while one is under way wait for it rather than starting their own.  Use
+// its Keyfunc method with Parse and friends.
+type JWKSProvider struct {
+	URL                string
+	FallbackURLs       []string       // Tried in order when URL can't be fetched
+	Offline            *JSONWebKeySet // Served when no URL could ever be fetched
+	Client             *http.Client   // HTTP client used for fetching.  Defaults to one with a timeout of DefaultFetchTimeout
+	RefreshInterval    time.Duration  // How long a fetched set is fresh.  Defaults to DefaultJWKSRefreshInterval
+	MinRefreshInterval time.Duration  // Minimum time between fetch attempts.  Defaults to DefaultJWKSMinRefreshInterval
+	CertificateLeeway  time.Duration  // Clock skew allowed when checking x5c certificate validity
+	Metrics            Metrics        // Measures fetches.  Defaults to DefaultMetrics
+	Tracer             Tracer         // Traces fetches.  Defaults to DefaultTracer
+	Logger             *slog.Logger   // Told about failed fetches.  Defaults to DefaultLogger
+
+	// Verify tokens without a kid against every key of a set with several,
+	// as a VerificationKeySet, rather than refusing them
+	TryAllKeys bool
+
+	mu        sync.RWMutex
+	parsed    jwkCache
+	keys      *JSONWebKeySet
+	fetched   time.Time // last successful fetch
+	attempted time.Time // start of the last fetch attempt, successful or not
+	flight    *jwksFetch
+}
+
+// A fetch of the JWK Set under way, which concurrent callers share
+type jwksFetch struct {
+	done chan struct{}
+	set  *JSONWebKeySet
+	err  error
+}
+
+// Create a provider for the JWK Set served at url, using default settings
+func NewJWKSProvider(url string) *JWKSProvider {
+	return &JWKSProvider{URL: url}
+}
+
+// Keyfunc resolving the verification key for a token from the JWK Set
+func (p *JWKSProvider) Keyfunc(token *Token) (interface{}, error) {
+	return p.LookupKey(token.Context(), token)
+}
+
+// Resolve the verification key for a token.  The key is selected by the
+// token's kid header.  Tokens without a kid are accepted only when the
+// set contains exactly one key, unless TryAllKeys is set.
+func (p *JWKSProvider) LookupKey(ctx context.Context, token *Token) (interface{}, error) {
+	set, err := p.KeySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	jwk := selectJWK(set, kid)
+	if jwk == nil && kid != "" {
+		// The issuer may have rotated keys since we last looked
+		if fresh, err := p.refresh(ctx, true); err == nil {
+			set = fresh
+			jwk = selectJWK(set, kid)
+		}
+	}
+	if jwk == nil && kid == "" && p.TryAllKeys {
+		return p.parsed.keySetForToken(set, token, p.CertificateLeeway)
+	}
+	if jwk == nil {
+		return nil, ErrJWKNotFound
+	}
+
+	return p.parsed.keyForToken(set, jwk, token, p.CertificateLeeway)
+}
+
+// Returns the cached JWK Set, fetching it if the cache is empty or stale.
+// If fetching fails, the stale cache or the Offline set is returned instead,
+// and an error only if neither is available.
+func (p *JWKSProvider) KeySet(ctx context.Context) (*JSONWebKeySet, error) {
+	p.mu.RLock()
+	keys, fetched := p.keys, p.fetched
+	p.mu.RUnlock()
+
+	if keys != nil && TimeFunc().Sub(fetched) < p.refreshInterval() {
+		return keys, nil
+	}
+
+	// With something to fall back on, don't hammer an endpoint that
+	// recently failed
+	set, err := p.refresh(ctx, keys != nil || p.Offline != nil)
+	if err == errRefreshLimited {
+		return p.fallback(), nil
+	}
+	if err != nil {
+		if set = p.fallback(); set == nil {
+			return nil, err
+		}
+		logAt(p.Logger, slog.LevelWarn, "jwt: serving stale JWK Set", slog.String("url", p.URL), slog.Time("fetched", fetched))
+	}
+	return set, nil
+}
+
+// Fetch the JWK Set now, replacing the cached copy.  Unlike KeySet this
+// reports failure to fetch from every URL even if a fallback is available.
+func (p *JWKSProvider) Refresh(ctx context.Context) error {
+	_, err := p.refresh(ctx, false)
+	return err
+}
+
+// Fetch the JWK Set, or wait for the fetch already under way.  With
+// limited, no fetch is started within MinRefreshInterval of the start of
+// the last one, and errRefreshLimited is returned instead.  The fetch is
+// not canceled with ctx, as other callers may be waiting for it; the
+// client's timeout bounds it.
+func (p *JWKSProvider) refresh(ctx context.Context, limited bool) (*JSONWebKeySet, error) {
+	p.mu.Lock()
+	f := p.flight
+	if f == nil {
+		if limited && TimeFunc().Sub(p.attempted) < p.minRefreshInterval() {
+			p.mu.Unlock()
+			return nil, errRefreshLimited
+		}
+		f = &jwksFetch{done: make(chan struct{})}
+		p.flight, p.attempted = f, TimeFunc()
+		go p.fetch(context.WithoutCancel(ctx), f)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.set, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Fetch the JWK Set from the first URL that serves it, completing f
+func (p *JWKSProvider) fetch(ctx context.Context, f *jwksFetch) {
+	set, err := p.fetchFirst(ctx)
+
+	p.mu.Lock()
+	if err == nil {
+		p.keys, p.fetched = set, TimeFunc()
+	}
+	p.flight = nil
+	p.mu.Unlock()
+
+	f.set, f.err = set, err
+	close(f.done)
+}
+
+func (p *JWKSProvider) fetchFirst(ctx context.Context) (*JSONWebKeySet, error) {
+	err := errors.New("no JWKS URL configured")
+	for _, url := range p.urls() {
+		set := new(JSONWebKeySet)
+		fetchCtx, span := startSpan(ctx, p.tracer(), SpanJWKSFetch)
+		span.SetAttribute(AttributeURL, url)
+		err = fetchJSON(fetchCtx, p.Client, url, set)
+		endSpan(span, err)
+		if m := p.metrics(); m != nil {
+			m.ObserveJWKSFetch(url, CodeOf(err))
+		}
+		if err != nil {
+			logAt(p.Logger, slog.LevelWarn, "jwt: fetching JWK Set failed", slog.String("url", url), slog.String("error", err.Error()))
+			continue
+		}
+		return set, nil
+	}
+	return nil, err
+}
+
+// The last fetched set, or the Offline set if nothing was ever fetched
+func (p *JWKSProvider) fallback() *JSONWebKeySet {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.keys != nil {
+		return p.keys
+	}
+	return p.Offline
+}
+
+func (p *JWKSProvider) urls() []string {
+	urls := make([]string, 0, 1+len(p.FallbackURLs))
+	if p.URL != "" {
+		urls = append(urls, p.URL)
+	}
+	return append(urls, p.FallbackURLs...)
+}
+
+func (p *JWKSProvider) minRefreshInterval() time.Duration {
+	if p.MinRefreshInterval == 0 {
+		return DefaultJWKSMinRefreshInterval
+	}
+	return p.MinRefreshInterval
+}
+
+func (p *JWKSProvider) refreshInterval() time.Duration {
+	if p.RefreshInterval == 0 {
+		return DefaultJWKSRefreshInterval
+	}
+	return p.RefreshInterval
+}
+
+// Returned when a JWK Set or discovery document could not be fetched
+type FetchError struct {
+	URL string
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("fetching %v: %v", e.URL, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// ----- helpers
+
+// Pick the verification key for kid.  Keys declared for verification win
+// over other keys sharing the kid, such as an encryption key published
+// under the same name.  Without a kid only an unambiguous set will do.
+func selectJWK(set *JSONWebKeySet, kid string) *JSONWebKey {
+	var match, candidate *JSONWebKey
+	var candidates int
+	for i := range set.Keys {
+		jwk := &set.Keys[i]
+		if kid != "" && jwk.Kid != kid {
+			continue
+		}
+		if match == nil {
+			match = jwk
+		}
+		if jwk.Permits("verify") {
+			if candidate == nil {
+				candidate = jwk
+			}
+			candidates++
+		}
+	}
+
+	if kid == "" {
+		if candidates == 1 {
+			return candidate
+		}
+		if candidates == 0 && len(set.Keys) == 1 {
+			return match
+		}
+		return nil
+	}
+	if candidate != nil {
+		return candidate
+	}
+	return match
+}
+
+// Decode a JWK of set for verifying token, rejecting keys bound to another
+// algorithm, declared for another purpose or with an x5c certificate not
+// valid at the parser's time.  The decoded key and certificate are kept
+// until the set changes, so they are not parsed again for every token.
+func (c *jwkCache) keyForToken(set *JSONWebKeySet, jwk *JSONWebKey, token *Token, leeway time.Duration) (interface{}, error) {
+	if alg, _ := token.Header["alg"].(string); jwk.Alg != "" && jwk.Alg != alg {
+		return nil, fmt.Errorf("key %v is for use with %v, not %v", jwk.Kid, jwk.Alg, alg)
+	}
+	parsed := c.get(set, jwk)
+	if parsed.certErr != nil {
+		return nil, parsed.certErr
+	}
+	if parsed.cert != nil {
+		if err := checkCertificateValidity(parsed.cert, token.time(), leeway); err != nil {
+			return nil, err
+		}
+	}
+	return parsed.key, parsed.keyErr
+}
+
+// Every key of set that may verify token, for a token without a kid.  Keys
+// that keyForToken rejects are left out.
+func (c *jwkCache) keySetForToken(set *JSONWebKeySet, token *Token, leeway time.Duration) (interface{}, error) {
+	var keys []interface{}
+	for i := range set.Keys {
+		jwk := &set.Keys[i]
+		if !jwk.Permits("verify") {
+			continue
+		}
+		if key, err := c.keyForToken(set, jwk, token, leeway); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, ErrJWKNotFound
+	}
+	return VerificationKeySet{Keys: keys}, nil
+}
+
+// Decoded keys of one JWK Set
+type jwkCache struct {
+	mu   sync.RWMutex
+	set  *JSONWebKeySet
+	keys map[*JSONWebKey]*parsedJWK
+}
+
+type parsedJWK struct {
+	cert    *x509.Certificate // Leaf of x5c, if any
+	certErr error
+	key     interface{} // From VerificationKey
+	keyErr  error
+}
+
+func (c *jwkCache) get(set *JSONWebKeySet, jwk *JSONWebKey) *parsedJWK {
+	c.mu.RLock()
+	parsed := c.keys[jwk]
+	if c.set != set {
+		parsed = nil
+	}
+	c.mu.RUnlock()
+	if parsed != nil {
+		return parsed
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.set != set {
+		c.set, c.keys = set, map[*JSONWebKey]*parsedJWK{}
+	}
+	if parsed = c.keys[jwk]; parsed == nil {
+		parsed = new(parsedJWK)
+		parsed.cert, parsed.certErr = jwk.leafCertificate()
+		parsed.key, parsed.keyErr = jwk.VerificationKey()
+		c.keys[jwk] = parsed
+	}
+	return parsed
+}
+
+// GET url and decode the JSON response body into v
+func fetchJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	if client == nil {
+		client = defaultFetchClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return &FetchError{url, err}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &FetchError{url, err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &FetchError{url, fmt.Errorf("unexpected status %v", resp.Status)}
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxFetchSize)).Decode(v); err != nil {
+		return &FetchError{url, err}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+// Serve whatever key set is currently stored in keys, counting fetches
+func newJWKSServer(keys *atomic.Value, fetches *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(fetches, 1)
+		json.NewEncoder(w).Encode(keys.Load())
+	}))
+}
+
+func makeJWKSToken(t *testing.T, kid string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"foo": "bar"})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func sampleJWK(kid, alg string) jwt.JSONWebKey {
+	jwk, _ := jwt.NewJSONWebKey(test.LoadRSAPublicKeyFromDisk("test/sample_key.pub"))
+	jwk.Kid = kid
+	jwk.Alg = alg
+	return *jwk
+}
+
+func TestJWKSProvider(t *testing.T) {
+	var keys atomic.Value
+	var fetches int32
+	keys.Store(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("old", "RS256")}})
+
+	server := newJWKSServer(&keys, &fetches)
+	defer server.Close()
+
+	provider := jwt.NewJWKSProvider(server.URL)
+	provider.MinRefreshInterval = -1 // always allow refetching on unknown kid
+
+	if _, err := jwt.Parse(makeJWKSToken(t, "old"), provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying token with known kid: %v", err)
+	}
+	if _, err := jwt.Parse(makeJWKSToken(t, "old"), provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying token from cache: %v", err)
+	}
+	if atomic.LoadInt32(&fetches) != 1 {
+		t.Errorf("Expected a single fetch.  Got %v", fetches)
+	}
+
+	// Rotate keys at the "issuer".  The unknown kid must trigger a refetch.
+	keys.Store(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("new", "RS256")}})
+	if _, err := jwt.Parse(makeJWKSToken(t, "new"), provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying token after rotation: %v", err)
+	}
+	if atomic.LoadInt32(&fetches) != 2 {
+		t.Errorf("Expected a refetch after rotation.  Got %v fetches", fetches)
+	}
+
+	// No kid is fine while the set is unambiguous
+	if _, err := jwt.Parse(makeJWKSToken(t, ""), provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying token without kid: %v", err)
+	}
+
+	if _, err := jwt.Parse(makeJWKSToken(t, "missing"), provider.Keyfunc); err == nil {
+		t.Errorf("Token with unknown kid passed validation")
+	} else if ve := err.(*jwt.ValidationError); ve.Inner != jwt.ErrJWKNotFound {
+		t.Errorf("Expected ErrJWKNotFound.  Got %v", ve.Inner)
+	}
+}
+
+func TestJWKSProvider_algMismatch(t *testing.T) {
+	var keys atomic.Value
+	var fetches int32
+	keys.Store(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("k", "PS256")}})
+
+	server := newJWKSServer(&keys, &fetches)
+	defer server.Close()
+
+	provider := jwt.NewJWKSProvider(server.URL)
+	if _, err := jwt.Parse(makeJWKSToken(t, "k"), provider.Keyfunc); err == nil {
+		t.Errorf("Token signed with an algorithm the key is not bound to passed validation")
+	}
+}
+
+func TestJWKSProvider_fetchError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	provider := jwt.NewJWKSProvider(server.URL)
+	if err := provider.Refresh(context.Background()); err == nil {
+		t.Errorf("Expected an error fetching from a failing endpoint")
+	}
+}
+
+func TestJWKSProvider_use(t *testing.T) {
+	var keys atomic.Value
+	var fetches int32
+
+	enc := sampleJWK("k", "")
+	enc.Use = "enc"
+	keys.Store(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{enc}})
+
+	server := newJWKSServer(&keys, &fetches)
+	defer server.Close()
+
+	provider := jwt.NewJWKSProvider(server.URL)
+	_, err := jwt.Parse(makeJWKSToken(t, "k"), provider.Keyfunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrJWKUsage {
+		t.Errorf("Expected ErrJWKUsage for an encryption key.  Got %v", err)
+	}
+
+	// A signature key sharing the kid is preferred over the encryption key
+	sig := sampleJWK("k", "")
+	sig.Use = "sig"
+	keys.Store(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{enc, sig}})
+	provider.Refresh(context.Background())
+	if _, err = jwt.Parse(makeJWKSToken(t, "k"), provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying with signature key: %v", err)
+	}
+}
+
+func TestJWKSProvider_failover(t *testing.T) {
+	var keys atomic.Value
+	var fetches int32
+	keys.Store(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("k", "RS256")}})
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	mirror := newJWKSServer(&keys, &fetches)
+	defer mirror.Close()
+
+	provider := &jwt.JWKSProvider{URL: down.URL, FallbackURLs: []string{mirror.URL}}
+	if _, err := jwt.Parse(makeJWKSToken(t, "k"), provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying through fallback URL: %v", err)
+	}
+
+	// Once every URL is down, the last fetched set keeps being served
+	mirror.Close()
+	provider.RefreshInterval = -1
+	provider.MinRefreshInterval = -1
+	if _, err := jwt.Parse(makeJWKSToken(t, "k"), provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying from stale cache: %v", err)
+	}
+	if err := provider.Refresh(context.Background()); err == nil {
+		t.Errorf("Expected Refresh to report the outage")
+	}
+}
+
+func TestJWKSProvider_offline(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+
+	offline := &jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("k", "RS256")}}
+
+	for _, url := range []string{down.URL, ""} {
+		provider := &jwt.JWKSProvider{URL: url, Offline: offline}
+		if _, err := jwt.Parse(makeJWKSToken(t, "k"), provider.Keyfunc); err != nil {
+			t.Errorf("[%v] Error verifying from offline set: %v", url, err)
+		}
+	}
+
+	provider := &jwt.JWKSProvider{URL: down.URL}
+	if _, err := jwt.Parse(makeJWKSToken(t, "k"), provider.Keyfunc); err == nil {
+		t.Errorf("Token passed validation without any key source")
+	}
+}
+
+func TestJWKSProvider_parsedKeyCache(t *testing.T) {
+	var keys atomic.Value
+	var fetches int32
+	keys.Store(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("k1", "RS256")}})
+
+	server := newJWKSServer(&keys, &fetches)
+	defer server.Close()
+	provider := jwt.NewJWKSProvider(server.URL)
+	provider.MinRefreshInterval = -1
+
+	lookup := func(kid string) interface{} {
+		token, _, _ := new(jwt.Parser).ParseUnverified(makeJWKSToken(t, kid), jwt.MapClaims{})
+		key, err := provider.LookupKey(context.Background(), token)
+		if err != nil {
+			t.Fatalf("[%v] Error looking up key: %v", kid, err)
+		}
+		return key
+	}
+
+	first := lookup("k1")
+	if lookup("k1") != first {
+		t.Errorf("Expected the parsed key to be reused")
+	}
+
+	// A new set is parsed again
+	keys.Store(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("k2", "RS256")}})
+	if lookup("k2") == first {
+		t.Errorf("Expected the key of the new set")
+	}
+}
+
+func TestJWKSProvider_concurrentFetches(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	keys := &jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("k1", "RS256")}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		json.NewEncoder(w).Encode(keys)
+	}))
+	defer server.Close()
+	provider := jwt.NewJWKSProvider(server.URL)
+
+	// A burst of tokens with forged kids, before and after the first fetch
+	burst := func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				jwt.Parse(makeJWKSToken(t, fmt.Sprintf("forged-%v", i)), provider.Keyfunc)
+			}(i)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+	}
+	burst()
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("Expected the burst to share one fetch.  Got %v", n)
+	}
+
+	// Once MinRefreshInterval has passed, a burst refetches once more
+	at(time.Now().Add(jwt.DefaultJWKSMinRefreshInterval+time.Second), func() {
+		release = make(chan struct{})
+		burst()
+	})
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("Expected one more fetch after MinRefreshInterval.  Got %v", n)
+	}
+
+	// Callers stop waiting when their context is done
+	at(time.Now().Add(2*jwt.DefaultJWKSMinRefreshInterval+time.Second), func() {
+		release = make(chan struct{})
+		defer close(release)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := provider.Refresh(ctx); err != context.DeadlineExceeded {
+			t.Errorf("Expected %v.  Got %v", context.DeadlineExceeded, err)
+		}
+	})
+}
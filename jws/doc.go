@@ -0,0 +1,8 @@
+// Package jws signs and verifies arbitrary payloads, such as webhook
+// bodies or manifests, as compact JSON Web Signatures, RFC 7515.  Unlike
+// package jwt, the payload is opaque bytes rather than claims, but the
+// signing methods are the same ones, from jwt's registry:
+//
+//	s, err := jws.Sign(body, jwt.SigningMethodES256, key)
+//	msg, err := jws.Verify(s, func(map[string]interface{}) (interface{}, error) { return &key.PublicKey, nil })
+package jws
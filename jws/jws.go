@@ -0,0 +1,155 @@
+package jws
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Errors
+var (
+	ErrMalformed      = errors.New("jws: token is malformed")
+	ErrUnsupported    = errors.New("jws: alg is not supported")
+	ErrMethodRefused  = errors.New("jws: alg is not allowed")
+	ErrCriticalHeader = errors.New("jws: token has a critical header that is not supported")
+	ErrNoKeyfunc      = errors.New("jws: no Keyfunc was provided")
+	ErrTooLarge       = errors.New("jws: token exceeds the size limit")
+)
+
+// Supplies the key for verifying, given the header of the token.  Like
+// jwt.Keyfunc, it can use kid or alg to choose.
+type Keyfunc func(header map[string]interface{}) (interface{}, error)
+
+// A verified JWS
+type Message struct {
+	Header  map[string]interface{}
+	Payload []byte
+}
+
+// Sign payload with method and key, returning the compact serialization
+func Sign(payload []byte, method jwt.SigningMethod, key interface{}) (string, error) {
+	return SignWithHeader(payload, method, key, nil)
+}
+
+// Sign, with header adding parameters such as kid or cty
+func SignWithHeader(payload []byte, method jwt.SigningMethod, key interface{}, header map[string]interface{}) (string, error) {
+	h := map[string]interface{}{}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["alg"] = method.Alg()
+
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	signing := jwt.EncodeSegment(headerJSON) + "." + jwt.EncodeSegment(payload)
+
+	sig, err := method.Sign(signing, key)
+	if err != nil {
+		return "", err
+	}
+	return signing + "." + sig, nil
+}
+
+// Verifies tokens.  The zero value accepts every registered signing method
+// and tokens up to jwt.DefaultMaxTokenLength.
+type Verifier struct {
+	Methods         []string // If populated, only these alg values are accepted
+	CriticalHeaders []string // Header extensions the application understands
+	MaxLength       int      // Longer tokens are rejected.  Defaults to jwt.DefaultMaxTokenLength, negative for no limit
+}
+
+// Verify a token in the compact serialization with the key from keyFunc
+func Verify(token string, keyFunc Keyfunc) (*Message, error) {
+	return new(Verifier).Verify(token, keyFunc)
+}
+
+func (v *Verifier) Verify(token string, keyFunc Keyfunc) (*Message, error) {
+	if max := v.maxLength(); max >= 0 && len(token) > max {
+		return nil, ErrTooLarge
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	headerJSON, err := jwt.DecodeSegment(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var header map[string]interface{}
+	if err = json.Unmarshal(headerJSON, &header); err != nil || header == nil {
+		return nil, ErrMalformed
+	}
+	payload, err := jwt.DecodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	alg, ok := header["alg"].(string)
+	if !ok {
+		return nil, ErrMalformed
+	}
+	if v.Methods != nil && !contains(v.Methods, alg) {
+		return nil, ErrMethodRefused
+	}
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, ErrUnsupported
+	}
+	if err = checkCritical(header, v.CriticalHeaders); err != nil {
+		return nil, err
+	}
+
+	if keyFunc == nil {
+		return nil, ErrNoKeyfunc
+	}
+	key, err := keyFunc(header)
+	if err != nil {
+		return nil, err
+	}
+	if err = method.Verify(parts[0]+"."+parts[1], parts[2], key); err != nil {
+		return nil, err
+	}
+	return &Message{header, payload}, nil
+}
+
+func (v *Verifier) maxLength() int {
+	if v.MaxLength == 0 {
+		return jwt.DefaultMaxTokenLength
+	}
+	return v.MaxLength
+}
+
+// ----- helpers
+
+func contains(list []string, name string) bool {
+	for _, l := range list {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Unknown extensions listed in crit must be rejected, RFC 7515 section 4.1.11
+func checkCritical(header map[string]interface{}, understood []string) error {
+	v, ok := header["crit"]
+	if !ok {
+		return nil
+	}
+	crit, ok := v.([]interface{})
+	if !ok || len(crit) == 0 {
+		return ErrMalformed
+	}
+	for _, c := range crit {
+		name, _ := c.(string)
+		if _, ok := header[name]; !ok || !contains(understood, name) {
+			return ErrCriticalHeader
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package jws
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+var sampleKey = []byte("webhook secret")
+
+func staticKey(key interface{}) Keyfunc {
+	return func(map[string]interface{}) (interface{}, error) { return key, nil }
+}
+
+func TestSign(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	payload := []byte("not JSON \x00\xff")
+
+	var signTestData = []struct {
+		method jwt.SigningMethod
+		sign   interface{}
+		verify interface{}
+		wrong  interface{}
+	}{
+		{jwt.SigningMethodHS256, sampleKey, sampleKey, []byte("other")},
+		{jwt.SigningMethodRS256, rsaKey, &rsaKey.PublicKey, sampleKey},
+		{jwt.SigningMethodPS384, rsaKey, &rsaKey.PublicKey, sampleKey},
+	}
+
+	for _, data := range signTestData {
+		name := data.method.Alg()
+		s, err := Sign(payload, data.method, data.sign)
+		if err != nil {
+			t.Errorf("[%v] Error signing: %v", name, err)
+			continue
+		}
+		msg, err := Verify(s, staticKey(data.verify))
+		if err != nil || !bytes.Equal(msg.Payload, payload) || msg.Header["alg"] != name {
+			t.Errorf("[%v] Error verifying: %v", name, err)
+		}
+		if _, err := Verify(s, staticKey(data.wrong)); err == nil {
+			t.Errorf("[%v] Expected verification with the wrong key to fail", name)
+		}
+	}
+}
+
+func TestSignWithHeader(t *testing.T) {
+	s, err := SignWithHeader([]byte("manifest"), jwt.SigningMethodHS256, sampleKey, map[string]interface{}{"kid": "k1", "alg": "none"})
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	msg, err := Verify(s, staticKey(sampleKey))
+	if err != nil || msg.Header["kid"] != "k1" || msg.Header["alg"] != "HS256" {
+		t.Errorf("Unexpected header %v: %v", msg, err)
+	}
+}
+
+var verifyErrorTestData = []struct {
+	name   string
+	mangle func([]string) []string
+	v      *Verifier
+	err    error
+}{
+	{"segments", func(p []string) []string { return p[:2] }, nil, ErrMalformed},
+	{"header", func(p []string) []string { return []string{"!", p[1], p[2]} }, nil, ErrMalformed},
+	{"payload", func(p []string) []string { return []string{p[0], "!", p[2]} }, nil, ErrMalformed},
+	{"no alg", func(p []string) []string { return []string{encodeHeader(`{"kid":"k1"}`), p[1], p[2]} }, nil, ErrMalformed},
+	{"unknown alg", func(p []string) []string { return []string{encodeHeader(`{"alg":"XX1"}`), p[1], p[2]} }, nil, ErrUnsupported},
+	{"refused", nil, &Verifier{Methods: []string{"ES256"}}, ErrMethodRefused},
+	{"crit", func(p []string) []string {
+		return []string{encodeHeader(`{"alg":"HS256","exp":1,"crit":["exp"]}`), p[1], p[2]}
+	}, nil, ErrCriticalHeader},
+	{"too long", nil, &Verifier{MaxLength: 10}, ErrTooLarge},
+	{"signature", func(p []string) []string { return []string{p[0], jwt.EncodeSegment([]byte("other")), p[2]} }, nil, jwt.ErrSignatureInvalid},
+}
+
+func TestVerify_errors(t *testing.T) {
+	s, _ := Sign([]byte("payload"), jwt.SigningMethodHS256, sampleKey)
+
+	for _, data := range verifyErrorTestData {
+		parts := strings.Split(s, ".")
+		if data.mangle != nil {
+			parts = data.mangle(parts)
+		}
+		v := data.v
+		if v == nil {
+			v = new(Verifier)
+		}
+		if _, err := v.Verify(strings.Join(parts, "."), staticKey(sampleKey)); err != data.err {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
+
+func encodeHeader(header string) string {
+	return jwt.EncodeSegment([]byte(header))
+}
@@ -0,0 +1,279 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrJWSNotCompact    = newError(CodeTokenMalformed, "JWS has several signatures or unprotected headers that the serialization cannot carry")
+	ErrTooFewSignatures = newError(CodeInvalidSignature, "JWS has fewer signatures than the parser requires")
+	ErrDuplicateSigner  = newError(CodeInvalidSignature, "JWS has two signatures by the same key")
+)
+
+// Signatures AllSignatures requires when Parser.MinSignatures is not set
+const DefaultMinSignatures = 2
+
+// Which signatures of a JSON serialized token VerifyJSON requires to verify
+type SignaturePolicy int
+
+const (
+	AnySignature  SignaturePolicy = iota // At least one signature must verify
+	AllSignatures                        // Every signature must verify, each by a different key, e.g. for dual control
+)
+
+// A token in the JWS JSON Serialization, RFC 7515 section 7.2.  Unlike the
+// compact form, it can carry several signatures over the same payload.
+type JSONWebSignature struct {
+	Payload    string          `json:"payload"`
+	Signatures []JSONSignature `json:"signatures"`
+}
+
+// Also accepts the flattened serialization of RFC 7515 section 7.2.2,
+// which has a single signature and its headers at the top level
+func (jws *JSONWebSignature) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Payload    string          `json:"payload"`
+		Signatures []JSONSignature `json:"signatures"`
+		JSONSignature
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	jws.Payload, jws.Signatures = raw.Payload, raw.Signatures
+	if raw.Signatures == nil && raw.Signature != "" {
+		jws.Signatures = []JSONSignature{raw.JSONSignature}
+	}
+	return nil
+}
+
+// The flattened serialization of jws.  Fails with ErrJWSNotCompact unless
+// there is exactly one signature.
+func (jws *JSONWebSignature) MarshalFlattened() ([]byte, error) {
+	if len(jws.Signatures) != 1 {
+		return nil, ErrJWSNotCompact
+	}
+	return json.Marshal(flattenedJWS{jws.Payload, jws.Signatures[0]})
+}
+
+// The compact serialization of jws.  Fails with ErrJWSNotCompact unless
+// there is exactly one signature, with no unprotected header.
+func (jws *JSONWebSignature) Compact() (string, error) {
+	if len(jws.Signatures) != 1 || len(jws.Signatures[0].Header) != 0 {
+		return "", ErrJWSNotCompact
+	}
+	sig := jws.Signatures[0]
+	return sig.Protected + "." + jws.Payload + "." + sig.Signature, nil
+}
+
+type flattenedJWS struct {
+	Payload string `json:"payload"`
+	JSONSignature
+}
+
+// One signature of a JSONWebSignature.  Protected is the encoded header
+// covered by the signature, Header holds parameters that are not.
+type JSONSignature struct {
+	Protected string                 `json:"protected,omitempty"`
+	Header    map[string]interface{} `json:"header,omitempty"`
+	Signature string                 `json:"signature"`
+}
+
+// A key to sign with, for SignJSON
+type JSONSigner struct {
+	Method      SigningMethod
+	Key         interface{}
+	Header      map[string]interface{} // Added to the protected header, e.g. kid
+	Unprotected map[string]interface{} // Sent in the clear, not covered by the signature
+}
+
+// Sign claims with each of signers and return the token in the general JWS
+// JSON Serialization
+func SignJSON(claims Claims, signers ...JSONSigner) ([]byte, error) {
+	jws, err := signJSON(claims, signers)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jws)
+}
+
+// Sign claims with signer and return the token in the flattened JWS JSON
+// Serialization
+func SignFlattenedJSON(claims Claims, signer JSONSigner) ([]byte, error) {
+	jws, err := signJSON(claims, []JSONSigner{signer})
+	if err != nil {
+		return nil, err
+	}
+	return jws.MarshalFlattened()
+}
+
+// Convert a compact token to the flattened JSON serialization.  The token
+// is not verified.
+func CompactToJSON(tokenString string) ([]byte, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+	}
+	jws := &JSONWebSignature{parts[1], []JSONSignature{{Protected: parts[0], Signature: parts[2]}}}
+	return jws.MarshalFlattened()
+}
+
+// Convert a token in either JSON serialization to the compact one.  The
+// token is not verified.
+func JSONToCompact(data []byte) (string, error) {
+	jws := new(JSONWebSignature)
+	if err := json.Unmarshal(data, jws); err != nil {
+		return "", &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	return jws.Compact()
+}
+
+func signJSON(claims Claims, signers []JSONSigner) (*JSONWebSignature, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	jws := &JSONWebSignature{Payload: EncodeSegment(payload)}
+
+	for _, signer := range signers {
+		token := NewWithClaims(signer.Method, claims)
+		for k, v := range signer.Header {
+			token.Header[k] = v
+		}
+		if err := checkCriticalForSigning(token.Header); err != nil {
+			return nil, err
+		}
+		header, err := json.Marshal(token.Header)
+		if err != nil {
+			return nil, err
+		}
+
+		protected := EncodeSegment(header)
+		sig, err := signer.Method.Sign(protected+"."+jws.Payload, signer.Key)
+		if err != nil {
+			return nil, err
+		}
+		jws.Signatures = append(jws.Signatures, JSONSignature{protected, signer.Unprotected, sig})
+	}
+
+	return jws, nil
+}
+
+// Parse and verify a token in the general or flattened JWS JSON
+// Serialization.  The token is valid if any of its signatures verifies;
+// the returned Token is the one for that signature, with the unprotected
+// header merged into Header.  Set Parser.SignaturePolicy to require all.
+//
+// With AllSignatures there must also be at least Parser.MinSignatures
+// signatures, and no two by the same key, so that a token cut down to one
+// signature, or with one signature repeated, is refused.  A signature's
+// key is the one the Keyfunc returned for it.
+func VerifyJSON(data []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).VerifyJSON(data, claims, keyFunc)
+}
+
+// VerifyJSON, with the options of the Parser applied to each signature.
+// Only the result for the token as a whole is reported to the Parser's
+// ErrorLogger, Metrics, Tracer and SecurityHook.
+func (p *Parser) VerifyJSON(data []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	return p.reported(context.Background(), func(ctx context.Context) (*Token, error) {
+		return p.verifyJSON(ctx, data, claims, keyFunc)
+	})
+}
+
+func (p *Parser) verifyJSON(ctx context.Context, data []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	jws := new(JSONWebSignature)
+	if err := json.Unmarshal(data, jws); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	if len(jws.Signatures) == 0 {
+		return nil, NewValidationError("token has no signatures", ValidationErrorMalformed)
+	}
+
+	var token *Token
+	var firstErr error
+	var signers []interface{}
+	for i := range jws.Signatures {
+		t, key, err := p.verifyJSONSignature(ctx, jws.Payload, &jws.Signatures[i], claims, keyFunc)
+		if p.SignaturePolicy == AnySignature {
+			if err == nil {
+				return t, nil
+			}
+			if token == nil {
+				token, firstErr = t, err
+			}
+			continue
+		}
+
+		if err != nil {
+			return t, err
+		}
+		for _, signer := range signers {
+			if sameKey(signer, key) {
+				return t, &ValidationError{Inner: ErrDuplicateSigner, Errors: ValidationErrorSignatureInvalid}
+			}
+		}
+		signers = append(signers, key)
+		if token == nil {
+			token = t
+		}
+	}
+
+	if p.SignaturePolicy == AllSignatures && len(signers) < p.minSignatures() {
+		return token, &ValidationError{Inner: ErrTooFewSignatures, Errors: ValidationErrorSignatureInvalid}
+	}
+	return token, firstErr
+}
+
+func (p *Parser) minSignatures() int {
+	if p.MinSignatures == 0 {
+		return DefaultMinSignatures
+	}
+	return p.MinSignatures
+}
+
+// Verify one signature as if it were a compact token, returning the key
+// the Keyfunc gave for it.  The unprotected header is visible to keyFunc.
+func (p *Parser) verifyJSONSignature(ctx context.Context, payload string, sig *JSONSignature, claims Claims, keyFunc Keyfunc) (*Token, interface{}, error) {
+	if strings.Contains(sig.Protected+payload+sig.Signature, ".") {
+		return nil, nil, NewValidationError("token segment contains '.'", ValidationErrorMalformed)
+	}
+
+	var key interface{}
+	merged := func(token *Token) (interface{}, error) {
+		for k, v := range sig.Header {
+			if _, ok := token.Header[k]; ok {
+				// RFC 7515 section 7.2.1 requires the headers to be disjoint
+				return nil, NewValidationError("header "+k+" is both protected and unprotected", ValidationErrorMalformed)
+			}
+			token.Header[k] = v
+		}
+		if keyFunc == nil {
+			return nil, NewValidationError("no Keyfunc was provided.", ValidationErrorUnverifiable)
+		}
+		var err error
+		key, err = keyFunc(token)
+		return key, err
+	}
+
+	token, err := p.parseWithClaims(ctx, sig.Protected+"."+payload+"."+sig.Signature, claims, merged, nil)
+	return token, key, err
+}
+
+// Reports whether a and b are the same key.  Secrets are compared by
+// value, and the standard library's public keys with their Equal method.
+func sameKey(a, b interface{}) bool {
+	if x, ok := hmacSecret(a); ok {
+		y, ok := hmacSecret(b)
+		return ok && bytes.Equal(x, y)
+	}
+	if k, ok := a.(interface{ Equal(crypto.PublicKey) bool }); ok {
+		return k.Equal(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,213 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestSignJSON(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	hmacKey := []byte("partner-secret")
+
+	data, err := jwt.SignJSON(jwt.MapClaims{"foo": "bar"},
+		jwt.JSONSigner{Method: jwt.SigningMethodRS256, Key: rsaKey, Header: map[string]interface{}{"kid": "rsa"}},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: hmacKey, Unprotected: map[string]interface{}{"kid": "hmac"}},
+	)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+
+	var jws jwt.JSONWebSignature
+	if err := json.Unmarshal(data, &jws); err != nil || len(jws.Signatures) != 2 {
+		t.Fatalf("Expected general serialization with two signatures.  Got %s", data)
+	}
+
+	// Each party can verify with its own key, found through either header
+	for _, kid := range []string{"rsa", "hmac"} {
+		token, err := jwt.VerifyJSON(data, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
+			if token.Header["kid"] != kid {
+				return nil, jwt.ErrKeyNotFound
+			}
+			if kid == "rsa" {
+				return &rsaKey.PublicKey, nil
+			}
+			return hmacKey, nil
+		})
+		if err != nil || !token.Valid || token.Claims.(jwt.MapClaims)["foo"] != "bar" {
+			t.Errorf("[%v] Error verifying: %v", kid, err)
+		}
+	}
+
+	if _, err := jwt.VerifyJSON(data, jwt.MapClaims{}, func(*jwt.Token) (interface{}, error) {
+		return []byte("wrong"), nil
+	}); err == nil {
+		t.Errorf("Expected verification with the wrong key to fail")
+	}
+}
+
+func TestVerifyJSON_malformed(t *testing.T) {
+	hmacKey := []byte("secret")
+	data, _ := jwt.SignJSON(jwt.MapClaims{"foo": "bar"}, jwt.JSONSigner{
+		Method: jwt.SigningMethodHS256, Key: hmacKey, Unprotected: map[string]interface{}{"alg": "none"},
+	})
+
+	for _, input := range [][]byte{data, []byte(`{"payload":"e30"}`), []byte(`[]`)} {
+		_, err := jwt.VerifyJSON(input, jwt.MapClaims{}, func(*jwt.Token) (interface{}, error) { return hmacKey, nil })
+		if !jwt.IsMalformed(err) {
+			t.Errorf("Expected %s to be malformed.  Got %v", input, err)
+		}
+	}
+}
+
+func TestFlattenedJSON(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return &rsaKey.PublicKey, nil }
+
+	data, err := jwt.SignFlattenedJSON(jwt.MapClaims{"foo": "bar"}, jwt.JSONSigner{
+		Method: jwt.SigningMethodRS256, Key: rsaKey, Unprotected: map[string]interface{}{"kid": "1"},
+	})
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	var flat map[string]interface{}
+	json.Unmarshal(data, &flat)
+	if flat["signatures"] != nil || flat["signature"] == nil || flat["header"] == nil {
+		t.Errorf("Expected flattened serialization.  Got %s", data)
+	}
+	if token, err := jwt.VerifyJSON(data, jwt.MapClaims{}, keyfunc); err != nil || token.Header["kid"] != "1" {
+		t.Errorf("Error verifying flattened token: %v", err)
+	}
+
+	// An unprotected header can't be carried by the compact form
+	if _, err := jwt.JSONToCompact(data); err != jwt.ErrJWSNotCompact {
+		t.Errorf("Expected ErrJWSNotCompact.  Got %v", err)
+	}
+
+	// Round trip from compact form
+	s := test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, rsaKey)
+	data, err = jwt.CompactToJSON(s)
+	if err != nil {
+		t.Fatalf("Error converting to JSON: %v", err)
+	}
+	if _, err := jwt.VerifyJSON(data, jwt.MapClaims{}, keyfunc); err != nil {
+		t.Errorf("Error verifying converted token: %v", err)
+	}
+	if compact, err := jwt.JSONToCompact(data); err != nil || compact != s {
+		t.Errorf("Expected round trip to the original token.  Got %v %v", compact, err)
+	}
+}
+
+func TestVerifyJSON_policy(t *testing.T) {
+	releaseKey := []byte("release service")
+	auditKey := []byte("audit service")
+
+	data, _ := jwt.SignJSON(jwt.MapClaims{"release": "v1.2.3"},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: releaseKey, Header: map[string]interface{}{"kid": "release"}},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS512, Key: auditKey, Header: map[string]interface{}{"kid": "audit"}},
+	)
+	keys := map[string][]byte{"release": releaseKey, "audit": auditKey}
+	keyfunc := func(token *jwt.Token) (interface{}, error) {
+		if key, ok := keys[token.Header["kid"].(string)]; ok {
+			return key, nil
+		}
+		return nil, jwt.ErrKeyNotFound
+	}
+
+	all := &jwt.Parser{SignaturePolicy: jwt.AllSignatures}
+	if _, err := all.VerifyJSON(data, jwt.MapClaims{}, keyfunc); err != nil {
+		t.Errorf("Error verifying both signatures: %v", err)
+	}
+
+	// Losing one of the keys fails AllSignatures but not AnySignature
+	delete(keys, "audit")
+	if _, err := all.VerifyJSON(data, jwt.MapClaims{}, keyfunc); !errors.Is(err, jwt.ErrKeyNotFound) {
+		t.Errorf("Expected AllSignatures to fail on the audit signature.  Got %v", err)
+	}
+	if _, err := new(jwt.Parser).VerifyJSON(data, jwt.MapClaims{}, keyfunc); err != nil {
+		t.Errorf("Expected AnySignature to accept the release signature.  Got %v", err)
+	}
+}
+
+func TestVerifyJSON_allSignaturesSigners(t *testing.T) {
+	releaseKey := []byte("release service")
+	auditKey := []byte("audit service")
+	keys := map[string][]byte{"release": releaseKey, "audit": auditKey, "alias": releaseKey}
+	keyfunc := func(token *jwt.Token) (interface{}, error) {
+		return keys[token.Header["kid"].(string)], nil
+	}
+	data, _ := jwt.SignJSON(jwt.MapClaims{"release": "v1.2.3"},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: releaseKey, Header: map[string]interface{}{"kid": "release"}},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: auditKey, Header: map[string]interface{}{"kid": "audit"}},
+	)
+	var jws jwt.JSONWebSignature
+	json.Unmarshal(data, &jws)
+	release := jws.Signatures[0]
+
+	// The same release signature twice, or once more under another kid
+	aliased, _ := jwt.SignJSON(jwt.MapClaims{"release": "v1.2.3"},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: releaseKey, Header: map[string]interface{}{"kid": "release"}},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: releaseKey, Header: map[string]interface{}{"kid": "alias"}},
+	)
+	cut, _ := json.Marshal(jwt.JSONWebSignature{Payload: jws.Payload, Signatures: []jwt.JSONSignature{release}})
+	repeated, _ := json.Marshal(jwt.JSONWebSignature{Payload: jws.Payload, Signatures: []jwt.JSONSignature{release, release}})
+
+	var tests = []struct {
+		name string
+		data []byte
+		min  int
+		err  error
+	}{
+		{"both", data, 0, nil},
+		{"cut down", cut, 0, jwt.ErrTooFewSignatures},
+		{"cut down, one required", cut, 1, nil},
+		{"repeated", repeated, 0, jwt.ErrDuplicateSigner},
+		{"same key, other kid", aliased, 0, jwt.ErrDuplicateSigner},
+		{"three required", data, 3, jwt.ErrTooFewSignatures},
+	}
+	for _, data := range tests {
+		p := &jwt.Parser{SignaturePolicy: jwt.AllSignatures, MinSignatures: data.min}
+		_, err := p.VerifyJSON(data.data, jwt.MapClaims{}, keyfunc)
+		if data.err == nil && err != nil {
+			t.Errorf("[%v] Error verifying: %v", data.name, err)
+		}
+		if data.err != nil && (!errors.Is(err, data.err) || !errors.Is(err, jwt.ErrSignatureInvalid)) {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
+
+func TestVerifyJSON_reporting(t *testing.T) {
+	data, _ := jwt.SignJSON(jwt.MapClaims{"foo": "bar"},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: []byte("old"), Header: map[string]interface{}{"kid": "old"}},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: []byte("new"), Header: map[string]interface{}{"kid": "new"}},
+	)
+	var rejections, events int
+	metrics := new(recordingMetrics)
+	p := &jwt.Parser{
+		ErrorLogger:  jwt.ErrorLoggerFunc(func(*jwt.Rejection) { rejections++ }),
+		SecurityHook: jwt.SecurityHookFunc(func(*jwt.SecurityEvent) { events++ }),
+		Metrics:      metrics,
+	}
+
+	// The old signature fails, but the token passes on the new one
+	keyfunc := func(*jwt.Token) (interface{}, error) { return []byte("new"), nil }
+	if _, err := p.VerifyJSON(data, jwt.MapClaims{}, keyfunc); err != nil {
+		t.Fatalf("Error verifying: %v", err)
+	}
+	if rejections != 0 || events != 0 || len(metrics.verifys) != 1 || metrics.verifys[0] != "HS256 " {
+		t.Errorf("Expected one successful verification reported.  Got %v rejections, %v events and %v", rejections, events, metrics.verifys)
+	}
+
+	// A token failing overall is reported once
+	keyfunc = func(*jwt.Token) (interface{}, error) { return []byte("other"), nil }
+	if _, err := p.VerifyJSON(data, jwt.MapClaims{}, keyfunc); err == nil {
+		t.Fatalf("Expected the token to be refused")
+	}
+	if rejections != 1 || events != 1 || len(metrics.verifys) != 2 {
+		t.Errorf("Expected one failed verification reported.  Got %v rejections, %v events and %v", rejections, events, metrics.verifys)
+	}
+}
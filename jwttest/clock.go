@@ -0,0 +1,72 @@
+package jwttest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Where a TokenFactory's Clock starts if it has none
+var DefaultTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// A clock that moves only when told to.  It is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// A Clock stopped at now
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// A Parser checking exp, iat and nbf at the clock's time
+func (c *Clock) Parser() *jwt.Parser {
+	return &jwt.Parser{TimeFunc: c.Now}
+}
+
+// Make the clock jwt.TimeFunc until t ends, for code checking times
+// with that rather than with a Parser.  Tests doing this can't be run in
+// parallel.
+func (c *Clock) Install(t testing.TB) {
+	prev := jwt.TimeFunc
+	jwt.TimeFunc = c.Now
+	t.Cleanup(func() { jwt.TimeFunc = prev })
+}
+
+// Make jwt.IdFunc count up from id-1 until t ends, so the claims jwt
+// fills in, like those of jwt.NewClientAssertion, are the same on every
+// run.  With Install, tests doing this can't be run in parallel.
+func InstallIds(t testing.TB) {
+	var mu sync.Mutex
+	var n int
+	prev := jwt.IdFunc
+	jwt.IdFunc = func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+		return fmt.Sprintf("id-%d", n)
+	}
+	t.Cleanup(func() { jwt.IdFunc = prev })
+}
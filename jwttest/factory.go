@@ -0,0 +1,148 @@
+package jwttest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Defaults of a TokenFactory
+const (
+	DefaultIssuer   = "https://issuer.example.com"
+	DefaultAudience = "https://api.example.com"
+	DefaultSubject  = "user-1"
+	DefaultLifetime = time.Hour
+
+	// The aud of WrongAudience tokens
+	WrongAudience = "https://other.example.com"
+)
+
+// Mints tokens signed with a fixture key, at times relative to a Clock.
+// The same calls make the same tokens on every run: jti claims count up
+// from jti-1, and tokens are signed Deterministic.  The PS algorithms are
+// the exception, as their signatures are randomized.  Fields left unset
+// take the defaults, and Key and Clock are set to them by the first
+// token.  Methods panic if signing fails, which with a fixture key it
+// doesn't.
+type TokenFactory struct {
+	Key      *Key   // Defaults to KeyFor("HS256")
+	Clock    *Clock // Defaults to a Clock at DefaultTime
+	Issuer   string
+	Audience string
+	Subject  string
+	Lifetime time.Duration
+
+	mu  sync.Mutex
+	jti int
+}
+
+// The claims of a valid token: iss, aud and sub, iat and nbf of now, exp
+// after Lifetime and the next jti
+func (f *TokenFactory) Claims() jwt.MapClaims {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.init()
+	f.jti++
+
+	now := f.Clock.Now().Unix()
+	return jwt.MapClaims{
+		"iss": or(f.Issuer, DefaultIssuer),
+		"aud": or(f.Audience, DefaultAudience),
+		"sub": or(f.Subject, DefaultSubject),
+		"iat": now,
+		"nbf": now,
+		"exp": now + int64(f.lifetime()/time.Second),
+		"jti": fmt.Sprintf("jti-%d", f.jti),
+	}
+}
+
+// A valid token with the claims of Claims, changed by overrides.  An
+// override of nil removes the claim.
+func (f *TokenFactory) Valid(overrides jwt.MapClaims) string {
+	claims := f.Claims()
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	return f.Sign(claims)
+}
+
+// A token that expired a minute ago, after being valid for Lifetime
+func (f *TokenFactory) Expired() string {
+	claims := f.Claims()
+	exp := f.Clock.Now().Add(-time.Minute).Unix()
+	claims["exp"] = exp
+	claims["iat"] = exp - int64(f.lifetime()/time.Second)
+	claims["nbf"] = claims["iat"]
+	return f.Sign(claims)
+}
+
+// A token issued now that is valid from a minute from now
+func (f *TokenFactory) NotYetValid() string {
+	claims := f.Claims()
+	nbf := f.Clock.Now().Add(time.Minute).Unix()
+	claims["nbf"] = nbf
+	claims["exp"] = nbf + int64(f.lifetime()/time.Second)
+	return f.Sign(claims)
+}
+
+// A valid token for the audience WrongAudience
+func (f *TokenFactory) WrongAudience() string {
+	return f.Valid(jwt.MapClaims{"aud": WrongAudience})
+}
+
+// Sign claims with the factory's key, setting kid in the header
+func (f *TokenFactory) Sign(claims jwt.Claims) string {
+	f.mu.Lock()
+	f.init()
+	key := f.Key
+	f.mu.Unlock()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	token.Deterministic = jwt.SignsDeterministically(key.Method)
+	s, err := token.SignedString(key.Signing)
+	if err != nil {
+		panic(fmt.Sprintf("jwttest: signing with %v: %v", key.Alg, err))
+	}
+	return s
+}
+
+// A Parser checking times at the factory's Clock and accepting only its
+// key's alg
+func (f *TokenFactory) Parser() *jwt.Parser {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.init()
+	p := f.Clock.Parser()
+	p.ValidMethods = []string{f.Key.Alg}
+	return p
+}
+
+func (f *TokenFactory) init() {
+	if f.Key == nil {
+		f.Key = KeyFor("HS256")
+	}
+	if f.Clock == nil {
+		f.Clock = NewClock(DefaultTime)
+	}
+}
+
+func (f *TokenFactory) lifetime() time.Duration {
+	if f.Lifetime == 0 {
+		return DefaultLifetime
+	}
+	return f.Lifetime
+}
+
+func or(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
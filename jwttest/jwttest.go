@@ -0,0 +1,139 @@
+// Package jwttest helps test code that issues and verifies tokens.  It
+// has fixed keys for every algorithm, a Clock that moves only when told
+// to, and a TokenFactory minting the valid and invalid tokens tests need:
+//
+//	f := &jwttest.TokenFactory{Key: jwttest.KeyFor("ES256")}
+//	expired := f.Expired()
+//	_, err := f.Parser().Parse(expired, f.Key.Keyfunc()) // jwt.ErrTokenExpired
+//	f.Clock.Advance(2 * time.Hour)
+//
+// The keys are the same on every run, so tokens can be kept in golden
+// files.  They are published with this package, so never trust them
+// outside of tests.
+package jwttest
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"embed"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+//go:embed keys/*.pem
+var pemKeys embed.FS
+
+// A fixed key for an algorithm
+type Key struct {
+	Alg          string
+	Kid          string // jwttest- and the alg
+	Method       jwt.SigningMethod
+	Signing      interface{} // The private key, or the secret for HMAC
+	Verification interface{} // The public key, or the secret for HMAC
+}
+
+// The fixture for alg, such as RS256, EdDSA or none.  Panics if alg has
+// none.  The RSA algorithms share one 2048 bit key.
+func KeyFor(alg string) *Key {
+	loadKeys()
+	k, ok := keys[alg]
+	if !ok {
+		panic(fmt.Sprintf("jwttest: no key for alg %q", alg))
+	}
+	key := *k
+	return &key
+}
+
+// The algorithms there are keys for, sorted
+func Algorithms() []string {
+	loadKeys()
+	algs := make([]string, 0, len(keys))
+	for alg := range keys {
+		algs = append(algs, alg)
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// A Keyfunc giving k to tokens of its alg, and refusing others
+func (k *Key) Keyfunc() jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if token.Method == nil || token.Method.Alg() != k.Alg {
+			return nil, fmt.Errorf("jwttest: token is not signed with %v", k.Alg)
+		}
+		return k.Verification, nil
+	}
+}
+
+// A JWK Set of the public keys of the RSA, ECDSA and EdDSA fixtures, with
+// their kids and algs, for tests of JWK Set clients
+func JWKSet() *jwt.JSONWebKeySet {
+	set := new(jwt.JSONWebKeySet)
+	for _, alg := range Algorithms() {
+		k := keys[alg]
+		if _, secret := k.Verification.([]byte); secret {
+			continue
+		}
+		jwk, err := jwt.NewJSONWebKey(k.Verification)
+		if err != nil {
+			continue
+		}
+		jwk.Kid, jwk.Alg, jwk.Use = k.Kid, k.Alg, "sig"
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set
+}
+
+var (
+	keys     map[string]*Key
+	keysOnce sync.Once
+)
+
+func loadKeys() {
+	keysOnce.Do(func() {
+		keys = map[string]*Key{}
+		add := func(alg string, signing, verification interface{}) {
+			keys[alg] = &Key{alg, "jwttest-" + alg, jwt.GetSigningMethod(alg), signing, verification}
+		}
+
+		for _, alg := range []string{"HS256", "HS384", "HS512"} {
+			sum := sha512.Sum512([]byte("jwttest " + alg))
+			secret := sum[:jwt.GetSigningMethod(alg).(*jwt.SigningMethodHMAC).Hash.Size()]
+			add(alg, secret, secret)
+		}
+
+		rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(readPEM("rsa.pem"))
+		if err != nil {
+			panic(err)
+		}
+		for _, alg := range []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"} {
+			add(alg, rsaKey, &rsaKey.PublicKey)
+		}
+
+		for alg, file := range map[string]string{"ES256": "ec256.pem", "ES384": "ec384.pem", "ES512": "ec512.pem"} {
+			ecKey, err := jwt.ParseECPrivateKeyFromPEM(readPEM(file))
+			if err != nil {
+				panic(err)
+			}
+			add(alg, ecKey, &ecKey.PublicKey)
+		}
+
+		seed := sha256.Sum256([]byte("jwttest EdDSA"))
+		edKey := ed25519.NewKeyFromSeed(seed[:])
+		add("EdDSA", edKey, edKey.Public())
+
+		add("none", jwt.UnsafeAllowNoneSignatureType, jwt.UnsafeAllowNoneSignatureType)
+	})
+}
+
+func readPEM(name string) []byte {
+	data, err := pemKeys.ReadFile("keys/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
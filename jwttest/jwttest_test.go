@@ -0,0 +1,130 @@
+package jwttest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/jwttest"
+)
+
+func TestKeyFor(t *testing.T) {
+	for _, alg := range []string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA", "none"} {
+		f := &jwttest.TokenFactory{Key: jwttest.KeyFor(alg)}
+		token, err := f.Parser().Parse(f.Valid(nil), f.Key.Keyfunc())
+		if err != nil || !token.Valid {
+			t.Errorf("[%v] Expected a valid token.  Got %v", alg, err)
+			continue
+		}
+		if kid := token.Header["kid"]; kid != "jwttest-"+alg {
+			t.Errorf("[%v] Expected kid jwttest-%v.  Got %v", alg, alg, kid)
+		}
+	}
+	if got := len(jwttest.Algorithms()); got != 14 {
+		t.Errorf("Expected 14 algorithms.  Got %v", got)
+	}
+	if got := len(jwttest.JWKSet().Keys); got != 10 {
+		t.Errorf("Expected 10 keys in the JWK Set.  Got %v", got)
+	}
+
+	// Keyfunc refuses tokens of other algs
+	hs := &jwttest.TokenFactory{Key: jwttest.KeyFor("HS256")}
+	if _, err := new(jwt.Parser).Parse(hs.Valid(nil), jwttest.KeyFor("HS384").Keyfunc()); err == nil {
+		t.Errorf("Expected an HS256 token to be refused by the HS384 key")
+	}
+}
+
+func TestTokenFactory_deterministic(t *testing.T) {
+	for _, alg := range []string{"HS256", "RS256", "ES256", "ES384", "ES512", "EdDSA"} {
+		a := &jwttest.TokenFactory{Key: jwttest.KeyFor(alg)}
+		b := &jwttest.TokenFactory{Key: jwttest.KeyFor(alg)}
+		if x, y := a.Valid(nil), b.Valid(nil); x != y {
+			t.Errorf("[%v] Expected the same token from each factory.  Got %v and %v", alg, x, y)
+		}
+	}
+
+	f := new(jwttest.TokenFactory)
+	first, second := f.Claims(), f.Claims()
+	if first["jti"] != "jti-1" || second["jti"] != "jti-2" {
+		t.Errorf("Expected jti-1 and jti-2.  Got %v and %v", first["jti"], second["jti"])
+	}
+	if first["iat"] != jwttest.DefaultTime.Unix() {
+		t.Errorf("Expected iat of %v.  Got %v", jwttest.DefaultTime.Unix(), first["iat"])
+	}
+}
+
+func TestTokenFactory(t *testing.T) {
+	f := new(jwttest.TokenFactory)
+	var tests = []struct {
+		name   string
+		token  string
+		expect error
+	}{
+		{"valid", f.Valid(nil), nil},
+		{"expired", f.Expired(), jwt.ErrTokenExpired},
+		{"not yet valid", f.NotYetValid(), jwt.ErrTokenNotValidYet},
+		{"issued later", f.Valid(jwt.MapClaims{"iat": jwttest.DefaultTime.Add(time.Hour).Unix()}), jwt.ErrTokenUsedBeforeIssued},
+	}
+	for _, data := range tests {
+		_, err := f.Parser().Parse(data.token, f.Key.Keyfunc())
+		if !errors.Is(err, data.expect) || data.expect == nil && err != nil {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.expect, err)
+		}
+	}
+
+	token, err := f.Parser().Parse(f.WrongAudience(), f.Key.Keyfunc())
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if claims.VerifyAudience(jwttest.DefaultAudience, true) || !claims.VerifyAudience(jwttest.WrongAudience, true) {
+		t.Errorf("Expected aud %v.  Got %v", jwttest.WrongAudience, claims["aud"])
+	}
+
+	token, err = f.Parser().Parse(f.Valid(jwt.MapClaims{"exp": nil, "role": "admin"}), f.Key.Keyfunc())
+	if claims := token.Claims.(jwt.MapClaims); err != nil || claims["exp"] != nil || claims["role"] != "admin" {
+		t.Errorf("Expected the overrides to be applied.  Got %v, %v", token.Claims, err)
+	}
+}
+
+func TestClock(t *testing.T) {
+	f := new(jwttest.TokenFactory)
+	token := f.Valid(nil)
+	parser := f.Parser()
+
+	f.Clock.Advance(jwttest.DefaultLifetime - time.Second)
+	if _, err := parser.Parse(token, f.Key.Keyfunc()); err != nil {
+		t.Errorf("Expected the token to be valid a second before it expires.  Got %v", err)
+	}
+	f.Clock.Advance(2 * time.Second)
+	if _, err := parser.Parse(token, f.Key.Keyfunc()); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("Expected the token to have expired.  Got %v", err)
+	}
+	f.Clock.Set(jwttest.DefaultTime)
+	if _, err := parser.Parse(token, f.Key.Keyfunc()); err != nil {
+		t.Errorf("Expected the token to be valid at %v.  Got %v", jwttest.DefaultTime, err)
+	}
+
+	t.Run("Install", func(t *testing.T) {
+		f.Clock.Install(t)
+		if _, err := jwt.Parse(token, f.Key.Keyfunc()); err != nil {
+			t.Errorf("Expected the installed clock to be used.  Got %v", err)
+		}
+	})
+	if _, err := jwt.Parse(token, f.Key.Keyfunc()); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Errorf("Expected TimeFunc to be restored.  Got %v", err)
+	}
+}
+
+func TestInstallIds(t *testing.T) {
+	t.Run("Install", func(t *testing.T) {
+		jwttest.InstallIds(t)
+		if first, second := jwt.IdFunc(), jwt.IdFunc(); first != "id-1" || second != "id-2" {
+			t.Errorf("Expected id-1 and id-2.  Got %v and %v", first, second)
+		}
+	})
+	if id := jwt.IdFunc(); id == "id-1" || id == "id-3" {
+		t.Errorf("Expected IdFunc to be restored.  Got %v", id)
+	}
+}
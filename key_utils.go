@@ -0,0 +1,54 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+)
+
+var (
+	ErrUnsupportedPEMBlock = newError(CodeInvalidKey, "PEM block does not contain a supported key type")
+)
+
+// Parse the first PEM encoded key or certificate in data, whatever its type.
+// Private keys may be PKCS1, PKCS8 or SEC1 encoded; public keys PKIX or
+// wrapped in an X.509 certificate, in which case the certificate's public
+// key is returned.
+func ParseKeyFromPEM(data []byte) (interface{}, error) {
+	var block *pem.Block
+	if block, _ = pem.Decode(data); block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, ErrUnsupportedPEMBlock
+}
+
+// Returns the key to verify with for key: the public half of asymmetric
+// private keys, or key itself for public and symmetric keys
+func verificationKey(key interface{}) interface{} {
+	if _, ok := key.([]byte); ok {
+		return key
+	}
+	if signer, ok := key.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return key
+}
@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrNoSigningKey = newError(CodeNoSigningKey, "no private key available for signing")
+)
+
+// Default time between checks for changes in FileKeyProvider.Watch
+const DefaultKeyFilePollInterval = 10 * time.Second
+
+// Serves keys from a file on disk and picks up changes to it, for example
+// a Kubernetes secret rotated by cert-manager.  The file may hold a PEM
+// encoded key or certificate, a single JWK or a JWK Set.  Certificates,
+// whether PEM or x5c, are checked for validity at verification time.
+//
+// Reloads swap the keys atomically: a token is always verified against one
+// consistent version of the file.  A file that fails to parse is ignored
+// and the previous keys stay in use.
+type FileKeyProvider struct {
+	Path     string
+	Interval time.Duration // Poll interval for Watch.  Defaults to DefaultKeyFilePollInterval
+	OnError  func(error)   // Called from Watch when a reload fails
+	Logger   *slog.Logger  // Told about reloads and failed reloads.  Defaults to DefaultLogger
+
+	CertificateLeeway time.Duration // Clock skew allowed when checking certificate validity
+
+	// Verify tokens without a kid against every key of a JWK Set with
+	// several, as a VerificationKeySet, rather than refusing them
+	TryAllKeys bool
+
+	keys   atomic.Value // *fileKeys
+	parsed jwkCache
+}
+
+type fileKeys struct {
+	raw     []byte
+	kid     string
+	signing interface{}    // nil if the file holds no private key
+	set     *JSONWebKeySet // for JWK and JWK Set files
+	verify  interface{}    // for PEM files
+	cert    *x509.Certificate
+}
+
+// Create a provider for the key file at path.  The file is loaded
+// immediately, so a missing or broken file is reported here.
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	p := &FileKeyProvider{Path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Re-read the key file, swapping in its keys if the contents changed
+func (p *FileKeyProvider) Reload() error {
+	raw, err := ioutil.ReadFile(p.Path)
+	if err != nil {
+		return err
+	}
+	if cur := p.current(); cur != nil && bytes.Equal(cur.raw, raw) {
+		return nil
+	}
+
+	keys, err := parseKeyFile(raw)
+	if err != nil {
+		return err
+	}
+	if p.current() != nil {
+		logAt(p.Logger, slog.LevelInfo, "jwt: key file reloaded", slog.String("path", p.Path), slog.String("kid", keys.kid))
+	}
+	p.keys.Store(keys)
+	return nil
+}
+
+// Poll the key file for changes until ctx is done.  Run it in its own goroutine.
+func (p *FileKeyProvider) Watch(ctx context.Context) {
+	interval := p.Interval
+	if interval == 0 {
+		interval = DefaultKeyFilePollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Reload(); err != nil {
+				logAt(p.Logger, slog.LevelWarn, "jwt: reloading key file failed, keeping previous keys", slog.String("path", p.Path), slog.String("error", err.Error()))
+				if p.OnError != nil {
+					p.OnError(err)
+				}
+			}
+		}
+	}
+}
+
+// The current private key, for use with SignedString
+func (p *FileKeyProvider) SigningKey() (interface{}, error) {
+	keys := p.current()
+	if keys == nil || keys.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+	return keys.signing, nil
+}
+
+// The kid of the current signing key.  Empty for PEM files.
+func (p *FileKeyProvider) KeyID() string {
+	if keys := p.current(); keys != nil {
+		return keys.kid
+	}
+	return ""
+}
+
+// Keyfunc returning the current verification key.  For JWK Set files the
+// key is selected by the token's kid header.
+func (p *FileKeyProvider) Keyfunc(token *Token) (interface{}, error) {
+	keys := p.current()
+	if keys == nil {
+		return nil, ErrJWKNotFound
+	}
+	if keys.set == nil {
+		if keys.cert != nil {
+			if err := checkCertificateValidity(keys.cert, token.time(), p.CertificateLeeway); err != nil {
+				return nil, err
+			}
+		}
+		return keys.verify, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	jwk := selectJWK(keys.set, kid)
+	if jwk == nil && kid == "" && p.TryAllKeys {
+		return p.parsed.keySetForToken(keys.set, token, p.CertificateLeeway)
+	}
+	if jwk == nil {
+		return nil, ErrJWKNotFound
+	}
+	return p.parsed.keyForToken(keys.set, jwk, token, p.CertificateLeeway)
+}
+
+func (p *FileKeyProvider) current() *fileKeys {
+	keys, _ := p.keys.Load().(*fileKeys)
+	return keys
+}
+
+// ----- helpers
+
+func parseKeyFile(raw []byte) (*fileKeys, error) {
+	keys := &fileKeys{raw: raw}
+
+	data := bytes.TrimSpace(raw)
+	if len(data) == 0 || data[0] != '{' {
+		key, err := ParseKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		keys.verify = verificationKey(key)
+		keys.cert = parseCertificateFromPEM(data)
+		if _, ok := key.(crypto.Signer); ok {
+			keys.signing = key
+		}
+		return keys, nil
+	}
+
+	// A JWK Set has a "keys" member, anything else is a single JWK
+	var probe struct {
+		Keys json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	if probe.Keys != nil {
+		set, err := ParseJWKSet(data)
+		if err != nil {
+			return nil, err
+		}
+		keys.set = set
+	} else {
+		jwk, err := ParseJWK(data)
+		if err != nil {
+			return nil, err
+		}
+		keys.set = &JSONWebKeySet{Keys: []JSONWebKey{*jwk}}
+	}
+
+	// Make sure every key decodes now rather than at verification time,
+	// and pick the first private key declared for signing
+	for i := range keys.set.Keys {
+		jwk := &keys.set.Keys[i]
+		key, err := jwk.Key()
+		if err != nil {
+			return nil, err
+		}
+		if keys.signing == nil && jwk.IsPrivate() && jwk.Permits("sign") {
+			keys.signing = key
+			keys.kid = jwk.Kid
+		}
+	}
+	return keys, nil
+}
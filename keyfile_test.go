@@ -0,0 +1,116 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func writeKeyFile(t *testing.T, path string, data []byte) {
+	// Write and rename, the way secret volumes are updated
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileKeyProvider_PEM(t *testing.T) {
+	priv, _ := ioutil.ReadFile("test/sample_key")
+	path := filepath.Join(t.TempDir(), "key.pem")
+	writeKeyFile(t, path, priv)
+
+	provider, err := jwt.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("Error loading key file: %v", err)
+	}
+
+	key, err := provider.SigningKey()
+	if err != nil {
+		t.Fatalf("Error getting signing key: %v", err)
+	}
+	s, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	if _, err = jwt.Parse(s, provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying with provider: %v", err)
+	}
+
+	// Swap in a public key only.  Verification keeps working, signing doesn't.
+	pub, _ := ioutil.ReadFile("test/sample_key.pub")
+	writeKeyFile(t, path, pub)
+	if err = provider.Reload(); err != nil {
+		t.Fatalf("Error reloading key file: %v", err)
+	}
+	if _, err = provider.SigningKey(); err != jwt.ErrNoSigningKey {
+		t.Errorf("Expected ErrNoSigningKey.  Got %v", err)
+	}
+	if _, err = jwt.Parse(s, provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying after reload: %v", err)
+	}
+
+	// A broken file keeps the last good keys
+	writeKeyFile(t, path, []byte("garbage"))
+	if err = provider.Reload(); err == nil {
+		t.Errorf("Expected an error reloading a broken key file")
+	}
+	if _, err = jwt.Parse(s, provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying after failed reload: %v", err)
+	}
+}
+
+func TestFileKeyProvider_JWKS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	writeSet := func(jwks ...*jwt.JSONWebKey) {
+		set := jwt.JSONWebKeySet{}
+		for _, jwk := range jwks {
+			set.Keys = append(set.Keys, *jwk)
+		}
+		data, _ := json.Marshal(set)
+		writeKeyFile(t, path, data)
+	}
+
+	first, _, _ := jwt.GenerateJWK("ES256")
+	writeSet(first)
+	provider, err := jwt.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("Error loading key file: %v", err)
+	}
+
+	sign := func() string {
+		key, err := provider.SigningKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"foo": "bar"})
+		token.Header["kid"] = provider.KeyID()
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	old := sign()
+
+	// Rotate: the new key signs, the old key is still published for verification
+	second, _, _ := jwt.GenerateJWK("ES256")
+	writeSet(second, first.Public())
+	if err = provider.Reload(); err != nil {
+		t.Fatalf("Error reloading key file: %v", err)
+	}
+	if provider.KeyID() != second.Kid {
+		t.Errorf("Signing key was not rotated")
+	}
+
+	for _, s := range []string{old, sign()} {
+		if _, err = jwt.Parse(s, provider.Keyfunc); err != nil {
+			t.Errorf("Error verifying after rotation: %v", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package jwt
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrEmptyKeySet = newError(CodeKeyNotFound, "verification key set has no keys")
+)
+
+// Keys to verify a token against when it doesn't say which key signed it,
+// for example during a rotation, when a token without a kid may be signed
+// by the old key or the new one.  Return one from a Keyfunc instead of a
+// key: the signature is valid if any of the keys verifies it.
+//
+// The keys are tried in order, or Parser.KeySetWorkers at a time.
+type VerificationKeySet struct {
+	Keys []interface{}
+}
+
+// Verify the signature of token over signingString with key, or with any
+// key of it if it is a VerificationKeySet.  If none verifies, the error of
+// the first key is returned.
+func (p *Parser) verifySignature(token *Token, signingString string, key interface{}) error {
+	if bk, ok := key.(*batchKey); ok {
+		return bk.verify(token, signingString)
+	}
+	set, ok := key.(VerificationKeySet)
+	if !ok {
+		return verifyToken(token.Method, signingString, token, key)
+	}
+	if len(set.Keys) == 0 {
+		return ErrEmptyKeySet
+	}
+
+	workers := min(p.KeySetWorkers, len(set.Keys))
+	if workers <= 1 {
+		var first error
+		for _, k := range set.Keys {
+			err := verifyToken(token.Method, signingString, token, k)
+			if err == nil {
+				return nil
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+
+	// Each worker takes the next untried key until one verifies, the keys
+	// run out or the context of the parse is done.  Verifications already
+	// started are left to finish.
+	ctx := token.Context()
+	errs := make([]error, len(set.Keys))
+	var next atomic.Int64
+	var verified atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !verified.Load() && ctx.Err() == nil {
+				i := int(next.Add(1)) - 1
+				if i >= len(set.Keys) {
+					return
+				}
+				if errs[i] = verifyToken(token.Method, signingString, token, set.Keys[i]); errs[i] == nil {
+					verified.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if verified.Load() {
+		return nil
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,103 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Counts verifications, from any goroutine
+type countingHMAC struct {
+	*jwt.SigningMethodHMAC
+	verifies atomic.Int32
+}
+
+func (m *countingHMAC) Verify(signingString, signature string, key interface{}) error {
+	m.verifies.Add(1)
+	return m.SigningMethodHMAC.Verify(signingString, signature, key)
+}
+
+func TestParser_VerificationKeySet(t *testing.T) {
+	method := &countingHMAC{SigningMethodHMAC: &jwt.SigningMethodHMAC{Name: "HS256-keyset", Hash: crypto.SHA256}}
+	jwt.RegisterSigningMethod(method.Alg(), func() jwt.SigningMethod { return method })
+
+	var keys []interface{}
+	for i := 0; i < 16; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("rotated secret %v", i)))
+	}
+	sign := func(key interface{}) string {
+		s, err := jwt.New(method).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	keyFunc := func(*jwt.Token) (interface{}, error) { return jwt.VerificationKeySet{Keys: keys}, nil }
+
+	for _, workers := range []int{0, 1, 4, 32} {
+		parser := &jwt.Parser{KeySetWorkers: workers}
+		for _, i := range []int{0, 7, 15} {
+			if _, err := parser.Parse(sign(keys[i]), keyFunc); err != nil {
+				t.Errorf("[%v workers, key %v] Error parsing: %v", workers, i, err)
+			}
+		}
+
+		method.verifies.Store(0)
+		_, err := parser.Parse(sign([]byte("unknown")), keyFunc)
+		if !errors.Is(err, jwt.ErrSignatureInvalid) {
+			t.Errorf("[%v workers] Expected ErrSignatureInvalid.  Got %v", workers, err)
+		}
+		if n := method.verifies.Load(); n != int32(len(keys)) {
+			t.Errorf("[%v workers] Expected every key to be tried.  Got %v", workers, n)
+		}
+	}
+
+	// Keys after the one that verifies are not tried
+	method.verifies.Store(0)
+	if _, err := new(jwt.Parser).Parse(sign(keys[0]), keyFunc); err != nil || method.verifies.Load() != 1 {
+		t.Errorf("Expected a single verification.  Got %v, %v", method.verifies.Load(), err)
+	}
+
+	empty := func(*jwt.Token) (interface{}, error) { return jwt.VerificationKeySet{}, nil }
+	if _, err := new(jwt.Parser).Parse(sign(keys[0]), empty); !errors.Is(err, jwt.ErrEmptyKeySet) {
+		t.Errorf("Expected ErrEmptyKeySet.  Got %v", err)
+	}
+}
+
+func TestParser_VerificationKeySet_cancel(t *testing.T) {
+	key := []byte("secret")
+	tokenString, _ := jwt.New(jwt.SigningMethodHS256).SignedString(key)
+	keyFunc := func(*jwt.Token) (interface{}, error) {
+		return jwt.VerificationKeySet{Keys: []interface{}{[]byte("a"), []byte("b"), key}}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	parser := &jwt.Parser{KeySetWorkers: 2}
+	if _, err := parser.ParseWithContext(ctx, tokenString, jwt.MapClaims{}, keyFunc); err == nil {
+		t.Errorf("Expected a parse whose context is done to fail")
+	}
+}
+
+func TestJWKSProvider_TryAllKeys(t *testing.T) {
+	pem, _ := os.ReadFile("test/ec256-public.pem")
+	ecKey, _ := jwt.ParseECPublicKeyFromPEM(pem)
+	ec, _ := jwt.NewJSONWebKey(ecKey)
+	offline := &jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{*ec, sampleJWK("old", "RS256"), sampleJWK("new", "RS256")}}
+	tokenString := makeJWKSToken(t, "")
+
+	provider := &jwt.JWKSProvider{Offline: offline}
+	if _, err := jwt.Parse(tokenString, provider.Keyfunc); err == nil {
+		t.Errorf("Expected a token without kid to be refused")
+	}
+	provider.TryAllKeys = true
+	if _, err := jwt.Parse(tokenString, provider.Keyfunc); err != nil {
+		t.Errorf("Error verifying against all keys: %v", err)
+	}
+}
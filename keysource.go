@@ -0,0 +1,162 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrKeyNotFound = newError(CodeKeyNotFound, "key not found")
+	ErrInvalidKid  = newError(CodeInvalidKid, "kid contains characters not allowed by the key source")
+)
+
+// Implement KeySource to load keys from a secret store, such as AWS Secrets
+// Manager, SSM Parameter Store or GCP Secret Manager.  Fetch returns the key
+// identified by kid, or ErrKeyNotFound if there is no such key.  The key
+// should be in the form the signing methods expect (see ParseKeyMaterial).
+type KeySource interface {
+	Fetch(ctx context.Context, kid string) (interface{}, error)
+}
+
+// Adapter to use an ordinary function as a KeySource
+type KeySourceFunc func(ctx context.Context, kid string) (interface{}, error)
+
+func (f KeySourceFunc) Fetch(ctx context.Context, kid string) (interface{}, error) {
+	return f(ctx, kid)
+}
+
+// Build a Keyfunc that fetches the key named by the token's kid header from
+// src, with the token's Context.  Private keys are reduced to their public
+// half for verification.
+func KeyfuncFromSource(src KeySource) Keyfunc {
+	return func(token *Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := src.Fetch(token.Context(), kid)
+		if err != nil {
+			return nil, err
+		}
+		return verificationKey(key), nil
+	}
+}
+
+// Decode key material as commonly stored in secret managers: a PEM encoded
+// key or certificate, a JWK, or otherwise a raw HMAC secret
+func ParseKeyMaterial(data []byte) (interface{}, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN")):
+		return ParseKeyFromPEM(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		jwk, err := ParseJWK(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return jwk.Key()
+	}
+	return data, nil
+}
+
+// Reads keys from environment variables.  The variable for a kid is Prefix
+// followed by the kid in upper case with '-' replaced by '_', e.g. kid
+// "2024-01" with Prefix "JWT_KEY_" is read from JWT_KEY_2024_01.  Kids may
+// hold only lower-case letters, digits and '-', so that no two of them
+// are read from the same variable; any other kid is rejected with
+// ErrInvalidKid.  An empty kid reads Prefix itself, minus any trailing '_'.
+type EnvKeySource struct {
+	Prefix string
+	Decode func([]byte) (interface{}, error) // Defaults to ParseKeyMaterial
+
+	cache keyMaterialCache
+}
+
+func (s *EnvKeySource) Fetch(ctx context.Context, kid string) (interface{}, error) {
+	name := s.Prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= '0' && r <= '9':
+			return r
+		case r == '-':
+			return '_'
+		}
+		return -1
+	}, kid)
+	if len(name) != len(s.Prefix)+len(kid) {
+		return nil, ErrInvalidKid
+	}
+
+	if kid == "" {
+		name = strings.TrimSuffix(name, "_")
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil, ErrKeyNotFound
+	}
+	return s.cache.decode(s.Decode, []byte(value))
+}
+
+// Reads keys from files in Dir, one per kid, named kid + Ext.  Kids are
+// used as file names, so any kid containing a path separator or starting
+// with a dot is rejected with ErrInvalidKid.
+type FileKeySource struct {
+	Dir    string
+	Ext    string                            // Appended to the kid, e.g. ".pem"
+	Decode func([]byte) (interface{}, error) // Defaults to ParseKeyMaterial
+
+	cache keyMaterialCache
+}
+
+func (s *FileKeySource) Fetch(ctx context.Context, kid string) (interface{}, error) {
+	if kid == "" || strings.HasPrefix(kid, ".") || strings.ContainsAny(kid, `/\`) {
+		return nil, ErrInvalidKid
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(s.Dir, kid+s.Ext))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s.cache.decode(s.Decode, data)
+}
+
+// Keys a source has decoded, by their material, so that a key that hasn't
+// changed is not parsed again on every Fetch.  Once maxCachedKeyMaterial
+// keys are cached, it starts over.
+type keyMaterialCache struct {
+	mu   sync.Mutex
+	keys map[string]interface{}
+}
+
+const maxCachedKeyMaterial = 64
+
+func (c *keyMaterialCache) decode(decode func([]byte) (interface{}, error), data []byte) (interface{}, error) {
+	c.mu.Lock()
+	key, ok := c.keys[string(data)]
+	c.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	if decode == nil {
+		decode = ParseKeyMaterial
+	}
+	key, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.keys == nil || len(c.keys) >= maxCachedKeyMaterial {
+		c.keys = map[string]interface{}{}
+	}
+	c.keys[string(data)] = key
+	c.mu.Unlock()
+	return key, nil
+}
@@ -0,0 +1,98 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/rsa"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestEnvKeySource(t *testing.T) {
+	t.Setenv("JWT_KEY_2024_01", "secret")
+	pem, _ := ioutil.ReadFile("test/sample_key.pub")
+	t.Setenv("JWT_KEY", string(pem))
+
+	src := &jwt.EnvKeySource{Prefix: "JWT_KEY_"}
+
+	key, err := src.Fetch(context.Background(), "2024-01")
+	if err != nil || !reflect.DeepEqual(key, []byte("secret")) {
+		t.Errorf("Expected raw secret.  Got %v %v", key, err)
+	}
+
+	key, err = src.Fetch(context.Background(), "")
+	if _, ok := key.(*rsa.PublicKey); !ok || err != nil {
+		t.Errorf("Expected RSA public key.  Got %T %v", key, err)
+	}
+
+	if _, err = src.Fetch(context.Background(), "unknown"); err != jwt.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound.  Got %v", err)
+	}
+
+	// Kids that could share a variable with another kid are refused
+	for _, kid := range []string{"2024.01", "2024_01", "2024 01", "2024-01\u00e9", "KEY"} {
+		if _, err = src.Fetch(context.Background(), kid); err != jwt.ErrInvalidKid {
+			t.Errorf("[%v] Expected ErrInvalidKid.  Got %v", kid, err)
+		}
+	}
+}
+
+func TestFileKeySource(t *testing.T) {
+	dir := t.TempDir()
+	priv, _ := ioutil.ReadFile("test/sample_key")
+	ioutil.WriteFile(filepath.Join(dir, "signer.pem"), priv, 0600)
+
+	src := &jwt.FileKeySource{Dir: dir, Ext: ".pem"}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"foo": "bar"})
+	token.Header["kid"] = "signer"
+	s, _ := token.SignedString(test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+
+	if _, err := jwt.Parse(s, jwt.KeyfuncFromSource(src)); err != nil {
+		t.Errorf("Error verifying with file key source: %v", err)
+	}
+
+	for _, kid := range []string{"missing", "../signer", ".hidden", ""} {
+		_, err := src.Fetch(context.Background(), kid)
+		if err == nil {
+			t.Errorf("[%v] Expected an error fetching key", kid)
+		}
+	}
+	if _, err := src.Fetch(context.Background(), "../signer"); err != jwt.ErrInvalidKid {
+		t.Errorf("Expected ErrInvalidKid for path traversal.  Got %v", err)
+	}
+
+	// The parsed key is reused until the file changes
+	first, _ := src.Fetch(context.Background(), "signer")
+	if again, _ := src.Fetch(context.Background(), "signer"); again != first {
+		t.Errorf("Expected the parsed key to be reused")
+	}
+	pub, _ := ioutil.ReadFile("test/sample_key.pub")
+	ioutil.WriteFile(filepath.Join(dir, "signer.pem"), pub, 0600)
+	if changed, err := src.Fetch(context.Background(), "signer"); err != nil || changed == first {
+		t.Errorf("Expected the new key after the file changed.  Got %v", err)
+	}
+}
+
+// The source is asked with the context the token is parsed with
+func TestKeyfuncFromSource_context(t *testing.T) {
+	type ctxKey struct{}
+	var got interface{}
+	src := jwt.KeySourceFunc(func(ctx context.Context, kid string) (interface{}, error) {
+		got = ctx.Value(ctxKey{})
+		return []byte("secret"), nil
+	})
+	s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString([]byte("secret"))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	if _, err := new(jwt.Parser).ParseWithContext(ctx, s, jwt.MapClaims{}, jwt.KeyfuncFromSource(src)); err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	if got != "request" {
+		t.Errorf("Expected the parse context.  Got %v", got)
+	}
+}
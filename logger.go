@@ -0,0 +1,23 @@
+package jwt
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger used by Parsers, JWKSProviders and FileKeyProviders that don't
+// set their own, for conditions worth an operator's attention that don't
+// fail the call: a stale JWK Set served because the issuer is down, a key
+// file that no longer parses, a token accepted with warnings.  Nil, the
+// default, logs nothing.  Rejected tokens are reported to
+// Parser.ErrorLogger instead.
+var DefaultLogger *slog.Logger
+
+func logAt(logger *slog.Logger, level slog.Level, msg string, attrs ...slog.Attr) {
+	if logger == nil {
+		logger = DefaultLogger
+	}
+	if logger != nil {
+		logger.LogAttrs(context.Background(), level, msg, attrs...)
+	}
+}
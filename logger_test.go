@@ -0,0 +1,71 @@
+package jwt_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	// Deprecated algorithm accepted by a Lenient Parser
+	key := []byte("secret")
+	s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"jti": "1"}).SignedString(key)
+	parser := &jwt.Parser{Lenient: true, DeprecatedMethods: []string{"HS256"}, Logger: logger}
+	if _, err := parser.Parse(s, func(*jwt.Token) (interface{}, error) { return key, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "token accepted with warning") || !strings.Contains(out, "code="+jwt.CodeDeprecatedAlgorithm.String()) {
+		t.Errorf("Expected a warning about the deprecated algorithm.  Got %q", out)
+	}
+
+	// Stale JWK Set served when the issuer goes down
+	buf.Reset()
+	up := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer server.Close()
+	provider := &jwt.JWKSProvider{URL: server.URL, RefreshInterval: -1, MinRefreshInterval: -1, Logger: logger}
+	if _, err := provider.KeySet(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	up = false
+	if _, err := provider.KeySet(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "fetching JWK Set failed") || !strings.Contains(out, "serving stale JWK Set") {
+		t.Errorf("Expected warnings about the failed fetch.  Got %q", out)
+	}
+
+	// Key file reloaded
+	buf.Reset()
+	path := filepath.Join(t.TempDir(), "key.json")
+	os.WriteFile(path, []byte(`{"kty":"oct","kid":"a","k":"c2VjcmV0"}`), 0600)
+	p, err := jwt.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Logger = logger
+	os.WriteFile(path, []byte(`{"kty":"oct","kid":"b","k":"c2VjcmV0"}`), 0600)
+	if err = p.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "key file reloaded") || !strings.Contains(out, "kid=b") {
+		t.Errorf("Expected a reload message.  Got %q", out)
+	}
+}
@@ -2,7 +2,9 @@ package jwt
 
 import (
 	"encoding/json"
-	"errors"
+	"slices"
+
+	"github.com/dgrijalva/jwt-go/jsoncodec"
 	// "fmt"
 )
 
@@ -12,11 +14,55 @@ import (
 // 如果没有提供claims，则使用本类型，如New中使用
 type MapClaims map[string]interface{}
 
+// Returns the aud claim as a list, accepting both the single string and
+// the array form.  Empty if unset.
+func (m MapClaims) GetAudience() []string {
+	switch aud := m["aud"].(type) {
+	case string:
+		if aud != "" {
+			return []string{aud}
+		}
+	case []string:
+		return aud
+	case []interface{}:
+		auds := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				auds = append(auds, s)
+			}
+		}
+		return auds
+	}
+	return nil
+}
+
+// Returns the iss claim, empty if unset or not a string
+func (m MapClaims) GetIssuer() string {
+	iss, _ := m["iss"].(string)
+	return iss
+}
+
+// Returns the jti claim, or "" if it is missing or not a string
+func (m MapClaims) GetId() string {
+	jti, _ := m["jti"].(string)
+	return jti
+}
+
+// Returns the sub claim, or "" if it is missing or not a string
+func (m MapClaims) GetSubject() string {
+	sub, _ := m["sub"].(string)
+	return sub
+}
+
+// Returns the iat claim, 0 if unset
+func (m MapClaims) GetIssuedAt() int64 {
+	return m.int64Claim("iat")
+}
+
 // Compares the aud claim against cmp.
 // If required is false, this method will return true if the value matches or is unset
 func (m MapClaims) VerifyAudience(cmp string, req bool) bool {
-	aud, _ := m["aud"].(string)
-	return verifyAud(aud, cmp, req)
+	return verifyAudList(m.GetAudience(), cmp, req)
 }
 
 // Compares the exp claim against cmp.
@@ -70,27 +116,137 @@ func (m MapClaims) VerifyNotBefore(cmp int64, req bool) bool {
 // As well, if any of the above claims are not in the token, it will still
 // be considered a valid claim.
 func (m MapClaims) Valid() error {
-	vErr := new(ValidationError)
-	now := TimeFunc().Unix()
+	return m.validAt(TimeFunc().Unix())
+}
+
+// Valid at now, a Unix time
+func (m MapClaims) validAt(now int64) error {
+	var vErr ValidationError
 
 	if m.VerifyExpiresAt(now, false) == false {
-		vErr.Inner = errors.New("Token is expired")
-		vErr.Errors |= ValidationErrorExpired
+		vErr.add(newExpiredError(m.int64Claim("exp"), now), ValidationErrorExpired)
 	}
 
 	if m.VerifyIssuedAt(now, false) == false {
-		vErr.Inner = errors.New("Token used before issued")
-		vErr.Errors |= ValidationErrorIssuedAt
+		vErr.add(newIssuedAtError(m.int64Claim("iat"), now), ValidationErrorIssuedAt)
 	}
 
 	if m.VerifyNotBefore(now, false) == false {
-		vErr.Inner = errors.New("Token is not valid yet")
-		vErr.Errors |= ValidationErrorNotValidYet
+		vErr.add(newNotValidYetError(m.int64Claim("nbf"), now), ValidationErrorNotValidYet)
 	}
 
 	if vErr.valid() {
 		return nil
 	}
 
-	return vErr
+	return vErr.escape()
+}
+
+// Numeric value of a time claim, 0 if unset
+func (m MapClaims) int64Claim(name string) int64 {
+	switch v := m[name].(type) {
+	case float64:
+		return int64(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	}
+	return 0
+}
+
+// Nesting beyond which MapClaims.MarshalJSON hands values to encoding/json,
+// which catches cycles
+const maxMarshalDepth = 100
+
+// Marshal the claims as encoding/json would, with keys in sorted order,
+// but without reflection for the usual claim values: strings, float64s,
+// ints, bools, nil, and slices and maps of those.  Anything else goes to
+// json.Marshal.  The output is the same for the same claims, so it can be
+// cached or compared with a fixture.
+func (m MapClaims) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	return appendJSONObject(make([]byte, 0, 32*len(m)), m, 0)
+}
+
+func appendJSONObject(dst []byte, m map[string]interface{}, depth int) ([]byte, error) {
+	var buf [16]string
+	keys := buf[:0]
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	dst = append(dst, '{')
+	var err error
+	for i, k := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(jsoncodec.AppendString(dst, k), ':')
+		if dst, err = appendJSONValue(dst, m[k], depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+func appendJSONValue(dst []byte, v interface{}, depth int) ([]byte, error) {
+	if depth > maxMarshalDepth {
+		return appendMarshaled(dst, v)
+	}
+	var err error
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case string:
+		return jsoncodec.AppendString(dst, v), nil
+	case float64:
+		if b, err := jsoncodec.AppendFloat(dst, v); err == nil {
+			return b, nil
+		}
+	case int:
+		return jsoncodec.AppendInt(dst, int64(v)), nil
+	case int64:
+		return jsoncodec.AppendInt(dst, v), nil
+	case bool:
+		return jsoncodec.AppendBool(dst, v), nil
+	case []string:
+		return jsoncodec.AppendStrings(dst, v), nil
+	case []interface{}:
+		if v == nil {
+			return append(dst, "null"...), nil
+		}
+		dst = append(dst, '[')
+		for i, e := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if dst, err = appendJSONValue(dst, e, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, ']'), nil
+	case map[string]interface{}:
+		if v == nil {
+			return append(dst, "null"...), nil
+		}
+		return appendJSONObject(dst, v, depth)
+	case MapClaims:
+		if v == nil {
+			return append(dst, "null"...), nil
+		}
+		return appendJSONObject(dst, v, depth)
+	}
+	// NaN and infinities too, so the error is that of encoding/json
+	return appendMarshaled(dst, v)
+}
+
+func appendMarshaled(dst []byte, v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, b...), nil
 }
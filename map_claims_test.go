@@ -0,0 +1,82 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var mapClaimsMarshalTestData = []struct {
+	name   string
+	claims jwt.MapClaims
+}{
+	{"empty", jwt.MapClaims{}},
+	{"registered", jwt.MapClaims{"sub": "alice", "iss": "https://auth.example.com", "exp": float64(1500000000), "iat": 1400000000, "nbf": int64(1300000000), "aud": []string{"a", "b"}}},
+	{"many keys", func() jwt.MapClaims {
+		m := jwt.MapClaims{}
+		for i := 0; i < 40; i++ {
+			m[fmt.Sprintf("k%02d", 39-i)] = i
+		}
+		return m
+	}()},
+	{"escapes", jwt.MapClaims{"<html>": "a & b <c>", "quote\"key": "\"\\\n\t\x01", "bad utf-8": "\xff", "line sep": " "}},
+	{"scalars", jwt.MapClaims{"nil": nil, "true": true, "false": false, "neg": -1.5, "small": 1e-7, "big": 1e21, "zero": 0.0}},
+	{"nested", jwt.MapClaims{"obj": map[string]interface{}{"z": 1.0, "a": []interface{}{"x", nil, map[string]interface{}{"m": jwt.MapClaims{"n": true}}}}}},
+	{"nil collections", jwt.MapClaims{"slice": []interface{}(nil), "strings": []string(nil), "map": map[string]interface{}(nil), "claims": jwt.MapClaims(nil)}},
+	{"other types", jwt.MapClaims{"number": json.Number("12.50"), "time": time.Unix(0, 0).UTC(), "aud": jwt.ClaimStrings{"a"}, "f32": float32(0.1), "uint": uint8(7), "raw": json.RawMessage(`{"b":1, "a":2}`)}},
+}
+
+func TestMapClaims_MarshalJSON(t *testing.T) {
+	for _, data := range mapClaimsMarshalTestData {
+		got, err := json.Marshal(data.claims)
+		if err != nil {
+			t.Errorf("[%v] Error marshaling: %v", data.name, err)
+			continue
+		}
+		expect, _ := json.Marshal(map[string]interface{}(data.claims))
+		if string(got) != string(expect) {
+			t.Errorf("[%v] Expected %s.  Got %s", data.name, expect, got)
+		}
+		// Stable across map iteration orders
+		for i := 0; i < 10; i++ {
+			if again, _ := json.Marshal(data.claims); string(again) != string(got) {
+				t.Errorf("[%v] Expected the same output each time.  Got %s", data.name, again)
+				break
+			}
+		}
+	}
+
+	for _, bad := range []interface{}{math.NaN(), math.Inf(1), make(chan int), []interface{}{math.Inf(-1)}} {
+		if _, err := json.Marshal(jwt.MapClaims{"bad": bad}); err == nil {
+			t.Errorf("[%v] Expected an error", bad)
+		}
+	}
+}
+
+func TestMapClaims_MarshalJSON_cycle(t *testing.T) {
+	m := map[string]interface{}{}
+	m["self"] = m
+	if _, err := json.Marshal(jwt.MapClaims{"m": m}); err == nil {
+		t.Errorf("Expected an error for a cycle")
+	}
+}
+
+func BenchmarkMapClaims_MarshalJSON(b *testing.B) {
+	claims := jwt.MapClaims{"sub": "1234567890", "name": "John Doe", "admin": true, "iat": float64(1516239022), "scope": []interface{}{"read", "write"}}
+	b.Run("MapClaims", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			claims.MarshalJSON()
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			json.Marshal(map[string]interface{}(claims))
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package jwt
+
+import "time"
+
+// Receives measurements of signing, verification and JWK Set fetches, for
+// export to a metrics system such as Prometheus.  code is "" on success.
+// Implementations are called inline and must be fast and safe for
+// concurrent use.
+type Metrics interface {
+	ObserveSign(alg string, code ErrorCode)
+	ObserveVerify(alg string, code ErrorCode, duration time.Duration)
+	ObserveJWKSFetch(url string, code ErrorCode)
+}
+
+// Metrics used by SignedString, and by Parsers and JWKSProviders that don't
+// set their own.  Set it once at startup, before tokens are handled.
+var DefaultMetrics Metrics
+
+func (p *Parser) metrics() Metrics {
+	if p.Metrics != nil {
+		return p.Metrics
+	}
+	return DefaultMetrics
+}
+
+func (p *JWKSProvider) metrics() Metrics {
+	if p.Metrics != nil {
+		return p.Metrics
+	}
+	return DefaultMetrics
+}
+
+// The alg header of a token, which may be nil or not yet parsed
+func tokenAlg(token *Token) string {
+	if token == nil {
+		return ""
+	}
+	alg, _ := token.Header["alg"].(string)
+	return alg
+}
@@ -0,0 +1,78 @@
+package jwt_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type recordingMetrics struct {
+	mu      sync.Mutex
+	signs   []string
+	verifys []string
+	fetches []string
+}
+
+func (m *recordingMetrics) ObserveSign(alg string, code jwt.ErrorCode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.signs = append(m.signs, alg+" "+code.String())
+}
+
+func (m *recordingMetrics) ObserveVerify(alg string, code jwt.ErrorCode, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifys = append(m.verifys, alg+" "+code.String())
+}
+
+func (m *recordingMetrics) ObserveJWKSFetch(url string, code jwt.ErrorCode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches = append(m.fetches, code.String())
+}
+
+func TestMetrics(t *testing.T) {
+	m := new(recordingMetrics)
+	jwt.DefaultMetrics = m
+	defer func() { jwt.DefaultMetrics = nil }()
+
+	key := []byte("secret")
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{}).SignedString("not bytes")
+
+	jwt.Parse(s, func(*jwt.Token) (interface{}, error) { return key, nil })
+	jwt.Parse(s, func(*jwt.Token) (interface{}, error) { return []byte("wrong"), nil })
+	jwt.Parse("garbage", nil)
+
+	// A Parser's own Metrics take precedence
+	own := new(recordingMetrics)
+	(&jwt.Parser{Metrics: own}).Parse(s, func(*jwt.Token) (interface{}, error) { return key, nil })
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	jwt.NewJWKSProvider(server.URL).Refresh(context.Background())
+
+	expect := func(name string, got, expected []string) {
+		if len(got) != len(expected) {
+			t.Errorf("[%v] Expected %v.  Got %v", name, expected, got)
+			return
+		}
+		for i := range got {
+			if got[i] != expected[i] {
+				t.Errorf("[%v] Expected %v.  Got %v", name, expected, got)
+			}
+		}
+	}
+	expect("sign", m.signs, []string{"HS256 ", "HS256 " + jwt.CodeInvalidKeyType.String()})
+	expect("verify", m.verifys, []string{"HS256 ", "HS256 " + jwt.CodeInvalidSignature.String(), " " + jwt.CodeTokenMalformed.String()})
+	expect("own verify", own.verifys, []string{"HS256 "})
+	expect("fetch", m.fetches, []string{jwt.CodeUnknown.String()})
+}
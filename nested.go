@@ -0,0 +1,80 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Sign a compact JWT as the payload of another, setting cty to JWT as
+// RFC 7519 section 5.2 requires, e.g. for a gateway countersigning tokens
+// from an issuer.  header may add parameters such as kid.
+func SignNested(inner string, method SigningMethod, key interface{}, header map[string]interface{}) (string, error) {
+	token := New(method)
+	for k, v := range header {
+		token.Header[k] = v
+	}
+	token.Header["cty"] = "JWT"
+	if err := checkCriticalForSigning(token.Header); err != nil {
+		return "", err
+	}
+
+	headerJSON, err := json.Marshal(token.Header)
+	if err != nil {
+		return "", err
+	}
+	signing := EncodeSegment(headerJSON) + "." + EncodeSegment([]byte(inner))
+
+	sig, err := method.Sign(signing, key)
+	if err != nil {
+		return "", err
+	}
+	return signing + "." + sig, nil
+}
+
+// Verify the outer token of a nested JWT with outerKeyFunc, then parse the
+// token it carries into claims and verify it with innerKeyFunc.  Returns
+// the inner token.
+func ParseNested(tokenString string, claims Claims, outerKeyFunc, innerKeyFunc Keyfunc) (*Token, error) {
+	return new(Parser).ParseNested(tokenString, claims, outerKeyFunc, innerKeyFunc)
+}
+
+// ParseNested, with the options of the Parser applied to both tokens.
+// The pair is reported to the Parser's ErrorLogger, Metrics, Tracer and
+// SecurityHook as a single token: the outer one if it fails, else the
+// inner one.
+func (p *Parser) ParseNested(tokenString string, claims Claims, outerKeyFunc, innerKeyFunc Keyfunc) (*Token, error) {
+	var outerErr bool
+	token, err := p.reported(context.Background(), func(ctx context.Context) (*Token, error) {
+		outer, err := p.verifyOuter(ctx, tokenString, outerKeyFunc)
+		if err != nil {
+			outerErr = true
+			return outer, err
+		}
+		return p.parseWithClaims(ctx, string(outer.Payload), claims, innerKeyFunc, nil)
+	})
+	if outerErr {
+		return nil, err
+	}
+	return token, err
+}
+
+// Verify the outer token of a nested JWT, leaving the inner token in its
+// Payload
+func (p *Parser) verifyOuter(ctx context.Context, tokenString string, keyFunc Keyfunc) (*Token, error) {
+	outer, parts, err := p.parseDetached(ctx, tokenString)
+	if err != nil {
+		return outer, err
+	}
+	if cty, _ := outer.Header["cty"].(string); !strings.EqualFold(cty, "JWT") {
+		return outer, NewValidationError("token is not a nested JWT", ValidationErrorMalformed)
+	}
+	if err = checkCritical(outer.Header, p.CriticalHeaders); err != nil {
+		return outer, err
+	}
+
+	if outer.Payload, err = DecodeSegment(parts[1]); err != nil {
+		return outer, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	return outer, p.verifyDetached(outer, parts[0]+"."+parts[1], keyFunc)
+}
@@ -0,0 +1,92 @@
+package jwt_test
+
+import (
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestSignNested(t *testing.T) {
+	issuerKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	gatewayKey := []byte("gateway secret")
+	issuerKeyfunc := func(*jwt.Token) (interface{}, error) { return &issuerKey.PublicKey, nil }
+	gatewayKeyfunc := func(*jwt.Token) (interface{}, error) { return gatewayKey, nil }
+
+	inner := test.MakeSampleToken(jwt.MapClaims{"sub": "alice"}, issuerKey)
+	s, err := jwt.SignNested(inner, jwt.SigningMethodHS256, gatewayKey, map[string]interface{}{"kid": "gw"})
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+
+	token, err := jwt.ParseNested(s, jwt.MapClaims{}, gatewayKeyfunc, issuerKeyfunc)
+	if err != nil || !token.Valid || token.Claims.(jwt.MapClaims)["sub"] != "alice" {
+		t.Errorf("Error parsing nested token: %v", err)
+	}
+
+	// Each layer is checked with its own key
+	if _, err := jwt.ParseNested(s, jwt.MapClaims{}, issuerKeyfunc, issuerKeyfunc); err == nil {
+		t.Errorf("Expected the outer signature to fail with the issuer key")
+	}
+	if _, err := jwt.ParseNested(s, jwt.MapClaims{}, gatewayKeyfunc, gatewayKeyfunc); err == nil {
+		t.Errorf("Expected the inner signature to fail with the gateway key")
+	}
+
+	// A plain token is not a nested one
+	if _, err := jwt.ParseNested(inner, jwt.MapClaims{}, issuerKeyfunc, issuerKeyfunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected a plain token to be malformed.  Got %v", err)
+	}
+
+	// The pair is reported once, whichever layer fails
+	var rejections int
+	metrics := new(recordingMetrics)
+	p := &jwt.Parser{ErrorLogger: jwt.ErrorLoggerFunc(func(*jwt.Rejection) { rejections++ }), Metrics: metrics}
+	if _, err := p.ParseNested(s, jwt.MapClaims{}, gatewayKeyfunc, issuerKeyfunc); err != nil {
+		t.Errorf("Error parsing nested token: %v", err)
+	}
+	if token, err := p.ParseNested(s, jwt.MapClaims{}, issuerKeyfunc, issuerKeyfunc); token != nil || err == nil {
+		t.Errorf("Expected the outer signature to fail with no token.  Got %v", err)
+	}
+	if _, err := p.ParseNested(s, jwt.MapClaims{}, gatewayKeyfunc, gatewayKeyfunc); err == nil {
+		t.Errorf("Expected the inner signature to fail")
+	}
+	if rejections != 2 || len(metrics.verifys) != 3 {
+		t.Errorf("Expected three results reported.  Got %v rejections and %v", rejections, metrics.verifys)
+	}
+}
+
+func TestParser_UnwrapNested(t *testing.T) {
+	issuerKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	gatewayKey := []byte("gateway secret")
+	inner := test.MakeSampleToken(jwt.MapClaims{"sub": "alice"}, issuerKey)
+	s, _ := jwt.SignNested(inner, jwt.SigningMethodHS256, gatewayKey, nil)
+
+	// One keyfunc for both layers, telling them apart by cty
+	var layers []string
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		layers = append(layers, token.ContentType())
+		if token.ContentType() == "JWT" {
+			return gatewayKey, nil
+		}
+		return &issuerKey.PublicKey, nil
+	}
+
+	parser := &jwt.Parser{UnwrapNested: true}
+	token, err := parser.Parse(s, keyFunc)
+	if err != nil || !token.Valid || token.Claims.(jwt.MapClaims)["sub"] != "alice" || token.Raw != inner {
+		t.Errorf("Error parsing nested token: %v", err)
+	}
+	if len(layers) != 2 || layers[0] != "JWT" || layers[1] != "" {
+		t.Errorf("Expected keyfunc for outer then inner token.  Got %q", layers)
+	}
+
+	wrongKey := func(*jwt.Token) (interface{}, error) { return &issuerKey.PublicKey, nil }
+	if _, err := parser.Parse(s, wrongKey); !jwt.IsSignatureInvalid(err) {
+		t.Errorf("Expected the outer signature to fail.  Got %v", err)
+	}
+
+	// Without UnwrapNested, the payload is not claims
+	if _, err := jwt.Parse(s, keyFunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected nested token to be malformed.  Got %v", err)
+	}
+}
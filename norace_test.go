@@ -0,0 +1,7 @@
+//go:build !race
+
+package jwt_test
+
+// Whether the race detector is on, which allocates on its own and so
+// throws off allocation counts
+const raceEnabled = false
@@ -0,0 +1,165 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Path of the OpenID Connect discovery document, relative to the issuer
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// The subset of OpenID Connect provider metadata used for token verification.
+// See https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type OIDCConfiguration struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                    string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                 string   `json:"userinfo_endpoint,omitempty"`
+	IntrospectionEndpoint            string   `json:"introspection_endpoint,omitempty"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// Fetch the discovery document for issuer.  The issuer in the document
+// must match the one requested exactly, as required by the spec, otherwise
+// an error is returned.  A nil client means one with a timeout of
+// DefaultFetchTimeout.
+func DiscoverOIDC(ctx context.Context, client *http.Client, issuer string) (*OIDCConfiguration, error) {
+	config := new(OIDCConfiguration)
+	if err := fetchJSON(ctx, client, strings.TrimSuffix(issuer, "/")+oidcDiscoveryPath, config); err != nil {
+		return nil, err
+	}
+
+	if config.Issuer != issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch, expected %q got %q", issuer, config.Issuer)
+	}
+	if config.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc: discovery document for %q has no jwks_uri", issuer)
+	}
+
+	return config, nil
+}
+
+// Verifies tokens issued by a single OpenID Connect provider.  Keys come
+// from the provider's jwks_uri and the iss claim must match the issuer.
+type OIDCProvider struct {
+	Config *OIDCConfiguration
+	JWKS   *JWKSProvider
+	Parser *Parser // Parser used for verification.  Defaults to new(Parser)
+}
+
+// Perform discovery for issuerURL and return a provider ready to verify
+// its tokens.  This is all it takes to stand up an OIDC resource server:
+//
+//	provider, err := jwt.NewOIDCProvider(ctx, "https://accounts.example.com")
+//	token, err := provider.Parse(tokenString)
+func NewOIDCProvider(ctx context.Context, issuerURL string) (*OIDCProvider, error) {
+	config, err := DiscoverOIDC(ctx, nil, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCProvider{
+		Config: config,
+		JWKS:   NewJWKSProvider(config.JWKSURI),
+	}, nil
+}
+
+// Keyfunc that checks the unverified iss claim against the provider before
+// looking up the key, so tokens from other issuers never trigger a fetch
+func (p *OIDCProvider) Keyfunc(token *Token) (interface{}, error) {
+	if err := ValidateIssuer(token.Claims, p.Config.Issuer); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorIssuer}
+	}
+	return p.JWKS.Keyfunc(token)
+}
+
+// Parse and verify a token issued by this provider
+func (p *OIDCProvider) Parse(tokenString string) (*Token, error) {
+	return p.ParseWithClaims(tokenString, MapClaims{})
+}
+
+// Parse and verify a token issued by this provider into custom claims.
+// The claims type must provide GetIssuer, as StandardClaims does.
+func (p *OIDCProvider) ParseWithClaims(tokenString string, claims Claims) (*Token, error) {
+	parser := p.Parser
+	if parser == nil {
+		parser = new(Parser)
+	}
+	return parser.ParseWithClaims(tokenString, claims, p.Keyfunc)
+}
+
+// Algorithms accepted by OIDCVerifier when discovery lists none.  RS256 is
+// the default of OpenID Connect Discovery section 3.
+var defaultOIDCAlgorithms = []string{"RS256"}
+
+// Verifies access tokens at an OpenID Connect resource server: the
+// signature with keys from the provider's jwks_uri, alg against the
+// algorithms the provider advertises, iss against the issuer, aud against
+// Audience, and the presence and value of exp.  Symmetric algorithms and
+// none are never accepted.
+type OIDCVerifier struct {
+	Provider *OIDCProvider
+	Audience string
+}
+
+// Perform discovery for issuerURL and return a verifier for its tokens
+// meant for audience:
+//
+//	verifier, err := jwt.NewOIDCVerifier(ctx, "https://accounts.example.com", "https://api.example.com")
+//	claims, err := verifier.Verify(ctx, tokenString)
+func NewOIDCVerifier(ctx context.Context, issuerURL, audience string) (*OIDCVerifier, error) {
+	provider, err := NewOIDCProvider(ctx, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []string
+	for _, alg := range provider.Config.IDTokenSigningAlgValuesSupported {
+		if alg != "none" && !strings.HasPrefix(alg, "HS") {
+			methods = append(methods, alg)
+		}
+	}
+	if len(methods) == 0 {
+		methods = defaultOIDCAlgorithms
+	}
+	provider.Parser = &Parser{ValidMethods: methods}
+
+	return &OIDCVerifier{Provider: provider, Audience: audience}, nil
+}
+
+// Verify tokenString and return its claims, as MapClaims.  ctx bounds any
+// fetch of the JWK Set this takes.
+func (v *OIDCVerifier) Verify(ctx context.Context, tokenString string) (Claims, error) {
+	p := v.Provider
+	parser := p.Parser
+	if parser == nil {
+		parser = &Parser{ValidMethods: defaultOIDCAlgorithms}
+	}
+
+	claims := MapClaims{}
+	keyFunc := func(token *Token) (interface{}, error) {
+		if err := ValidateIssuer(token.Claims, p.Config.Issuer); err != nil {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorIssuer}
+		}
+		return p.JWKS.LookupKey(ctx, token)
+	}
+	// aud and exp are checked within the parse, so that their failures
+	// reach the parser's ErrorLogger, Metrics and SecurityHook too, and
+	// exp by the parser's clock
+	check := func(token *Token) error {
+		if err := ValidateAudience(claims, v.Audience); err != nil {
+			return &ValidationError{Inner: err, Errors: ValidationErrorAudience}
+		}
+		if !claims.VerifyExpiresAt(token.time().Unix(), true) {
+			return NewValidationError("token has no exp claim", ValidationErrorExpired)
+		}
+		return nil
+	}
+	if _, err := parser.parseWithContext(ctx, tokenString, claims, keyFunc, nil, check); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,187 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+// A minimal OpenID provider serving discovery and a JWK Set
+func newOIDCServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&jwt.OIDCConfiguration{
+			Issuer:  server.URL,
+			JWKSURI: server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{sampleJWK("k1", "RS256")}})
+	})
+	return server
+}
+
+func makeOIDCToken(t *testing.T, iss string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": iss, "sub": "alice"})
+	token.Header["kid"] = "k1"
+	s, err := token.SignedString(test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestOIDCProvider(t *testing.T) {
+	server := newOIDCServer(t)
+	defer server.Close()
+
+	provider, err := jwt.NewOIDCProvider(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Error during discovery: %v", err)
+	}
+	if provider.JWKS.URL != server.URL+"/keys" {
+		t.Errorf("JWKS provider not wired to jwks_uri.  Got %v", provider.JWKS.URL)
+	}
+
+	token, err := provider.Parse(makeOIDCToken(t, server.URL))
+	if err != nil || !token.Valid {
+		t.Errorf("Error verifying token: %v", err)
+	}
+
+	// A token from any other issuer is rejected before key lookup
+	_, err = provider.Parse(makeOIDCToken(t, "https://evil.example.com"))
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors&jwt.ValidationErrorIssuer == 0 {
+		t.Errorf("Expected issuer validation error.  Got %v", err)
+	}
+
+	// StandardClaims work as well
+	token, err = provider.ParseWithClaims(makeOIDCToken(t, server.URL), &jwt.StandardClaims{})
+	if err != nil || token.Claims.(*jwt.StandardClaims).Subject != "alice" {
+		t.Errorf("Error verifying token into StandardClaims: %v", err)
+	}
+}
+
+func TestDiscoverOIDC_issuerMismatch(t *testing.T) {
+	server := newOIDCServer(t)
+	defer server.Close()
+
+	if _, err := jwt.DiscoverOIDC(context.Background(), nil, server.URL+"/other"); err == nil {
+		t.Errorf("Expected discovery to fail for a mismatched issuer")
+	}
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	server := newOIDCServer(t)
+	defer server.Close()
+
+	verifier, err := jwt.NewOIDCVerifier(context.Background(), server.URL, "https://api.example.com")
+	if err != nil {
+		t.Fatalf("Error during discovery: %v", err)
+	}
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	exp := time.Now().Add(time.Hour).Unix()
+
+	var oidcVerifierTestData = []struct {
+		name   string
+		method jwt.SigningMethod
+		key    interface{}
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{"valid", jwt.SigningMethodRS256, privateKey, jwt.MapClaims{"iss": server.URL, "aud": "https://api.example.com", "exp": exp}, true},
+		{"other issuer", jwt.SigningMethodRS256, privateKey, jwt.MapClaims{"iss": "https://evil.example.com", "aud": "https://api.example.com", "exp": exp}, false},
+		{"other audience", jwt.SigningMethodRS256, privateKey, jwt.MapClaims{"iss": server.URL, "aud": "https://other.example.com", "exp": exp}, false},
+		{"no exp", jwt.SigningMethodRS256, privateKey, jwt.MapClaims{"iss": server.URL, "aud": "https://api.example.com"}, false},
+		{"hmac", jwt.SigningMethodHS256, []byte("secret"), jwt.MapClaims{"iss": server.URL, "aud": "https://api.example.com", "exp": exp}, false},
+		{"not advertised", jwt.SigningMethodPS256, privateKey, jwt.MapClaims{"iss": server.URL, "aud": "https://api.example.com", "exp": exp}, false},
+	}
+
+	for _, data := range oidcVerifierTestData {
+		token := jwt.NewWithClaims(data.method, data.claims)
+		token.Header["kid"] = "k1"
+		s, err := token.SignedString(data.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := verifier.Verify(context.Background(), s)
+		if data.valid && (err != nil || claims.(jwt.MapClaims)["iss"] != server.URL) {
+			t.Errorf("[%v] Error verifying token: %v", data.name, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("[%v] Invalid token passed verification", data.name)
+		}
+	}
+}
+
+// exp is checked by the parser's clock, not the global one
+func TestOIDCVerifier_clock(t *testing.T) {
+	server := newOIDCServer(t)
+	defer server.Close()
+
+	verifier, err := jwt.NewOIDCVerifier(context.Background(), server.URL, "https://api.example.com")
+	if err != nil {
+		t.Fatalf("Error during discovery: %v", err)
+	}
+	then := time.Now().Add(-2 * time.Hour)
+	verifier.Provider.Parser.TimeFunc = func() time.Time { return then }
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": server.URL, "aud": "https://api.example.com", "exp": then.Add(time.Hour).Unix()})
+	token.Header["kid"] = "k1"
+	s, err := token.SignedString(test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = verifier.Verify(context.Background(), s); err != nil {
+		t.Errorf("Expected a token unexpired at the parser's time to pass.  Got %v", err)
+	}
+}
+
+func TestOIDCVerifier_reporting(t *testing.T) {
+	server := newOIDCServer(t)
+	defer server.Close()
+
+	verifier, err := jwt.NewOIDCVerifier(context.Background(), server.URL, "https://api.example.com")
+	if err != nil {
+		t.Fatalf("Error during discovery: %v", err)
+	}
+	var rejections []jwt.ErrorCode
+	metrics := new(recordingMetrics)
+	verifier.Provider.Parser.ErrorLogger = jwt.ErrorLoggerFunc(func(r *jwt.Rejection) {
+		rejections = append(rejections, r.Code)
+	})
+	verifier.Provider.Parser.Metrics = metrics
+
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	exp := time.Now().Add(time.Hour).Unix()
+	for _, claims := range []jwt.MapClaims{
+		{"iss": server.URL, "aud": "https://other.example.com", "exp": exp},
+		{"iss": server.URL, "aud": "https://api.example.com"},
+	} {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "k1"
+		s, err := token.SignedString(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = verifier.Verify(context.Background(), s); err == nil {
+			t.Errorf("Expected %v to be refused", claims)
+		}
+	}
+
+	expect := []jwt.ErrorCode{jwt.CodeInvalidAudience, jwt.CodeTokenExpired}
+	if !reflect.DeepEqual(rejections, expect) {
+		t.Errorf("Expected rejections %v.  Got %v", expect, rejections)
+	}
+	if verifys := []string{"RS256 " + expect[0].String(), "RS256 " + expect[1].String()}; !reflect.DeepEqual(metrics.verifys, verifys) {
+		t.Errorf("Expected verifications %v.  Got %v", verifys, metrics.verifys)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build oteljwt
+
+// Package oteljwt traces token parsing, key lookup, signing and JWK Set
+// fetches with OpenTelemetry.
+//
+// It depends on go.opentelemetry.io/otel, which the rest of jwt-go does
+// not, so it is only built with the oteljwt tag:
+//
+//	go get go.opentelemetry.io/otel
+//	go build -tags oteljwt
+//
+// Install a tracer for the whole package, using the global provider:
+//
+//	jwt.DefaultTracer = oteljwt.NewTracer(nil)
+//
+// Pass the request context with Parser.ParseWithContext so the spans
+// join the request's trace.  With an instrumented JWKSProvider.Client,
+// the HTTP fetches show up beneath jwt.JWKSFetch.
+package oteljwt
+
+import (
+	"context"
+
+	"github.com/dgrijalva/jwt-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instrumentation scope of the spans
+const ScopeName = "github.com/dgrijalva/jwt-go"
+
+// A jwt.Tracer starting spans with tp, or the global TracerProvider if
+// tp is nil
+func NewTracer(tp trace.TracerProvider) jwt.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &tracer{tp.Tracer(ScopeName)}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, jwt.Span) {
+	ctx, s := t.tracer.Start(ctx, name)
+	return ctx, span{s}
+}
+
+type span struct {
+	span trace.Span
+}
+
+func (s span) SetAttribute(key, value string) {
+	s.span.SetAttributes(attribute.String(key, value))
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, jwt.CodeOf(err).String())
+	}
+	s.span.End()
+}
@@ -0,0 +1,49 @@
+//go:build oteljwt
+
+package oteljwt
+
+import (
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	parser := &jwt.Parser{Tracer: NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))}
+
+	key := []byte("secret")
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": "https://issuer.example.com"})
+	token.Header["kid"] = "k1"
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse(s, func(*jwt.Token) (interface{}, error) { return []byte("wrong"), nil })
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != jwt.SpanKeyfunc || spans[1].Name() != jwt.SpanParse {
+		t.Fatalf("Expected keyfunc and parse spans.  Got %v", spans)
+	}
+	attrs := map[string]string{}
+	for _, kv := range spans[1].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	expected := map[string]string{
+		jwt.AttributeAlg:       "HS256",
+		jwt.AttributeKid:       "k1",
+		jwt.AttributeIssuer:    "https://issuer.example.com",
+		jwt.AttributeErrorCode: jwt.CodeInvalidSignature.String(),
+	}
+	for k, v := range expected {
+		if attrs[k] != v {
+			t.Errorf("[%v] Expected %v.  Got %v", k, v, attrs[k])
+		}
+	}
+	if status := spans[1].Status(); status.Code != codes.Error || status.Description != jwt.CodeInvalidSignature.String() {
+		t.Errorf("Expected an error status.  Got %v", status)
+	}
+}
@@ -2,15 +2,78 @@ package jwt
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 )
 
+var (
+	ErrInvalidCritical = newError(CodeInvalidHeader, "crit must list header parameters that are set and not registered")
+)
+
+// Tokens longer than this are rejected as malformed before any decoding
+const DefaultMaxTokenLength = 1 << 20
+
+// Header parameters that, when present, must be strings
+var stringHeaders = []string{"alg", "kid", "typ", "cty"}
+
 type Parser struct {
 	ValidMethods         []string // If populated, only these methods will be considered valid
 	UseJSONNumber        bool     // Use JSON Number format in JSON decoder
 	SkipClaimsValidation bool     // Skip claims validation during token parsing
+	MaxTokenLength       int      // Longer tokens are malformed.  Defaults to DefaultMaxTokenLength, negative for no limit
+	CriticalHeaders      []string // Header extensions the application understands.  Tokens may only list these in crit
+
+	// The time exp, iat and nbf are checked at, for MapClaims,
+	// StandardClaims and TimedClaims.  Other claims, including types
+	// embedding StandardClaims without a ValidAt of their own, are checked
+	// with their Valid method, at the package's TimeFunc.  Defaults to
+	// TimeFunc.
+	TimeFunc func() time.Time
+
+	// Report some issues as Token.Warnings instead of failing: an iat up to
+	// LenientLeeway in the future, a missing jti and use of a method from
+	// DeprecatedMethods.  Only the first is an error otherwise.
+	Lenient           bool
+	LenientLeeway     time.Duration // Defaults to DefaultLenientLeeway
+	DeprecatedMethods []string
+
+	ErrorLogger ErrorLogger  // If set, told about every token rejected
+	Metrics     Metrics      // Measures verification.  Defaults to DefaultMetrics
+	Tracer      Tracer       // Traces verification.  Defaults to DefaultTracer
+	Logger      *slog.Logger // Told about tokens accepted with Warnings.  Defaults to DefaultLogger
+
+	// Told about forged, replayed and revoked tokens.  Defaults to
+	// DefaultSecurityHook.
+	SecurityHook SecurityHook
+
+	SignaturePolicy SignaturePolicy // Signatures VerifyJSON requires.  Defaults to AnySignature
+	MinSignatures   int             // Signatures, by distinct keys, AllSignatures requires.  Defaults to 2
+
+	// If set, asked about tokens that are otherwise valid.  Revoked tokens
+	// fail with ErrTokenRevoked, and so do all tokens if it can't answer.
+	Revocation RevocationChecker
+
+	// Honor the cty header, RFC 7519 section 5.2.  With RawPayloads, a
+	// payload whose content type is not JSON is left in Token.Payload and
+	// not decoded or validated as claims.  With UnwrapNested, a token with
+	// cty JWT is verified and the token it carries is parsed and returned
+	// in its place; keyFunc is called for each.  Otherwise, payloads are
+	// always decoded as claims.
+	RawPayloads  bool
+	UnwrapNested bool
+
+	// Keys of a VerificationKeySet verified at once, so that a token
+	// signed by the last of many keys isn't held up by trying the others
+	// in turn.  Once one key verifies, no more are tried.  Defaults to 1,
+	// trying the keys in order.
+	KeySetWorkers int
 }
 
 // Parse, validate, and return a token.
@@ -21,62 +84,110 @@ func (p *Parser) Parse(tokenString string, keyFunc Keyfunc) (*Token, error) {
 }
 
 func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyfunc) (*Token, error) {
-	token, parts, err := p.ParseUnverified(tokenString, claims)
-	if err != nil {
-		return token, err
-	}
+	return p.ParseWithContext(context.Background(), tokenString, claims, keyFunc)
+}
 
-	// Verify signing method is in the required set
-	if p.ValidMethods != nil {
-		var signingMethodValid = false
-		var alg = token.Method.Alg()
-		for _, m := range p.ValidMethods {
-			if m == alg {
-				signingMethodValid = true
-				break
-			}
+// ParseWithClaims as part of the operation in ctx.  The span of the parse
+// is a child of the one in ctx, and keyFunc can get the context with
+// Token.Context, so the JWK Set fetches of a JWKSProvider are traced too.
+func (p *Parser) ParseWithContext(ctx context.Context, tokenString string, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	return p.parseWithContext(ctx, tokenString, claims, keyFunc, nil, nil)
+}
+
+// ParseWithContext, decoding into scratch while it has room.  check, if
+// set, is run on tokens that are otherwise valid, and its error is reported
+// like the parser's own.
+func (p *Parser) parseWithContext(ctx context.Context, tokenString string, claims Claims, keyFunc Keyfunc, scratch *[]byte, check func(*Token) error) (*Token, error) {
+	return p.reported(ctx, func(ctx context.Context) (*Token, error) {
+		token, err := p.parseWithClaims(ctx, tokenString, claims, keyFunc, scratch)
+		if err == nil && check != nil {
+			err = check(token)
 		}
-		if !signingMethodValid {
-			// signing method is not in the listed set
-			return token, NewValidationError(fmt.Sprintf("signing method %v is invalid", alg), ValidationErrorSignatureInvalid)
+		return token, err
+	})
+}
+
+// Run parse as one verification: traced and measured, with its warnings
+// logged and its failure told to the ErrorLogger and SecurityHook
+func (p *Parser) reported(ctx context.Context, parse func(context.Context) (*Token, error)) (*Token, error) {
+	start := time.Now()
+	ctx, span := startSpan(ctx, p.tracer(), SpanParse)
+	token, err := parse(ctx)
+	if m := p.metrics(); m != nil {
+		m.ObserveVerify(tokenAlg(token), CodeOf(err), time.Since(start))
+	}
+	endTokenSpan(span, token, err)
+	if err == nil && token != nil {
+		for _, w := range token.Warnings {
+			logAt(p.Logger, slog.LevelWarn, "jwt: token accepted with warning",
+				slog.String("code", w.Code.String()), slog.String("field", w.Field), slog.String("warning", w.Message),
+				slog.String("alg", tokenAlg(token)))
 		}
 	}
+	if ve, ok := err.(*ValidationError); ok && ve.Context == nil && token != nil && token.Header != nil {
+		err = ve.withContext(token)
+	}
+	if err != nil && p.ErrorLogger != nil {
+		p.ErrorLogger.LogRejection(newRejection(err))
+	}
+	reportSecurityEvent(ctx, p.securityHook(), token, err)
+	return token, err
+}
 
-	// Lookup key
-	var key interface{}
-	if keyFunc == nil {
-		// keyFunc was not provided.  short circuiting validation
-		return token, NewValidationError("no Keyfunc was provided.", ValidationErrorUnverifiable)
+func (p *Parser) parseWithClaims(ctx context.Context, tokenString string, claims Claims, keyFunc Keyfunc, scratch *[]byte) (*Token, error) {
+	token, parts, err := p.parseUnverified(tokenString, claims, scratch)
+	if err != nil {
+		return token, err
 	}
-	if key, err = keyFunc(token); err != nil {
-		// keyFunc returned an error
-		if ve, ok := err.(*ValidationError); ok {
-			return token, ve
+	token.ctx, token.now = ctx, p.TimeFunc
+	if err = checkCritical(token.Header, p.CriticalHeaders); err != nil {
+		return token, err
+	}
+
+	key, err := p.lookupKey(token, keyFunc)
+	if err != nil {
+		return token, err
+	}
+
+	if p.unwraps(token.Header) {
+		token.Signature = parts[2]
+		if err = p.verifySignature(token, tokenString[:len(parts[0])+1+len(parts[1])], key); err != nil {
+			return token, &ValidationError{Inner: err, Errors: ValidationErrorSignatureInvalid}
 		}
-		return token, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+		return p.parseWithClaims(ctx, string(token.Payload), claims, keyFunc, scratch)
 	}
 
-	vErr := &ValidationError{}
+	// Kept on the stack, and copied to the heap only if the token is refused
+	var vErr ValidationError
 
 	// Validate Claims
-	if !p.SkipClaimsValidation {
-		if err := token.Claims.Valid(); err != nil {
+	if !p.SkipClaimsValidation && p.decodesClaims(token.Header) {
+		if err := p.validClaims(token.Claims); err != nil {
 
 			// If the Claims Valid returned an error, check if it is a validation error,
 			// If it was another error type, create a ValidationError with a generic ClaimsInvalid flag set
 			if e, ok := err.(*ValidationError); !ok {
-				vErr = &ValidationError{Inner: err, Errors: ValidationErrorClaimsInvalid}
+				vErr = ValidationError{Inner: err, Errors: ValidationErrorClaimsInvalid}
 			} else {
-				vErr = e
+				vErr = *e
 			}
 		}
 	}
 
 	// Perform validation
 	token.Signature = parts[2]
-	if err = token.Method.Verify(strings.Join(parts[0:2], "."), token.Signature, key); err != nil {
-		vErr.Inner = err
-		vErr.Errors |= ValidationErrorSignatureInvalid
+	if err = p.verifySignature(token, tokenString[:len(parts[0])+1+len(parts[1])], key); err != nil {
+		vErr.add(err, ValidationErrorSignatureInvalid)
+	}
+
+	if p.Lenient {
+		p.relax(token, &vErr)
+	}
+
+	if p.Revocation != nil && vErr.valid() {
+		if err = checkRevoked(ctx, p.Revocation, token); err != nil {
+			vErr.add(err, ValidationErrorId)
+		}
 	}
 
 	if vErr.valid() {
@@ -84,7 +195,8 @@ func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyf
 		return token, nil
 	}
 
-	return token, vErr
+	// Escaped with its context, rather than copied again to add it
+	return token, vErr.withContext(token)
 }
 
 // WARNING: Don't use this method unless you know what you're doing
@@ -94,55 +206,392 @@ func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyf
 // been checked previously in the stack) and you want to extract values from
 // it.
 func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Token, parts []string, err error) {
-	parts = strings.Split(tokenString, ".")
-	if len(parts) != 3 {
+	token, segments, err := p.parseUnverified(tokenString, claims, nil)
+	if token == nil {
+		return nil, nil, err
+	}
+	return token, segments[:], err
+}
+
+func (p *Parser) parseUnverified(tokenString string, claims Claims, scratch *[]byte) (token *Token, parts [3]string, err error) {
+	if err = p.checkLength(tokenString); err != nil {
+		return nil, parts, err
+	}
+
+	var ok bool
+	if parts, ok = splitToken(tokenString); !ok {
 		return nil, parts, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
 	}
 
 	token = &Token{Raw: tokenString}
 
+	// Decode every segment into one buffer, from one copy of tokenString
+	// (or from tokenString itself, with -tags jwtunsafe)
+	raw := tokenBytes(scratch, tokenString)
+	buf := decodeBuffer(scratch, SegmentEncoding.DecodedLen(len(raw)))
+	rawClaims := raw[len(parts[0])+1 : len(parts[0])+1+len(parts[1])]
+	rawSig := raw[len(raw)-len(parts[2]):]
+
 	// parse Header
 	var headerBytes []byte
-	if headerBytes, err = DecodeSegment(parts[0]); err != nil {
+	if headerBytes, buf, err = decodeSegmentInto(buf, raw[:len(parts[0])]); err != nil {
 		if strings.HasPrefix(strings.ToLower(tokenString), "bearer ") {
 			return token, parts, NewValidationError("tokenstring should not contain 'bearer '", ValidationErrorMalformed)
 		}
 		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
 	}
-	if err = json.Unmarshal(headerBytes, &token.Header); err != nil {
-		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	token.RawHeader = headerBytes
+	if sig, rest, err := decodeSegmentInto(buf, rawSig); err == nil {
+		token.SignatureBytes, buf = sig, rest
+	}
+	if token.Header, err = parseHeader(headerBytes); err != nil {
+		return token, parts, err
 	}
 
 	// parse Claims
 	var claimBytes []byte
 	token.Claims = claims
 
-	if claimBytes, err = DecodeSegment(parts[1]); err != nil {
+	if claimBytes, _, err = decodeSegmentInto(buf, rawClaims); err != nil {
 		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
 	}
-	dec := json.NewDecoder(bytes.NewBuffer(claimBytes))
-	if p.UseJSONNumber {
-		dec.UseNumber()
+	token.Payload = claimBytes
+	token.RawClaims = claimBytes
+	if !p.decodesClaims(token.Header) {
+		return token, parts, p.lookupMethod(token)
 	}
-	// JSON Decode.  Special case for map type to avoid weird pointer behavior
-	if c, ok := token.Claims.(MapClaims); ok {
+	dec := getClaimsDecoder(claimBytes, p.UseJSONNumber)
+	// JSON Decode.  Special case for map type to avoid weird pointer behavior.
+	// The claims are copied before their address is taken, so that the
+	// common case of a pointer doesn't move them to the heap.
+	if m, ok := token.Claims.(MapClaims); ok {
+		c := m
 		err = dec.Decode(&c)
+	} else if claims != nil && reflect.TypeOf(claims).Kind() == reflect.Ptr {
+		err = dec.Decode(claims)
 	} else {
-		err = dec.Decode(&claims)
+		c := claims
+		err = dec.Decode(&c)
 	}
+	putClaimsDecoder(dec, err)
 	// Handle decode error
 	if err != nil {
-		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+		return token, parts, &ValidationError{Inner: &claimsDecodeError{err}, Errors: ValidationErrorMalformed}
+	}
+
+	return token, parts, p.lookupMethod(token)
+}
+
+// Refuse tokens longer than the Parser allows, before any decoding
+func (p *Parser) checkLength(tokenString string) error {
+	if max := p.maxTokenLength(); max >= 0 && len(tokenString) > max {
+		return NewValidationError("token is too long", ValidationErrorMalformed)
+	}
+	return nil
+}
+
+// Decode the protected header of a token, which must be a JSON object
+// whose stringHeaders are strings
+func parseHeader(headerBytes []byte) (map[string]interface{}, error) {
+	header, err := decodeHeader(headerBytes)
+	if err != nil {
+		return header, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	if header == nil {
+		return nil, NewValidationError("token header is not a JSON object", ValidationErrorMalformed)
+	}
+	for _, name := range stringHeaders {
+		if v, ok := header[name]; ok {
+			if _, ok = v.(string); !ok {
+				return header, NewValidationError(fmt.Sprintf("token header %v is not a string", name), ValidationErrorMalformed)
+			}
+		}
+	}
+	return header, nil
+}
+
+// The three segments of a compact token, if it has exactly three.  Unlike
+// strings.Split, this needs no allocation.
+func splitToken(tokenString string) (parts [3]string, ok bool) {
+	header, rest, ok := strings.Cut(tokenString, ".")
+	if !ok {
+		return parts, false
+	}
+	payload, sig, ok := strings.Cut(rest, ".")
+	if !ok || strings.IndexByte(sig, '.') >= 0 {
+		return parts, false
+	}
+	return [3]string{header, payload, sig}, true
+}
+
+// Decode seg into the start of buf, returning the decoded bytes and the
+// rest of buf.  The decoded slice is capped, so appending to it can't
+// overwrite what is decoded after it.
+func decodeSegmentInto(buf, seg []byte) (decoded, rest []byte, err error) {
+	if seg, err = unpad(seg); err != nil {
+		return nil, buf, err
+	}
+	n, err := SegmentEncoding.Decode(buf, seg)
+	if err != nil {
+		return nil, buf, err
+	}
+	return buf[:n:n], buf[n:], nil
+}
+
+// Decoders of claims, with and without UseNumber.  A json.Decoder can't be
+// pointed at new input, but one reading from a bytes.Reader that it has
+// drained can be refilled by resetting the reader.
+var claimsDecoders [2]sync.Pool
+
+// Payloads larger than this don't return their decoder to the pool, so it
+// doesn't keep a large buffer alive
+const maxPooledClaimsSize = 64 << 10
+
+type claimsDecoder struct {
+	*json.Decoder
+	r         bytes.Reader
+	useNumber bool
+	start     int64 // InputOffset before the current input
+}
+
+func getClaimsDecoder(claimBytes []byte, useNumber bool) *claimsDecoder {
+	i := 0
+	if useNumber {
+		i = 1
+	}
+	dec, _ := claimsDecoders[i].Get().(*claimsDecoder)
+	if dec == nil {
+		dec = &claimsDecoder{useNumber: useNumber}
+		dec.Decoder = json.NewDecoder(&dec.r)
+		if useNumber {
+			dec.UseNumber()
+		}
+	}
+	dec.r.Reset(claimBytes)
+	dec.start = dec.InputOffset()
+	return dec
+}
+
+// Return dec to its pool, unless decoding failed or stopped short of the
+// end of the input, leaving bytes that the next use would see
+func putClaimsDecoder(dec *claimsDecoder, err error) {
+	if err != nil || dec.r.Size() > maxPooledClaimsSize || dec.InputOffset()-dec.start != dec.r.Size() {
+		return
 	}
+	i := 0
+	if dec.useNumber {
+		i = 1
+	}
+	claimsDecoders[i].Put(dec)
+}
 
-	// Lookup signature method
+// Lookup signature method
+func (p *Parser) lookupMethod(token *Token) error {
 	if method, ok := token.Header["alg"].(string); ok {
 		if token.Method = GetSigningMethod(method); token.Method == nil {
-			return token, parts, NewValidationError("signing method (alg) is unavailable.", ValidationErrorUnverifiable)
+			return NewValidationError("signing method (alg) is unavailable.", ValidationErrorUnverifiable)
 		}
 	} else {
-		return token, parts, NewValidationError("signing method (alg) is unspecified.", ValidationErrorUnverifiable)
+		return NewValidationError("signing method (alg) is unspecified.", ValidationErrorUnverifiable)
+	}
+	return nil
+}
+
+// Whether the payload of a token with header is decoded as claims
+func (p *Parser) decodesClaims(header map[string]interface{}) bool {
+	cty, _ := header["cty"].(string)
+	switch {
+	case p.unwraps(header):
+		return false
+	case p.RawPayloads:
+		return isJSONContentType(cty)
+	}
+	return true
+}
+
+// Whether a token with header carries a nested JWT to parse in its place
+func (p *Parser) unwraps(header map[string]interface{}) bool {
+	cty, _ := header["cty"].(string)
+	return p.UnwrapNested && strings.EqualFold(cty, "JWT")
+}
+
+// Content types of claims.  cty may omit the application/ prefix, RFC
+// 7515 section 4.1.10.
+func isJSONContentType(cty string) bool {
+	if cty == "" {
+		return true
+	}
+	cty = strings.ToLower(cty)
+	if i := strings.IndexByte(cty, ';'); i >= 0 {
+		cty = cty[:i]
+	}
+	cty = strings.TrimPrefix(strings.TrimSpace(cty), "application/")
+	return cty == "json" || strings.HasSuffix(cty, "+json")
+}
+
+// Check the signing method is in the required set, then ask keyFunc for
+// the verification key
+func (p *Parser) lookupKey(token *Token, keyFunc Keyfunc) (interface{}, error) {
+	// Verify signing method is in the required set
+	if p.ValidMethods != nil {
+		var signingMethodValid = false
+		var alg = token.Method.Alg()
+		for _, m := range p.ValidMethods {
+			if m == alg {
+				signingMethodValid = true
+				break
+			}
+		}
+		if !signingMethodValid {
+			// signing method is not in the listed set
+			return nil, NewValidationError(fmt.Sprintf("signing method %v is invalid", alg), ValidationErrorSignatureInvalid)
+		}
 	}
 
-	return token, parts, nil
+	// Lookup key
+	if keyFunc == nil {
+		// keyFunc was not provided.  short circuiting validation
+		return nil, NewValidationError("no Keyfunc was provided.", ValidationErrorUnverifiable)
+	}
+	// keyFunc sees the context of its own span
+	parent := token.ctx
+	var span Span
+	token.ctx, span = startSpan(token.Context(), p.tracer(), SpanKeyfunc)
+	key, err := keyFunc(token)
+	token.ctx = parent
+	endSpan(span, err)
+	if err != nil {
+		// keyFunc returned an error
+		if ve, ok := err.(*ValidationError); ok {
+			return nil, ve
+		}
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable, keyfunc: true}
+	}
+	return key, nil
+}
+
+// Check the crit header, RFC 7515 section 4.1.11: if present it must list
+// header parameters that are present and understood by the application
+func checkCritical(header map[string]interface{}, understood []string) error {
+	v, ok := header["crit"]
+	if !ok {
+		return nil
+	}
+	crit, ok := v.([]interface{})
+	if !ok || len(crit) == 0 {
+		return NewValidationError("token header crit must be a non-empty array", ValidationErrorMalformed)
+	}
+
+	for _, c := range crit {
+		name, ok := c.(string)
+		if !ok {
+			return NewValidationError("token header crit must list header names", ValidationErrorMalformed)
+		}
+		if _, ok = header[name]; !ok {
+			return NewValidationError(fmt.Sprintf("critical header %v is missing", name), ValidationErrorMalformed)
+		}
+		if !containsString(understood, name) {
+			return NewValidationError(fmt.Sprintf("critical header %v is not supported", name), ValidationErrorUnverifiable)
+		}
+	}
+	return nil
+}
+
+// Header parameters defined by RFC 7515, which crit must not list
+var registeredHeaders = []string{"alg", "jku", "jwk", "kid", "x5u", "x5c", "x5t", "x5t#S256", "typ", "cty", "crit"}
+
+// Check a crit header before signing: each entry must name a header
+// parameter that is set and not one of the registered ones
+func checkCriticalForSigning(header map[string]interface{}) error {
+	var names []string
+	switch crit := header["crit"].(type) {
+	case nil:
+		return nil
+	case []string:
+		names = crit
+	case []interface{}:
+		for _, c := range crit {
+			name, ok := c.(string)
+			if !ok {
+				return ErrInvalidCritical
+			}
+			names = append(names, name)
+		}
+	default:
+		return ErrInvalidCritical
+	}
+
+	if len(names) == 0 {
+		return ErrInvalidCritical
+	}
+	for _, name := range names {
+		if _, ok := header[name]; !ok || containsString(registeredHeaders, name) {
+			return ErrInvalidCritical
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) maxTokenLength() int {
+	if p.MaxTokenLength == 0 {
+		return DefaultMaxTokenLength
+	}
+	return p.MaxTokenLength
+}
+
+// Validate claims at the parser's time
+func (p *Parser) validClaims(claims Claims) error {
+	if p.TimeFunc == nil {
+		return claims.Valid()
+	}
+	now := p.TimeFunc()
+	switch c := claims.(type) {
+	case MapClaims:
+		return c.validAt(now.Unix())
+	case *MapClaims:
+		return c.validAt(now.Unix())
+	case StandardClaims:
+		return c.CheckTimes(now)
+	case *StandardClaims:
+		return c.CheckTimes(now)
+	case TimedClaims:
+		return c.ValidAt(now)
+	}
+	return claims.Valid()
+}
+
+// Turn the failures tolerated in Lenient mode into warnings and add
+// warnings for the other issues it reports
+func (p *Parser) relax(token *Token, vErr *ValidationError) {
+	leeway := p.LenientLeeway
+	if leeway == 0 {
+		leeway = DefaultLenientLeeway
+	}
+
+	var iat *IssuedAtError
+	if vErr.Errors&ValidationErrorIssuedAt != 0 && errors.As(vErr.Inner, &iat) && iat.IssuedIn <= leeway {
+		vErr.remove(iat, ValidationErrorIssuedAt)
+		token.Warnings = append(token.Warnings, Warning{CodeTokenUsedBeforeIssued, "iat", iat.Error()})
+	}
+
+	if claims, ok := token.Claims.(interface{ GetId() string }); ok && claims.GetId() == "" {
+		token.Warnings = append(token.Warnings, Warning{CodeInvalidId, "jti", "token has no jti claim"})
+	}
+
+	alg := token.Method.Alg()
+	for _, m := range p.DeprecatedMethods {
+		if m == alg {
+			token.Warnings = append(token.Warnings, Warning{CodeDeprecatedAlgorithm, "alg", fmt.Sprintf("signing method %v is deprecated", alg)})
+			break
+		}
+	}
 }
@@ -0,0 +1,69 @@
+package jwt_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+// Parse must never panic, whatever the input.  Seeds beyond these live in
+// testdata/fuzz/FuzzParse.
+func FuzzParse(f *testing.F) {
+	for _, data := range jwtTestData {
+		if data.tokenString != "" {
+			f.Add(data.tokenString)
+		}
+	}
+	f.Add(test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, test.LoadRSAPrivateKeyFromDisk("test/sample_key")))
+
+	keyfuncs := []jwt.Keyfunc{defaultKeyFunc, emptyKeyFunc, errorKeyFunc, nilKeyFunc}
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, keyfunc := range keyfuncs {
+			for _, claims := range []jwt.Claims{jwt.MapClaims{}, &jwt.StandardClaims{}} {
+				token, err := jwt.ParseWithClaims(s, claims, keyfunc)
+				if err == nil && !token.Valid {
+					t.Errorf("Token without error is not valid")
+				}
+				var ve *jwt.ValidationError
+				if err != nil && !errors.As(err, &ve) {
+					t.Errorf("Expected a ValidationError.  Got %T", err)
+				}
+			}
+		}
+	})
+}
+
+var malformedTestData = []struct {
+	name   string
+	header string
+	claims string
+}{
+	{"alg number", `{"alg":256}`, `{}`},
+	{"kid number", `{"alg":"RS256","kid":7}`, `{}`},
+	{"typ object", `{"alg":"RS256","typ":{}}`, `{}`},
+	{"header null", `null`, `{}`},
+	{"header array", `[]`, `{}`},
+	{"claims array", `{"alg":"RS256"}`, `[]`},
+}
+
+func TestParse_malformed(t *testing.T) {
+	for _, data := range malformedTestData {
+		s := jwt.EncodeSegment([]byte(data.header)) + "." + jwt.EncodeSegment([]byte(data.claims)) + ".c2ln"
+		_, err := jwt.Parse(s, defaultKeyFunc)
+		if !jwt.IsMalformed(err) {
+			t.Errorf("[%v] Expected a malformed token error.  Got %v", data.name, err)
+		}
+	}
+
+	long := strings.Repeat("a", jwt.DefaultMaxTokenLength+1)
+	if _, err := jwt.Parse(long, defaultKeyFunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected an oversized token to be malformed.  Got %v", err)
+	}
+	parser := &jwt.Parser{MaxTokenLength: -1}
+	if _, err := parser.Parse(long, defaultKeyFunc); err == nil || err.Error() == "token is too long" {
+		t.Errorf("Expected no length limit.  Got %v", err)
+	}
+}
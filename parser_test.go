@@ -1,6 +1,8 @@
 package jwt_test
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/rsa"
 	"encoding/json"
 	"fmt"
@@ -287,6 +289,148 @@ func TestParser_ParseUnverified(t *testing.T) {
 	}
 }
 
+var segmentCountTestData = []struct {
+	name        string
+	tokenString string
+}{
+	{"one", "eyJhbGciOiJIUzI1NiJ9"},
+	{"two", "eyJhbGciOiJIUzI1NiJ9.e30"},
+	{"four", "eyJhbGciOiJIUzI1NiJ9.e30.c2ln.c2ln"},
+	{"trailing dot", "eyJhbGciOiJIUzI1NiJ9.e30.c2ln."},
+}
+
+func TestParser_ParseUnverified_segments(t *testing.T) {
+	for _, data := range segmentCountTestData {
+		token, _, err := new(jwt.Parser).ParseUnverified(data.tokenString, jwt.MapClaims{})
+		if token != nil || jwt.CodeOf(err) != jwt.CodeTokenMalformed {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, jwt.CodeTokenMalformed, err)
+		}
+	}
+
+	token, parts, err := new(jwt.Parser).ParseUnverified("eyJhbGciOiJIUzI1NiJ9.e30.c2ln", jwt.MapClaims{})
+	if err != nil || !reflect.DeepEqual(parts, []string{"eyJhbGciOiJIUzI1NiJ9", "e30", "c2ln"}) {
+		t.Fatalf("Unexpected parts %q, %v", parts, err)
+	}
+
+	// The decoded segments share a buffer, but must not overlap
+	claims := append([]byte(nil), token.RawClaims...)
+	token.RawHeader = append(token.RawHeader, "clobber"...)
+	if !bytes.Equal(token.RawClaims, claims) || string(token.SignatureBytes) != "sig" {
+		t.Errorf("Appending to RawHeader changed the other segments: %q %q", token.RawClaims, token.SignatureBytes)
+	}
+}
+
+// Counts the signatures checked with each of its methods
+type bytesVerifierMethod struct {
+	*jwt.SigningMethodHMAC
+	verify, verifyBytes int
+}
+
+func (m *bytesVerifierMethod) Verify(signingString, signature string, key interface{}) error {
+	m.verify++
+	return m.SigningMethodHMAC.Verify(signingString, signature, key)
+}
+
+func (m *bytesVerifierMethod) VerifyBytes(signingString string, sig []byte, key interface{}) error {
+	m.verifyBytes++
+	return m.SigningMethodHMAC.VerifyBytes(signingString, sig, key)
+}
+
+// Overrides Verify only, as pinning or logging wrappers do
+type verifyOverrideMethod struct {
+	*jwt.SigningMethodHMAC
+	verify int
+}
+
+func (m *verifyOverrideMethod) Verify(signingString, signature string, key interface{}) error {
+	m.verify++
+	return m.SigningMethodHMAC.Verify(signingString, signature, key)
+}
+
+func TestParser_BytesVerifier(t *testing.T) {
+	counted := &bytesVerifierMethod{SigningMethodHMAC: &jwt.SigningMethodHMAC{Name: "HS256-counted", Hash: crypto.SHA256}}
+	override := &verifyOverrideMethod{SigningMethodHMAC: &jwt.SigningMethodHMAC{Name: "HS256-override", Hash: crypto.SHA256}}
+	jwt.RegisterSigningMethod(counted.Alg(), func() jwt.SigningMethod { return counted })
+	jwt.RegisterSigningMethod(override.Alg(), func() jwt.SigningMethod { return override })
+
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	for _, method := range []jwt.SigningMethod{counted, override} {
+		tokenString, err := jwt.New(method).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = jwt.Parse(tokenString, keyFunc); err != nil {
+			t.Errorf("[%v] Error parsing: %v", method.Alg(), err)
+		}
+		if _, err = jwt.Parse(tokenString[:len(tokenString)-2]+"AA", keyFunc); err == nil {
+			t.Errorf("[%v] Expected an invalid signature", method.Alg())
+		}
+		if _, err = jwt.Parse(tokenString[:len(tokenString)-2]+"!!", keyFunc); err == nil {
+			t.Errorf("[%v] Expected an invalid signature", method.Alg())
+		}
+	}
+
+	// Types other than the package's own are checked with Verify, even
+	// when they implement VerifyBytes
+	if counted.verifyBytes != 0 || counted.verify != 3 {
+		t.Errorf("Expected 0 calls to VerifyBytes and 3 to Verify.  Got %v and %v", counted.verifyBytes, counted.verify)
+	}
+	if override.verify != 3 {
+		t.Errorf("Expected the overriding Verify to be called 3 times.  Got %v", override.verify)
+	}
+}
+
+// Claims decoders are reused, so one token must not affect the next
+func TestParser_claimsDecoderReuse(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	sign := func(payload string) string {
+		signing := jwt.EncodeSegment([]byte(`{"alg":"HS256"}`)) + "." + jwt.EncodeSegment([]byte(payload))
+		sig, _ := jwt.SigningMethodHS256.Sign(signing, key)
+		return signing + "." + sig
+	}
+
+	for i, data := range []struct {
+		payload   string
+		useNumber bool
+		expect    interface{}
+	}{
+		{`{"n":1}`, false, float64(1)},
+		{`{"n":2}`, true, json.Number("2")},
+		{`{"n":3} {"n":4}`, false, float64(3)}, // Only the first value is read
+		{`{"n":5}`, false, float64(5)},
+		{`{"n":6}` + "\n", true, json.Number("6")},
+		{`{"n":7}`, true, json.Number("7")},
+		{`{"n":`, false, nil},
+		{`{"n":8}`, false, float64(8)},
+	} {
+		parser := &jwt.Parser{UseJSONNumber: data.useNumber}
+		token, err := parser.Parse(sign(data.payload), keyFunc)
+		if data.expect == nil {
+			if err == nil {
+				t.Errorf("[%v] Expected an error", i)
+			}
+			continue
+		}
+		if err != nil || token.Claims.(jwt.MapClaims)["n"] != data.expect {
+			t.Errorf("[%v] Expected n=%v.  Got %v, %v", i, data.expect, token.Claims, err)
+		}
+	}
+}
+
+func BenchmarkParseHS256(b *testing.B) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{Subject: "1234567890", Issuer: "example"}).SignedString(key)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := jwt.ParseWithClaims(tokenString, &jwt.StandardClaims{}, keyFunc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 // Helper method for benchmarking various methods
 func benchmarkSigning(b *testing.B, method jwt.SigningMethod, key interface{}) {
 	t := jwt.New(method)
@@ -299,3 +443,114 @@ func benchmarkSigning(b *testing.B, method jwt.SigningMethod, key interface{}) {
 	})
 
 }
+
+// Headers decode as encoding/json would decode them into a map, whether or
+// not they take the fast path
+func TestParser_headers(t *testing.T) {
+	var tests = []string{
+		`{"alg":"HS256","typ":"JWT"}`,
+		` { "alg" : "HS256" , "kid" : "key-1" } `,
+		`{"alg":"HS256","kid":"café \"1\"","x-custom":"😀"}`,
+		"{\"alg\":\"HS256\",\"kid\":\"\xff\"}",
+		`{"alg":"HS256","alg":"HS384"}`,
+		`{"alg":"HS256","kid":null}`,
+		`{"alg":"HS256","b64":false,"crit":["b64"]}`,
+		`{"alg":"HS256","n":1.5,"jwk":{"kty":"oct"}}`,
+		`{}`,
+		`null`,
+		`[]`,
+		`{"alg":"HS256"`,
+		`{"alg":"HS256"} {}`,
+	}
+	claims := jwt.EncodeSegment([]byte(`{"sub":"1234567890"}`))
+	for _, header := range tests {
+		var expect map[string]interface{}
+		expectErr := json.Unmarshal([]byte(header), &expect)
+
+		token, _, err := new(jwt.Parser).ParseUnverified(jwt.EncodeSegment([]byte(header))+"."+claims+".sig", jwt.MapClaims{})
+		if expectErr != nil {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors&jwt.ValidationErrorMalformed == 0 {
+				t.Errorf("[%v] Expected a malformed token.  Got %v", header, err)
+			}
+			continue
+		}
+		if token == nil || !reflect.DeepEqual(token.Header, expect) {
+			t.Errorf("[%v] Expected header %v.  Got %v", header, expect, token)
+		}
+	}
+}
+
+// Claims checking their own time, which TimeFunc is given to
+type timedClaims struct {
+	jwt.StandardClaims
+	at *time.Time
+}
+
+func (c *timedClaims) ValidAt(now time.Time) error {
+	*c.at = now
+	return nil
+}
+
+// Claims overriding the Valid of StandardClaims, which TimeFunc must not
+// bypass
+type strictClaims struct {
+	jwt.StandardClaims
+}
+
+func (c strictClaims) Valid() error {
+	if c.Subject == "" {
+		return fmt.Errorf("sub is required")
+	}
+	return c.StandardClaims.Valid()
+}
+
+// Types embedding StandardClaims don't get a ValidAt that would skip their
+// own Valid
+func TestStandardClaims_notTimed(t *testing.T) {
+	if _, ok := interface{}(strictClaims{}).(jwt.TimedClaims); ok {
+		t.Errorf("Expected strictClaims not to be TimedClaims")
+	}
+}
+
+func TestParser_TimeFunc(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	past := time.Unix(1000, 0)
+	sign := func(c jwt.Claims) string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	parser := &jwt.Parser{TimeFunc: func() time.Time { return past }}
+
+	var tests = []struct {
+		name   string
+		claims jwt.Claims
+		parse  jwt.Claims
+		valid  bool
+	}{
+		{"MapClaims valid then", jwt.MapClaims{"exp": 1500, "nbf": 500}, jwt.MapClaims{}, true},
+		{"MapClaims expired then", jwt.MapClaims{"exp": 900}, jwt.MapClaims{}, false},
+		{"MapClaims not valid yet then", jwt.MapClaims{"nbf": time.Now().Unix()}, jwt.MapClaims{}, false},
+		{"StandardClaims valid then", jwt.StandardClaims{ExpiresAt: 1500}, &jwt.StandardClaims{}, true},
+		{"StandardClaims issued after then", jwt.StandardClaims{IssuedAt: 1001}, &jwt.StandardClaims{}, false},
+		{"own Valid kept", strictClaims{jwt.StandardClaims{ExpiresAt: 1500}}, &strictClaims{}, false},
+		{"SessionClaims valid then", &jwt.SessionClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: 1500}}, &jwt.SessionClaims{}, true},
+	}
+	for _, data := range tests {
+		_, err := parser.ParseWithClaims(sign(data.claims), data.parse, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Expected valid.  Got %v", data.name, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("[%v] Expected an error", data.name)
+		}
+	}
+
+	var at time.Time
+	if _, err := parser.ParseWithClaims(sign(jwt.StandardClaims{}), &timedClaims{at: &at}, keyFunc); err != nil || !at.Equal(past) {
+		t.Errorf("Expected ValidAt to be given %v.  Got %v, %v", past, at, err)
+	}
+}
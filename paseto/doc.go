@@ -0,0 +1,18 @@
+// Package paseto issues and verifies PASETO version 4 tokens, an
+// alternative to JOSE with no algorithm negotiation: v4.public tokens are
+// always signed with Ed25519, and v4.local tokens always encrypted with
+// XChaCha20 and authenticated with keyed BLAKE2b.
+//
+// Claims are the same types used with package jwt, so code working on
+// them does not change.  They are converted through their JSON form, with
+// exp, nbf and iat written as RFC 3339 strings, and validated with their
+// Valid method:
+//
+//	token, err := paseto.Sign(claims, privateKey, nil, nil)
+//	token, err := paseto.Encrypt(claims, sharedKey, []byte(`{"kid":"k1"}`), nil)
+//	t, err := paseto.Parse(token, &MyClaims{}, keyFunc)
+//
+// Set Parser.Purposes to accept only one purpose.  The key returned by the
+// Keyfunc must suit the token's purpose, so a public key can never be used
+// as a shared secret.
+package paseto
@@ -0,0 +1,345 @@
+package paseto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Errors
+var (
+	ErrMalformed      = errors.New("paseto: token is malformed")
+	ErrUnsupported    = errors.New("paseto: version or purpose is not supported")
+	ErrPurposeRefused = errors.New("paseto: purpose is not allowed")
+	ErrInvalidKey     = errors.New("paseto: key is invalid for the purpose")
+	ErrVerification   = errors.New("paseto: signature or authentication tag is invalid")
+	ErrNoKeyfunc      = errors.New("paseto: no Keyfunc was provided")
+	ErrTooLarge       = errors.New("paseto: token exceeds the size limit")
+)
+
+// Token purposes of version 4
+const (
+	Public = "public" // Signed with Ed25519
+	Local  = "local"  // Encrypted with XChaCha20 and authenticated with BLAKE2b
+)
+
+const version = "v4"
+
+// Key and nonce sizes of v4.local
+const (
+	KeySize   = 32
+	nonceSize = 32
+	macSize   = 32
+)
+
+// A PASETO.  Fields are populated when you Parse a token.
+type Token struct {
+	Version string
+	Purpose string // Public or Local
+	Footer  []byte // Authenticated but not encrypted, e.g. a key id
+	Claims  jwt.Claims
+	Valid   bool
+}
+
+// Supplies the key for verifying or decrypting, given the token before its
+// claims are decoded.  Like jwt.Keyfunc, it can use the Footer to choose.
+// A v4.public token needs an ed25519.PublicKey, a v4.local one a []byte of
+// KeySize bytes.
+type Keyfunc func(*Token) (interface{}, error)
+
+// Sign claims as a v4.public token.  footer, if not empty, is appended in
+// the clear; implicit is an assertion that is signed but not sent, and the
+// verifying Parser must be given the same one.
+func Sign(claims jwt.Claims, key ed25519.PrivateKey, footer, implicit []byte) (string, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return "", ErrInvalidKey
+	}
+	m, err := encodeClaims(claims)
+	if err != nil {
+		return "", err
+	}
+
+	h := header(Public)
+	sig := ed25519.Sign(key, pae([]byte(h), m, footer, implicit))
+	return h + encode(append(m, sig...)) + encodeFooter(footer), nil
+}
+
+// Encrypt claims as a v4.local token with the shared key of KeySize bytes.
+// footer and implicit are as for Sign.
+func Encrypt(claims jwt.Claims, key, footer, implicit []byte) (string, error) {
+	m, err := encodeClaims(claims)
+	if err != nil {
+		return "", err
+	}
+	n := make([]byte, nonceSize)
+	if _, err = rand.Read(n); err != nil {
+		return "", err
+	}
+	return encrypt(m, key, n, footer, implicit)
+}
+
+func encrypt(m, key, n, footer, implicit []byte) (string, error) {
+	if len(key) != KeySize {
+		return "", ErrInvalidKey
+	}
+	h := header(Local)
+	ek, n2, ak := splitKey(key, n)
+	c := xchacha20(ek, n2, m)
+	t := blake2b(pae([]byte(h), n, c, footer, implicit), ak, macSize)
+
+	payload := append(append(append([]byte{}, n...), c...), t...)
+	return h + encode(payload) + encodeFooter(footer), nil
+}
+
+// Parses tokens.  The zero value accepts both purposes.
+type Parser struct {
+	Purposes             []string // If populated, only these purposes are accepted
+	Implicit             []byte   // The implicit assertion the tokens were made with
+	MaxLength            int      // Longer tokens are rejected.  Defaults to jwt.DefaultMaxTokenLength, negative for no limit
+	SkipClaimsValidation bool     // Skip calling Valid on the claims
+}
+
+// Parse and verify or decrypt a v4 token, decoding its claims into claims
+func Parse(tokenString string, claims jwt.Claims, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).Parse(tokenString, claims, keyFunc)
+}
+
+func (p *Parser) Parse(tokenString string, claims jwt.Claims, keyFunc Keyfunc) (*Token, error) {
+	if max := p.maxLength(); max >= 0 && len(tokenString) > max {
+		return nil, ErrTooLarge
+	}
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, ErrMalformed
+	}
+	if parts[0] != version || (parts[1] != Public && parts[1] != Local) {
+		return nil, ErrUnsupported
+	}
+
+	token := &Token{Version: parts[0], Purpose: parts[1], Claims: claims}
+	payload, err := decode(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 4 {
+		if token.Footer, err = decode(parts[3]); err != nil || len(token.Footer) == 0 {
+			return nil, ErrMalformed
+		}
+	}
+	if p.Purposes != nil && !contains(p.Purposes, token.Purpose) {
+		return token, ErrPurposeRefused
+	}
+
+	if keyFunc == nil {
+		return token, ErrNoKeyfunc
+	}
+	key, err := keyFunc(token)
+	if err != nil {
+		return token, err
+	}
+
+	var m []byte
+	if token.Purpose == Public {
+		m, err = verify(payload, key, token.Footer, p.Implicit)
+	} else {
+		m, err = decrypt(payload, key, token.Footer, p.Implicit)
+	}
+	if err != nil {
+		return token, err
+	}
+
+	if err = decodeClaims(m, claims); err != nil {
+		return token, err
+	}
+	if !p.SkipClaimsValidation {
+		if err = claims.Valid(); err != nil {
+			return token, err
+		}
+	}
+	token.Valid = true
+	return token, nil
+}
+
+func (p *Parser) maxLength() int {
+	if p.MaxLength == 0 {
+		return jwt.DefaultMaxTokenLength
+	}
+	return p.MaxLength
+}
+
+func verify(payload []byte, key interface{}, footer, implicit []byte) ([]byte, error) {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok || len(pub) != ed25519.PublicKeySize {
+		return nil, ErrInvalidKey
+	}
+	if len(payload) < ed25519.SignatureSize {
+		return nil, ErrMalformed
+	}
+	split := len(payload) - ed25519.SignatureSize
+	m, sig := payload[:split], payload[split:]
+	if !ed25519.Verify(pub, pae([]byte(header(Public)), m, footer, implicit), sig) {
+		return nil, ErrVerification
+	}
+	return m, nil
+}
+
+func decrypt(payload []byte, key interface{}, footer, implicit []byte) ([]byte, error) {
+	k, ok := key.([]byte)
+	if !ok || len(k) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	if len(payload) < nonceSize+macSize {
+		return nil, ErrMalformed
+	}
+	n := payload[:nonceSize]
+	c := payload[nonceSize : len(payload)-macSize]
+	t := payload[len(payload)-macSize:]
+
+	ek, n2, ak := splitKey(k, n)
+	expected := blake2b(pae([]byte(header(Local)), n, c, footer, implicit), ak, macSize)
+	if subtle.ConstantTimeCompare(t, expected) != 1 {
+		return nil, ErrVerification
+	}
+	return xchacha20(ek, n2, c), nil
+}
+
+// Derive the encryption key, its nonce and the authentication key from
+// key and the token's nonce
+func splitKey(key, n []byte) (ek, n2, ak []byte) {
+	tmp := blake2b(append([]byte("paseto-encryption-key"), n...), key, 56)
+	ak = blake2b(append([]byte("paseto-auth-key-for-aead"), n...), key, 32)
+	return tmp[:32], tmp[32:], ak
+}
+
+// ----- helpers
+
+func header(purpose string) string {
+	return version + "." + purpose + "."
+}
+
+// Pre-authentication encoding, which ties the pieces together so that
+// none can be moved into another
+func pae(pieces ...[]byte) []byte {
+	var buf bytes.Buffer
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(pieces)))
+	buf.Write(n[:])
+	for _, p := range pieces {
+		binary.LittleEndian.PutUint64(n[:], uint64(len(p)))
+		buf.Write(n[:])
+		buf.Write(p)
+	}
+	return buf.Bytes()
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func encodeFooter(footer []byte) string {
+	if len(footer) == 0 {
+		return ""
+	}
+	return "." + encode(footer)
+}
+
+func decode(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.Strict().DecodeString(s)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return b, nil
+}
+
+func contains(list []string, name string) bool {
+	for _, l := range list {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ----- claims
+
+// PASETO times are RFC 3339 strings where JWT has NumericDates
+var timeClaims = []string{"exp", "nbf", "iat"}
+
+// Encode claims as JSON, with their times as strings
+func encodeClaims(claims jwt.Claims) ([]byte, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err = dec.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	for _, name := range timeClaims {
+		n, ok := m[name].(json.Number)
+		if !ok {
+			continue
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, err
+		}
+		sec := int64(f)
+		nsec := int64((f - float64(sec)) * 1e9)
+		m[name] = time.Unix(sec, nsec).UTC().Format(time.RFC3339Nano)
+	}
+	return json.Marshal(m)
+}
+
+// Decode the JSON payload into claims, with its times as NumericDates
+func decodeClaims(data []byte, claims jwt.Claims) error {
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil || m == nil {
+		return ErrMalformed
+	}
+
+	for _, name := range timeClaims {
+		v, ok := m[name]
+		if !ok {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return ErrMalformed
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return ErrMalformed
+		}
+		m[name] = t.Unix()
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	// Special case for map type to avoid weird pointer behavior, as in jwt
+	if c, ok := claims.(jwt.MapClaims); ok {
+		err = json.Unmarshal(data, &c)
+	} else {
+		err = json.Unmarshal(data, claims)
+	}
+	if err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package paseto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func staticKey(key interface{}) Keyfunc {
+	return func(*Token) (interface{}, error) { return key, nil }
+}
+
+func TestSignAndEncrypt(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	shared := make([]byte, KeySize)
+	rand.Read(shared)
+
+	claims := &jwt.StandardClaims{
+		Issuer:    "gateway",
+		Subject:   "alice",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		IssuedAt:  time.Now().Unix(),
+	}
+	footer := []byte(`{"kid":"k1"}`)
+
+	var pasetoTestData = []struct {
+		name    string
+		make    func() (string, error)
+		key     interface{}
+		wrong   interface{}
+		purpose string
+	}{
+		{"public", func() (string, error) { return Sign(claims, priv, footer, nil) }, pub, shared, Public},
+		{"local", func() (string, error) { return Encrypt(claims, shared, footer, nil) }, shared, pub, Local},
+	}
+
+	for _, data := range pasetoTestData {
+		s, err := data.make()
+		if err != nil {
+			t.Errorf("[%v] Error creating token: %v", data.name, err)
+			continue
+		}
+		if !strings.HasPrefix(s, "v4."+data.purpose+".") {
+			t.Errorf("[%v] Unexpected token %v", data.name, s)
+		}
+		if data.purpose == Local && strings.Contains(s, "alice") {
+			t.Errorf("[%v] Claims are not encrypted", data.name)
+		}
+
+		got := &jwt.StandardClaims{}
+		token, err := Parse(s, got, staticKey(data.key))
+		if err != nil || !token.Valid || token.Purpose != data.purpose || string(token.Footer) != string(footer) {
+			t.Errorf("[%v] Error parsing: %v", data.name, err)
+			continue
+		}
+		if *got != *claims {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, claims, got)
+		}
+
+		if _, err = Parse(s, &jwt.StandardClaims{}, staticKey(data.wrong)); err != ErrInvalidKey {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, ErrInvalidKey, err)
+		}
+		p := &Parser{Implicit: []byte("tenant-1")}
+		if _, err = p.Parse(s, &jwt.StandardClaims{}, staticKey(data.key)); err != ErrVerification {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, ErrVerification, err)
+		}
+		other := []string{Local}
+		if data.purpose == Local {
+			other = []string{Public}
+		}
+		p = &Parser{Purposes: other}
+		if _, err = p.Parse(s, &jwt.StandardClaims{}, staticKey(data.key)); err != ErrPurposeRefused {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, ErrPurposeRefused, err)
+		}
+
+		// The footer is authenticated
+		tampered := s[:strings.LastIndex(s, ".")+1] + encode([]byte(`{"kid":"k2"}`))
+		if _, err = Parse(tampered, &jwt.StandardClaims{}, staticKey(data.key)); err != ErrVerification {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, ErrVerification, err)
+		}
+	}
+}
+
+func TestParse_implicit(t *testing.T) {
+	shared := make([]byte, KeySize)
+	s, _ := Encrypt(jwt.MapClaims{"sub": "alice"}, shared, nil, []byte("tenant-1"))
+	p := &Parser{Implicit: []byte("tenant-1")}
+	if _, err := p.Parse(s, jwt.MapClaims{}, staticKey(shared)); err != nil {
+		t.Errorf("Error parsing: %v", err)
+	}
+	if _, err := Parse(s, jwt.MapClaims{}, staticKey(shared)); err != ErrVerification {
+		t.Errorf("Expected %v.  Got %v", ErrVerification, err)
+	}
+}
+
+func TestParse_expired(t *testing.T) {
+	shared := make([]byte, KeySize)
+	claims := jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}
+	s, _ := Encrypt(claims, shared, nil, nil)
+
+	_, err := Parse(s, jwt.MapClaims{}, staticKey(shared))
+	if vErr, ok := err.(*jwt.ValidationError); !ok || vErr.Errors&jwt.ValidationErrorExpired == 0 {
+		t.Errorf("Expected an expired error.  Got %v", err)
+	}
+	if _, err = (&Parser{SkipClaimsValidation: true}).Parse(s, jwt.MapClaims{}, staticKey(shared)); err != nil {
+		t.Errorf("Error parsing: %v", err)
+	}
+}
+
+func TestParse_malformed(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"v4.public",
+		"v4.public.AAAA",
+		"v4.local.AAAA",
+		"v4.local.AAAA.",
+		"v4.public.a.b.c",
+	} {
+		if _, err := Parse(s, jwt.MapClaims{}, staticKey(nil)); err != ErrMalformed && err != ErrInvalidKey {
+			t.Errorf("[%q] Expected %v.  Got %v", s, ErrMalformed, err)
+		}
+	}
+	for _, s := range []string{"v3.public.AAAA", "v4.secret.AAAA", "v2.local.AAAA"} {
+		if _, err := Parse(s, jwt.MapClaims{}, staticKey(nil)); err != ErrUnsupported {
+			t.Errorf("[%q] Expected %v.  Got %v", s, ErrUnsupported, err)
+		}
+	}
+}
+
+func TestParse_vector(t *testing.T) {
+	// Test vector 4-S-1 of the PASETO specification
+	key := ed25519.PrivateKey(mustHex("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2"))
+	s := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	claims := jwt.MapClaims{}
+	p := &Parser{SkipClaimsValidation: true}
+	if _, err := p.Parse(s, claims, staticKey(key.Public())); err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	if claims["data"] != "this is a signed message" || claims["exp"] != float64(1640995200) {
+		t.Errorf("Unexpected claims %v", claims)
+	}
+}
+
+// Test vectors 4-E-1 to 4-E-9 of the PASETO specification
+var localVectors = []struct {
+	name     string
+	nonce    string
+	data     string
+	footer   string
+	implicit string
+	token    string
+}{
+	{"4-E-1", "0000000000000000000000000000000000000000000000000000000000000000", "this is a secret message", "", "",
+		"v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg"},
+	{"4-E-2", "0000000000000000000000000000000000000000000000000000000000000000", "this is a hidden message", "", "",
+		"v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvS2csCgglvpk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XIemu9chy3WVKvRBfg6t8wwYHK0ArLxxfZP73W_vfwt5A"},
+	{"4-E-3", "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8", "this is a secret message", "", "",
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6-tyebyWG6Ov7kKvBdkrrAJ837lKP3iDag2hzUPHuMKA"},
+	{"4-E-4", "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8", "this is a hidden message", "", "",
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4gt6TiLm55vIH8c_lGxxZpE3AWlH4WTR0v45nsWoU3gQ"},
+	{"4-E-5", "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8", "this is a secret message", `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`, "",
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4x-RMNXtQNbz7FvFZ_G-lFpk5RG3EOrwDL6CgDqcerSQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9"},
+	{"4-E-6", "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8", "this is a hidden message", `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`, "",
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6pWSA5HX2wjb3P-xLQg5K5feUCX4P2fpVK3ZLWFbMSxQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9"},
+	{"4-E-7", "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8", "this is a secret message", `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`, `{"test-vector":"4-E-7"}`,
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t40KCCWLA7GYL9KFHzKlwY9_RnIfRrMQpueydLEAZGGcA.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9"},
+	{"4-E-8", "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8", "this is a hidden message", `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`, `{"test-vector":"4-E-8"}`,
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t5uvqQbMGlLLNYBc7A6_x7oqnpUK5WLvj24eE4DVPDZjw.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9"},
+	{"4-E-9", "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8", "this is a hidden message", "arbitrary-string-that-isn't-json", `{"test-vector":"4-E-9"}`,
+		"v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6tybdlmnMwcDMw0YxA_gFSE_IUWl78aMtOepFYSWYfQA.YXJiaXRyYXJ5LXN0cmluZy10aGF0LWlzbid0LWpzb24"},
+}
+
+func TestParse_localVectors(t *testing.T) {
+	key := mustHex("707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f")
+	for _, data := range localVectors {
+		m := []byte(`{"data":"` + data.data + `","exp":"2022-01-01T00:00:00+00:00"}`)
+		s, err := encrypt(m, key, mustHex(data.nonce), []byte(data.footer), []byte(data.implicit))
+		if err != nil || s != data.token {
+			t.Errorf("[%v] Expected %v.  Got %v, %v", data.name, data.token, s, err)
+		}
+
+		claims := jwt.MapClaims{}
+		p := &Parser{Implicit: []byte(data.implicit), SkipClaimsValidation: true}
+		token, err := p.Parse(data.token, claims, staticKey(key))
+		if err != nil {
+			t.Errorf("[%v] Error parsing: %v", data.name, err)
+			continue
+		}
+		if claims["data"] != data.data || claims["exp"] != float64(1640995200) {
+			t.Errorf("[%v] Unexpected claims %v", data.name, claims)
+		}
+		if string(token.Footer) != data.footer {
+			t.Errorf("[%v] Expected footer %q.  Got %q", data.name, data.footer, token.Footer)
+		}
+
+		// The implicit assertion is authenticated, though not sent
+		if data.implicit != "" {
+			if _, err = Parse(data.token, jwt.MapClaims{}, staticKey(key)); err != ErrVerification {
+				t.Errorf("[%v] Expected %v without the implicit assertion.  Got %v", data.name, ErrVerification, err)
+			}
+		}
+	}
+}
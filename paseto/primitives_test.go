@@ -0,0 +1,48 @@
+package paseto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func count(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+func TestBlake2b(t *testing.T) {
+	// RFC 7693 appendix A
+	if got := blake2b([]byte("abc"), nil, 64); !bytes.Equal(got, mustHex("ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923")) {
+		t.Errorf("Unexpected BLAKE2b-512 of abc: %x", got)
+	}
+	// First keyed vector of the reference implementation
+	if got := blake2b(nil, count(64), 64); !bytes.Equal(got, mustHex("10ebb67700b1868efb4417987acf4690ae9d972fb7a590c2f02871799aaa4786b5e996e8f0f4eb981fc214b005f42d2ff4233499391653df7aefcbc13fc51568")) {
+		t.Errorf("Unexpected keyed BLAKE2b-512 of the empty message: %x", got)
+	}
+}
+
+func TestChaCha20(t *testing.T) {
+	// RFC 8439 section 2.4.2
+	plaintext := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	want := mustHex("6e2e359a2568f98041ba0728dd0d6981e97e7aec1d4360c20a27afccfd9fae0bf91b65c5524733ab8f593dabcd62b3571639d624e65152ab8f530c359f0861d807ca0dbf500d6a6156a38e088a22b65e52bc514d16ccf806818ce91ab77937365af90bbf74a35be6b40b8eedf2785e42874d")
+	if got := chacha20(count(32), mustHex("000000000000004a00000000"), 1, plaintext); !bytes.Equal(got, want) {
+		t.Errorf("Unexpected ciphertext: %x", got)
+	}
+
+	// draft-irtf-cfrg-xchacha section 2.2.1
+	if got := hchacha20(count(32), mustHex("000000090000004a0000000031415927")); !bytes.Equal(got, mustHex("82413b4227b27bfed30e42508a877d73a0f9e4d58a74a853c12ec41326d3ecdc")) {
+		t.Errorf("Unexpected HChaCha20 subkey: %x", got)
+	}
+}
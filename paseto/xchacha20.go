@@ -0,0 +1,93 @@
+package paseto
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// XChaCha20, draft-irtf-cfrg-xchacha, which v4.local encrypts with.  The
+// standard library has no implementation.
+
+var chachaConstants = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+func chachaRounds(s *[16]uint32) {
+	qr := func(a, b, c, d int) {
+		s[a] += s[b]
+		s[d] = bits.RotateLeft32(s[d]^s[a], 16)
+		s[c] += s[d]
+		s[b] = bits.RotateLeft32(s[b]^s[c], 12)
+		s[a] += s[b]
+		s[d] = bits.RotateLeft32(s[d]^s[a], 8)
+		s[c] += s[d]
+		s[b] = bits.RotateLeft32(s[b]^s[c], 7)
+	}
+	for i := 0; i < 10; i++ {
+		qr(0, 4, 8, 12)
+		qr(1, 5, 9, 13)
+		qr(2, 6, 10, 14)
+		qr(3, 7, 11, 15)
+		qr(0, 5, 10, 15)
+		qr(1, 6, 11, 12)
+		qr(2, 7, 8, 13)
+		qr(3, 4, 9, 14)
+	}
+}
+
+func chachaState(key []byte, words [4]uint32) [16]uint32 {
+	var s [16]uint32
+	copy(s[:4], chachaConstants[:])
+	for i := 0; i < 8; i++ {
+		s[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	copy(s[12:], words[:])
+	return s
+}
+
+// Derive a subkey from key and a 16 byte nonce
+func hchacha20(key, nonce []byte) []byte {
+	var words [4]uint32
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	s := chachaState(key, words)
+	chachaRounds(&s)
+
+	out := make([]byte, 32)
+	for i, w := range append(s[:4:4], s[12:]...) {
+		binary.LittleEndian.PutUint32(out[4*i:], w)
+	}
+	return out
+}
+
+// ChaCha20 of RFC 8439: XOR src with the key stream for key, a 12 byte
+// nonce and the initial block counter
+func chacha20(key, nonce []byte, counter uint32, src []byte) []byte {
+	words := [4]uint32{counter}
+	for i := 0; i < 3; i++ {
+		words[1+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+
+	dst := make([]byte, len(src))
+	var block [64]byte
+	for off := 0; off < len(src); off += 64 {
+		s := chachaState(key, words)
+		x := s
+		chachaRounds(&x)
+		for i := range x {
+			binary.LittleEndian.PutUint32(block[4*i:], x[i]+s[i])
+		}
+		for i := off; i < len(src) && i < off+64; i++ {
+			dst[i] = src[i] ^ block[i-off]
+		}
+		words[0]++
+	}
+	return dst
+}
+
+// XOR src with the XChaCha20 key stream for key and a 24 byte nonce
+func xchacha20(key, nonce, src []byte) []byte {
+	subkey := hchacha20(key, nonce[:16])
+	var n [12]byte
+	copy(n[4:], nonce[16:])
+	return chacha20(subkey, n[:], 0, src)
+}
@@ -0,0 +1,55 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/subtle"
+)
+
+var (
+	ErrKeyNotPinned = newError(CodeKeyNotPinned, "verification key does not match any pinned thumbprint")
+)
+
+// Wrap keyFunc so that only keys whose RFC 7638 SHA-256 thumbprint, base64url
+// encoded, is listed in thumbprints are accepted.  Whatever the wrapped
+// Keyfunc returns, for example a key freshly fetched from a JWKS endpoint,
+// is rejected with ErrKeyNotPinned unless it is pinned.  This keeps an
+// attacker who controls the key publication endpoint from introducing keys.
+//
+// Thumbprints can be obtained from JSONWebKey.Thumbprint, or from the kid of
+// keys made by GenerateJWK.
+func PinnedKeyfunc(keyFunc Keyfunc, thumbprints ...string) Keyfunc {
+	pins := make([][]byte, 0, len(thumbprints))
+	for _, t := range thumbprints {
+		if b, err := DecodeSegment(t); err == nil {
+			pins = append(pins, b)
+		}
+	}
+
+	return func(token *Token) (interface{}, error) {
+		key, err := keyFunc(token)
+		if err != nil {
+			return nil, err
+		}
+		if !keyIsPinned(key, pins) {
+			return nil, ErrKeyNotPinned
+		}
+		return key, nil
+	}
+}
+
+func keyIsPinned(key interface{}, pins [][]byte) bool {
+	jwk, err := NewJSONWebKey(key)
+	if err != nil {
+		return false
+	}
+	thumb, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return false
+	}
+	for _, pin := range pins {
+		if subtle.ConstantTimeCompare(thumb, pin) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package jwt_test
+
+import (
+	"crypto"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestPinnedKeyfunc(t *testing.T) {
+	priv := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	pub := test.LoadRSAPublicKeyFromDisk("test/sample_key.pub")
+	s := test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, priv)
+
+	jwk, _ := jwt.NewJSONWebKey(pub)
+	thumb, _ := jwk.Thumbprint(crypto.SHA256)
+	pinned := jwt.EncodeSegment(thumb)
+
+	other, _, _ := jwt.GenerateJWK("RS256")
+
+	keyfunc := func(*jwt.Token) (interface{}, error) { return pub, nil }
+
+	if _, err := jwt.Parse(s, jwt.PinnedKeyfunc(keyfunc, other.Kid, pinned)); err != nil {
+		t.Errorf("Error verifying with pinned key: %v", err)
+	}
+
+	_, err := jwt.Parse(s, jwt.PinnedKeyfunc(keyfunc, other.Kid))
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrKeyNotPinned {
+		t.Errorf("Expected ErrKeyNotPinned.  Got %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+// Package policy decides whether verified claims may do something, from
+// small rules over scopes, roles, audience and other claims, combined with
+// All, Any and Not.  It covers the simple authorization most services
+// need without a policy engine:
+//
+//	rule := policy.All(policy.Scope("orders:write"), policy.Any(policy.Role("admin"), policy.Claim("tenant", "acme")))
+//	m := &request.Middleware{Keyfunc: keyFunc, Authorize: policy.Authorize(rule)}
+//
+// Rules can also be loaded from configuration with Parse.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Errors
+var (
+	ErrDenied = errors.New("policy: claims do not satisfy the policy")
+)
+
+// Claim holding roles, for Role
+const RolesClaim = "roles"
+
+// Decides on a set of claims
+type Rule func(claims jwt.MapClaims) bool
+
+// Whether claims satisfy r.  Claims other than MapClaims are converted
+// through JSON; claims that can't be are denied.
+func (r Rule) Allow(claims jwt.Claims) bool {
+	m, ok := claims.(jwt.MapClaims)
+	if !ok {
+		data, err := json.Marshal(claims)
+		if err != nil || json.Unmarshal(data, &m) != nil {
+			return false
+		}
+	}
+	return r(m)
+}
+
+// A check for request.Middleware.Authorize, failing with ErrDenied
+func Authorize(r Rule) func(*jwt.Token) error {
+	return func(token *jwt.Token) error {
+		if !r.Allow(token.Claims) {
+			return ErrDenied
+		}
+		return nil
+	}
+}
+
+// Allows claims that every rule allows.  With no rules, that is every
+// claims, so Parse refuses an empty all.
+func All(rules ...Rule) Rule {
+	return func(claims jwt.MapClaims) bool {
+		for _, r := range rules {
+			if !r(claims) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Allows claims that any rule allows.  With no rules, none are allowed.
+func Any(rules ...Rule) Rule {
+	return func(claims jwt.MapClaims) bool {
+		for _, r := range rules {
+			if r(claims) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Allows claims r denies
+func Not(r Rule) Rule {
+	return func(claims jwt.MapClaims) bool {
+		return !r(claims)
+	}
+}
+
+// Requires all of scopes, from the space separated scope claim of RFC 8693
+// section 4.2 or an scp array.  With no scopes, every claims are allowed,
+// so Parse refuses an empty scope.
+func Scope(scopes ...string) Rule {
+	return func(claims jwt.MapClaims) bool {
+		granted := strings.Fields(stringClaim(claims["scope"]))
+		granted = append(granted, strings.Fields(strings.Join(claimStrings(claims["scp"]), " "))...)
+		for _, s := range scopes {
+			if !contains(granted, s) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Requires any of roles in the roles claim.  With no roles, the claim only
+// has to be present, as for Claim.
+func Role(roles ...string) Rule {
+	return Claim(RolesClaim, roles...)
+}
+
+// Requires aud to contain audience
+func Audience(audience string) Rule {
+	return func(claims jwt.MapClaims) bool {
+		return contains(claims.GetAudience(), audience)
+	}
+}
+
+// Requires iss to be issuer
+func Issuer(issuer string) Rule {
+	return func(claims jwt.MapClaims) bool {
+		return claims.GetIssuer() == issuer
+	}
+}
+
+// Requires claim name to be, or for an array to contain, any of values.
+// Numbers and booleans compare by their JSON text.  With no values, the
+// claim only has to be present.
+func Claim(name string, values ...string) Rule {
+	return func(claims jwt.MapClaims) bool {
+		v, ok := claims[name]
+		if !ok {
+			return false
+		}
+		if len(values) == 0 {
+			return true
+		}
+		have := claimStrings(v)
+		for _, want := range values {
+			if contains(have, want) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Build a rule from its JSON form.  Each object has exactly one member:
+//
+//	{"all": [rule, ...]}           {"any": [rule, ...]}        {"not": rule}
+//	{"scope": "a b"}               {"role": ["admin", "ops"]}  {"audience": "api"}
+//	{"issuer": "https://idp"}      {"claim": {"tenant": ["acme"]}}
+//	{"present": ["mfa", ...]}
+//
+// Lists, strings and objects must not be empty, nor null: a rule with
+// nothing to check would allow every claims, which a typo in a policy
+// shouldn't do.  Claims that only have to be present are named with
+// present.
+func Parse(data []byte) (Rule, error) {
+	var spec map[string]json.RawMessage
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	if len(spec) != 1 {
+		return nil, fmt.Errorf("policy: rule must have exactly one member, got %d", len(spec))
+	}
+
+	var op string
+	var arg json.RawMessage
+	for op, arg = range spec {
+	}
+
+	switch op {
+	case "all", "any":
+		var subs []json.RawMessage
+		if err := json.Unmarshal(arg, &subs); err != nil || len(subs) == 0 {
+			return nil, fmt.Errorf("policy: %v takes a list of rules", op)
+		}
+		rules := make([]Rule, len(subs))
+		for i, sub := range subs {
+			var err error
+			if rules[i], err = Parse(sub); err != nil {
+				return nil, err
+			}
+		}
+		if op == "all" {
+			return All(rules...), nil
+		}
+		return Any(rules...), nil
+	case "not":
+		r, err := Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		return Not(r), nil
+	case "scope":
+		list, err := parseStrings(op, arg)
+		if err != nil {
+			return nil, err
+		}
+		scopes := strings.Fields(strings.Join(list, " "))
+		if len(scopes) == 0 {
+			return nil, errors.New("policy: scope takes at least one scope")
+		}
+		return Scope(scopes...), nil
+	case "role":
+		list, err := parseStrings(op, arg)
+		if err != nil {
+			return nil, err
+		}
+		return Role(list...), nil
+	case "audience", "issuer":
+		var s string
+		if err := json.Unmarshal(arg, &s); err != nil || s == "" {
+			return nil, fmt.Errorf("policy: %v takes a string", op)
+		}
+		if op == "audience" {
+			return Audience(s), nil
+		}
+		return Issuer(s), nil
+	case "claim":
+		var claims map[string]json.RawMessage
+		if err := json.Unmarshal(arg, &claims); err != nil || len(claims) == 0 {
+			return nil, errors.New("policy: claim takes an object of claim names")
+		}
+		var rules []Rule
+		for name, values := range claims {
+			list, err := parseStrings(op, values)
+			if err != nil {
+				return nil, err
+			}
+			if name == "" {
+				return nil, errors.New("policy: claim names must not be empty")
+			}
+			rules = append(rules, Claim(name, list...))
+		}
+		return All(rules...), nil
+	case "present":
+		names, err := parseStrings(op, arg)
+		if err != nil {
+			return nil, err
+		}
+		rules := make([]Rule, len(names))
+		for i, name := range names {
+			rules[i] = Claim(name)
+		}
+		return All(rules...), nil
+	default:
+		return nil, fmt.Errorf("policy: unknown rule %q", op)
+	}
+}
+
+// A string or a non-empty list of strings, none of them empty
+func parseStrings(op string, arg json.RawMessage) ([]string, error) {
+	var list jwt.ClaimStrings
+	if err := json.Unmarshal(arg, &list); err != nil || len(list) == 0 || contains(list, "") {
+		return nil, fmt.Errorf("policy: %v takes a string or a list of strings, none of them empty", op)
+	}
+	return list, nil
+}
+
+func stringClaim(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// The values of a claim as strings: the elements of an array, or the
+// value itself
+func claimStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, e := range v {
+			list = append(list, claimStrings(e)...)
+		}
+		return list
+	case []string:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return []string{string(data)}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var policyTestClaims = jwt.MapClaims{
+	"iss":    "https://idp.example.com",
+	"aud":    []interface{}{"api", "web"},
+	"scope":  "orders:read orders:write",
+	"roles":  []interface{}{"ops"},
+	"tenant": "acme",
+	"mfa":    true,
+}
+
+var policyTestData = []struct {
+	name    string
+	rule    Rule
+	allowed bool
+}{
+	{"scope", Scope("orders:read"), true},
+	{"scopes", Scope("orders:read", "orders:write"), true},
+	{"missing scope", Scope("orders:read", "admin"), false},
+	{"role", Role("admin", "ops"), true},
+	{"missing role", Role("admin"), false},
+	{"audience", Audience("web"), true},
+	{"issuer", Issuer("https://other.example.com"), false},
+	{"claim", Claim("tenant", "acme"), true},
+	{"bool claim", Claim("mfa", "true"), true},
+	{"claim present", Claim("mfa"), true},
+	{"claim absent", Claim("org"), false},
+	{"all", All(Scope("orders:write"), Any(Role("admin"), Claim("tenant", "acme"))), true},
+	{"any", Any(Role("admin"), Claim("tenant", "other")), false},
+	{"not", Not(Role("banned")), true},
+}
+
+func TestRules(t *testing.T) {
+	for _, data := range policyTestData {
+		if got := data.rule.Allow(policyTestClaims); got != data.allowed {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.allowed, got)
+		}
+	}
+
+	// scp arrays and struct claims
+	if !Scope("read").Allow(jwt.MapClaims{"scp": []interface{}{"read", "write"}}) {
+		t.Errorf("Expected scp to be honored")
+	}
+	if !Issuer("idp").Allow(&jwt.StandardClaims{Issuer: "idp"}) {
+		t.Errorf("Expected struct claims to be converted")
+	}
+}
+
+var parseTestData = []struct {
+	name    string
+	spec    string
+	allowed bool
+	err     bool
+}{
+	{"scope", `{"scope": "orders:read orders:write"}`, true, false},
+	{"nested", `{"all": [{"audience": "api"}, {"any": [{"role": ["admin"]}, {"claim": {"tenant": "acme"}}]}]}`, true, false},
+	{"not", `{"not": {"present": "mfa"}}`, false, false},
+	{"present", `{"present": ["tenant", "roles"]}`, true, false},
+	{"unknown", `{"group": "x"}`, false, true},
+	{"two members", `{"scope": "a", "role": "b"}`, false, true},
+	{"bad argument", `{"all": {"scope": "a"}}`, false, true},
+	{"not json", `scope`, false, true},
+
+	// Rules that would check nothing, and so allow every claims
+	{"empty all", `{"all": []}`, false, true},
+	{"null all", `{"all": null}`, false, true},
+	{"empty any", `{"any": []}`, false, true},
+	{"empty scope", `{"scope": ""}`, false, true},
+	{"blank scope", `{"scope": ["  "]}`, false, true},
+	{"empty scope list", `{"scope": []}`, false, true},
+	{"null scope", `{"scope": null}`, false, true},
+	{"empty role list", `{"role": []}`, false, true},
+	{"empty role", `{"role": ["admin", ""]}`, false, true},
+	{"empty audience", `{"audience": ""}`, false, true},
+	{"null issuer", `{"issuer": null}`, false, true},
+	{"empty claim list", `{"claim": {"mfa": []}}`, false, true},
+	{"null claim", `{"claim": {"tenant": null}}`, false, true},
+	{"empty claim name", `{"claim": {"": "x"}}`, false, true},
+	{"empty claims", `{"claim": {}}`, false, true},
+	{"empty present", `{"present": []}`, false, true},
+	{"null not", `{"not": null}`, false, true},
+}
+
+func TestParse(t *testing.T) {
+	for _, data := range parseTestData {
+		rule, err := Parse([]byte(data.spec))
+		if data.err {
+			if err == nil {
+				t.Errorf("[%v] Expected an error", data.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%v] Error parsing: %v", data.name, err)
+			continue
+		}
+		if got := rule.Allow(policyTestClaims); got != data.allowed {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.allowed, got)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	authorize := Authorize(Role("admin"))
+	if err := authorize(&jwt.Token{Claims: policyTestClaims}); err != ErrDenied {
+		t.Errorf("Expected %v.  Got %v", ErrDenied, err)
+	}
+	if err := authorize(&jwt.Token{Claims: jwt.MapClaims{"roles": "admin"}}); err != nil {
+		t.Errorf("Expected the token to be allowed.  Got %v", err)
+	}
+}
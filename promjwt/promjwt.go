@@ -0,0 +1,84 @@
+//go:build promjwt
+
+// Package promjwt exports the measurements of jwt.Metrics to Prometheus.
+//
+// It depends on github.com/prometheus/client_golang, which the rest of
+// jwt-go does not, so it is only built with the promjwt tag:
+//
+//	go get github.com/prometheus/client_golang
+//	go build -tags promjwt
+//
+// Register the collectors and install them for the whole package:
+//
+//	jwt.DefaultMetrics = promjwt.New(prometheus.DefaultRegisterer)
+//
+// Successes are counted with the code "ok", failures with their
+// jwt.ErrorCode.
+package promjwt
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Code label of successful operations
+const CodeOK = "ok"
+
+// The collectors, for use as a jwt.Metrics
+type Metrics struct {
+	Signs          *prometheus.CounterVec   // jwt_sign_total{alg,code}
+	Verifications  *prometheus.CounterVec   // jwt_verify_total{alg,code}
+	VerifyDuration *prometheus.HistogramVec // jwt_verify_duration_seconds{alg}
+	JWKSFetches    *prometheus.CounterVec   // jwt_jwks_fetch_total{url,code}
+}
+
+// Create the collectors and register them with reg
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		Signs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "jwt",
+			Name:      "sign_total",
+			Help:      "Tokens signed, by alg and error code.",
+		}, []string{"alg", "code"}),
+		Verifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "jwt",
+			Name:      "verify_total",
+			Help:      "Tokens verified, by alg and error code.",
+		}, []string{"alg", "code"}),
+		VerifyDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "jwt",
+			Name:      "verify_duration_seconds",
+			Help:      "Time taken to verify a token, including key lookup.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"alg"}),
+		JWKSFetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "jwt",
+			Name:      "jwks_fetch_total",
+			Help:      "JWK Set fetches, by URL and error code.",
+		}, []string{"url", "code"}),
+	}
+	reg.MustRegister(m.Signs, m.Verifications, m.VerifyDuration, m.JWKSFetches)
+	return m
+}
+
+func (m *Metrics) ObserveSign(alg string, code jwt.ErrorCode) {
+	m.Signs.WithLabelValues(alg, label(code)).Inc()
+}
+
+func (m *Metrics) ObserveVerify(alg string, code jwt.ErrorCode, duration time.Duration) {
+	m.Verifications.WithLabelValues(alg, label(code)).Inc()
+	m.VerifyDuration.WithLabelValues(alg).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveJWKSFetch(url string, code jwt.ErrorCode) {
+	m.JWKSFetches.WithLabelValues(url, label(code)).Inc()
+}
+
+func label(code jwt.ErrorCode) string {
+	if code == "" {
+		return CodeOK
+	}
+	return code.String()
+}
@@ -0,0 +1,40 @@
+//go:build promjwt
+
+package promjwt
+
+import (
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	parser := &jwt.Parser{Metrics: m}
+
+	key := []byte("secret")
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse(s, func(*jwt.Token) (interface{}, error) { return key, nil })
+	parser.Parse(s, func(*jwt.Token) (interface{}, error) { return []byte("wrong"), nil })
+	parser.Parse(s, func(*jwt.Token) (interface{}, error) { return []byte("wrong"), nil })
+
+	if got := testutil.ToFloat64(m.Verifications.WithLabelValues("HS256", CodeOK)); got != 1 {
+		t.Errorf("Expected 1 success.  Got %v", got)
+	}
+	if got := testutil.ToFloat64(m.Verifications.WithLabelValues("HS256", jwt.CodeInvalidSignature.String())); got != 2 {
+		t.Errorf("Expected 2 failures.  Got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.VerifyDuration); got != 1 {
+		t.Errorf("Expected 1 histogram series.  Got %v", got)
+	}
+
+	m.ObserveSign("RS256", "")
+	if got := testutil.ToFloat64(m.Signs.WithLabelValues("RS256", CodeOK)); got != 1 {
+		t.Errorf("Expected 1 signature.  Got %v", got)
+	}
+}
@@ -0,0 +1,17 @@
+package jwt
+
+// Number of leading characters Redact keeps.  Enough to tell tokens apart
+// by their header, far too few to contain any claims or the signature.
+const redactKeep = 6
+
+// Shorten token for logging, so it can be correlated without being
+// replayed: "eyJhbGciOi..." becomes "eyJhbG…<redacted>".  The errors
+// returned by this package contain neither the token, its signature nor
+// its claim values, though Claims types' own Valid and UnmarshalJSON can
+// put claims in theirs.  Header values, such as alg and kid, may appear.
+func Redact(token string) string {
+	if len(token) <= 2*redactKeep {
+		return "<redacted>"
+	}
+	return token[:redactKeep] + "…<redacted>"
+}
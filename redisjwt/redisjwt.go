@@ -0,0 +1,76 @@
+//go:build redisjwt
+
+// Package redisjwt keeps the jti of one-time tokens in Redis, so servers
+// sharing a Redis reject a token presented to any of them twice.
+//
+// It depends on github.com/redis/go-redis/v9, which the rest of jwt-go
+// does not, so it is only built with the redisjwt tag:
+//
+//	go get github.com/redis/go-redis/v9
+//	go build -tags redisjwt
+//
+// Use a ReplayStore wherever jwt takes one:
+//
+//	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	validator := &jwt.ClientAssertionValidator{..., Replay: redisjwt.NewReplayStore(rdb)}
+package redisjwt
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/redis/go-redis/v9"
+)
+
+// Prefix of the keys if ReplayStore.Prefix is empty
+const DefaultPrefix = "jwt:jti:"
+
+// The part of the go-redis API used.  *redis.Client, *redis.ClusterClient
+// and *redis.Ring all provide it.
+type Client interface {
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+}
+
+// A jwt.ReplayStore recording each id with SET NX, expiring with the
+// token, so the check and the record are one atomic step across servers
+type ReplayStore struct {
+	Client  Client
+	Prefix  string        // Prepended to ids to make keys.  Defaults to DefaultPrefix
+	Timeout time.Duration // Bounds each call to Redis, if set
+}
+
+func NewReplayStore(client Client) *ReplayStore {
+	return &ReplayStore{Client: client}
+}
+
+// Record id until expiresAt.  Returns jwt.ErrTokenReplayed if it was
+// recorded before, or the error from Redis if it can't be reached, in
+// which case the token should be refused.
+func (s *ReplayStore) Record(id string, expiresAt time.Time) error {
+	ttl := expiresAt.Sub(jwt.TimeFunc())
+	if ttl <= 0 {
+		// Expired tokens are refused anyway, and a zero TTL would never expire
+		return nil
+	}
+
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	ok, err := s.Client.SetNX(ctx, prefix+id, 1, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return jwt.ErrTokenReplayed
+	}
+	return nil
+}
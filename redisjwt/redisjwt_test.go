@@ -0,0 +1,57 @@
+//go:build redisjwt
+
+package redisjwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/redis/go-redis/v9"
+)
+
+// Records SET NX calls like a Redis that never evicts
+type fakeClient struct {
+	keys map[string]time.Duration
+	err  error
+}
+
+func (c *fakeClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	if c.err != nil {
+		return redis.NewBoolResult(false, c.err)
+	}
+	if _, ok := c.keys[key]; ok {
+		return redis.NewBoolResult(false, nil)
+	}
+	c.keys[key] = expiration
+	return redis.NewBoolResult(true, nil)
+}
+
+func TestReplayStore(t *testing.T) {
+	client := &fakeClient{keys: map[string]time.Duration{}}
+	store := NewReplayStore(client)
+	exp := time.Now().Add(time.Minute)
+
+	if err := store.Record("a", exp); err != nil {
+		t.Fatalf("Error recording: %v", err)
+	}
+	if ttl := client.keys[DefaultPrefix+"a"]; ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected a TTL up to exp.  Got %v", ttl)
+	}
+	if err := store.Record("a", exp); err != jwt.ErrTokenReplayed {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenReplayed, err)
+	}
+	if err := store.Record("b", exp); err != nil {
+		t.Errorf("Error recording another id: %v", err)
+	}
+	if err := store.Record("old", time.Now().Add(-time.Minute)); err != nil || len(client.keys) != 2 {
+		t.Errorf("Expected an expired id not to be stored.  Got %v", err)
+	}
+
+	client.err = errors.New("connection refused")
+	if err := store.Record("c", exp); err != client.err {
+		t.Errorf("Expected %v.  Got %v", client.err, err)
+	}
+}
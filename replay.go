@@ -0,0 +1,52 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	ErrTokenReplayed = newError(CodeTokenReplayed, "token has already been used")
+)
+
+// Implement ReplayStore to reject one-time tokens, such as client
+// assertions, presented more than once.  Record returns ErrTokenReplayed
+// if id was recorded before and has not yet expired.  Stores shared by
+// several servers must record atomically.
+type ReplayStore interface {
+	Record(id string, expiresAt time.Time) error
+}
+
+// Adapter to use an ordinary function as a ReplayStore
+type ReplayStoreFunc func(id string, expiresAt time.Time) error
+
+func (f ReplayStoreFunc) Record(id string, expiresAt time.Time) error {
+	return f(id, expiresAt)
+}
+
+// ReplayStore for a single process.  Ids are forgotten once they expire.
+type MemoryReplayStore struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{seen: map[string]time.Time{}}
+}
+
+func (s *MemoryReplayStore) Record(id string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := TimeFunc()
+	if exp, ok := s.seen[id]; ok && now.Before(exp) {
+		return ErrTokenReplayed
+	}
+	for k, exp := range s.seen {
+		if !now.Before(exp) {
+			delete(s.seen, k)
+		}
+	}
+	s.seen[id] = expiresAt
+	return nil
+}
@@ -0,0 +1,90 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Error codes from RFC 6750 section 3.1
+const (
+	BearerErrorInvalidRequest    = "invalid_request"
+	BearerErrorInvalidToken      = "invalid_token"
+	BearerErrorInsufficientScope = "insufficient_scope"
+)
+
+// The parameters of a Bearer WWW-Authenticate challenge, as defined by
+// RFC 6750 section 3.  Empty fields are left out of the header.
+type BearerChallenge struct {
+	Realm            string
+	Scope            string
+	Error            string
+	ErrorDescription string
+
+	Err error // What the challenge reports, for logging.  Not sent
+}
+
+// Map an error from ParseFromRequest to a challenge for realm.  A request
+// without a token gets a bare challenge, as the RFC asks; any other error
+// is reported as invalid_token, described by jwt.DescribeError.
+func NewBearerChallenge(realm string, err error) *BearerChallenge {
+	c := &BearerChallenge{Realm: realm, Err: err}
+	if err == nil || errors.Is(err, ErrNoTokenInRequest) {
+		return c
+	}
+	c.Error = BearerErrorInvalidToken
+	c.ErrorDescription = jwt.DescribeError(err).Message
+	return c
+}
+
+// The value of the WWW-Authenticate header
+func (c *BearerChallenge) String() string {
+	var params []string
+	for _, p := range []struct{ name, value string }{
+		{"realm", c.Realm},
+		{"scope", c.Scope},
+		{"error", c.Error},
+		{"error_description", c.ErrorDescription},
+	} {
+		if p.value != "" {
+			params = append(params, p.name+`="`+bearerQuote(p.value)+`"`)
+		}
+	}
+	if len(params) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// The status code the RFC recommends for the challenge's error
+func (c *BearerChallenge) StatusCode() int {
+	switch c.Error {
+	case BearerErrorInvalidRequest:
+		return http.StatusBadRequest
+	case BearerErrorInsufficientScope:
+		return http.StatusForbidden
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+// Write the challenge header and status code for err to w.  Nothing is
+// written to the body, so callers may add one after calling this.
+func WriteBearerChallenge(w http.ResponseWriter, realm string, err error) {
+	c := NewBearerChallenge(realm, err)
+	w.Header().Set("WWW-Authenticate", c.String())
+	w.WriteHeader(c.StatusCode())
+}
+
+// Drop the characters RFC 6750 does not allow in parameter values:
+// double quote, backslash and anything outside printable ASCII
+func bearerQuote(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,81 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+var bearerTestData = []struct {
+	name   string
+	claims jwt.MapClaims
+	header map[string]string
+	status int
+	expect string
+}{
+	{
+		"no token",
+		nil,
+		map[string]string{},
+		http.StatusUnauthorized,
+		`Bearer realm="example"`,
+	},
+	{
+		"expired token",
+		jwt.MapClaims{"exp": float64(time.Now().Unix() - 100)},
+		map[string]string{"Authorization": "Bearer %v"},
+		http.StatusUnauthorized,
+		`Bearer realm="example", error="invalid_token", error_description="token is expired"`,
+	},
+	{
+		"malformed token",
+		nil,
+		map[string]string{"Authorization": "Bearer not-a-token"},
+		http.StatusUnauthorized,
+		`Bearer realm="example", error="invalid_token", error_description="token is malformed"`,
+	},
+}
+
+func TestWriteBearerChallenge(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	publicKey := test.LoadRSAPublicKeyFromDisk("../test/sample_key.pub")
+
+	for _, data := range bearerTestData {
+		headers := make(map[string]string)
+		for k, v := range data.header {
+			if data.claims != nil {
+				v = fmt.Sprintf(v, test.MakeSampleToken(data.claims, privateKey))
+			}
+			headers[k] = v
+		}
+		r := makeExampleRequest("GET", "/", headers, nil)
+
+		_, err := ParseFromRequest(r, AuthorizationHeaderExtractor, func(*jwt.Token) (interface{}, error) {
+			return publicKey, nil
+		})
+
+		w := httptest.NewRecorder()
+		WriteBearerChallenge(w, "example", err)
+		if w.Code != data.status {
+			t.Errorf("[%v] Expected status %v.  Got %v", data.name, data.status, w.Code)
+		}
+		if got := w.Header().Get("WWW-Authenticate"); got != data.expect {
+			t.Errorf("[%v] Unexpected challenge: %v", data.name, got)
+		}
+	}
+}
+
+func TestBearerChallenge_String(t *testing.T) {
+	c := &BearerChallenge{Scope: "read write", Error: BearerErrorInsufficientScope, ErrorDescription: `needs "write"`}
+	if s := c.String(); s != `Bearer scope="read write", error="insufficient_scope", error_description="needs write"` {
+		t.Errorf("Unexpected challenge: %v", s)
+	}
+	if c.StatusCode() != http.StatusForbidden {
+		t.Errorf("Expected 403 for insufficient_scope.  Got %v", c.StatusCode())
+	}
+}
@@ -0,0 +1,107 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Errors
+var (
+	ErrCookieTokenExpired = errors.New("token has already expired")
+)
+
+// Name of the token cookie if CookieOptions.Name is empty
+const DefaultCookieName = "token"
+
+// Settings for the cookie a token is kept in.  The zero value is a
+// Secure, HttpOnly, SameSite=Lax cookie named DefaultCookieName on path /.
+type CookieOptions struct {
+	Name     string
+	Path     string
+	Domain   string
+	SameSite http.SameSite // Defaults to http.SameSiteLaxMode
+
+	// Leave out the Secure attribute, so the cookie is also sent over
+	// plain http.  Only for local development.
+	Insecure bool
+}
+
+// Write tokenString into a cookie on w.  The cookie expires with the
+// token: Max-Age and Expires are taken from its exp claim, or left unset
+// for a session cookie if it has none.  The token is not verified, but
+// one that has already expired is refused with ErrCookieTokenExpired.
+func SetTokenCookie(w http.ResponseWriter, tokenString string, opts *CookieOptions) error {
+	var claims expClaims
+	if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, &claims); err != nil {
+		return err
+	}
+
+	cookie := opts.cookie(tokenString)
+	if claims.ExpiresAt != 0 {
+		exp := time.Unix(int64(claims.ExpiresAt), 0)
+		maxAge := int(exp.Sub(jwt.TimeFunc()) / time.Second)
+		if maxAge <= 0 {
+			return ErrCookieTokenExpired
+		}
+		cookie.MaxAge = maxAge
+		cookie.Expires = exp.UTC()
+	}
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+// Delete the token cookie, as on logout.  opts must match the ones the
+// cookie was set with, or the browser keeps it.
+func ClearTokenCookie(w http.ResponseWriter, opts *CookieOptions) {
+	cookie := opts.cookie("")
+	cookie.MaxAge = -1
+	cookie.Expires = time.Unix(0, 0).UTC()
+	http.SetCookie(w, cookie)
+}
+
+// Extractor for the cookie written by SetTokenCookie with these options.
+// A nil *CookieOptions uses the defaults.
+func (opts *CookieOptions) Extractor() Extractor {
+	return CookieExtractor{opts.name()}
+}
+
+func (opts *CookieOptions) name() string {
+	if opts == nil || opts.Name == "" {
+		return DefaultCookieName
+	}
+	return opts.Name
+}
+
+func (opts *CookieOptions) cookie(value string) *http.Cookie {
+	if opts == nil {
+		opts = &CookieOptions{}
+	}
+	c := &http.Cookie{
+		Name:     opts.name(),
+		Value:    value,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		Secure:   !opts.Insecure,
+		HttpOnly: true,
+		SameSite: opts.SameSite,
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	return c
+}
+
+// Just enough of the claims to find exp, whatever the shape of the rest
+type expClaims struct {
+	ExpiresAt float64 `json:"exp"`
+}
+
+func (expClaims) Valid() error {
+	return nil
+}
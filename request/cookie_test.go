@@ -0,0 +1,67 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestSetTokenCookie(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	exp := time.Now().Add(time.Hour).Unix()
+	s := test.MakeSampleToken(jwt.MapClaims{"sub": "alice", "exp": exp}, privateKey)
+
+	w := httptest.NewRecorder()
+	if err := SetTokenCookie(w, s, nil); err != nil {
+		t.Fatal(err)
+	}
+	cookies := (&http.Response{Header: w.Header()}).Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected 1 cookie.  Got %v", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != DefaultCookieName || c.Value != s || c.Path != "/" || !c.Secure || !c.HttpOnly || c.SameSite != http.SameSiteLaxMode {
+		t.Errorf("Unexpected cookie %v", c)
+	}
+	if c.MaxAge < 3590 || c.MaxAge > 3600 || c.Expires.Unix() != exp {
+		t.Errorf("Expected expiry at %v.  Got Max-Age %v, Expires %v", exp, c.MaxAge, c.Expires)
+	}
+
+	// Round trip through the matching extractor
+	r := makeExampleRequest("GET", "/", nil, nil)
+	r.AddCookie(c)
+	if got, err := (*CookieOptions)(nil).Extractor().ExtractToken(r); got != s || err != nil {
+		t.Errorf("Expected the token back.  Got '%v' %v", got, err)
+	}
+
+	// Options, and a token without exp makes a session cookie
+	opts := &CookieOptions{Name: "session", Path: "/app", SameSite: http.SameSiteStrictMode, Insecure: true}
+	w = httptest.NewRecorder()
+	if err := SetTokenCookie(w, test.MakeSampleToken(jwt.MapClaims{"sub": "alice"}, privateKey), opts); err != nil {
+		t.Fatal(err)
+	}
+	c = (&http.Response{Header: w.Header()}).Cookies()[0]
+	if c.Name != "session" || c.Path != "/app" || c.Secure || c.SameSite != http.SameSiteStrictMode || c.MaxAge != 0 || !c.Expires.IsZero() {
+		t.Errorf("Unexpected cookie %v", c)
+	}
+
+	// Expired
+	expired := test.MakeSampleToken(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}, privateKey)
+	if err := SetTokenCookie(httptest.NewRecorder(), expired, nil); err != ErrCookieTokenExpired {
+		t.Errorf("Expected %v.  Got %v", ErrCookieTokenExpired, err)
+	}
+	if err := SetTokenCookie(httptest.NewRecorder(), "nope", nil); err == nil {
+		t.Errorf("Expected an error for a malformed token")
+	}
+
+	w = httptest.NewRecorder()
+	ClearTokenCookie(w, opts)
+	c = (&http.Response{Header: w.Header()}).Cookies()[0]
+	if c.Name != "session" || c.Path != "/app" || c.MaxAge != -1 || c.Value != "" {
+		t.Errorf("Unexpected cookie %v", c)
+	}
+}
@@ -2,6 +2,7 @@
 // HTTP requests.
 //
 // The main function is ParseFromRequest and it's WithClaims variant.
-// See examples for how to use the various Extractor implementations
-// or roll your own.
+// Extractors look in headers, the query, form bodies or cookies and can
+// be combined with MultiExtractor.  See examples for how to use the
+// various Extractor implementations or roll your own.
 package request
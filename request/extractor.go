@@ -50,6 +50,49 @@ func (e ArgumentExtractor) ExtractToken(req *http.Request) (string, error) {
 	return "", ErrNoTokenInRequest
 }
 
+// Extract token from URL query parameters only, unlike ArgumentExtractor,
+// which also reads the body.  Parameter names are tried in order until
+// there's a match.  RFC 6750 section 2.3 discourages tokens in URLs, as
+// they end up in logs; prefer a header where clients allow it.
+type QueryExtractor []string
+
+func (e QueryExtractor) ExtractToken(req *http.Request) (string, error) {
+	query := req.URL.Query()
+	for _, arg := range e {
+		if ah := query.Get(arg); ah != "" {
+			return ah, nil
+		}
+	}
+	return "", ErrNoTokenInRequest
+}
+
+// Extract token from a POSTed form body only, as RFC 6750 section 2.2
+// describes for access_token.  Field names are tried in order until
+// there's a match.  Only urlencoded bodies are read.
+type FormExtractor []string
+
+func (e FormExtractor) ExtractToken(req *http.Request) (string, error) {
+	for _, field := range e {
+		if ah := req.PostFormValue(field); ah != "" {
+			return ah, nil
+		}
+	}
+	return "", ErrNoTokenInRequest
+}
+
+// Extractor for finding a token in a cookie, e.g. for browser sessions.
+// Cookie names are tried in order until there's a match.
+type CookieExtractor []string
+
+func (e CookieExtractor) ExtractToken(req *http.Request) (string, error) {
+	for _, name := range e {
+		if c, err := req.Cookie(name); err == nil && c.Value != "" {
+			return c.Value, nil
+		}
+	}
+	return "", ErrNoTokenInRequest
+}
+
 // Tries Extractors in order until one returns a token string or an error occurs
 type MultiExtractor []Extractor
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -53,6 +54,38 @@ var extractorTestData = []struct {
 		token:     "",
 		err:       ErrNoTokenInRequest,
 	},
+	{
+		name:      "query",
+		extractor: QueryExtractor{"token"},
+		headers:   map[string]string{},
+		query:     url.Values{"token": {extractorTestTokenA}},
+		token:     extractorTestTokenA,
+		err:       nil,
+	},
+	{
+		name:      "query miss",
+		extractor: QueryExtractor{"token"},
+		headers:   map[string]string{},
+		query:     url.Values{"other": {extractorTestTokenA}},
+		token:     "",
+		err:       ErrNoTokenInRequest,
+	},
+	{
+		name:      "cookie",
+		extractor: CookieExtractor{"missing", "session"},
+		headers:   map[string]string{"Cookie": "theme=dark; session=" + extractorTestTokenA},
+		query:     nil,
+		token:     extractorTestTokenA,
+		err:       nil,
+	},
+	{
+		name:      "cookie miss",
+		extractor: CookieExtractor{"session"},
+		headers:   map[string]string{"Cookie": "theme=dark"},
+		query:     nil,
+		token:     "",
+		err:       ErrNoTokenInRequest,
+	},
 	{
 		name:      "filter",
 		extractor: AuthorizationHeaderExtractor,
@@ -82,6 +115,21 @@ func TestExtractor(t *testing.T) {
 	}
 }
 
+func TestFormExtractor(t *testing.T) {
+	body := url.Values{"access_token": {extractorTestTokenA}}.Encode()
+	r, _ := http.NewRequest("POST", "/?access_token="+extractorTestTokenB, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// The body only, never the URL
+	if token, err := (FormExtractor{"access_token"}).ExtractToken(r); token != extractorTestTokenA || err != nil {
+		t.Errorf("Expected token '%v'.  Got '%v' %v", extractorTestTokenA, token, err)
+	}
+	r = makeExampleRequest("GET", "/", nil, url.Values{"access_token": {extractorTestTokenB}})
+	if _, err := (FormExtractor{"access_token"}).ExtractToken(r); err != ErrNoTokenInRequest {
+		t.Errorf("Expected error '%v'.  Got '%v'", ErrNoTokenInRequest, err)
+	}
+}
+
 func makeExampleRequest(method, path string, headers map[string]string, urlArgs url.Values) *http.Request {
 	r, _ := http.NewRequest(method, fmt.Sprintf("%v?%v", path, urlArgs.Encode()), nil)
 	for k, v := range headers {
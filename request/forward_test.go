@@ -0,0 +1,78 @@
+package request
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestClaimForwarder(t *testing.T) {
+	f := &ClaimForwarder{
+		Headers: map[string]string{"sub": "X-User-Id", "tenant": "X-Tenant", "scope": "X-Scopes", "groups": "X-Groups"},
+		Secret:  []byte("proxy secret"),
+	}
+
+	// The client tries to set a header the token doesn't cover
+	h := http.Header{}
+	h.Set("X-Tenant", "acme")
+	h.Set("X-User-Id", "root")
+	claims := jwt.MapClaims{
+		"sub":    "alice\r\nX-Admin: true",
+		"scope":  "read write",
+		"groups": []interface{}{"dev", "ops,admin"},
+	}
+	if err := f.Forward(h, claims); err != nil {
+		t.Fatal(err)
+	}
+	if h.Get("X-Tenant") != "" {
+		t.Errorf("Expected the client's X-Tenant to be removed.  Got %v", h.Get("X-Tenant"))
+	}
+	if got := h.Get("X-User-Id"); got != "alice%0D%0AX-Admin:%20true" {
+		t.Errorf("Expected an encoded X-User-Id.  Got %v", got)
+	}
+
+	r := makeExampleRequest("GET", "/", nil, nil)
+	r.Header = h
+	got, err := f.Claims(r)
+	if err != nil {
+		t.Fatalf("Error reading forwarded claims: %v", err)
+	}
+	expected := map[string][]string{
+		"sub":    {"alice\r\nX-Admin: true"},
+		"scope":  {"read", "write"},
+		"groups": {"dev", "ops,admin"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v.  Got %v", expected, got)
+	}
+
+	// Tampering with any forwarding header, even an absent one, breaks the signature
+	for _, header := range []string{"X-User-Id", "X-Tenant"} {
+		r.Header = h.Clone()
+		r.Header.Set(header, "mallory")
+		if _, err = f.Claims(r); err != ErrForwardedClaimsInvalid {
+			t.Errorf("[%v] Expected %v.  Got %v", header, ErrForwardedClaimsInvalid, err)
+		}
+	}
+
+	r.Header = h.Clone()
+	r.Header.Del(DefaultForwardedClaimsSignatureHeader)
+	if _, err = f.Claims(r); err != ErrForwardedClaimsUnsigned {
+		t.Errorf("Expected %v.  Got %v", ErrForwardedClaimsUnsigned, err)
+	}
+
+	// A different secret is not trusted
+	other := &ClaimForwarder{Headers: f.Headers, Secret: []byte("other")}
+	r.Header = h
+	if _, err = other.Claims(r); err != ErrForwardedClaimsInvalid {
+		t.Errorf("Expected %v.  Got %v", ErrForwardedClaimsInvalid, err)
+	}
+
+	// Struct claims go through JSON
+	h = http.Header{}
+	if err = f.Forward(h, &jwt.StandardClaims{Subject: "bob"}); err != nil || h.Get("X-User-Id") != "bob" {
+		t.Errorf("Expected X-User-Id bob.  Got %v %v", h.Get("X-User-Id"), err)
+	}
+}
@@ -0,0 +1,84 @@
+package request
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// net/http middleware that requires a valid token on every request.  The
+// verified token is stored in the request context, where handlers get it
+// with jwt.FromContext.  Failures are answered with the Bearer challenges of
+// RFC 6750 section 3: 401 invalid_token, or 403 insufficient_scope when
+// Authorize refuses.
+type Middleware struct {
+	Keyfunc   jwt.Keyfunc
+	Extractor Extractor              // Defaults to AuthorizationHeaderExtractor
+	Parser    *jwt.Parser            // Defaults to a zero Parser
+	NewClaims func() jwt.Claims      // Fresh claims for each request.  Defaults to MapClaims
+	Realm     string                 // Sent in challenges if set
+	Scope     string                 // Sent in insufficient_scope challenges, e.g. the scope required
+	Authorize func(*jwt.Token) error // If set, checks the verified token, e.g. for a scope
+}
+
+// Wrap next so that it only sees requests with a valid token
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, challenge := m.Verify(r)
+		if challenge != nil {
+			w.Header().Set("WWW-Authenticate", challenge.String())
+			w.WriteHeader(challenge.StatusCode())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(jwt.NewContext(r.Context(), token)))
+	})
+}
+
+// Extract and check the token of r as Handler does.  On failure, returns
+// the challenge to answer with instead, so adapters for other frameworks
+// can share the same rules.
+func (m *Middleware) Verify(r *http.Request) (*jwt.Token, *BearerChallenge) {
+	extractor := m.Extractor
+	if extractor == nil {
+		extractor = AuthorizationHeaderExtractor
+	}
+	tokenString, source, err := extractWithSource(extractor, r)
+	if err != nil {
+		return nil, NewBearerChallenge(m.Realm, err)
+	}
+	return m.verifyToken(jwt.WithTokenSource(r.Context(), source), tokenString)
+}
+
+// Check a token that was already extracted, as Verify does
+func (m *Middleware) VerifyToken(tokenString string) (*jwt.Token, *BearerChallenge) {
+	return m.verifyToken(context.Background(), tokenString)
+}
+
+func (m *Middleware) verifyToken(ctx context.Context, tokenString string) (*jwt.Token, *BearerChallenge) {
+	parser := m.Parser
+	if parser == nil {
+		parser = new(jwt.Parser)
+	}
+	var claims jwt.Claims = jwt.MapClaims{}
+	if m.NewClaims != nil {
+		claims = m.NewClaims()
+	}
+
+	token, err := parser.ParseWithContext(ctx, tokenString, claims, m.Keyfunc)
+	if err != nil {
+		return nil, NewBearerChallenge(m.Realm, err)
+	}
+	if m.Authorize != nil {
+		if err = m.Authorize(token); err != nil {
+			return nil, &BearerChallenge{
+				Realm:            m.Realm,
+				Scope:            m.Scope,
+				Error:            BearerErrorInsufficientScope,
+				ErrorDescription: err.Error(),
+				Err:              err,
+			}
+		}
+	}
+	return token, nil
+}
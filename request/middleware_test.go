@@ -0,0 +1,92 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestMiddleware(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	publicKey := test.LoadRSAPublicKeyFromDisk("../test/sample_key.pub")
+
+	m := &Middleware{
+		Keyfunc: func(*jwt.Token) (interface{}, error) { return publicKey, nil },
+		Realm:   "example",
+		Scope:   "admin",
+		Authorize: func(token *jwt.Token) error {
+			if token.Claims.(jwt.MapClaims)["scope"] != "admin" {
+				return errors.New("admin scope required")
+			}
+			return nil
+		},
+	}
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := jwt.FromContext(r.Context())
+		if !ok || !token.Valid {
+			t.Errorf("Expected a valid token in the context")
+		}
+		w.Write([]byte(token.Claims.(jwt.MapClaims)["sub"].(string)))
+	}))
+
+	var middlewareTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		status int
+		body   string
+		header string
+	}{
+		{"valid", jwt.MapClaims{"sub": "alice", "scope": "admin"}, http.StatusOK, "alice", ""},
+		{"no token", nil, http.StatusUnauthorized, "", `Bearer realm="example"`},
+		{"expired", jwt.MapClaims{"sub": "alice", "scope": "admin", "exp": float64(time.Now().Unix() - 100)}, http.StatusUnauthorized, "", `Bearer realm="example", error="invalid_token", error_description="token is expired"`},
+		{"refused", jwt.MapClaims{"sub": "bob", "scope": "read"}, http.StatusForbidden, "", `Bearer realm="example", scope="admin", error="insufficient_scope", error_description="admin scope required"`},
+	}
+
+	for _, data := range middlewareTestData {
+		headers := map[string]string{}
+		if data.claims != nil {
+			headers["Authorization"] = "Bearer " + test.MakeSampleToken(data.claims, privateKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, makeExampleRequest("GET", "/", headers, nil))
+
+		if w.Code != data.status || w.Body.String() != data.body || w.Header().Get("WWW-Authenticate") != data.header {
+			t.Errorf("[%v] Unexpected response %v %q %q", data.name, w.Code, w.Body.String(), w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestMiddleware_claims(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	publicKey := test.LoadRSAPublicKeyFromDisk("../test/sample_key.pub")
+
+	m := &Middleware{
+		Keyfunc:   func(*jwt.Token) (interface{}, error) { return publicKey, nil },
+		Extractor: CookieExtractor{"session"},
+		Parser:    &jwt.Parser{ValidMethods: []string{"RS256"}},
+		NewClaims: func() jwt.Claims { return new(jwt.StandardClaims) },
+	}
+	var subjects []string
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := jwt.ClaimsFromContext[*jwt.StandardClaims](r.Context())
+		subjects = append(subjects, claims.Subject)
+	}))
+
+	// Each request gets its own claims
+	for _, sub := range []string{"alice", "bob"} {
+		s := test.MakeSampleToken(jwt.MapClaims{"sub": sub}, privateKey)
+		handler.ServeHTTP(httptest.NewRecorder(), makeExampleRequest("GET", "/", map[string]string{"Cookie": "session=" + s}, nil))
+	}
+	if len(subjects) != 2 || subjects[0] != "alice" || subjects[1] != "bob" {
+		t.Errorf("Unexpected subjects %v", subjects)
+	}
+
+	if _, ok := jwt.FromContext(httptest.NewRequest("GET", "/", nil).Context()); ok {
+		t.Errorf("Expected no token outside the middleware")
+	}
+}
@@ -0,0 +1,62 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ExtractToken, also saying where the token was found for
+// jwt.SecurityEvent.Source: "header:Authorization", "query:access_token",
+// and so on, or the type of an Extractor this package doesn't know.
+func extractWithSource(e Extractor, req *http.Request) (string, string, error) {
+	switch e := e.(type) {
+	case MultiExtractor:
+		for _, extractor := range e {
+			if tok, source, err := extractWithSource(extractor, req); tok != "" {
+				return tok, source, nil
+			} else if err != ErrNoTokenInRequest {
+				return "", "", err
+			}
+		}
+		return "", "", ErrNoTokenInRequest
+	case *MultiExtractor:
+		return extractWithSource(*e, req)
+	case *PostExtractionFilter:
+		tok, source, err := extractWithSource(e.Extractor, req)
+		if tok == "" {
+			return "", "", err
+		}
+		tok, err = e.Filter(tok)
+		return tok, source, err
+	case HeaderExtractor:
+		return extractNamed(e, "header", req)
+	case ArgumentExtractor:
+		return extractNamed(e, "argument", req)
+	case QueryExtractor:
+		return extractNamed(e, "query", req)
+	case FormExtractor:
+		return extractNamed(e, "form", req)
+	case CookieExtractor:
+		return extractNamed(e, "cookie", req)
+	case SubprotocolExtractor:
+		tok, err := e.ExtractToken(req)
+		return tok, "subprotocol", err
+	}
+	tok, err := e.ExtractToken(req)
+	return tok, fmt.Sprintf("%T", e), err
+}
+
+// Try each name of e on its own, to learn which one matched
+func extractNamed[E interface {
+	~[]string
+	Extractor
+}](e E, kind string, req *http.Request) (string, string, error) {
+	for _, name := range e {
+		if tok, err := (E{name}).ExtractToken(req); tok != "" {
+			return tok, kind + ":" + name, nil
+		} else if err != ErrNoTokenInRequest {
+			return "", "", err
+		}
+	}
+	return "", "", ErrNoTokenInRequest
+}
@@ -0,0 +1,57 @@
+package request
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+var sourceTestData = []struct {
+	name      string
+	extractor Extractor
+	headers   map[string]string
+	query     url.Values
+	source    string
+}{
+	{"second header", HeaderExtractor{"Foo", "Bar"}, map[string]string{"Bar": extractorTestTokenA}, nil, "header:Bar"},
+	{"authorization", AuthorizationHeaderExtractor, map[string]string{"Authorization": "Bearer " + extractorTestTokenA}, nil, "header:Authorization"},
+	{"oauth2 query", OAuth2Extractor, nil, url.Values{"access_token": {extractorTestTokenA}}, "argument:access_token"},
+	{"websocket", WebSocketExtractor, map[string]string{"Sec-WebSocket-Protocol": "bearer, " + extractorTestTokenA}, nil, "subprotocol"},
+	{"cookie", CookieExtractor{"session"}, map[string]string{"Cookie": "session=" + extractorTestTokenA}, nil, "cookie:session"},
+}
+
+func TestExtractWithSource(t *testing.T) {
+	for _, data := range sourceTestData {
+		r := makeExampleRequest("GET", "/", data.headers, data.query)
+		token, source, err := extractWithSource(data.extractor, r)
+		if err != nil || source != data.source {
+			t.Errorf("[%v] Expected source %q.  Got %q, %v", data.name, data.source, source, err)
+		}
+		if plain, _ := data.extractor.ExtractToken(r); token != plain {
+			t.Errorf("[%v] Expected token %q, as ExtractToken returns.  Got %q", data.name, plain, token)
+		}
+	}
+}
+
+func TestMiddleware_securitySource(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+
+	var events []*jwt.SecurityEvent
+	m := &Middleware{
+		Keyfunc:   func(*jwt.Token) (interface{}, error) { return &privateKey.PublicKey, nil },
+		Extractor: OAuth2Extractor,
+		Parser:    &jwt.Parser{SecurityHook: jwt.SecurityHookFunc(func(e *jwt.SecurityEvent) { events = append(events, e) })},
+	}
+
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"sub": "alice"}, privateKey)
+	forged := tokenString[:len(tokenString)-4] + "AAAA"
+	r := makeExampleRequest("GET", "/", nil, url.Values{"access_token": {forged}})
+	if _, challenge := m.Verify(r); challenge == nil {
+		t.Fatal("Expected the forged token to be refused")
+	}
+	if len(events) != 1 || events[0].Code != jwt.CodeInvalidSignature || events[0].Source != "argument:access_token" {
+		t.Errorf("Expected an invalid_signature event from argument:access_token.  Got %+v", events)
+	}
+}
@@ -0,0 +1,91 @@
+package request
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Browsers cannot set headers on a WebSocket handshake, so the token has
+// to travel in the query, a cookie, or the Sec-WebSocket-Protocol header:
+//
+//	new WebSocket(url, ["bearer", token])
+//
+// The server must then select the marker, "bearer", as the subprotocol,
+// and never echo the token.  With gorilla/websocket that is
+// Upgrader{Subprotocols: []string{"bearer"}}.
+
+// The token follows this marker in Sec-WebSocket-Protocol by default
+const WebSocketTokenMarker = "bearer"
+
+// Extract token from the Sec-WebSocket-Protocol header of a handshake, as
+// the protocol following the marker, which defaults to WebSocketTokenMarker
+type SubprotocolExtractor string
+
+func (e SubprotocolExtractor) ExtractToken(req *http.Request) (string, error) {
+	marker := string(e)
+	if marker == "" {
+		marker = WebSocketTokenMarker
+	}
+	var protocols []string
+	for _, h := range req.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(h, ",") {
+			protocols = append(protocols, strings.TrimSpace(p))
+		}
+	}
+	for i, p := range protocols {
+		if p == marker && i+1 < len(protocols) && protocols[i+1] != "" {
+			return protocols[i+1], nil
+		}
+	}
+	return "", ErrNoTokenInRequest
+}
+
+// Extractor for WebSocket handshakes.  Looks in the Sec-WebSocket-Protocol
+// header after the bearer marker, then the access_token query parameter.
+var WebSocketExtractor = &MultiExtractor{
+	SubprotocolExtractor(WebSocketTokenMarker),
+	QueryExtractor{"access_token"},
+}
+
+// Whether req asks to upgrade to a WebSocket, RFC 6455 section 4.1
+func IsWebSocketUpgrade(req *http.Request) bool {
+	return headerHasToken(req.Header, "Connection", "upgrade") && headerHasToken(req.Header, "Upgrade", "websocket")
+}
+
+// Verify the token of a WebSocket handshake with the rules of m, then call
+// upgrade to complete it.  m.Extractor defaults to WebSocketExtractor.
+// Handshakes without a valid token are answered with the challenge, as
+// browsers see only that the connection failed; other requests with 400.
+func WebSocketHandler(m *Middleware, upgrade func(w http.ResponseWriter, r *http.Request, token *jwt.Token)) http.Handler {
+	ws := *m
+	if ws.Extractor == nil {
+		ws.Extractor = WebSocketExtractor
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsWebSocketUpgrade(r) {
+			http.Error(w, "expected a WebSocket handshake", http.StatusBadRequest)
+			return
+		}
+		token, challenge := ws.Verify(r)
+		if challenge != nil {
+			w.Header().Set("WWW-Authenticate", challenge.String())
+			w.WriteHeader(challenge.StatusCode())
+			return
+		}
+		upgrade(w, r.WithContext(jwt.NewContext(r.Context(), token)), token)
+	})
+}
+
+// Whether a comma separated header lists token, ignoring case
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+var subprotocolTestData = []struct {
+	name      string
+	extractor Extractor
+	protocols string
+	token     string
+	err       error
+}{
+	{"marker first", SubprotocolExtractor(""), "bearer, " + extractorTestTokenA, extractorTestTokenA, nil},
+	{"after others", SubprotocolExtractor(""), "chat,bearer," + extractorTestTokenA, extractorTestTokenA, nil},
+	{"custom marker", SubprotocolExtractor("access_token"), "access_token, " + extractorTestTokenA, extractorTestTokenA, nil},
+	{"no marker", SubprotocolExtractor(""), "chat, " + extractorTestTokenA, "", ErrNoTokenInRequest},
+	{"marker last", SubprotocolExtractor(""), "chat, bearer", "", ErrNoTokenInRequest},
+	{"none", SubprotocolExtractor(""), "", "", ErrNoTokenInRequest},
+}
+
+func TestSubprotocolExtractor(t *testing.T) {
+	for _, data := range subprotocolTestData {
+		r := makeExampleRequest("GET", "/", map[string]string{"Sec-WebSocket-Protocol": data.protocols}, nil)
+		token, err := data.extractor.ExtractToken(r)
+		if token != data.token || err != data.err {
+			t.Errorf("[%v] Expected '%v' %v.  Got '%v' %v", data.name, data.token, data.err, token, err)
+		}
+	}
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	publicKey := test.LoadRSAPublicKeyFromDisk("../test/sample_key.pub")
+	s := test.MakeSampleToken(jwt.MapClaims{"sub": "alice"}, privateKey)
+
+	var upgraded []string
+	handler := WebSocketHandler(&Middleware{
+		Keyfunc: func(*jwt.Token) (interface{}, error) { return publicKey, nil },
+	}, func(w http.ResponseWriter, r *http.Request, token *jwt.Token) {
+		upgraded = append(upgraded, token.Claims.(jwt.MapClaims)["sub"].(string))
+		w.WriteHeader(http.StatusSwitchingProtocols)
+	})
+
+	upgrade := map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "websocket"}
+	var websocketTestData = []struct {
+		name    string
+		headers map[string]string
+		query   url.Values
+		status  int
+	}{
+		{"subprotocol", map[string]string{"Sec-WebSocket-Protocol": "bearer, " + s}, nil, http.StatusSwitchingProtocols},
+		{"query", nil, url.Values{"access_token": {s}}, http.StatusSwitchingProtocols},
+		{"no token", nil, nil, http.StatusUnauthorized},
+		{"invalid token", map[string]string{"Sec-WebSocket-Protocol": "bearer, nope"}, nil, http.StatusUnauthorized},
+	}
+
+	for _, data := range websocketTestData {
+		headers := map[string]string{}
+		for k, v := range upgrade {
+			headers[k] = v
+		}
+		for k, v := range data.headers {
+			headers[k] = v
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, makeExampleRequest("GET", "/ws", headers, data.query))
+		if w.Code != data.status {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.status, w.Code)
+		}
+	}
+	if len(upgraded) != 2 || upgraded[0] != "alice" {
+		t.Errorf("Unexpected upgrades %v", upgraded)
+	}
+
+	// Not a handshake
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, makeExampleRequest("GET", "/ws", nil, url.Values{"access_token": {s}}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected %v.  Got %v", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTokenRevoked = newError(CodeTokenRevoked, "token has been revoked")
+)
+
+// Implement RevocationChecker to refuse tokens revoked before they expire,
+// by jti or because every token of their subject issued before a cutoff
+// was revoked, as on a password change.  Set Parser.Revocation to
+// consult it.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, id, subject string, issuedAt time.Time) (bool, error)
+}
+
+// Adapter to use an ordinary function as a RevocationChecker
+type RevocationCheckerFunc func(ctx context.Context, id, subject string, issuedAt time.Time) (bool, error)
+
+func (f RevocationCheckerFunc) IsRevoked(ctx context.Context, id, subject string, issuedAt time.Time) (bool, error) {
+	return f(ctx, id, subject, issuedAt)
+}
+
+// Ask checker about token, using its jti, sub and iat claims.  Claims must
+// be MapClaims or provide GetId, GetSubject and GetIssuedAt, as
+// StandardClaims does.  A checker that fails refuses the token.
+func checkRevoked(ctx context.Context, checker RevocationChecker, token *Token) error {
+	var id, subject string
+	var iat time.Time
+	if c, ok := token.Claims.(interface{ GetId() string }); ok {
+		id = c.GetId()
+	}
+	if c, ok := token.Claims.(interface{ GetSubject() string }); ok {
+		subject = c.GetSubject()
+	}
+	if c, ok := token.Claims.(interface{ GetIssuedAt() int64 }); ok && c.GetIssuedAt() != 0 {
+		iat = time.Unix(c.GetIssuedAt(), 0)
+	}
+
+	revoked, err := checker.IsRevoked(ctx, id, subject, iat)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+const (
+	// Default time a RevocationCache remembers an answer
+	DefaultRevocationCacheTTL = 10 * time.Second
+	// Default number of answers a RevocationCache keeps
+	DefaultRevocationCacheSize = 10000
+)
+
+// Remembers the answers of a RevocationChecker backed by a database for a
+// short while, so busy tokens don't cost a query per request.  A token
+// revoked elsewhere is refused once the cached answer expires; Invalidate
+// to refuse it at once.  Errors are not cached.
+type RevocationCache struct {
+	Checker RevocationChecker
+	TTL     time.Duration // Defaults to DefaultRevocationCacheTTL
+	Size    int           // Defaults to DefaultRevocationCacheSize
+
+	mu      sync.Mutex
+	entries map[string]revocationEntry
+}
+
+type revocationEntry struct {
+	revoked bool
+	expires time.Time
+}
+
+func NewRevocationCache(checker RevocationChecker, ttl time.Duration) *RevocationCache {
+	return &RevocationCache{Checker: checker, TTL: ttl}
+}
+
+func (c *RevocationCache) IsRevoked(ctx context.Context, id, subject string, issuedAt time.Time) (bool, error) {
+	key := id + "\x00" + subject + "\x00" + strconv.FormatInt(issuedAt.Unix(), 10)
+	now := TimeFunc()
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.revoked, nil
+	}
+
+	revoked, err := c.Checker.IsRevoked(ctx, id, subject, issuedAt)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	size := intOr(c.Size, DefaultRevocationCacheSize)
+	if len(c.entries) >= size {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if c.entries == nil {
+		c.entries = map[string]revocationEntry{}
+	}
+	if len(c.entries) < size {
+		c.entries[key] = revocationEntry{revoked, now.Add(durationOr(c.TTL, DefaultRevocationCacheTTL))}
+	}
+	return revoked, nil
+}
+
+// Forget every answer, after revoking tokens
+func (c *RevocationCache) Invalidate() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
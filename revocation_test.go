@@ -0,0 +1,75 @@
+package jwt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestParser_Revocation(t *testing.T) {
+	cutoff := time.Now().Add(-time.Hour)
+	calls := 0
+	checker := jwt.RevocationCheckerFunc(func(ctx context.Context, id, subject string, issuedAt time.Time) (bool, error) {
+		calls++
+		if subject == "broken" {
+			return false, errors.New("database is down")
+		}
+		return id == "revoked" || (subject == "bob" && issuedAt.Before(cutoff)), nil
+	})
+	cache := jwt.NewRevocationCache(checker, time.Minute)
+	parser := &jwt.Parser{Revocation: cache}
+
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	sign := func(claims jwt.Claims) string {
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		return s
+	}
+
+	var revocationTestData = []struct {
+		name    string
+		token   string
+		revoked bool
+	}{
+		{"valid", sign(jwt.MapClaims{"jti": "1", "sub": "alice"}), false},
+		{"by jti", sign(jwt.MapClaims{"jti": "revoked", "sub": "alice"}), true},
+		{"by subject", sign(jwt.MapClaims{"sub": "bob", "iat": float64(cutoff.Add(-time.Minute).Unix())}), true},
+		{"issued after cutoff", sign(&jwt.StandardClaims{Subject: "bob", IssuedAt: cutoff.Add(time.Minute).Unix()}), false},
+		{"checker fails", sign(jwt.MapClaims{"sub": "broken"}), true},
+	}
+	for _, data := range revocationTestData {
+		_, err := parser.Parse(data.token, keyFunc)
+		if data.revoked && err == nil {
+			t.Errorf("[%v] Expected the token to be refused", data.name)
+		}
+		if !data.revoked && err != nil {
+			t.Errorf("[%v] Error parsing: %v", data.name, err)
+		}
+	}
+	if _, err := parser.Parse(revocationTestData[1].token, keyFunc); !errors.Is(err, jwt.ErrTokenRevoked) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenRevoked, err)
+	}
+
+	// Answers are cached, failures are not
+	before := calls
+	parser.Parse(revocationTestData[0].token, keyFunc)
+	parser.Parse(revocationTestData[4].token, keyFunc)
+	if calls != before+1 {
+		t.Errorf("Expected 1 call to the checker.  Got %v", calls-before)
+	}
+	cache.Invalidate()
+	parser.Parse(revocationTestData[0].token, keyFunc)
+	if calls != before+2 {
+		t.Errorf("Expected the checker to be asked again after Invalidate")
+	}
+
+	// Forged tokens don't reach the checker
+	before = calls
+	parser.Parse(revocationTestData[0].token, func(*jwt.Token) (interface{}, error) { return []byte("wrong"), nil })
+	if calls != before {
+		t.Errorf("Expected no call for a token with a bad signature")
+	}
+}
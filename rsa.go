@@ -47,14 +47,16 @@ func (m *SigningMethodRSA) Alg() string {
 // Implements the Verify method from SigningMethod
 // For this signing method, must be an *rsa.PublicKey structure.
 func (m *SigningMethodRSA) Verify(signingString, signature string, key interface{}) error {
-	var err error
-
 	// Decode the signature
-	var sig []byte
-	if sig, err = DecodeSegment(signature); err != nil {
+	sig, err := DecodeSegment(signature)
+	if err != nil {
 		return err
 	}
+	return m.VerifyBytes(signingString, sig, key)
+}
 
+// Implements BytesVerifier.  Verify, with the signature already decoded
+func (m *SigningMethodRSA) VerifyBytes(signingString string, sig []byte, key interface{}) error {
 	var rsaKey *rsa.PublicKey
 	var ok bool
 
@@ -67,7 +69,7 @@ func (m *SigningMethodRSA) Verify(signingString, signature string, key interface
 		return ErrHashUnavailable
 	}
 	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
+	hasher.Write(stringBytes(signingString))
 
 	// Verify the signature
 	return rsa.VerifyPKCS1v15(rsaKey, m.Hash, hasher.Sum(nil), sig)
@@ -90,7 +92,7 @@ func (m *SigningMethodRSA) Sign(signingString string, key interface{}) (string,
 	}
 
 	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
+	hasher.Write(stringBytes(signingString))
 
 	// Sign the string and return the encoded bytes
 	if sigBytes, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, m.Hash, hasher.Sum(nil)); err == nil {
@@ -71,14 +71,16 @@ func init() {
 // Implements the Verify method from SigningMethod
 // For this verify method, key must be an rsa.PublicKey struct
 func (m *SigningMethodRSAPSS) Verify(signingString, signature string, key interface{}) error {
-	var err error
-
 	// Decode the signature
-	var sig []byte
-	if sig, err = DecodeSegment(signature); err != nil {
+	sig, err := DecodeSegment(signature)
+	if err != nil {
 		return err
 	}
+	return m.VerifyBytes(signingString, sig, key)
+}
 
+// Implements BytesVerifier.  Verify, with the signature already decoded
+func (m *SigningMethodRSAPSS) VerifyBytes(signingString string, sig []byte, key interface{}) error {
 	var rsaKey *rsa.PublicKey
 	switch k := key.(type) {
 	case *rsa.PublicKey:
@@ -92,7 +94,7 @@ func (m *SigningMethodRSAPSS) Verify(signingString, signature string, key interf
 		return ErrHashUnavailable
 	}
 	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
+	hasher.Write(stringBytes(signingString))
 
 	return rsa.VerifyPSS(rsaKey, m.Hash, hasher.Sum(nil), sig, m.Options)
 }
@@ -115,7 +117,7 @@ func (m *SigningMethodRSAPSS) Sign(signingString string, key interface{}) (strin
 	}
 
 	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
+	hasher.Write(stringBytes(signingString))
 
 	// Sign the string and return the encoded bytes
 	if sigBytes, err := rsa.SignPSS(rand.Reader, rsaKey, m.Hash, hasher.Sum(nil), m.Options); err == nil {
@@ -0,0 +1,51 @@
+package jwt
+
+import (
+	"context"
+)
+
+// The bytes of scratch ParseWithScratch needs to do all its decoding there
+// for a token of tokenLen bytes
+func ScratchSize(tokenLen int) int {
+	n := SegmentEncoding.DecodedLen(tokenLen)
+	if !zeroCopy {
+		n += tokenLen // The copy of the token that is decoded from
+	}
+	return n
+}
+
+// Like ParseWithClaims, but with the token copied and its segments decoded
+// into scratch rather than into buffers of their own.  With scratch of at
+// least ScratchSize(len(tokenString)) bytes, parsing and verifying an HMAC
+// token allocates only what is returned: the Token, its Header and whatever
+// claims decodes into.  Shorter scratch is used while it lasts, and the
+// rest is allocated as ParseWithClaims would.
+//
+// The returned token's RawHeader, RawClaims, Payload and SignatureBytes
+// point into scratch, so reuse scratch only once done with them; claims
+// never do.
+func (p *Parser) ParseWithScratch(scratch []byte, tokenString string, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	return p.parseWithContext(context.Background(), tokenString, claims, keyFunc, &scratch, nil)
+}
+
+// The bytes of tokenString to decode from: in scratch if it has room, and
+// unless they can be had without a copy
+func tokenBytes(scratch *[]byte, tokenString string) []byte {
+	if zeroCopy || scratch == nil || len(*scratch) < len(tokenString) {
+		return stringBytes(tokenString)
+	}
+	b := (*scratch)[:len(tokenString):len(tokenString)]
+	copy(b, tokenString)
+	*scratch = (*scratch)[len(tokenString):]
+	return b
+}
+
+// A buffer of n bytes for decoding a token, from scratch if it has room
+func decodeBuffer(scratch *[]byte, n int) []byte {
+	if scratch == nil || len(*scratch) < n {
+		return make([]byte, n)
+	}
+	buf := (*scratch)[:n:n]
+	*scratch = (*scratch)[n:]
+	return buf
+}
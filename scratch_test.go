@@ -0,0 +1,80 @@
+package jwt_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func scratchToken(t testing.TB) (string, jwt.Keyfunc) {
+	var key interface{} = []byte("secret")
+	claims := &jwt.StandardClaims{Subject: "1234567890", Issuer: "https://auth.example.com"}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenString, func(*jwt.Token) (interface{}, error) { return key, nil }
+}
+
+func TestParser_ParseWithScratch(t *testing.T) {
+	tokenString, keyFunc := scratchToken(t)
+	size := jwt.ScratchSize(len(tokenString))
+
+	// Scratch too short for anything, for part of the decoding, and for all of it
+	for _, n := range []int{0, size / 2, size, 2 * size} {
+		scratch := make([]byte, n)
+		claims := &jwt.StandardClaims{}
+		token, err := new(jwt.Parser).ParseWithScratch(scratch, tokenString, claims, keyFunc)
+		if err != nil || !token.Valid {
+			t.Errorf("[%v] Expected a valid token.  Got %v", n, err)
+			continue
+		}
+
+		// Reusing the scratch mustn't change the claims
+		for i := range scratch {
+			scratch[i] = 0
+		}
+		if claims.Subject != "1234567890" || claims.Issuer != "https://auth.example.com" {
+			t.Errorf("[%v] Expected the signed claims.  Got %+v", n, claims)
+		}
+	}
+
+	if _, err := new(jwt.Parser).ParseWithScratch(make([]byte, size), tokenString+"x", &jwt.StandardClaims{}, keyFunc); err == nil {
+		t.Errorf("Expected a bad signature to be refused")
+	}
+}
+
+func TestParser_ParseWithScratch_allocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful under the race detector")
+	}
+	tokenString, keyFunc := scratchToken(t)
+	scratch := make([]byte, jwt.ScratchSize(len(tokenString)))
+	p := new(jwt.Parser)
+
+	// Without scratch, the token is decoded into one buffer, from one copy
+	// of it unless those are zero-copy: nothing else may be allocated
+	// that isn't returned
+	buffers := 2.0
+	if jwt.ScratchSize(len(tokenString)) == base64.RawURLEncoding.DecodedLen(len(tokenString)) {
+		buffers = 1
+	}
+	plain := testing.AllocsPerRun(100, func() { p.ParseWithClaims(tokenString, &jwt.StandardClaims{}, keyFunc) })
+	withScratch := testing.AllocsPerRun(100, func() { p.ParseWithScratch(scratch, tokenString, &jwt.StandardClaims{}, keyFunc) })
+	if withScratch != plain-buffers {
+		t.Errorf("Expected %v allocations.  Got %v", plain-buffers, withScratch)
+	}
+}
+
+func BenchmarkParseWithScratch(b *testing.B) {
+	tokenString, keyFunc := scratchToken(b)
+	scratch := make([]byte, jwt.ScratchSize(len(tokenString)))
+	p := new(jwt.Parser)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.ParseWithScratch(scratch, tokenString, &jwt.StandardClaims{}, keyFunc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
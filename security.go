@@ -0,0 +1,83 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+)
+
+// Describes a token presentation that may be an attack rather than a
+// mistake: a signature that does not verify, a replayed token or a revoked
+// one.  Code is CodeInvalidSignature, CodeTokenReplayed or CodeTokenRevoked.
+// Alg, Kid and Issuer come from the unverified token and may be empty.
+type SecurityEvent struct {
+	Code   ErrorCode
+	Err    error
+	Alg    string
+	Kid    string
+	Issuer string
+	Source string // Where the token was found, e.g. "header:Authorization", if the caller said with WithTokenSource
+}
+
+// Receives SecurityEvents, e.g. to alert on forged tokens or credential
+// stuffing.  Implementations are called inline, so should hand slow work
+// such as network calls off to another goroutine.
+type SecurityHook interface {
+	OnSecurityEvent(*SecurityEvent)
+}
+
+// Adapter to use an ordinary function as a SecurityHook
+type SecurityHookFunc func(*SecurityEvent)
+
+func (f SecurityHookFunc) OnSecurityEvent(e *SecurityEvent) {
+	f(e)
+}
+
+// SecurityHook used by Parsers that don't set their own, and so by
+// everything that verifies with one.  Set it once at startup.
+var DefaultSecurityHook SecurityHook
+
+type sourceKey struct{}
+
+// Returns a copy of ctx saying where the token about to be parsed with it
+// came from, for SecurityEvent.Source.  Package request does this for its
+// Middleware.
+func WithTokenSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+func (p *Parser) securityHook() SecurityHook {
+	if p != nil && p.SecurityHook != nil {
+		return p.SecurityHook
+	}
+	return DefaultSecurityHook
+}
+
+// Tell hook about err, if it is one that raises a SecurityEvent.  token
+// may be nil.
+func reportSecurityEvent(ctx context.Context, hook SecurityHook, token *Token, err error) {
+	if hook == nil || err == nil {
+		return
+	}
+
+	e := &SecurityEvent{Err: err}
+	var ve *ValidationError
+	switch {
+	case errors.Is(err, ErrTokenReplayed), errors.Is(err, ErrRefreshTokenReused):
+		e.Code = CodeTokenReplayed
+	case errors.Is(err, ErrTokenRevoked):
+		e.Code = CodeTokenRevoked
+	case errors.As(err, &ve) && ve.Errors&ValidationErrorSignatureInvalid != 0:
+		e.Code = CodeInvalidSignature
+	default:
+		return
+	}
+
+	if token != nil && token.Header != nil {
+		c := newTokenContext(token)
+		e.Alg, e.Kid, e.Issuer = c.Alg, c.Kid, c.Issuer
+	}
+	if ctx != nil {
+		e.Source, _ = ctx.Value(sourceKey{}).(string)
+	}
+	hook.OnSecurityEvent(e)
+}
@@ -0,0 +1,75 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestParser_SecurityHook(t *testing.T) {
+	key := []byte("security secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	sign := func(k []byte) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{Issuer: "https://auth.example.com", Id: "jti-1", Subject: "alice"})
+		token.Header["kid"] = "k1"
+		s, err := token.SignedString(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	var events []*jwt.SecurityEvent
+	parser := &jwt.Parser{SecurityHook: jwt.SecurityHookFunc(func(e *jwt.SecurityEvent) { events = append(events, e) })}
+	ctx := jwt.WithTokenSource(context.Background(), "header:Authorization")
+
+	// Accepted tokens and ordinary failures are not events
+	parser.ParseWithContext(ctx, sign(key), &jwt.StandardClaims{}, keyFunc)
+	parser.ParseWithContext(ctx, "not.a.token", &jwt.StandardClaims{}, keyFunc)
+	if len(events) != 0 {
+		t.Fatalf("Expected no events.  Got %+v", events)
+	}
+
+	parser.ParseWithContext(ctx, sign([]byte("forged")), &jwt.StandardClaims{}, keyFunc)
+	parser.Revocation = jwt.RevocationCheckerFunc(func(context.Context, string, string, time.Time) (bool, error) { return true, nil })
+	parser.ParseWithContext(ctx, sign(key), &jwt.StandardClaims{}, keyFunc)
+
+	expect := []jwt.ErrorCode{jwt.CodeInvalidSignature, jwt.CodeTokenRevoked}
+	if len(events) != len(expect) {
+		t.Fatalf("Expected %v events.  Got %+v", len(expect), events)
+	}
+	for i, e := range events {
+		if e.Code != expect[i] || e.Kid != "k1" || e.Issuer != "https://auth.example.com" || e.Alg != "HS256" || e.Source != "header:Authorization" || e.Err == nil {
+			t.Errorf("[%v] Unexpected event %+v", expect[i], e)
+		}
+	}
+}
+
+func TestTokenIssuer_securityHook(t *testing.T) {
+	key := []byte("session secret")
+	var events []*jwt.SecurityEvent
+	issuer := &jwt.TokenIssuer{
+		Method:  jwt.SigningMethodHS256,
+		Key:     key,
+		Keyfunc: func(*jwt.Token) (interface{}, error) { return key, nil },
+		Parser:  &jwt.Parser{SecurityHook: jwt.SecurityHookFunc(func(e *jwt.SecurityEvent) { events = append(events, e) })},
+	}
+
+	pair, err := issuer.Issue("alice")
+	if err != nil {
+		t.Fatalf("Error issuing: %v", err)
+	}
+	if _, err = issuer.Refresh(pair.RefreshToken); err != nil {
+		t.Fatalf("Error refreshing: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("Expected no events.  Got %+v", events)
+	}
+
+	issuer.Refresh(pair.RefreshToken)
+	if len(events) != 1 || events[0].Code != jwt.CodeTokenReplayed || events[0].Alg != "HS256" {
+		t.Errorf("Expected a replay event.  Got %+v", events)
+	}
+}
@@ -0,0 +1,246 @@
+// Package sessions ties tokens to server-side session records, for
+// deployments that need logout to take effect at once rather than when
+// the token expires.
+//
+// Login creates a session and a token whose sid claim names it.  Every
+// request looks the session up, so deleting it with Logout or LogoutAll
+// revokes the tokens issued for it:
+//
+//	manager := &sessions.Manager{Store: sessions.NewMemoryStore(), Method: jwt.SigningMethodHS256, Key: key, Keyfunc: keyFunc}
+//	tokenString, session, err := manager.Login(ctx, "alice", nil)
+//	...
+//	http.Handle("/", manager.Handler(handler))
+//
+// Handlers get the session with FromContext, and the token with
+// jwt.FromContext.
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/request"
+)
+
+// Errors
+var (
+	ErrNotFound     = errors.New("sessions: session not found")
+	ErrNoSessionId  = errors.New("sessions: token has no sid claim")
+	ErrWrongSubject = errors.New("sessions: token subject does not match the session")
+)
+
+// Lifetime of sessions and their tokens if Manager.Lifetime is zero
+const DefaultLifetime = 12 * time.Hour
+
+// A server-side session
+type Session struct {
+	Id        string
+	Subject   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Data      map[string]interface{} // Application data, given to Login
+}
+
+// Implement Store to keep sessions in a database shared by servers.  Get
+// returns ErrNotFound for a session that was deleted or has expired.
+type Store interface {
+	Create(ctx context.Context, s *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	Delete(ctx context.Context, id string) error
+	DeleteSubject(ctx context.Context, subject string) error
+}
+
+// Creates sessions with tokens, and checks tokens against them
+type Manager struct {
+	Store    Store
+	Method   jwt.SigningMethod
+	Key      interface{} // Signing key
+	Keyfunc  jwt.Keyfunc // Supplies the key for verifying tokens
+	Issuer   string      // If set, the iss of tokens, and required of them
+	Lifetime time.Duration
+
+	Parser    *jwt.Parser       // Defaults to a zero Parser
+	Extractor request.Extractor // For Handler.  Defaults to request.AuthorizationHeaderExtractor
+	Realm     string            // Sent in Handler's challenges if set
+}
+
+// Start a session for subject and return it with a token naming it
+func (m *Manager) Login(ctx context.Context, subject string, data map[string]interface{}) (string, *Session, error) {
+	now := jwt.TimeFunc()
+	// The id is looked up as a bearer credential, so it is always random,
+	// and never from jwt.IdFunc
+	s := &Session{
+		Id:        rand.Text(),
+		Subject:   subject,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.lifetime()),
+		Data:      data,
+	}
+	if err := m.Store.Create(ctx, s); err != nil {
+		return "", nil, err
+	}
+
+	tokenString, err := jwt.NewWithClaims(m.Method, &jwt.SessionClaims{
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: s.ExpiresAt.Unix(),
+			Id:        jwt.IdFunc(),
+			IssuedAt:  now.Unix(),
+			Issuer:    m.Issuer,
+			Subject:   subject,
+		},
+		SessionId: s.Id,
+	}).SignedString(m.Key)
+	if err != nil {
+		m.Store.Delete(ctx, s.Id)
+		return "", nil, err
+	}
+	return tokenString, s, nil
+}
+
+// Verify tokenString and return its session.  Fails with ErrNotFound after
+// the session was logged out.
+func (m *Manager) Lookup(ctx context.Context, tokenString string) (*Session, *jwt.Token, error) {
+	parser := m.Parser
+	if parser == nil {
+		parser = new(jwt.Parser)
+	}
+	claims := new(jwt.SessionClaims)
+	token, err := parser.ParseWithContext(ctx, tokenString, claims, m.Keyfunc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.Issuer != "" {
+		if err = jwt.ValidateIssuer(claims, m.Issuer); err != nil {
+			return nil, nil, err
+		}
+	}
+	if claims.SessionId == "" {
+		return nil, nil, ErrNoSessionId
+	}
+
+	s, err := m.Store.Get(ctx, claims.SessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.Subject != claims.Subject {
+		return nil, nil, ErrWrongSubject
+	}
+	return s, token, nil
+}
+
+// End the session, revoking its tokens
+func (m *Manager) Logout(ctx context.Context, id string) error {
+	return m.Store.Delete(ctx, id)
+}
+
+// End every session of subject
+func (m *Manager) LogoutAll(ctx context.Context, subject string) error {
+	return m.Store.DeleteSubject(ctx, subject)
+}
+
+// Wrap next so that it only sees requests with a token of a live session.
+// Others are answered 401 with a Bearer challenge.
+func (m *Manager) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		extractor := m.Extractor
+		if extractor == nil {
+			extractor = request.AuthorizationHeaderExtractor
+		}
+		tokenString, err := extractor.ExtractToken(r)
+		var s *Session
+		var token *jwt.Token
+		if err == nil {
+			s, token, err = m.Lookup(r.Context(), tokenString)
+		}
+		if err != nil {
+			challenge := request.NewBearerChallenge(m.Realm, err)
+			if errors.Is(err, ErrNotFound) || errors.Is(err, ErrNoSessionId) || errors.Is(err, ErrWrongSubject) {
+				challenge.Error = request.BearerErrorInvalidToken
+				challenge.ErrorDescription = "session is not active"
+			}
+			w.Header().Set("WWW-Authenticate", challenge.String())
+			w.WriteHeader(challenge.StatusCode())
+			return
+		}
+
+		ctx := jwt.NewContext(NewContext(r.Context(), s), token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (m *Manager) lifetime() time.Duration {
+	if m.Lifetime == 0 {
+		return DefaultLifetime
+	}
+	return m.Lifetime
+}
+
+type contextKey struct{}
+
+// Returns a copy of ctx carrying s
+func NewContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// The session stored in ctx by Handler
+func FromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(contextKey{}).(*Session)
+	return s, ok
+}
+
+// Store for a single process.  Expired sessions are dropped as new ones
+// are created.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]*Session{}}
+}
+
+func (st *MemoryStore) Create(ctx context.Context, s *Session) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	now := jwt.TimeFunc()
+	for id, old := range st.sessions {
+		if !now.Before(old.ExpiresAt) {
+			delete(st.sessions, id)
+		}
+	}
+	st.sessions[s.Id] = s
+	return nil
+}
+
+func (st *MemoryStore) Get(ctx context.Context, id string) (*Session, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.sessions[id]
+	if !ok || !jwt.TimeFunc().Before(s.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return s, nil
+}
+
+func (st *MemoryStore) Delete(ctx context.Context, id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.sessions, id)
+	return nil
+}
+
+func (st *MemoryStore) DeleteSubject(ctx context.Context, subject string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for id, s := range st.sessions {
+		if s.Subject == subject {
+			delete(st.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package sessions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestManager(t *testing.T) {
+	key := []byte("session secret")
+	m := &Manager{
+		Store:   NewMemoryStore(),
+		Method:  jwt.SigningMethodHS256,
+		Key:     key,
+		Keyfunc: func(*jwt.Token) (interface{}, error) { return key, nil },
+	}
+	ctx := context.Background()
+
+	tokenString, s, err := m.Login(ctx, "alice", map[string]interface{}{"role": "admin"})
+	if err != nil {
+		t.Fatalf("Error logging in: %v", err)
+	}
+	other, _, err := m.Login(ctx, "alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, token, err := m.Lookup(ctx, tokenString)
+	if err != nil || got.Id != s.Id || got.Data["role"] != "admin" || token.Claims.(*jwt.SessionClaims).SessionId != s.Id {
+		t.Fatalf("Error looking up the session: %v", err)
+	}
+
+	var seen []string
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, _ := FromContext(r.Context())
+		token, _ := jwt.FromContext(r.Context())
+		seen = append(seen, s.Subject+" "+token.Claims.(*jwt.SessionClaims).Subject)
+	}))
+	serve := func(tokenString string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tokenString != "" {
+			r.Header.Set("Authorization", "Bearer "+tokenString)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve(tokenString); w.Code != http.StatusOK || len(seen) != 1 || seen[0] != "alice alice" {
+		t.Errorf("Expected the request through.  Got %v %v", w.Code, seen)
+	}
+	if w := serve(""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token.  Got %v", w.Code)
+	}
+
+	// Logout takes effect at once, for that session only
+	if err = m.Logout(ctx, s.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = m.Lookup(ctx, tokenString); err != ErrNotFound {
+		t.Errorf("Expected %v.  Got %v", ErrNotFound, err)
+	}
+	w := serve(tokenString)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Header().Get("WWW-Authenticate"), "session is not active") {
+		t.Errorf("Expected a challenge for a logged out session.  Got %v %v", w.Code, w.Header())
+	}
+	if w := serve(other); w.Code != http.StatusOK {
+		t.Errorf("Expected the other session to live.  Got %v", w.Code)
+	}
+
+	if err = m.LogoutAll(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(other); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected every session to be logged out.  Got %v", w.Code)
+	}
+
+	// Tokens without a session are refused
+	plain, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	if _, _, err = m.Lookup(ctx, plain); err != ErrNoSessionId {
+		t.Errorf("Expected %v.  Got %v", ErrNoSessionId, err)
+	}
+}
+
+// Session ids are looked up as credentials, so they stay random when
+// jwt.IdFunc is fixed for reproducible tokens
+func TestManager_IdFunc(t *testing.T) {
+	prev := jwt.IdFunc
+	defer func() { jwt.IdFunc = prev }()
+	jwt.IdFunc = func() string { return "fixed" }
+
+	key := []byte("session secret")
+	m := &Manager{Store: NewMemoryStore(), Method: jwt.SigningMethodHS256, Key: key, Keyfunc: func(*jwt.Token) (interface{}, error) { return key, nil }}
+	_, first, err := m.Login(context.Background(), "alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := m.Login(context.Background(), "bob", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Id == "fixed" || first.Id == second.Id {
+		t.Errorf("Expected random session ids.  Got %v and %v", first.Id, second.Id)
+	}
+}
@@ -0,0 +1,167 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// The typ of a Security Event Token, RFC 8417 section 2.3
+const SecurityEventType = "secevent+jwt"
+
+var (
+	ErrTokenInvalidType = newError(CodeInvalidHeader, "token has an unexpected typ")
+)
+
+// Events of a SET, keyed by event type URI, e.g.
+// "https://schemas.openid.net/secevent/caep/event-type/session-revoked"
+type SecurityEvents map[string]interface{}
+
+// Decode the payload of eventType into v.  Fails if the SET does not carry
+// that event.
+func (e SecurityEvents) Decode(eventType string, v interface{}) error {
+	payload, ok := e[eventType]
+	if !ok {
+		return errors.New("SET has no " + eventType + " event")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Claims of a Security Event Token, as used by CAEP and the Shared Signals
+// Framework.  Unlike an access token, a SET describes something that has
+// happened, so iat, iss and jti are required and exp is optional.
+type SecurityEventClaims struct {
+	Issuer        string                 `json:"iss"`
+	IssuedAt      int64                  `json:"iat"`
+	Id            string                 `json:"jti"`
+	Audience      ClaimStrings           `json:"aud,omitempty"`
+	Subject       string                 `json:"sub,omitempty"`
+	SubjectId     map[string]interface{} `json:"sub_id,omitempty"` // Subject identifier, RFC 9493
+	ExpiresAt     int64                  `json:"exp,omitempty"`
+	TransactionId string                 `json:"txn,omitempty"` // Correlates SETs for the same transaction
+	TimeOfEvent   int64                  `json:"toe,omitempty"` // When the event happened
+	Events        SecurityEvents         `json:"events"`
+}
+
+// Start a SET from issuer to audience, issued now with a jti from IdFunc.  Add
+// events with AddEvent and sign it with NewSecurityEventToken.
+func NewSecurityEvent(issuer string, audience ...string) *SecurityEventClaims {
+	return &SecurityEventClaims{
+		Issuer:   issuer,
+		IssuedAt: TimeFunc().Unix(),
+		Id:       IdFunc(),
+		Audience: audience,
+		Events:   SecurityEvents{},
+	}
+}
+
+// Add an event of eventType, with payload, and return c for chaining.  A
+// nil payload is sent as an empty object.
+func (c *SecurityEventClaims) AddEvent(eventType string, payload interface{}) *SecurityEventClaims {
+	if payload == nil {
+		payload = struct{}{}
+	}
+	if c.Events == nil {
+		c.Events = SecurityEvents{}
+	}
+	c.Events[eventType] = payload
+	return c
+}
+
+// Failures of SecurityEventClaims.Valid, made once rather than per token
+var (
+	errSETNoIssuer   = errors.New("SET has no iss claim")
+	errSETNoIssuedAt = errors.New("SET has no iat claim")
+	errSETNoId       = errors.New("SET has no jti claim")
+	errSETNoEvents   = errors.New("SET has no events")
+)
+
+// Validates the SET rules of RFC 8417 section 2.2: iss, iat, jti and at
+// least one event are required, iat must not be in the future and exp is
+// checked only if present.
+func (c *SecurityEventClaims) Valid() error {
+	var vErr ValidationError
+	now := TimeFunc().Unix()
+
+	if c.Issuer == "" {
+		vErr.add(errSETNoIssuer, ValidationErrorIssuer)
+	}
+	if c.IssuedAt == 0 {
+		vErr.add(errSETNoIssuedAt, ValidationErrorIssuedAt)
+	} else if !verifyIat(c.IssuedAt, now, true) {
+		vErr.add(newIssuedAtError(c.IssuedAt, now), ValidationErrorIssuedAt)
+	}
+	if c.Id == "" {
+		vErr.add(errSETNoId, ValidationErrorId)
+	}
+	if !verifyExp(c.ExpiresAt, now, false) {
+		vErr.add(newExpiredError(c.ExpiresAt, now), ValidationErrorExpired)
+	}
+	if len(c.Events) == 0 {
+		vErr.add(errSETNoEvents, ValidationErrorClaimsInvalid)
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+	return vErr.escape()
+}
+
+// Returns the aud claim
+func (c *SecurityEventClaims) GetAudience() []string {
+	return c.Audience
+}
+
+// Returns the iss claim
+func (c *SecurityEventClaims) GetIssuer() string {
+	return c.Issuer
+}
+
+// Returns the jti claim
+func (c *SecurityEventClaims) GetId() string {
+	return c.Id
+}
+
+// A token for claims with typ set to secevent+jwt, ready to sign
+func NewSecurityEventToken(method SigningMethod, claims *SecurityEventClaims) *Token {
+	return NewWithClaims(method, claims).WithHeader("typ", SecurityEventType)
+}
+
+// Parse and verify a SET.  typ must be secevent+jwt, so other tokens from
+// the same issuer cannot be passed off as events.  When audience is not
+// empty, aud must contain it.
+func ParseSecurityEvent(tokenString string, audience string, keyFunc Keyfunc) (*SecurityEventClaims, error) {
+	return new(Parser).ParseSecurityEvent(tokenString, audience, keyFunc)
+}
+
+// ParseSecurityEvent, with the options of the Parser
+func (p *Parser) ParseSecurityEvent(tokenString string, audience string, keyFunc Keyfunc) (*SecurityEventClaims, error) {
+	claims := new(SecurityEventClaims)
+	token, err := p.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkType(token, SecurityEventType); err != nil {
+		return nil, err
+	}
+	if audience != "" {
+		if err = ValidateAudience(claims, audience); err != nil {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorAudience}
+		}
+	}
+	return claims, nil
+}
+
+// Check the typ header for explicit typing, RFC 8725 section 3.11.  The
+// comparison ignores case and an application/ prefix.
+func checkType(token *Token, typ string) error {
+	got, _ := token.Header["typ"].(string)
+	if !strings.EqualFold(strings.TrimPrefix(strings.ToLower(got), "application/"), typ) {
+		return &ValidationError{Inner: ErrTokenInvalidType, Errors: ValidationErrorMalformed}
+	}
+	return nil
+}
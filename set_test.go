@@ -0,0 +1,97 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const sessionRevoked = "https://schemas.openid.net/secevent/caep/event-type/session-revoked"
+
+func TestSecurityEvent(t *testing.T) {
+	key := []byte("transmitter secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	claims := jwt.NewSecurityEvent("https://idp.example.com", "https://rp.example.com").
+		AddEvent(sessionRevoked, map[string]interface{}{"event_timestamp": 1615304991})
+	claims.TransactionId = "txn-1"
+	s, err := jwt.NewSecurityEventToken(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+
+	got, err := jwt.ParseSecurityEvent(s, "https://rp.example.com", keyFunc)
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	var event struct {
+		Timestamp int64 `json:"event_timestamp"`
+	}
+	if err := got.Events.Decode(sessionRevoked, &event); err != nil || event.Timestamp != 1615304991 {
+		t.Errorf("Unexpected event %v: %v", event, err)
+	}
+	if got.Id == "" || got.Id != claims.Id || got.TransactionId != "txn-1" {
+		t.Errorf("Unexpected claims %+v", got)
+	}
+	if err := got.Events.Decode("urn:example:other", &event); err == nil {
+		t.Errorf("Expected decoding a missing event to fail")
+	}
+
+	if _, err := jwt.ParseSecurityEvent(s, "https://other.example.com", keyFunc); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInvalidAudience, err)
+	}
+
+	// An access token from the same issuer is not an event
+	access, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if _, err := jwt.ParseSecurityEvent(access, "", keyFunc); !errors.Is(err, jwt.ErrTokenInvalidType) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInvalidType, err)
+	}
+}
+
+func TestSecurityEventClaims_Valid(t *testing.T) {
+	now := time.Now().Unix()
+	events := jwt.SecurityEvents{sessionRevoked: map[string]interface{}{}}
+
+	var securityEventTestData = []struct {
+		name   string
+		claims *jwt.SecurityEventClaims
+		err    error
+	}{
+		{"valid", &jwt.SecurityEventClaims{Issuer: "iss", IssuedAt: now, Id: "1", Events: events}, nil},
+		{"no exp is fine", &jwt.SecurityEventClaims{Issuer: "iss", IssuedAt: now - 86400, Id: "1", Events: events}, nil},
+		{"expired", &jwt.SecurityEventClaims{Issuer: "iss", IssuedAt: now, Id: "1", ExpiresAt: now - 10, Events: events}, jwt.ErrTokenExpired},
+		{"no iat", &jwt.SecurityEventClaims{Issuer: "iss", Id: "1", Events: events}, jwt.ErrTokenUsedBeforeIssued},
+		{"future iat", &jwt.SecurityEventClaims{Issuer: "iss", IssuedAt: now + 100, Id: "1", Events: events}, jwt.ErrTokenUsedBeforeIssued},
+		{"no jti", &jwt.SecurityEventClaims{Issuer: "iss", IssuedAt: now, Events: events}, jwt.ErrTokenInvalidId},
+		{"no iss", &jwt.SecurityEventClaims{IssuedAt: now, Id: "1", Events: events}, jwt.ErrTokenInvalidIssuer},
+		{"no events", &jwt.SecurityEventClaims{Issuer: "iss", IssuedAt: now, Id: "1"}, jwt.ErrTokenInvalidClaims},
+	}
+
+	for _, data := range securityEventTestData {
+		err := data.claims.Valid()
+		if (data.err == nil) != (err == nil) || (data.err != nil && !errors.Is(err, data.err)) {
+			t.Errorf("[%v] Expected %v.  Got %v", data.name, data.err, err)
+		}
+	}
+}
+
+func TestClaimStrings(t *testing.T) {
+	var claims struct {
+		Aud jwt.ClaimStrings `json:"aud"`
+	}
+	for _, in := range []string{`{"aud":"a"}`, `{"aud":["a"]}`} {
+		if err := json.Unmarshal([]byte(in), &claims); err != nil || len(claims.Aud) != 1 || claims.Aud[0] != "a" {
+			t.Errorf("[%v] Unexpected %v: %v", in, claims.Aud, err)
+		}
+	}
+	if out, _ := json.Marshal(claims); string(out) != `{"aud":"a"}` {
+		t.Errorf("Expected single audience as a string.  Got %s", out)
+	}
+	claims.Aud = jwt.ClaimStrings{"a", "b"}
+	if out, _ := json.Marshal(claims); string(out) != `{"aud":["a","b"]}` {
+		t.Errorf("Expected audiences as an array.  Got %s", out)
+	}
+}
@@ -14,6 +14,37 @@ type SigningMethod interface {
 	Alg() string                                                   // returns the alg identifier for this method (example: 'HS256')
 }
 
+// Implemented by the package's methods, which can check a signature that
+// is already decoded.  Parsers pass Token.SignatureBytes to the package's
+// own method types, saving the second base64 decode Verify would do.
+// Other methods, including types that embed one of the package's and
+// override Verify, are always checked with Verify.
+type BytesVerifier interface {
+	VerifyBytes(signingString string, sig []byte, key interface{}) error
+}
+
+// Verify the signature of a parsed token with method, from its
+// SignatureBytes if method is one of the package's own types.  The switch
+// is on exact types, as a type embedding one of them would otherwise have
+// its Verify skipped for the promoted VerifyBytes.
+func verifyToken(method SigningMethod, signingString string, token *Token, key interface{}) error {
+	if sig := token.SignatureBytes; sig != nil {
+		switch m := method.(type) {
+		case *SigningMethodHMAC:
+			return m.VerifyBytes(signingString, sig, key)
+		case *SigningMethodRSA:
+			return m.VerifyBytes(signingString, sig, key)
+		case *SigningMethodRSAPSS:
+			return m.VerifyBytes(signingString, sig, key)
+		case *SigningMethodECDSA:
+			return m.VerifyBytes(signingString, sig, key)
+		case *SigningMethodEd25519:
+			return m.VerifyBytes(signingString, sig, key)
+		}
+	}
+	return method.Verify(signingString, token.Signature, key)
+}
+
 // Register the "alg" name and a factory function for signing method.
 // This is typically done during init() in the method's implementation
 func RegisterSigningMethod(alg string, f func() SigningMethod) {
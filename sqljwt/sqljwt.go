@@ -0,0 +1,183 @@
+// Package sqljwt keeps token revocations in a SQL database through
+// database/sql, for servers that must refuse a token before it expires.
+//
+// Tokens are revoked by jti, or by subject: every token of the subject
+// issued before a cutoff, as on a password change or logout everywhere.
+// The Store is a jwt.RevocationChecker; wrap it in a jwt.RevocationCache
+// so busy tokens don't cost a query per request:
+//
+//	store := sqljwt.NewStore(db)
+//	if err := store.Migrate(ctx); err != nil { ... }
+//	parser := &jwt.Parser{Revocation: jwt.NewRevocationCache(store, 10*time.Second)}
+//
+// The SQL is plain enough for PostgreSQL, MySQL and SQLite.  Set Dollar
+// for drivers that want $1 placeholders.
+package sqljwt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Errors
+var (
+	ErrInvalidTable = errors.New("sqljwt: table name is not a plain identifier")
+	ErrEmptyName    = errors.New("sqljwt: jti or subject is empty")
+)
+
+// Table used if Store.Table is empty
+const DefaultTable = "jwt_revocations"
+
+// Kinds of revocation, in the kind column
+const (
+	kindToken   = "jti"
+	kindSubject = "sub"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Revocations in a table of DB.  Rows can be deleted with Purge once the
+// tokens they revoke have expired.
+type Store struct {
+	DB     *sql.DB
+	Table  string // Defaults to DefaultTable.  May be schema qualified
+	Dollar bool   // Use $1 placeholders, as PostgreSQL does, rather than ?
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{DB: db}
+}
+
+// The statements that create the table, for use with a migration tool.
+// They are safe to run again.
+func (s *Store) Schema() ([]string, error) {
+	table, err := s.table()
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		"CREATE TABLE IF NOT EXISTS " + table + ` (
+	kind CHAR(3) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	issued_before BIGINT NOT NULL,
+	expires_at BIGINT NOT NULL,
+	PRIMARY KEY (kind, name)
+)`,
+	}, nil
+}
+
+// Create the table if it doesn't exist
+func (s *Store) Migrate(ctx context.Context) error {
+	statements, err := s.Schema()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		if _, err = s.DB.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Revoke the token with jti id, which expires at expiresAt
+func (s *Store) RevokeToken(ctx context.Context, id string, expiresAt time.Time) error {
+	return s.revoke(ctx, kindToken, id, time.Time{}, expiresAt)
+}
+
+// Revoke every token of subject issued before issuedBefore, including
+// those without an iat.  The revocation is kept until until, which should
+// be at least issuedBefore plus the longest lifetime of a token.
+func (s *Store) RevokeSubject(ctx context.Context, subject string, issuedBefore, until time.Time) error {
+	return s.revoke(ctx, kindSubject, subject, issuedBefore, until)
+}
+
+func (s *Store) revoke(ctx context.Context, kind, name string, issuedBefore, until time.Time) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, s.query("DELETE FROM %s WHERE kind = ? AND name = ?", table), kind, name); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, s.query("INSERT INTO %s (kind, name, issued_before, expires_at) VALUES (?, ?, ?, ?)", table),
+		kind, name, issuedBefore.Unix(), until.Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) IsRevoked(ctx context.Context, id, subject string, issuedAt time.Time) (bool, error) {
+	table, err := s.table()
+	if err != nil {
+		return false, err
+	}
+
+	var n int
+	err = s.DB.QueryRowContext(ctx, s.query(`SELECT COUNT(*) FROM %s WHERE expires_at > ? AND `+
+		`((kind = 'jti' AND name = ?) OR (kind = 'sub' AND name = ? AND issued_before > ?))`, table),
+		jwt.TimeFunc().Unix(), id, subject, issuedAt.Unix()).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Delete revocations that have run out, returning how many
+func (s *Store) Purge(ctx context.Context) (int64, error) {
+	table, err := s.table()
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.DB.ExecContext(ctx, s.query("DELETE FROM %s WHERE expires_at <= ?", table), jwt.TimeFunc().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) table() (string, error) {
+	if s.Table == "" {
+		return DefaultTable, nil
+	}
+	if !identifier.MatchString(s.Table) {
+		return "", ErrInvalidTable
+	}
+	return s.Table, nil
+}
+
+// Fill in the table and number the placeholders if Dollar is set
+func (s *Store) query(format, table string) string {
+	q := fmt.Sprintf(format, table)
+	if !s.Dollar {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,179 @@
+package sqljwt
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// A driver that understands just the statements of Store, keeping the
+// table in memory
+type fakeDriver struct {
+	mu      sync.Mutex
+	created bool
+	rows    map[[2]string][2]int64 // kind, name: issued_before, expires_at
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.d, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS jwt_revocations"):
+		d.created = true
+		return driver.RowsAffected(0), nil
+	case strings.HasSuffix(s.query, "WHERE kind = ? AND name = ?"):
+		delete(d.rows, [2]string{args[0].(string), args[1].(string)})
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "INSERT INTO jwt_revocations"):
+		d.rows[[2]string{args[0].(string), args[1].(string)}] = [2]int64{args[2].(int64), args[3].(int64)}
+		return driver.RowsAffected(1), nil
+	case strings.HasSuffix(s.query, "WHERE expires_at <= ?"):
+		n := int64(0)
+		for k, v := range d.rows {
+			if v[1] <= args[0].(int64) {
+				delete(d.rows, k)
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	}
+	return nil, errors.New("unexpected statement: " + s.query)
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.query, "SELECT COUNT(*) FROM jwt_revocations") {
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+	d := s.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now, id, subject, iat := args[0].(int64), args[1].(string), args[2].(string), args[3].(int64)
+	n := int64(0)
+	if v, ok := d.rows[[2]string{kindToken, id}]; ok && v[1] > now {
+		n++
+	}
+	if v, ok := d.rows[[2]string{kindSubject, subject}]; ok && v[1] > now && v[0] > iat {
+		n++
+	}
+	return &fakeRows{n: n}, nil
+}
+
+type fakeRows struct {
+	n    int64
+	done bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"count"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.n
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	d := &fakeDriver{rows: map[[2]string][2]int64{}}
+	sql.Register("sqljwt-fake", d)
+	db, err := sql.Open("sqljwt-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+	if err = store.Migrate(ctx); err != nil || !d.created {
+		t.Fatalf("Error migrating: %v", err)
+	}
+
+	now := time.Now()
+	if err = store.RevokeToken(ctx, "stolen", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.RevokeSubject(ctx, "bob", now, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.RevokeToken(ctx, "", now.Add(time.Hour)); err != ErrEmptyName {
+		t.Errorf("Expected %v.  Got %v", ErrEmptyName, err)
+	}
+
+	var storeTestData = []struct {
+		name    string
+		id      string
+		subject string
+		iat     time.Time
+		revoked bool
+	}{
+		{"by jti", "stolen", "alice", now, true},
+		{"other jti", "fine", "alice", now, false},
+		{"issued before cutoff", "1", "bob", now.Add(-time.Minute), true},
+		{"issued after cutoff", "2", "bob", now.Add(time.Minute), false},
+		{"no iat", "3", "bob", time.Time{}, true},
+	}
+	for _, data := range storeTestData {
+		revoked, err := store.IsRevoked(ctx, data.id, data.subject, data.iat)
+		if err != nil || revoked != data.revoked {
+			t.Errorf("[%v] Expected %v.  Got %v %v", data.name, data.revoked, revoked, err)
+		}
+	}
+
+	// Expired revocations are ignored, then purged
+	defer func() { jwt.TimeFunc = time.Now }()
+	jwt.TimeFunc = func() time.Time { return now.Add(2 * time.Hour) }
+	if revoked, _ := store.IsRevoked(ctx, "stolen", "", now); revoked {
+		t.Errorf("Expected an expired revocation to be ignored")
+	}
+	if n, err := store.Purge(ctx); n != 2 || err != nil {
+		t.Errorf("Expected 2 rows purged.  Got %v %v", n, err)
+	}
+}
+
+func TestStore_query(t *testing.T) {
+	s := &Store{Dollar: true}
+	if got := s.query("DELETE FROM %s WHERE kind = ? AND name = ?", "t"); got != "DELETE FROM t WHERE kind = $1 AND name = $2" {
+		t.Errorf("Unexpected query %v", got)
+	}
+	for _, table := range []string{"revocations; DROP TABLE users", "a b", "1abc"} {
+		s.Table = table
+		if _, err := s.Schema(); err != ErrInvalidTable {
+			t.Errorf("[%v] Expected %v.  Got %v", table, ErrInvalidTable, err)
+		}
+	}
+	s.Table = "auth.revocations"
+	if _, err := s.Schema(); err != nil {
+		t.Errorf("Expected a schema qualified table to be accepted.  Got %v", err)
+	}
+}
@@ -0,0 +1,411 @@
+package jwt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"io"
+	"math/big"
+)
+
+// Streaming signing and verification of compact JWS, for payloads such as
+// signed file manifests that are too large to buffer.  The payload is
+// base64url encoded and hashed as it passes through, so it is never held
+// in memory.  Only methods that sign a digest can stream: HMAC, RSA,
+// RSA-PSS and ECDSA, but not EdDSA.  JWE is not covered, as its AES-GCM
+// content encryption needs the whole plaintext.
+
+var (
+	ErrStreamingUnsupported = newError(CodeUnsupportedAlgorithm, "signing method cannot sign or verify a stream")
+)
+
+// Writes a compact JWS to an io.Writer as its payload is written to it.
+// Close must be called to write the signature.
+type StreamSigner struct {
+	w       io.Writer
+	method  SigningMethod
+	key     interface{}
+	hasher  hash.Hash
+	encoder io.WriteCloser
+}
+
+// Start a compact JWS on w signed with method and key.  The header
+// segment is written immediately; header may add parameters such as kid
+// or cty.
+func NewStreamSigner(w io.Writer, method SigningMethod, key interface{}, header map[string]interface{}) (*StreamSigner, error) {
+	hasher, err := newDigest(method, key)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := encodeStreamHeader(method, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.WriteString(w, protected); err != nil {
+		return nil, err
+	}
+	hasher.Write([]byte(protected))
+
+	s := &StreamSigner{w: w, method: method, key: key, hasher: hasher}
+	s.encoder = base64.NewEncoder(base64.RawURLEncoding, io.MultiWriter(w, hasher))
+	return s, nil
+}
+
+// Write payload bytes
+func (s *StreamSigner) Write(p []byte) (int, error) {
+	return s.encoder.Write(p)
+}
+
+// Finish the payload and write the signature segment.  Does not close the
+// underlying writer.
+func (s *StreamSigner) Close() error {
+	if err := s.encoder.Close(); err != nil {
+		return err
+	}
+	sig, err := signDigest(s.method, s.hasher.Sum(nil), s.key)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(s.w, "."+sig)
+	return err
+}
+
+// SignDetached, reading content from r instead of memory
+func SignDetachedStream(method SigningMethod, key interface{}, content io.Reader, header map[string]interface{}) (string, error) {
+	hasher, err := newDigest(method, key)
+	if err != nil {
+		return "", err
+	}
+	protected, err := encodeStreamHeader(method, header)
+	if err != nil {
+		return "", err
+	}
+	hasher.Write([]byte(protected))
+
+	encoder := base64.NewEncoder(base64.RawURLEncoding, hasher)
+	if _, err = io.Copy(encoder, content); err != nil {
+		return "", err
+	}
+	encoder.Close()
+
+	sig, err := signDigest(method, hasher.Sum(nil), key)
+	if err != nil {
+		return "", err
+	}
+	return protected[:len(protected)-1] + ".." + sig, nil
+}
+
+// VerifyDetached, reading content from r instead of memory
+func VerifyDetachedStream(tokenString string, content io.Reader, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).VerifyDetachedStream(tokenString, content, keyFunc)
+}
+
+func (p *Parser) VerifyDetachedStream(tokenString string, content io.Reader, keyFunc Keyfunc) (*Token, error) {
+	return p.reported(context.Background(), func(ctx context.Context) (*Token, error) {
+		return p.verifyDetachedStream(ctx, tokenString, content, keyFunc)
+	})
+}
+
+func (p *Parser) verifyDetachedStream(ctx context.Context, tokenString string, content io.Reader, keyFunc Keyfunc) (*Token, error) {
+	token, parts, err := p.parseDetached(ctx, tokenString)
+	if err != nil {
+		return token, err
+	}
+	if parts[1] != "" {
+		return token, NewValidationError("token payload segment is not empty", ValidationErrorMalformed)
+	}
+	if err = checkCritical(token.Header, p.CriticalHeaders); err != nil {
+		return token, err
+	}
+
+	return token, p.verifyStream(token, parts[0], keyFunc, func(hasher hash.Hash) error {
+		encoder := base64.NewEncoder(base64.RawURLEncoding, hasher)
+		if _, err := io.Copy(encoder, content); err != nil {
+			return err
+		}
+		return encoder.Close()
+	})
+}
+
+// Read a compact JWS from r, writing its decoded payload to payload and
+// verifying the signature at the end.  The payload is written before it is
+// verified, so it must be discarded if an error is returned.  The header
+// and signature segments are limited to Parser.MaxTokenLength.  The
+// payload is not interpreted as claims, so the returned Token has empty
+// Claims.
+func VerifyStream(r io.Reader, payload io.Writer, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).VerifyStream(r, payload, keyFunc)
+}
+
+func (p *Parser) VerifyStream(r io.Reader, payload io.Writer, keyFunc Keyfunc) (*Token, error) {
+	return p.reported(context.Background(), func(ctx context.Context) (*Token, error) {
+		return p.readStream(ctx, r, payload, keyFunc)
+	})
+}
+
+func (p *Parser) readStream(ctx context.Context, r io.Reader, payload io.Writer, keyFunc Keyfunc) (*Token, error) {
+	br := bufio.NewReader(r)
+	max := p.maxTokenLength()
+
+	protected, err := readSegment(br, max, true)
+	if err != nil {
+		return nil, err
+	}
+	token, _, err := p.parseDetached(ctx, protected+"..")
+	if err != nil {
+		return token, err
+	}
+	token.Raw = ""
+	if err = checkCritical(token.Header, p.CriticalHeaders); err != nil {
+		return token, err
+	}
+
+	return token, p.verifyStream(token, protected, keyFunc, func(hasher hash.Hash) error {
+		seg := &segmentReader{br: br}
+		decoder := base64.NewDecoder(base64.RawURLEncoding, io.TeeReader(seg, hasher))
+		if _, err := io.Copy(payload, decoder); err != nil {
+			if _, ok := err.(base64.CorruptInputError); ok {
+				return &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+			}
+			return err
+		}
+		if !seg.sawDot {
+			return NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+		}
+
+		if token.Signature, err = readSegment(br, max, false); err != nil {
+			return err
+		}
+		if sig, err := DecodeSegment(token.Signature); err == nil {
+			token.SignatureBytes = sig
+		}
+		return nil
+	})
+}
+
+// Look up the key, hash protected "." and whatever writePayload adds, and
+// verify token's signature over the digest
+func (p *Parser) verifyStream(token *Token, protected string, keyFunc Keyfunc, writePayload func(hash.Hash) error) error {
+	key, err := p.lookupKey(token, keyFunc)
+	if err != nil {
+		return err
+	}
+	hasher, err := newDigest(token.Method, key)
+	if err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+	}
+	hasher.Write([]byte(protected + "."))
+	if err = writePayload(hasher); err != nil {
+		return err
+	}
+
+	if err = verifyDigest(token.Method, hasher.Sum(nil), token.Signature, key); err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorSignatureInvalid}
+	}
+	token.Valid = true
+	return nil
+}
+
+// ----- helpers
+
+// The encoded header segment, with its trailing "."
+func encodeStreamHeader(method SigningMethod, header map[string]interface{}) (string, error) {
+	h := map[string]interface{}{"alg": method.Alg()}
+	for k, v := range header {
+		h[k] = v
+	}
+	if err := checkCriticalForSigning(h); err != nil {
+		return "", err
+	}
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	return EncodeSegment(headerJSON) + ".", nil
+}
+
+// Read a segment of at most max bytes, up to a "." when dot is set or the
+// end of input otherwise
+func readSegment(br *bufio.Reader, max int, dot bool) (string, error) {
+	var buf bytes.Buffer
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF && !dot {
+			return buf.String(), nil
+		}
+		if err == io.EOF {
+			return "", NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+		}
+		if err != nil {
+			return "", err
+		}
+		if c == '.' {
+			if dot {
+				return buf.String(), nil
+			}
+			return "", NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+		}
+		if max >= 0 && buf.Len() >= max {
+			return "", NewValidationError("token is too long", ValidationErrorMalformed)
+		}
+		buf.WriteByte(c)
+	}
+}
+
+// Reads from br up to, but not including, the next "."
+type segmentReader struct {
+	br     *bufio.Reader
+	buf    []byte
+	sawDot bool
+	err    error
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	if len(s.buf) == 0 {
+		if s.sawDot || s.err != nil {
+			return 0, io.EOF
+		}
+		var err error
+		s.buf, err = s.br.ReadSlice('.')
+		switch err {
+		case nil:
+			s.buf = s.buf[:len(s.buf)-1]
+			s.sawDot = true
+		case bufio.ErrBufferFull:
+		default:
+			s.err = err
+			if err != io.EOF {
+				return 0, err
+			}
+		}
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+// The hash a streaming signature over method is computed with
+func newDigest(method SigningMethod, key interface{}) (hash.Hash, error) {
+	var h crypto.Hash
+	switch m := method.(type) {
+	case *SigningMethodHMAC:
+		keyBytes, ok := hmacSecret(key)
+		if !ok {
+			return nil, ErrInvalidKeyType
+		}
+		if !m.Hash.Available() {
+			return nil, ErrHashUnavailable
+		}
+		return hmac.New(m.Hash.New, keyBytes), nil
+	case *SigningMethodRSA:
+		h = m.Hash
+	case *SigningMethodRSAPSS:
+		h = m.Hash
+	case *SigningMethodECDSA:
+		h = m.Hash
+	default:
+		return nil, ErrStreamingUnsupported
+	}
+	if !h.Available() {
+		return nil, ErrHashUnavailable
+	}
+	return h.New(), nil
+}
+
+// Sign the digest from newDigest, as method.Sign would the signing string
+func signDigest(method SigningMethod, digest []byte, key interface{}) (string, error) {
+	var sig []byte
+	var err error
+	switch m := method.(type) {
+	case *SigningMethodHMAC:
+		sig = digest
+	case *SigningMethodRSA:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", ErrInvalidKey
+		}
+		sig, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, m.Hash, digest)
+	case *SigningMethodRSAPSS:
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", ErrInvalidKeyType
+		}
+		sig, err = rsa.SignPSS(rand.Reader, rsaKey, m.Hash, digest, m.Options)
+	case *SigningMethodECDSA:
+		ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return "", ErrInvalidKeyType
+		}
+		if ecdsaKey.Curve.Params().BitSize != m.CurveBits {
+			return "", ErrInvalidKey
+		}
+		var r, s *big.Int
+		if r, s, err = ecdsa.Sign(rand.Reader, ecdsaKey, digest); err == nil {
+			sig = make([]byte, 2*m.KeySize)
+			r.FillBytes(sig[:m.KeySize])
+			s.FillBytes(sig[m.KeySize:])
+		}
+	default:
+		return "", ErrStreamingUnsupported
+	}
+	if err != nil {
+		return "", err
+	}
+	return EncodeSegment(sig), nil
+}
+
+// Verify signature over the digest from newDigest, as method.Verify would
+// over the signing string
+func verifyDigest(method SigningMethod, digest []byte, signature string, key interface{}) error {
+	sig, err := DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	return verifyDigestBytes(method, digest, sig, key)
+}
+
+// verifyDigest, with the signature already decoded
+func verifyDigestBytes(method SigningMethod, digest, sig []byte, key interface{}) error {
+	switch m := method.(type) {
+	case *SigningMethodHMAC:
+		if !hmac.Equal(sig, digest) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	case *SigningMethodRSA:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidKeyType
+		}
+		return rsa.VerifyPKCS1v15(rsaKey, m.Hash, digest, sig)
+	case *SigningMethodRSAPSS:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidKey
+		}
+		return rsa.VerifyPSS(rsaKey, m.Hash, digest, sig, m.Options)
+	case *SigningMethodECDSA:
+		ecdsaKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrInvalidKeyType
+		}
+		if len(sig) != 2*m.KeySize {
+			return ErrECDSAVerification
+		}
+		r := new(big.Int).SetBytes(sig[:m.KeySize])
+		s := new(big.Int).SetBytes(sig[m.KeySize:])
+		if !ecdsa.Verify(ecdsaKey, digest, r, s) {
+			return ErrECDSAVerification
+		}
+		return nil
+	}
+	return ErrStreamingUnsupported
+}
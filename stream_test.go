@@ -0,0 +1,131 @@
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestStreamSigner(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	hmacKey := []byte("stream secret")
+
+	var streamTestData = []struct {
+		method jwt.SigningMethod
+		sign   interface{}
+		verify interface{}
+	}{
+		{jwt.SigningMethodHS256, hmacKey, hmacKey},
+		{jwt.SigningMethodRS256, rsaKey, &rsaKey.PublicKey},
+		{jwt.SigningMethodPS384, rsaKey, &rsaKey.PublicKey},
+		{jwt.SigningMethodES384, ecKey, &ecKey.PublicKey},
+	}
+
+	payload := bytes.Repeat([]byte("file manifest entry\n"), 100000)
+	for _, data := range streamTestData {
+		name := data.method.Alg()
+		keyFunc := func(*jwt.Token) (interface{}, error) { return data.verify, nil }
+
+		var out bytes.Buffer
+		s, err := jwt.NewStreamSigner(&out, data.method, data.sign, map[string]interface{}{"cty": "manifest"})
+		if err != nil {
+			t.Errorf("[%v] Error creating signer: %v", name, err)
+			continue
+		}
+		for i := 0; i < len(payload); i += 4093 {
+			end := i + 4093
+			if end > len(payload) {
+				end = len(payload)
+			}
+			s.Write(payload[i:end])
+		}
+		if err = s.Close(); err != nil {
+			t.Errorf("[%v] Error signing: %v", name, err)
+			continue
+		}
+		tokenString := out.String()
+
+		// The result is an ordinary compact JWS
+		parts := strings.Split(tokenString, ".")
+		if len(parts) != 3 || data.method.Verify(parts[0]+"."+parts[1], parts[2], data.verify) != nil {
+			t.Errorf("[%v] Expected the token to verify as a whole", name)
+		}
+
+		var got bytes.Buffer
+		token, err := jwt.VerifyStream(strings.NewReader(tokenString), &got, keyFunc)
+		if err != nil || !token.Valid || token.Header["cty"] != "manifest" {
+			t.Errorf("[%v] Error verifying: %v", name, err)
+		}
+		if !bytes.Equal(got.Bytes(), payload) {
+			t.Errorf("[%v] Payload differs, %v bytes for %v", name, got.Len(), len(payload))
+		}
+
+		tampered := parts[0] + "." + parts[1][:100] + "x" + parts[1][101:] + "." + parts[2]
+		if _, err = jwt.VerifyStream(strings.NewReader(tampered), new(bytes.Buffer), keyFunc); !jwt.IsSignatureInvalid(err) {
+			t.Errorf("[%v] Expected a modified payload to fail.  Got %v", name, err)
+		}
+	}
+}
+
+func TestDetachedStream(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return &privateKey.PublicKey, nil }
+	content := bytes.Repeat([]byte("a large document "), 100000)
+
+	s, err := jwt.SignDetachedStream(jwt.SigningMethodRS256, privateKey, bytes.NewReader(content), map[string]interface{}{"kid": "docs"})
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+
+	// Interchangeable with the buffered functions
+	if token, err := jwt.VerifyDetached(s, content, keyfunc); err != nil || !token.Valid {
+		t.Errorf("Error verifying: %v", err)
+	}
+	buffered, _ := jwt.SignDetached(jwt.SigningMethodRS256, privateKey, content, nil)
+	if token, err := jwt.VerifyDetachedStream(buffered, bytes.NewReader(content), keyfunc); err != nil || !token.Valid {
+		t.Errorf("Error verifying: %v", err)
+	}
+	if _, err := jwt.VerifyDetachedStream(s, bytes.NewReader(content[1:]), keyfunc); !jwt.IsSignatureInvalid(err) {
+		t.Errorf("Expected modified content to fail.  Got %v", err)
+	}
+}
+
+func TestVerifyStream_malformed(t *testing.T) {
+	key := []byte("stream secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	valid, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	parts := strings.Split(valid, ".")
+
+	for _, s := range []string{
+		"",
+		parts[0],
+		parts[0] + "." + parts[1],
+		valid + ".extra",
+		"!!!." + parts[1] + "." + parts[2],
+		parts[0] + ".!!!." + parts[2],
+	} {
+		if _, err := jwt.VerifyStream(strings.NewReader(s), new(bytes.Buffer), keyFunc); !jwt.IsMalformed(err) {
+			t.Errorf("[%q] Expected malformed.  Got %v", s, err)
+		}
+	}
+
+	p := &jwt.Parser{MaxTokenLength: 10}
+	if _, err := p.VerifyStream(strings.NewReader(valid), new(bytes.Buffer), keyFunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected an oversized header to be malformed.  Got %v", err)
+	}
+}
+
+func TestStreamSigner_unsupported(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := jwt.NewStreamSigner(new(bytes.Buffer), jwt.SigningMethodEdDSA, key, nil); err != jwt.ErrStreamingUnsupported {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrStreamingUnsupported, err)
+	}
+}
@@ -1,9 +1,14 @@
 package jwt
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
-	"strings"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -14,6 +19,12 @@ import (
 // 如果你的服务器与你的token使用不同的时区，这是非常有用的用来测试
 var TimeFunc = time.Now
 
+// IdFunc provides the jti of the claims this package fills in, such as
+// those of NewClientAssertion.  Override it, with TimeFunc, to make those
+// tokens the same on every run.  Ids that are looked up as credentials,
+// session ids and the jtis of refresh tokens, are always random.
+var IdFunc = rand.Text
+
 // Parse methods use this callback function to supply
 // the key for verification.  The function receives the parsed,
 // but unverified Token.  This allows you to use properties in the
@@ -31,6 +42,50 @@ type Token struct {
 	Claims    Claims                 // The second segment of the token token的载荷 接口类型
 	Signature string                 // The third segment of the token.  Populated when you Parse a token token的签名
 	Valid     bool                   // Is the token valid?  Populated when you Parse/Verify a token token是否有效,解析和验证是赋值
+	Warnings  []Warning              // Issues tolerated by a Lenient Parser
+	Payload   []byte                 // The decoded second segment.  Populated when you Parse a token
+
+	// The decoded segments, as signed.  Populated when you Parse a token, so
+	// they can be hashed or decoded again without splitting Raw.  RawClaims
+	// holds the same bytes as Payload.  SignatureBytes is nil if the
+	// signature is not valid base64url, and is what Parsers verify when the
+	// method is one of the package's own.
+	RawHeader      []byte
+	RawClaims      []byte
+	SignatureBytes []byte
+
+	// Sign to the same string every time, so tokens can be kept in
+	// golden files.  ECDSA nonces are derived as RFC 6979 describes, and
+	// methods signing with randomness they can't derive, like PS256,
+	// fail with ErrNotDeterministic.  See SignsDeterministically.
+	Deterministic bool
+
+	ctx context.Context
+	now func() time.Time // The parsing Parser's TimeFunc, if it has one
+}
+
+// The context the token is parsed in, for use by Keyfuncs.  Set by
+// Parser.ParseWithContext, context.Background otherwise.
+func (t *Token) Context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// The time the token is checked at, for Keyfuncs validating certificates:
+// the parsing Parser's TimeFunc, or TimeFunc
+func (t *Token) time() time.Time {
+	if t == nil || t.now == nil {
+		return TimeFunc()
+	}
+	return t.now()
+}
+
+// The cty header: the media type of the payload, or "" for claims
+func (t *Token) ContentType() string {
+	cty, _ := t.Header["cty"].(string)
+	return cty
 }
 
 // Create a new Token.  Takes a signing method  实例化token，设置签名使用的算法
@@ -52,20 +107,81 @@ func NewWithClaims(method SigningMethod, claims Claims) *Token {
 	}
 }
 
+// Set header parameter name to value and return the token, for chaining:
+//
+//	s, err := jwt.New(jwt.SigningMethodES256).WithHeader("kid", kid).SignedString(key)
+func (t *Token) WithHeader(name string, value interface{}) *Token {
+	t.Header[name] = value
+	return t
+}
+
+// Add names to the crit header, marking those header parameters as
+// extensions the recipient must understand.  Signing fails unless each is
+// set, with WithHeader, by the time the token is signed.
+func (t *Token) WithCritical(names ...string) *Token {
+	crit, _ := t.Header["crit"].([]string)
+	for _, name := range names {
+		if !containsString(crit, name) {
+			crit = append(crit, name)
+		}
+	}
+	t.Header["crit"] = crit
+	return t
+}
+
 // Get the complete, signed token
 // 调用SigningString生成token，签名的过程需要接受签名key
 func (t *Token) SignedString(key interface{}) (string, error) {
-	var sig, sstr string
-	var err error
-	// 生成待签名的字符串
-	if sstr, err = t.SigningString(); err != nil {
-		return "", err
+	_, span := startSpan(context.Background(), DefaultTracer, SpanSign)
+	b, err := t.appendSignedString(nil, key)
+	if DefaultMetrics != nil {
+		DefaultMetrics.ObserveSign(t.Method.Alg(), CodeOf(err))
 	}
-	// 签名操作
-	if sig, err = t.Method.Sign(sstr, key); err != nil {
+	endTokenSpan(span, t, err)
+	if err != nil {
 		return "", err
 	}
-	return strings.Join([]string{sstr, sig}, "."), nil
+	return bytesString(b), nil
+}
+
+// SignedString, appending the token to dst and returning the extended
+// buffer.  Minting many tokens into a reused buffer saves most of the
+// allocations of SignedString.  On error, dst is returned unchanged.
+func (t *Token) AppendSignedString(dst []byte, key interface{}) ([]byte, error) {
+	_, span := startSpan(context.Background(), DefaultTracer, SpanSign)
+	b, err := t.appendSignedString(dst, key)
+	if DefaultMetrics != nil {
+		DefaultMetrics.ObserveSign(t.Method.Alg(), CodeOf(err))
+	}
+	endTokenSpan(span, t, err)
+	return b, err
+}
+
+func (t *Token) appendSignedString(dst []byte, key interface{}) ([]byte, error) {
+	start := len(dst)
+	// 生成待签名的字符串, leaving room for the signature
+	b, err := t.appendSigningString(dst, signatureReserve(t.Method, key))
+	if err != nil {
+		return dst, err
+	}
+	// 签名操作.  HMAC signs the bytes directly, saving the string conversions
+	if m, ok := t.Method.(*SigningMethodHMAC); ok {
+		if b, err = m.appendSign(b, b[start:], key); err != nil {
+			return dst, err
+		}
+		return b, nil
+	}
+	var sig string
+	if t.Deterministic {
+		sig, err = signDeterministic(t.Method, bytesString(b[start:]), key)
+	} else {
+		sig, err = t.Method.Sign(bytesString(b[start:]), key)
+	}
+	if err != nil {
+		return dst, err
+	}
+	b = append(b, '.')
+	return append(b, sig...), nil
 }
 
 // Generate the signing string.  This is the
@@ -74,25 +190,115 @@ func (t *Token) SignedString(key interface{}) (string, error) {
 // the SignedString.
 // 生成签名字符串。这是所有处理中最重要的部分。除非你需要一些特殊的操作，否则仅仅使用SignedString进行签名操作
 func (t *Token) SigningString() (string, error) {
-	var err error
-	parts := make([]string, 2)
-	for i, _ := range parts {
-		var jsonValue []byte
-		if i == 0 {
-			// 拼装头Header信息，转成json字符串
-			if jsonValue, err = json.Marshal(t.Header); err != nil {
-				return "", err
-			}
-		} else {
-			 // 拼装 Payload 载荷信息，转成json字符串
-			if jsonValue, err = json.Marshal(t.Claims); err != nil {
-				return "", err
-			}
+	b, err := t.SigningStringTo(nil)
+	if err != nil {
+		return "", err
+	}
+	return bytesString(b), nil
+}
+
+// SigningString, written over buf and returned in it, or in a larger
+// buffer if buf is too small.  Building signing strings for many tokens
+// into a reused buffer allocates only to marshal their claims.  SignedString
+// builds its signing string the same way, with room left for the signature.
+func (t *Token) SigningStringTo(buf []byte) ([]byte, error) {
+	return t.appendSigningString(buf[:0], 0)
+}
+
+// Append the signing string to dst, growing it once to fit the signing
+// string and reserve more bytes
+func (t *Token) appendSigningString(dst []byte, reserve int) ([]byte, error) {
+	if err := checkCriticalForSigning(t.Header); err != nil {
+		return dst, err
+	}
+	// 拼装头Header信息，转成json字符串
+	seg, ok := t.defaultHeaderSegment()
+	var header []byte
+	if !ok {
+		var err error
+		if header, err = json.Marshal(t.Header); err != nil {
+			return dst, err
 		}
+	}
+	// 拼装 Payload 载荷信息，转成json字符串
+	claims, err := json.Marshal(t.Claims)
+	if err != nil {
+		return dst, err
+	}
 
-		parts[i] = EncodeSegment(jsonValue)
+	dst = slices.Grow(dst, len(seg)+SegmentEncoding.EncodedLen(len(header))+1+SegmentEncoding.EncodedLen(len(claims))+reserve)
+	if ok {
+		dst = append(dst, seg...)
+	} else {
+		dst = appendEncodeSegment(dst, header)
 	}
-	return strings.Join(parts, "."), nil // 使用"."拼接字符串
+	// 使用"."拼接
+	dst = append(dst, '.')
+	return appendEncodeSegment(dst, claims), nil
+}
+
+// Bytes to leave after the signing string for "." and the signature that
+// method makes with key, or 0 if its size isn't known in advance
+func signatureReserve(method SigningMethod, key interface{}) int {
+	var n int
+	switch m := method.(type) {
+	case *SigningMethodHMAC:
+		// With room for the sum too, see appendSign
+		n = m.Hash.Size()
+		return 1 + SegmentEncoding.EncodedLen(n) + n
+	case *SigningMethodRSA, *SigningMethodRSAPSS:
+		if k, ok := key.(*rsa.PrivateKey); ok {
+			n = k.Size()
+		}
+	case *SigningMethodECDSA:
+		n = 2 * m.KeySize
+	case *SigningMethodEd25519:
+		n = ed25519.SignatureSize
+	}
+	if n == 0 {
+		return 0
+	}
+	return 1 + SegmentEncoding.EncodedLen(n)
+}
+
+// Encoded headers of tokens made by New and NewWithClaims, by alg.  Most
+// tokens keep that header, so it need not be marshaled for each one.
+var headerSegments = struct {
+	sync.RWMutex
+	m map[string]string
+}{m: map[string]string{}}
+
+// Only so many algs are cached, in case Alg is not a constant
+const maxHeaderSegments = 64
+
+// The encoded header, if it is still the one NewWithClaims set
+func (t *Token) defaultHeaderSegment() (string, bool) {
+	if len(t.Header) != 2 || t.Header["typ"] != "JWT" || t.Method == nil {
+		return "", false
+	}
+	alg, ok := t.Header["alg"].(string)
+	if !ok || alg != t.Method.Alg() {
+		return "", false
+	}
+
+	headerSegments.RLock()
+	seg, ok := headerSegments.m[alg]
+	headerSegments.RUnlock()
+	if ok {
+		return seg, true
+	}
+
+	header, err := json.Marshal(t.Header)
+	if err != nil {
+		return "", false
+	}
+	seg = EncodeSegment(header)
+	headerSegments.Lock()
+	if len(headerSegments.m) < maxHeaderSegments {
+		headerSegments.m[alg] = seg
+	}
+	headerSegments.Unlock()
+	return seg, true
 }
 
 // Parse, validate, and return a token. 解析并且验证token
@@ -111,15 +317,30 @@ func ParseWithClaims(tokenString string, claims Claims, keyFunc Keyfunc) (*Token
 // Encode JWT specific base64url encoding with padding stripped
 // 使用base64url 编码 JWT
 func EncodeSegment(seg []byte) string {
-	// TrimRight去除尾部的等号
-	return strings.TrimRight(base64.URLEncoding.EncodeToString(seg), "=")
+	return bytesString(appendEncodeSegment(nil, seg))
 }
 
-// Decode JWT specific base64url encoding with padding stripped
+// Decode JWT specific base64url encoding with padding stripped.  Padded
+// segments are accepted too, as long as the padding is correct.
 func DecodeSegment(seg string) ([]byte, error) {
-	if l := len(seg) % 4; l > 0 {
-		seg += strings.Repeat("=", 4-l)
+	seg, err := unpad(seg)
+	if err != nil {
+		return nil, err
 	}
 
-	return base64.URLEncoding.DecodeString(seg)
+	buf := make([]byte, SegmentEncoding.DecodedLen(len(seg)))
+	n, err := SegmentEncoding.Decode(buf, stringBytes(seg))
+	return buf[:n], err
+}
+
+// Strip the padding off seg, failing if there is more than its length needs
+func unpad[S string | []byte](seg S) (S, error) {
+	n := len(seg)
+	for len(seg) > 0 && seg[len(seg)-1] == '=' {
+		seg = seg[:len(seg)-1]
+	}
+	if pad := n - len(seg); pad > 0 && (len(seg)%4 == 0 || pad > 4-len(seg)%4) {
+		return seg, base64.CorruptInputError(len(seg))
+	}
+	return seg, nil
 }
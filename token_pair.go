@@ -0,0 +1,205 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+)
+
+// The typ of access tokens, RFC 9068 section 2.1, and of refresh tokens
+// minted by TokenIssuer.  Distinct types keep one from being presented as
+// the other.
+const (
+	AccessTokenType  = "at+jwt"
+	RefreshTokenType = "refresh+jwt"
+)
+
+// Lifetimes used when TokenIssuer leaves them zero
+const (
+	DefaultAccessTokenLifetime  = 15 * time.Minute
+	DefaultRefreshTokenLifetime = 7 * 24 * time.Hour
+)
+
+var (
+	ErrRefreshTokenReused = newError(CodeTokenReplayed, "refresh token has already been used")
+)
+
+// Claims of the tokens minted by TokenIssuer.  Both tokens of a pair, and
+// every pair rotated from them, share the sid.
+type SessionClaims struct {
+	StandardClaims
+	SessionId string `json:"sid,omitempty"`
+}
+
+// Implements TimedClaims, as SessionClaims has no checks beyond those of
+// StandardClaims
+func (c SessionClaims) ValidAt(now time.Time) error {
+	return c.CheckTimes(now)
+}
+
+// A short-lived access token and the long-lived refresh token that renews
+// it
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	SessionId        string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// Mints token pairs and rotates them on refresh.  Each refresh token can be
+// used once: presenting it again means it was stolen, so the session is
+// reported to OnReuse, which should revoke it.
+type TokenIssuer struct {
+	Method   SigningMethod
+	Key      interface{} // Signing key
+	Keyfunc  Keyfunc     // Supplies the key for verifying the tokens
+	Issuer   string      // If set, the iss of both tokens, and required on refresh
+	Audience string      // aud of access tokens
+
+	AccessLifetime  time.Duration // Defaults to DefaultAccessTokenLifetime
+	RefreshLifetime time.Duration // Defaults to DefaultRefreshTokenLifetime
+
+	// Records the jti of each refresh token used.  Defaults to a
+	// MemoryReplayStore, so servers sharing sessions must set one they
+	// share.
+	Replay ReplayStore
+
+	// Called with the claims of a refresh token presented a second time
+	OnReuse func(claims *SessionClaims)
+
+	// Parses refresh tokens.  Defaults to a zero Parser.
+	Parser *Parser
+
+	once sync.Once
+}
+
+// Start a session for subject and mint its first pair
+func (i *TokenIssuer) Issue(subject string) (*TokenPair, error) {
+	return i.mint(subject, rand.Text())
+}
+
+// Validate refreshToken, use it up and mint the next pair of its session.
+// A refresh token used before fails with ErrRefreshTokenReused, after
+// calling OnReuse.
+func (i *TokenIssuer) Refresh(refreshToken string) (*TokenPair, error) {
+	claims := new(SessionClaims)
+	token, err := i.parser().ParseWithClaims(refreshToken, claims, i.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkType(token, RefreshTokenType); err != nil {
+		return nil, err
+	}
+	if i.Issuer != "" {
+		if err = ValidateIssuer(claims, i.Issuer); err != nil {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorIssuer}
+		}
+	}
+	if claims.Id == "" || claims.SessionId == "" || claims.ExpiresAt == 0 {
+		return nil, NewValidationError("refresh token needs jti, sid and exp claims", ValidationErrorClaimsInvalid)
+	}
+
+	if err = i.replay().Record(claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		if errors.Is(err, ErrTokenReplayed) {
+			if i.OnReuse != nil {
+				i.OnReuse(claims)
+			}
+			err = ErrRefreshTokenReused
+		}
+		err = &ValidationError{Inner: err, Errors: ValidationErrorId}
+		reportSecurityEvent(context.Background(), i.parser().securityHook(), token, err)
+		return nil, err
+	}
+
+	return i.mint(claims.Subject, claims.SessionId)
+}
+
+// Parse and verify an access token minted by i.  typ must be at+jwt, so a
+// refresh token is refused.
+func (i *TokenIssuer) VerifyAccessToken(tokenString string) (*SessionClaims, error) {
+	claims := new(SessionClaims)
+	token, err := i.parser().ParseWithClaims(tokenString, claims, i.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkType(token, AccessTokenType); err != nil {
+		return nil, err
+	}
+	if i.Issuer != "" {
+		if err = ValidateIssuer(claims, i.Issuer); err != nil {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorIssuer}
+		}
+	}
+	if i.Audience != "" {
+		if err = ValidateAudience(claims, i.Audience); err != nil {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorAudience}
+		}
+	}
+	return claims, nil
+}
+
+func (i *TokenIssuer) mint(subject, sid string) (*TokenPair, error) {
+	now := TimeFunc()
+	pair := &TokenPair{
+		SessionId:        sid,
+		AccessExpiresAt:  now.Add(durationOr(i.AccessLifetime, DefaultAccessTokenLifetime)),
+		RefreshExpiresAt: now.Add(durationOr(i.RefreshLifetime, DefaultRefreshTokenLifetime)),
+	}
+
+	var err error
+	pair.AccessToken, err = i.sign(AccessTokenType, subject, i.Audience, sid, now, pair.AccessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	pair.RefreshToken, err = i.sign(RefreshTokenType, subject, "", sid, now, pair.RefreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+func (i *TokenIssuer) sign(typ, subject, audience, sid string, now, exp time.Time) (string, error) {
+	// Refresh token jtis are recorded to detect reuse, so unlike the
+	// informational jti of access tokens they are never from IdFunc
+	jti := IdFunc()
+	if typ == RefreshTokenType {
+		jti = rand.Text()
+	}
+	return NewWithClaims(i.Method, &SessionClaims{
+		StandardClaims: StandardClaims{
+			Audience:  audience,
+			ExpiresAt: exp.Unix(),
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			Issuer:    i.Issuer,
+			Subject:   subject,
+		},
+		SessionId: sid,
+	}).WithHeader("typ", typ).SignedString(i.Key)
+}
+
+func (i *TokenIssuer) parser() *Parser {
+	if i.Parser == nil {
+		return new(Parser)
+	}
+	return i.Parser
+}
+
+func (i *TokenIssuer) replay() ReplayStore {
+	i.once.Do(func() {
+		if i.Replay == nil {
+			i.Replay = NewMemoryReplayStore()
+		}
+	})
+	return i.Replay
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
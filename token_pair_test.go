@@ -0,0 +1,110 @@
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestTokenIssuer(t *testing.T) {
+	key := []byte("session secret")
+	var revoked []string
+	issuer := &jwt.TokenIssuer{
+		Method:   jwt.SigningMethodHS256,
+		Key:      key,
+		Keyfunc:  func(*jwt.Token) (interface{}, error) { return key, nil },
+		Issuer:   "https://auth.example.com",
+		Audience: "https://api.example.com",
+		OnReuse:  func(c *jwt.SessionClaims) { revoked = append(revoked, c.SessionId) },
+	}
+
+	pair, err := issuer.Issue("alice")
+	if err != nil {
+		t.Fatalf("Error issuing: %v", err)
+	}
+	if pair.SessionId == "" || !pair.AccessExpiresAt.Before(pair.RefreshExpiresAt) {
+		t.Errorf("Unexpected pair %+v", pair)
+	}
+
+	claims, err := issuer.VerifyAccessToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Error verifying access token: %v", err)
+	}
+	if claims.Subject != "alice" || claims.SessionId != pair.SessionId || claims.Audience != issuer.Audience {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+
+	// The tokens can't stand in for each other
+	if _, err = issuer.VerifyAccessToken(pair.RefreshToken); !errors.Is(err, jwt.ErrTokenInvalidType) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInvalidType, err)
+	}
+	if _, err = issuer.Refresh(pair.AccessToken); !errors.Is(err, jwt.ErrTokenInvalidType) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrTokenInvalidType, err)
+	}
+
+	next, err := issuer.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Error refreshing: %v", err)
+	}
+	if next.SessionId != pair.SessionId || next.RefreshToken == pair.RefreshToken {
+		t.Errorf("Expected a rotated pair in the same session.  Got %+v", next)
+	}
+	if claims, err = issuer.VerifyAccessToken(next.AccessToken); err != nil || claims.Subject != "alice" {
+		t.Errorf("Error verifying refreshed access token: %v", err)
+	}
+
+	// Reuse of the old refresh token is reported
+	_, err = issuer.Refresh(pair.RefreshToken)
+	if !errors.Is(err, jwt.ErrRefreshTokenReused) {
+		t.Errorf("Expected %v.  Got %v", jwt.ErrRefreshTokenReused, err)
+	}
+	if len(revoked) != 1 || revoked[0] != pair.SessionId {
+		t.Errorf("Expected session %v to be reported.  Got %v", pair.SessionId, revoked)
+	}
+
+	// Refresh tokens from another issuer are refused
+	other := &jwt.TokenIssuer{Method: jwt.SigningMethodHS256, Key: key, Issuer: "https://other.example.com"}
+	foreign, err := other.Issue("mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vErr *jwt.ValidationError
+	if _, err = issuer.Refresh(foreign.RefreshToken); !errors.As(err, &vErr) || vErr.Errors != jwt.ValidationErrorIssuer {
+		t.Errorf("Expected an issuer error.  Got %v", err)
+	}
+}
+
+// Session ids and refresh token jtis are credentials, so they stay random
+// when IdFunc is fixed for reproducible tokens
+func TestTokenIssuer_IdFunc(t *testing.T) {
+	prev := jwt.IdFunc
+	defer func() { jwt.IdFunc = prev }()
+	jwt.IdFunc = func() string { return "fixed" }
+
+	key := []byte("session secret")
+	issuer := &jwt.TokenIssuer{Method: jwt.SigningMethodHS256, Key: key, Keyfunc: func(*jwt.Token) (interface{}, error) { return key, nil }}
+	first, err := issuer.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := issuer.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.SessionId == "fixed" || first.SessionId == second.SessionId {
+		t.Errorf("Expected random session ids.  Got %v and %v", first.SessionId, second.SessionId)
+	}
+
+	claims, err := issuer.VerifyAccessToken(first.AccessToken)
+	if err != nil || claims.Id != "fixed" {
+		t.Errorf("Expected the access token jti from IdFunc.  Got %v, %v", claims, err)
+	}
+	// Refreshing one session must not use up the refresh token of another
+	if _, err := issuer.Refresh(first.RefreshToken); err != nil {
+		t.Errorf("Error refreshing: %v", err)
+	}
+	if _, err := issuer.Refresh(second.RefreshToken); err != nil {
+		t.Errorf("Expected the second session's refresh token to be unused.  Got %v", err)
+	}
+}
@@ -0,0 +1,296 @@
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var criticalSigningTestData = []struct {
+	name  string
+	token *jwt.Token
+	valid bool
+}{
+	{"none", jwt.New(jwt.SigningMethodHS256).WithHeader("kid", "1"), true},
+	{"present", jwt.New(jwt.SigningMethodHS256).WithHeader("exp", 1).WithCritical("exp"), true},
+	{"missing", jwt.New(jwt.SigningMethodHS256).WithCritical("exp"), false},
+	{"registered", jwt.New(jwt.SigningMethodHS256).WithCritical("alg"), false},
+	{"empty", jwt.New(jwt.SigningMethodHS256).WithCritical(), false},
+	{"set by hand", jwt.New(jwt.SigningMethodHS256).WithHeader("crit", "exp"), false},
+}
+
+func TestToken_WithCritical(t *testing.T) {
+	key := []byte("secret")
+	parser := &jwt.Parser{CriticalHeaders: []string{"exp"}}
+
+	for _, data := range criticalSigningTestData {
+		s, err := data.token.SignedString(key)
+		if (err == nil) != data.valid {
+			t.Errorf("[%v] Expected valid %v.  Got %v", data.name, data.valid, err)
+		}
+		if err != nil {
+			if err != jwt.ErrInvalidCritical {
+				t.Errorf("[%v] Expected ErrInvalidCritical.  Got %v", data.name, err)
+			}
+			continue
+		}
+		if _, err := parser.Parse(s, func(*jwt.Token) (interface{}, error) { return key, nil }); err != nil {
+			t.Errorf("[%v] Error parsing signed token: %v", data.name, err)
+		}
+	}
+}
+
+func TestToken_TypedHeader(t *testing.T) {
+	key := []byte("secret")
+	token := jwt.New(jwt.SigningMethodHS256).SetTypedHeader(&jwt.Header{
+		Typ:   "at+jwt",
+		Kid:   "2024-01",
+		Crit:  []string{"exp"},
+		Extra: map[string]interface{}{"exp": 1},
+	})
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+
+	parser := &jwt.Parser{CriticalHeaders: []string{"exp"}}
+	parsed, err := parser.Parse(s, func(*jwt.Token) (interface{}, error) { return key, nil })
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	h, err := parsed.TypedHeader()
+	if err != nil {
+		t.Fatalf("Error reading header: %v", err)
+	}
+	if h.Alg != "HS256" || h.Typ != "at+jwt" || h.Kid != "2024-01" || len(h.Crit) != 1 || h.Extra["exp"] != 1.0 || len(h.Extra) != 1 {
+		t.Errorf("Unexpected header %+v", h)
+	}
+}
+
+func TestNewHeader_malformed(t *testing.T) {
+	_, err := jwt.NewHeader(map[string]interface{}{"kid": 7})
+	if !jwt.IsMalformed(err) {
+		t.Errorf("Expected malformed error.  Got %v", err)
+	}
+}
+
+var contentTypeTestData = []struct {
+	cty    string
+	claims bool
+}{
+	{"", true},
+	{"json", true},
+	{"application/json; charset=utf-8", true},
+	{"secevent+jwt", false},
+	{"application/secevent+json", true},
+	{"text/plain", false},
+	{"JWT", false},
+}
+
+func TestParser_RawPayloads(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := &jwt.Parser{RawPayloads: true}
+
+	for _, data := range contentTypeTestData {
+		header := jwt.EncodeSegment([]byte(`{"alg":"HS256","cty":"` + data.cty + `"}`))
+		payload := `{"exp":1}`
+		signing := header + "." + jwt.EncodeSegment([]byte(payload))
+		sig, _ := jwt.SigningMethodHS256.Sign(signing, key)
+
+		token, err := parser.Parse(signing+"."+sig, keyFunc)
+		if string(token.Payload) != payload || token.ContentType() != data.cty {
+			t.Errorf("[%v] Unexpected payload %q", data.cty, token.Payload)
+		}
+		// Claims are decoded, and so found expired, only for JSON
+		if data.claims != jwt.IsExpired(err) || data.claims == (err == nil) {
+			t.Errorf("[%v] Expected claims %v.  Got %v", data.cty, data.claims, err)
+		}
+	}
+}
+
+func TestToken_RawSegments(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	parts := strings.Split(s, ".")
+
+	token, err := jwt.Parse(s, keyFunc)
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+	header, _ := jwt.DecodeSegment(parts[0])
+	claims, _ := jwt.DecodeSegment(parts[1])
+	sig, _ := jwt.DecodeSegment(parts[2])
+	if !bytes.Equal(token.RawHeader, header) || !bytes.Equal(token.RawClaims, claims) || !bytes.Equal(token.SignatureBytes, sig) {
+		t.Errorf("Unexpected segments %q %q %x", token.RawHeader, token.RawClaims, token.SignatureBytes)
+	}
+
+	// The raw claims can be decoded again, into another type
+	var c struct{ Foo string }
+	if err := json.Unmarshal(token.RawClaims, &c); err != nil || c.Foo != "bar" {
+		t.Errorf("Error decoding raw claims: %v", err)
+	}
+
+	// Populated without verifying, but not when the signature is not base64url
+	token, _, err = new(jwt.Parser).ParseUnverified(parts[0]+"."+parts[1]+".!!!", jwt.MapClaims{})
+	if err != nil || token.RawHeader == nil || token.SignatureBytes != nil {
+		t.Errorf("Unexpected segments for an unverified token: %v", err)
+	}
+}
+
+var segmentTestData = []struct {
+	name    string
+	segment string
+	decoded string
+	valid   bool
+}{
+	{"unpadded", "YWI", "ab", true},
+	{"padded", "YWI=", "ab", true},
+	{"partly padded", "YQ=", "a", true},
+	{"fully padded", "YQ==", "a", true},
+	{"too much padding", "YQ===", "", false},
+	{"padding after a full block", "YWJj=", "", false},
+	{"padding in the middle", "YQ=a", "", false},
+	{"only padding", "==", "", false},
+	{"standard alphabet", "+/8", "", false},
+	{"empty", "", "", true},
+}
+
+func TestDecodeSegment(t *testing.T) {
+	for _, data := range segmentTestData {
+		decoded, err := jwt.DecodeSegment(data.segment)
+		if (err == nil) != data.valid || string(decoded) != data.decoded {
+			t.Errorf("[%v] Expected %q, %v.  Got %q, %v", data.name, data.decoded, data.valid, decoded, err)
+		}
+	}
+	if s := jwt.EncodeSegment([]byte("ab")); s != "YWI" {
+		t.Errorf("Expected YWI.  Got %v", s)
+	}
+}
+
+func BenchmarkEncodeSegment(b *testing.B) {
+	seg := bytes.Repeat([]byte(`{"sub":"1234567890","name":"John Doe"}`), 4)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jwt.EncodeSegment(seg)
+	}
+}
+
+func BenchmarkDecodeSegment(b *testing.B) {
+	seg := jwt.EncodeSegment(bytes.Repeat([]byte(`{"sub":"1234567890","name":"John Doe"}`), 4))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jwt.DecodeSegment(seg)
+	}
+}
+
+func TestToken_AppendSignedString(t *testing.T) {
+	edKey := ed25519.NewKeyFromSeed(ed25519Seed)
+	for _, data := range []struct {
+		method jwt.SigningMethod
+		key    interface{}
+	}{
+		{jwt.SigningMethodHS256, []byte("secret")},
+		{jwt.SigningMethodEdDSA, edKey},
+	} {
+		token := jwt.NewWithClaims(data.method, jwt.MapClaims{"sub": "alice"})
+		s, err := token.SignedString(data.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf, err := token.AppendSignedString([]byte("Bearer "), data.key)
+		if err != nil || string(buf) != "Bearer "+s {
+			t.Errorf("[%v] Expected %v.  Got %s, %v", data.method.Alg(), "Bearer "+s, buf, err)
+		}
+
+		// The buffer is left alone on failure
+		if buf, err = token.AppendSignedString(buf[:7], 42); err == nil || string(buf) != "Bearer " {
+			t.Errorf("[%v] Expected the buffer back and an error.  Got %q, %v", data.method.Alg(), buf, err)
+		}
+	}
+}
+
+func BenchmarkAppendSignedString(b *testing.B) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1234567890"})
+	key := []byte("secret")
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = token.AppendSignedString(buf[:0], key); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestToken_SigningString_header(t *testing.T) {
+	for _, token := range []*jwt.Token{
+		jwt.New(jwt.SigningMethodHS256),
+		jwt.New(jwt.SigningMethodHS256), // Cached by now
+		jwt.New(jwt.SigningMethodHS384),
+		jwt.New(jwt.SigningMethodHS256).WithHeader("kid", "k1"),
+		jwt.New(jwt.SigningMethodHS256).WithHeader("typ", "at+jwt"),
+		jwt.New(jwt.SigningMethodHS256).WithHeader("alg", "HS384"),
+	} {
+		s, err := token.SigningString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, _ := json.Marshal(token.Header)
+		if expect := jwt.EncodeSegment(header); !strings.HasPrefix(s, expect+".") {
+			t.Errorf("[%v] Expected header %s.  Got %v", token.Header, header, s)
+		}
+	}
+}
+
+func TestToken_SigningStringTo(t *testing.T) {
+	claims := jwt.StandardClaims{Subject: "1234567890", Issuer: "https://auth.example.com", ExpiresAt: 1500000000}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	expect, err := token.SigningString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Too small, then reused with what is left from before
+	buf := make([]byte, 4)
+	for i := 0; i < 2; i++ {
+		if buf, err = token.SigningStringTo(buf); err != nil || string(buf) != expect {
+			t.Errorf("[%v] Expected %v.  Got %s, %v", i, expect, buf, err)
+		}
+	}
+
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful under the race detector")
+	}
+	marshal := testing.AllocsPerRun(100, func() { json.Marshal(token.Claims) })
+	allocs := testing.AllocsPerRun(100, func() { token.SigningStringTo(buf) })
+	if allocs != marshal {
+		t.Errorf("Expected %v allocations.  Got %v", marshal, allocs)
+	}
+}
+
+// Apart from marshaling the claims, an HS256 token takes one allocation,
+// its buffer, sized up front, and its copy as a string without jwtunsafe
+func TestToken_SignedString_allocations(t *testing.T) {
+	claims := jwt.StandardClaims{Subject: "1234567890", Issuer: "https://auth.example.com", ExpiresAt: 1500000000}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	var key interface{} = jwt.NewHMACKey([]byte("secret"))
+	if _, err := token.SignedString(key); err != nil {
+		t.Fatal(err)
+	}
+
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful under the race detector")
+	}
+	marshal := testing.AllocsPerRun(100, func() { json.Marshal(token.Claims) })
+	sign := testing.AllocsPerRun(100, func() { token.SignedString(key) })
+	if expect := marshal + 1 + stringCopyAllocs; sign != expect {
+		t.Errorf("Expected %v allocations.  Got %v", expect, sign)
+	}
+}
@@ -0,0 +1,85 @@
+package jwt
+
+import "context"
+
+// Names of the spans started by the package
+const (
+	SpanParse     = "jwt.Parse"
+	SpanKeyfunc   = "jwt.Keyfunc"
+	SpanSign      = "jwt.SignedString"
+	SpanJWKSFetch = "jwt.JWKSFetch"
+)
+
+// Attributes set on spans.  Those describing a token are taken from its
+// unverified header and claims.
+const (
+	AttributeAlg       = "jwt.alg"
+	AttributeKid       = "jwt.kid"
+	AttributeIssuer    = "jwt.iss"
+	AttributeErrorCode = "jwt.error_code"
+	AttributeURL       = "url.full"
+)
+
+// Starts spans around parsing, key lookup, signing and JWK Set fetches,
+// for distributed tracing.  The oteljwt package adapts OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// A span started by a Tracer
+type Span interface {
+	SetAttribute(key, value string)
+	End(err error) // err is nil on success
+}
+
+// Tracer used by SignedString, and by Parsers and JWKSProviders that don't
+// set their own.  Set it once at startup, before tokens are handled.
+var DefaultTracer Tracer
+
+func (p *Parser) tracer() Tracer {
+	if p.Tracer != nil {
+		return p.Tracer
+	}
+	return DefaultTracer
+}
+
+func (p *JWKSProvider) tracer() Tracer {
+	if p.Tracer != nil {
+		return p.Tracer
+	}
+	return DefaultTracer
+}
+
+// Start a span with tracer, which may be nil
+func startSpan(ctx context.Context, tracer Tracer, name string) (context.Context, Span) {
+	if tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return tracer.Start(ctx, name)
+}
+
+// Describe token on span and end it, with the code of err if it failed
+func endTokenSpan(span Span, token *Token, err error) {
+	if token != nil {
+		span.SetAttribute(AttributeAlg, tokenAlg(token))
+		if kid, _ := token.Header["kid"].(string); kid != "" {
+			span.SetAttribute(AttributeKid, kid)
+		}
+		if c, ok := token.Claims.(interface{ GetIssuer() string }); ok && c.GetIssuer() != "" {
+			span.SetAttribute(AttributeIssuer, c.GetIssuer())
+		}
+	}
+	endSpan(span, err)
+}
+
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.SetAttribute(AttributeErrorCode, CodeOf(err).String())
+	}
+	span.End(err)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key, value string) {}
+func (noopSpan) End(err error)                  {}
@@ -0,0 +1,90 @@
+package jwt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type spanKey struct{}
+
+// Records spans as "name<parent", with their attributes
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name   string
+	parent string
+	attrs  map[string]string
+	ended  bool
+	err    error
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, jwt.Span) {
+	s := &recordedSpan{name: name, attrs: map[string]string{}}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		s.parent = parent.name
+	}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (s *recordedSpan) SetAttribute(key, value string) {
+	s.attrs[key] = value
+}
+
+func (s *recordedSpan) End(err error) {
+	s.ended, s.err = true, err
+}
+
+func TestTracing(t *testing.T) {
+	server := newOIDCServer(t)
+	defer server.Close()
+
+	tracer := new(recordingTracer)
+	provider := &jwt.JWKSProvider{URL: server.URL + "/keys", Tracer: tracer}
+	parser := &jwt.Parser{Tracer: tracer}
+
+	ctx, root := tracer.Start(context.Background(), "request")
+	defer root.End(nil)
+	if _, err := parser.ParseWithContext(ctx, makeOIDCToken(t, server.URL), jwt.MapClaims{}, provider.Keyfunc); err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+
+	expected := []string{"request<", jwt.SpanParse + "<request", jwt.SpanKeyfunc + "<" + jwt.SpanParse, jwt.SpanJWKSFetch + "<" + jwt.SpanKeyfunc}
+	if len(tracer.spans) != len(expected) {
+		t.Fatalf("Expected spans %v.  Got %v", expected, len(tracer.spans))
+	}
+	for i, s := range tracer.spans {
+		if s.name+"<"+s.parent != expected[i] {
+			t.Errorf("Expected span %v.  Got %v<%v", expected[i], s.name, s.parent)
+		}
+	}
+	parse := tracer.spans[1]
+	if !parse.ended || parse.attrs[jwt.AttributeAlg] != "RS256" || parse.attrs[jwt.AttributeKid] != "k1" || parse.attrs[jwt.AttributeIssuer] != server.URL {
+		t.Errorf("Unexpected parse span %+v", parse)
+	}
+	if tracer.spans[3].attrs[jwt.AttributeURL] != server.URL+"/keys" {
+		t.Errorf("Unexpected fetch span %+v", tracer.spans[3])
+	}
+
+	// Failures carry the error code
+	tracer.spans = nil
+	parser.Parse("garbage", provider.Keyfunc)
+	if len(tracer.spans) != 1 || tracer.spans[0].attrs[jwt.AttributeErrorCode] != jwt.CodeTokenMalformed.String() || tracer.spans[0].err == nil {
+		t.Errorf("Expected a failed parse span.  Got %+v", tracer.spans)
+	}
+
+	jwt.DefaultTracer = tracer
+	defer func() { jwt.DefaultTracer = nil }()
+	tracer.spans = nil
+	if _, err := jwt.New(jwt.SigningMethodHS256).SignedString("not bytes"); !errors.Is(err, jwt.ErrInvalidKeyType) {
+		t.Fatalf("Expected %v.  Got %v", jwt.ErrInvalidKeyType, err)
+	}
+	if len(tracer.spans) != 1 || tracer.spans[0].name != jwt.SpanSign || tracer.spans[0].attrs[jwt.AttributeErrorCode] != jwt.CodeInvalidKeyType.String() {
+		t.Errorf("Expected a failed sign span.  Got %+v", tracer.spans)
+	}
+}
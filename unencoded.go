@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Sign payload with the unencoded payload option of RFC 7797: the header
+// sets b64 to false and lists it in crit, and the payload is signed as is
+// rather than base64url encoded.  The returned compact JWS has an empty
+// payload segment, so payload must be sent alongside it.  header may add
+// parameters such as kid.
+func SignUnencoded(method SigningMethod, key interface{}, payload []byte, header map[string]interface{}) (string, error) {
+	h := map[string]interface{}{"alg": method.Alg()}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["b64"] = false
+	crit, _ := h["crit"].([]string)
+	if !containsString(crit, "b64") {
+		h["crit"] = append(crit, "b64")
+	}
+	if err := checkCriticalForSigning(h); err != nil {
+		return "", err
+	}
+
+	headerJSON, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	protected := EncodeSegment(headerJSON)
+
+	sig, err := method.Sign(protected+"."+string(payload), key)
+	if err != nil {
+		return "", err
+	}
+	return protected + ".." + sig, nil
+}
+
+// Verify a token created with SignUnencoded against its detached payload
+func VerifyUnencoded(tokenString string, payload []byte, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).VerifyUnencoded(tokenString, payload, keyFunc)
+}
+
+// VerifyUnencoded, checking the signing method and crit header with the
+// options of the Parser.  The payload is not interpreted as claims, so the
+// returned Token has empty Claims.  A token carrying its unencoded payload
+// rather than an empty segment is accepted if payload is nil.  The result
+// is reported as VerifyDetached's is.
+func (p *Parser) VerifyUnencoded(tokenString string, payload []byte, keyFunc Keyfunc) (*Token, error) {
+	return p.reported(context.Background(), func(ctx context.Context) (*Token, error) {
+		return p.verifyUnencoded(ctx, tokenString, payload, keyFunc)
+	})
+}
+
+func (p *Parser) verifyUnencoded(ctx context.Context, tokenString string, payload []byte, keyFunc Keyfunc) (*Token, error) {
+	token, parts, err := p.parseDetached(ctx, tokenString)
+	if err != nil {
+		return token, err
+	}
+	if payload == nil {
+		payload = []byte(parts[1])
+	} else if parts[1] != "" {
+		return token, NewValidationError("token has both an attached and a detached payload", ValidationErrorMalformed)
+	}
+
+	if b64, ok := token.Header["b64"].(bool); !ok || b64 {
+		return token, NewValidationError("token header b64 must be false", ValidationErrorMalformed)
+	}
+	crit, _ := token.Header["crit"].([]interface{})
+	if !containsCrit(crit, "b64") {
+		return token, NewValidationError("token header crit must include b64", ValidationErrorMalformed)
+	}
+	if err = checkCritical(token.Header, append([]string{"b64"}, p.CriticalHeaders...)); err != nil {
+		return token, err
+	}
+
+	return token, p.verifyDetached(token, parts[0]+"."+string(payload), keyFunc)
+}
+
+func containsCrit(crit []interface{}, name string) bool {
+	for _, c := range crit {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package jwt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+func TestSignUnencoded(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return &privateKey.PublicKey, nil }
+	payload := []byte(`{"Data":{"Payment":{"Amount":"10.00"}}}`)
+
+	s, err := jwt.SignUnencoded(jwt.SigningMethodPS256, privateKey, payload, map[string]interface{}{"kid": "fapi"})
+	if err != nil {
+		t.Fatalf("Error signing: %v", err)
+	}
+	if parts := strings.Split(s, "."); len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("Expected a detached payload.  Got %v", s)
+	}
+
+	token, err := jwt.VerifyUnencoded(s, payload, keyfunc)
+	if err != nil || !token.Valid || token.Header["kid"] != "fapi" {
+		t.Errorf("Error verifying: %v", err)
+	}
+
+	if _, err := jwt.VerifyUnencoded(s, []byte(`{"Data":{"Payment":{"Amount":"99.00"}}}`), keyfunc); !jwt.IsSignatureInvalid(err) {
+		t.Errorf("Expected a tampered payload to fail verification.  Got %v", err)
+	}
+
+	// The b64 extension is critical, so ordinary parsing refuses the token
+	if _, err := jwt.Parse(s, keyfunc); err == nil {
+		t.Errorf("Expected Parse to reject an unencoded payload token")
+	}
+
+	// Ordinary tokens are not accepted in place of unencoded ones
+	compact := test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, privateKey)
+	if _, err := jwt.VerifyUnencoded(compact, nil, keyfunc); !jwt.IsMalformed(err) {
+		t.Errorf("Expected a token without b64=false to be malformed.  Got %v", err)
+	}
+}
+
+var criticalTestData = []struct {
+	name   string
+	header string
+	valid  bool
+}{
+	{"understood", `{"alg":"HS256","crit":["exp"],"exp":1}`, true},
+	{"not understood", `{"alg":"HS256","crit":["foo"],"foo":1}`, false},
+	{"missing", `{"alg":"HS256","crit":["exp"]}`, false},
+	{"empty", `{"alg":"HS256","crit":[]}`, false},
+	{"not a list", `{"alg":"HS256","crit":"exp"}`, false},
+}
+
+func TestParser_critical(t *testing.T) {
+	key := []byte("secret")
+	parser := &jwt.Parser{CriticalHeaders: []string{"exp"}}
+
+	for _, data := range criticalTestData {
+		signing := jwt.EncodeSegment([]byte(data.header)) + "." + jwt.EncodeSegment([]byte(`{}`))
+		sig, _ := jwt.SigningMethodHS256.Sign(signing, key)
+		_, err := parser.Parse(signing+"."+sig, func(*jwt.Token) (interface{}, error) { return key, nil })
+		if (err == nil) != data.valid {
+			t.Errorf("[%v] Expected valid %v.  Got %v", data.name, data.valid, err)
+		}
+	}
+}
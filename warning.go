@@ -0,0 +1,20 @@
+package jwt
+
+import (
+	"fmt"
+	"time"
+)
+
+// How far in the future a Lenient Parser accepts iat, with a warning
+const DefaultLenientLeeway = time.Minute
+
+// An issue with a token that a Lenient Parser tolerated
+type Warning struct {
+	Code    ErrorCode // The code of the error it would otherwise be, if any
+	Field   string    // The claim or header at fault
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%v: %v", w.Field, w.Message)
+}
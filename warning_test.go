@@ -0,0 +1,60 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/dgrijalva/jwt-go/test"
+)
+
+var lenientTestData = []struct {
+	name     string
+	claims   jwt.MapClaims
+	valid    bool
+	warnings []jwt.ErrorCode
+}{
+	{"clean", jwt.MapClaims{"jti": "1"}, true, nil},
+	{"missing jti", jwt.MapClaims{}, true, []jwt.ErrorCode{jwt.CodeInvalidId}},
+	{"iat within leeway", jwt.MapClaims{"jti": "1", "iat": float64(10030)}, true, []jwt.ErrorCode{jwt.CodeTokenUsedBeforeIssued}},
+	{"iat beyond leeway", jwt.MapClaims{"jti": "1", "iat": float64(10300)}, false, nil},
+	{"iat and exp", jwt.MapClaims{"jti": "1", "iat": float64(10030), "exp": float64(9000)}, false, []jwt.ErrorCode{jwt.CodeTokenUsedBeforeIssued}},
+}
+
+func TestParser_lenient(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	parser := &jwt.Parser{Lenient: true}
+
+	at(time.Unix(10000, 0), func() {
+		for _, data := range lenientTestData {
+			token, err := parser.Parse(test.MakeSampleToken(data.claims, privateKey), defaultKeyFunc)
+			if token.Valid != data.valid {
+				t.Errorf("[%v] Expected valid %v.  Got %v", data.name, data.valid, err)
+			}
+			if len(token.Warnings) != len(data.warnings) {
+				t.Errorf("[%v] Expected %v warnings.  Got %v", data.name, len(data.warnings), token.Warnings)
+				continue
+			}
+			for i, w := range token.Warnings {
+				if w.Code != data.warnings[i] {
+					t.Errorf("[%v] Expected warning %v.  Got %v", data.name, data.warnings[i], w)
+				}
+			}
+			if data.name == "iat and exp" && err.(*jwt.ValidationError).Errors != jwt.ValidationErrorExpired {
+				t.Errorf("[%v] Expected only the exp failure to remain.  Got %v", data.name, err)
+			}
+		}
+
+		// Strict parsing still rejects a future iat and has no warnings
+		token, err := new(jwt.Parser).Parse(test.MakeSampleToken(jwt.MapClaims{"iat": float64(10030)}, privateKey), defaultKeyFunc)
+		if err == nil || len(token.Warnings) != 0 {
+			t.Errorf("Expected strict parser to fail without warnings.  Got %v %v", err, token.Warnings)
+		}
+	})
+
+	parser.DeprecatedMethods = []string{"RS256"}
+	token, err := parser.Parse(test.MakeSampleToken(jwt.MapClaims{"jti": "1"}, privateKey), defaultKeyFunc)
+	if err != nil || len(token.Warnings) != 1 || token.Warnings[0].Code != jwt.CodeDeprecatedAlgorithm {
+		t.Errorf("Expected a deprecated algorithm warning.  Got %v %v", err, token.Warnings)
+	}
+}